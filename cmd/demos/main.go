@@ -0,0 +1,89 @@
+// Runs the OpenGL 3.2 demos, hosted as scenes in one window, from one binary.
+//
+// The scenes come from the scene registry of the multisample package (see
+// multisample.Scenes). Flags go before the command or after the scene name,
+// without a command -scene runs.
+//
+//	go run ./cmd/demos list
+//	go run ./cmd/demos run msaa -fps 0
+//	go run ./cmd/demos bench quad -benchframes 1000
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/go-gl/example/gl32-cube/test32-framebuffer-multisample/multisample"
+)
+
+func main() {
+	flag.Usage = usage
+	flag.Parse()
+	args := flag.Args()
+
+	if len(args) == 0 {
+		multisample.Run("")
+		return
+	}
+
+	switch args[0] {
+	case "list":
+		for i, scene := range multisample.Scenes() {
+			fmt.Printf("%-12v F%v\n", scene.Name(), i+1)
+		}
+	case "run", "bench":
+		if len(args) < 2 {
+			log.Fatalf("%v needs a scene: %v", args[0], sceneNames())
+		}
+		if !hasScene(args[1]) {
+			log.Fatalf("unknown scene %q, use %v", args[1], sceneNames())
+		}
+		if err := flag.CommandLine.Parse(args[2:]); err != nil {
+			log.Fatalln(err)
+		}
+		if flag.NArg() > 0 {
+			log.Fatalf("unexpected arguments after %v %v: %v", args[0], args[1], flag.Args())
+		}
+		if args[0] == "run" {
+			multisample.Run(args[1])
+		} else {
+			multisample.Bench(args[1])
+		}
+	default:
+		log.Fatalf("unknown command %q, use list, run or bench", args[0])
+	}
+}
+
+// usage prints the command line help, the commands and the flags
+func usage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintf(out, "usage: %v [flags] [command] [flags]\n\n", os.Args[0])
+	fmt.Fprintln(out, "commands:")
+	fmt.Fprintln(out, "  list           list the scenes")
+	fmt.Fprintln(out, "  run <scene>    run a scene, like -scene (the default command)")
+	fmt.Fprintln(out, "  bench <scene>  run a scene unthrottled for -benchframes frames and print frame time statistics")
+	fmt.Fprintln(out, "\nflags:")
+	flag.PrintDefaults()
+}
+
+// hasScene is whether the registry has a scene called name
+func hasScene(name string) bool {
+	for _, scene := range multisample.Scenes() {
+		if scene.Name() == name {
+			return true
+		}
+	}
+	return false
+}
+
+// sceneNames lists the scenes, for error messages
+func sceneNames() string {
+	var names []string
+	for _, scene := range multisample.Scenes() {
+		names = append(names, scene.Name())
+	}
+	return strings.Join(names, ", ")
+}
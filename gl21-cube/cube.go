@@ -6,6 +6,7 @@
 package main // import "github.com/go-gl/example/gl21-cube"
 
 import (
+	"flag"
 	"image"
 	"image/draw"
 	_ "image/png"
@@ -13,6 +14,7 @@ import (
 	"os"
 	"runtime"
 
+	"github.com/go-gl/example/internal/capture"
 	"github.com/go-gl/gl/v2.1/gl"
 	"github.com/go-gl/glfw/v3.3/glfw"
 )
@@ -31,6 +33,8 @@ func init() {
 }
 
 func main() {
+	flag.Parse()
+
 	if err := glfw.Init(); err != nil {
 		log.Fatalln("failed to initialize glfw:", err)
 	}
@@ -39,6 +43,9 @@ func main() {
 	glfw.WindowHint(glfw.Resizable, glfw.False)
 	glfw.WindowHint(glfw.ContextVersionMajor, 2)
 	glfw.WindowHint(glfw.ContextVersionMinor, 1)
+	if capture.Enabled() {
+		glfw.WindowHint(glfw.Visible, glfw.False) // thumbnail and screenshot mode render offscreen
+	}
 	window, err := glfw.CreateWindow(width, height, "Cube", nil, nil)
 	if err != nil {
 		panic(err)
@@ -55,6 +62,11 @@ func main() {
 	setupScene()
 	for !window.ShouldClose() {
 		drawScene()
+		if capture.Enabled() {
+			fbWidth, fbHeight := window.GetFramebufferSize()
+			capture.Write(fbWidth, fbHeight, gl.ReadPixels)
+			break
+		}
 		window.SwapBuffers()
 		glfw.PollEvents()
 	}
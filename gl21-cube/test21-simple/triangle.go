@@ -1,10 +1,12 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"log"
 	"runtime"
 
+	"github.com/go-gl/example/internal/capture"
 	"github.com/go-gl/gl/v2.1/gl"
 	"github.com/go-gl/glfw/v3.3/glfw"
 )
@@ -21,6 +23,9 @@ func init() {
 
 func main() {
 
+	// parse command line flags, see -thumbnail and -screenshot
+	flag.Parse()
+
 	// initalize glfw
 	err := glfw.Init()
 	if err != nil {
@@ -33,6 +38,11 @@ func main() {
 	glfw.WindowHint(glfw.ContextVersionMajor, 2)
 	glfw.WindowHint(glfw.ContextVersionMinor, 1)
 
+	// thumbnail and screenshot mode render offscreen, no need to show the window
+	if capture.Enabled() {
+		glfw.WindowHint(glfw.Visible, glfw.False)
+	}
+
 	// create window handle
 	window, err := glfw.CreateWindow(windowWidth, windowHeight, "Triangle 3D", nil, nil)
 	if err != nil {
@@ -56,6 +66,13 @@ func main() {
 		// draw into buffer
 		draw()
 
+		// thumbnail and screenshot mode, save the first frame and quit (see tools/thumbnails and tools/imagediff)
+		if capture.Enabled() {
+			width, height := window.GetFramebufferSize()
+			capture.Write(width, height, gl.ReadPixels)
+			break
+		}
+
 		// render buffer to screen
 		window.SwapBuffers()
 
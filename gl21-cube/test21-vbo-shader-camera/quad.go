@@ -1,12 +1,14 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"image/color"
 	"log"
 	"runtime"
 	"strings"
 
+	"github.com/go-gl/example/internal/capture"
 	"github.com/go-gl/gl/v2.1/gl"
 	"github.com/go-gl/glfw/v3.3/glfw"
 	"github.com/go-gl/mathgl/mgl32"
@@ -39,6 +41,9 @@ func init() {
 
 func main() {
 
+	// parse command line flags, see -thumbnail and -screenshot
+	flag.Parse()
+
 	// initalize glfw
 	err := glfw.Init()
 	if err != nil {
@@ -51,6 +56,11 @@ func main() {
 	glfw.WindowHint(glfw.ContextVersionMajor, 2)
 	glfw.WindowHint(glfw.ContextVersionMinor, 1)
 
+	// thumbnail and screenshot mode render offscreen, no need to show the window
+	if capture.Enabled() {
+		glfw.WindowHint(glfw.Visible, glfw.False)
+	}
+
 	// create window handle
 	window, err := glfw.CreateWindow(windowWidth, windowHeight, "Quad 3D", nil, nil)
 	if err != nil {
@@ -77,6 +87,13 @@ func main() {
 		// draw into buffer
 		draw()
 
+		// thumbnail and screenshot mode, save the first frame and quit (see tools/thumbnails and tools/imagediff)
+		if capture.Enabled() {
+			width, height := window.GetFramebufferSize()
+			capture.Write(width, height, gl.ReadPixels)
+			break
+		}
+
 		// render buffer to screen
 		window.SwapBuffers()
 
@@ -6,12 +6,14 @@
 package main // import "github.com/go-gl/example/gl21-cube"
 
 import (
+	"flag"
 	"go/build"
 	_ "image/png"
 	"log"
 	"os"
 	"runtime"
 
+	"github.com/go-gl/example/internal/capture"
 	"github.com/go-gl/gl/v2.1/gl"
 	"github.com/go-gl/glfw/v3.3/glfw"
 )
@@ -30,6 +32,8 @@ func init() {
 }
 
 func main() {
+	flag.Parse()
+
 	if err := glfw.Init(); err != nil {
 		log.Fatalln("failed to initialize glfw:", err)
 	}
@@ -38,6 +42,9 @@ func main() {
 	glfw.WindowHint(glfw.Resizable, glfw.False)
 	glfw.WindowHint(glfw.ContextVersionMajor, 2)
 	glfw.WindowHint(glfw.ContextVersionMinor, 1)
+	if capture.Enabled() {
+		glfw.WindowHint(glfw.Visible, glfw.False) // thumbnail and screenshot mode render offscreen
+	}
 	window, err := glfw.CreateWindow(width, height, "Cube", nil, nil)
 	if err != nil {
 		panic(err)
@@ -56,6 +63,11 @@ func main() {
 	setupScene()
 	for !window.ShouldClose() {
 		drawScene()
+		if capture.Enabled() {
+			fbWidth, fbHeight := window.GetFramebufferSize()
+			capture.Write(fbWidth, fbHeight, gl.ReadPixels)
+			break
+		}
 		window.SwapBuffers()
 		glfw.PollEvents()
 	}
@@ -0,0 +1,17 @@
+// Runs the OpenGL 3.2 multisample quad demo, or with -scene another of the
+// demos hosted in the multisample package. cmd/demos lists and benchmarks
+// them too.
+//
+//	go run . -scene msaa -fps 0
+package main
+
+import (
+	"flag"
+
+	"github.com/go-gl/example/gl32-cube/test32-framebuffer-multisample/multisample"
+)
+
+func main() {
+	flag.Parse()
+	multisample.Run("")
+}
@@ -0,0 +1,116 @@
+package multisample
+
+import (
+	"math"
+
+	"github.com/go-gl/gl/v3.2-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Arcball rotates the model matrix with the mouse (Shoemake's arcball).
+//
+// The window is mapped onto a virtual ball, dragging from one point of the
+// ball to another rotates by the arc between them. Dragging across the whole
+// window is a half turn and moving the cursor back undoes the rotation.
+// The ball is aligned with the window, which matches the eye axes as long
+// as the camera looks (roughly) down the negative z-axis.
+//
+// https://en.wikibooks.org/wiki/OpenGL_Programming/Modern_OpenGL_Tutorial_Arcball
+type Arcball struct {
+	program      uint32 // program owning the "model" uniform
+	modelUniform int32
+
+	pivot    mgl32.Vec3 // point the model rotates around
+	rotation mgl32.Quat // current rotation
+
+	dragging  bool
+	dragStart mgl32.Vec3 // point on the ball where the drag started
+	dragBase  mgl32.Quat // rotation when the drag started
+
+	dirty bool // model matrix changed since last Update
+}
+
+// NewArcball creates an arcball for program rotating the model around pivot
+func NewArcball(program uint32, pivot mgl32.Vec3) *Arcball {
+	return &Arcball{
+		program:      program,
+		modelUniform: gl.GetUniformLocation(program, gl.Str("model\x00")),
+		pivot:        pivot,
+		rotation:     mgl32.QuatIdent(),
+		dirty:        true,
+	}
+}
+
+// Begin starts a drag at the cursor position (window coordinates)
+func (a *Arcball) Begin(x, y float64) {
+	a.dragging = true
+	a.dragStart = arcballPoint(x, y)
+	a.dragBase = a.rotation
+}
+
+// Drag rotates by the arc from the drag start to the cursor position (window coordinates)
+func (a *Arcball) Drag(x, y float64) {
+	if !a.dragging {
+		return
+	}
+	current := arcballPoint(x, y)
+	angle := float32(math.Acos(float64(mgl32.Clamp(a.dragStart.Dot(current), -1, 1))))
+	axis := a.dragStart.Cross(current)
+	if axis.Len() < 1e-6 {
+		return
+	}
+	a.rotation = mgl32.QuatRotate(angle, axis.Normalize()).Mul(a.dragBase).Normalize()
+	a.dirty = true
+}
+
+// End stops dragging, the rotation is kept
+func (a *Arcball) End() {
+	a.dragging = false
+}
+
+// Reset removes any rotation
+func (a *Arcball) Reset() {
+	a.rotation = mgl32.QuatIdent()
+	a.dirty = true
+}
+
+func (a *Arcball) Rotation() mgl32.Quat { return a.rotation }
+
+// Model is the matrix to transform from object to world coordinates
+func (a *Arcball) Model() mgl32.Mat4 {
+	toPivot := mgl32.Translate3D(a.pivot.X(), a.pivot.Y(), a.pivot.Z())
+	fromPivot := mgl32.Translate3D(-a.pivot.X(), -a.pivot.Y(), -a.pivot.Z())
+	return toPivot.Mul4(a.rotation.Mat4()).Mul4(fromPivot)
+}
+
+// Update uploads the model matrix if it changed, the arcball's program must be in use
+func (a *Arcball) Update() {
+
+	if !a.dirty {
+		return
+	}
+
+	// CREATE (OBJECT) MODEL MATRIX
+	// a matrix to transform from object to world coordinates
+	model := a.Model()
+	gl.UniformMatrix4fv(a.modelUniform, 1, false, &model[0])
+
+	a.dirty = false
+
+}
+
+// arcballPoint maps window coordinates onto the unit ball, points outside
+// the ball are moved onto its edge
+func arcballPoint(x, y float64) mgl32.Vec3 {
+	radius := float32(math.Min(windowWidth, windowHeight)) / 2
+	p := mgl32.Vec3{
+		(float32(x) - windowWidth/2) / radius,
+		(windowHeight/2 - float32(y)) / radius, // window y points down
+		0,
+	}
+	if d := p.X()*p.X() + p.Y()*p.Y(); d <= 1 {
+		p[2] = float32(math.Sqrt(float64(1 - d)))
+		return p
+	}
+	return p.Normalize()
+}
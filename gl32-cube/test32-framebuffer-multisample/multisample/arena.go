@@ -0,0 +1,162 @@
+package multisample
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/go-gl/gl/v3.2-core/gl"
+)
+
+const (
+	arenaAlignment = 4 // byte alignment of blocks, vertex attributes should start on 4 byte boundaries
+)
+
+// ArenaBlock is the part of a BufferArena's VBO and IBO owned by one object
+type ArenaBlock struct {
+	VertexOffset int // byte offset in the arena VBO
+	VertexBytes  int
+	IndexOffset  int // byte offset in the arena IBO
+	IndexBytes   int
+}
+
+// arenaRange is a free byte range of an arena buffer
+type arenaRange struct {
+	offset int
+	bytes  int
+}
+
+// BufferArena packs the vertices and indices of many small objects into one
+// VBO and one IBO. Binding the buffers once and drawing each object at its
+// offset (attribute pointers and DrawElements take byte offsets, see
+// Layout.EnableAt) saves a buffer bind per object.
+//
+// Blocks are handed out first-fit from a free list and merged with their
+// neighbours when freed. The buffers never grow, that would move every block,
+// so Alloc fails once the arena is full.
+type BufferArena struct {
+	vbo        uint32
+	ibo        uint32
+	vertexFree []arenaRange // sorted by offset
+	indexFree  []arenaRange // sorted by offset
+}
+
+// NewBufferArena allocates the VBO and IBO, requires a current GL context
+func NewBufferArena(label string, vertexBytes, indexBytes int) *BufferArena {
+
+	a := &BufferArena{
+		vertexFree: []arenaRange{{0, vertexBytes}},
+		indexFree:  []arenaRange{{0, indexBytes}},
+	}
+
+	a.vbo = genBuffer(label + " vbo")
+	gl.BindBuffer(gl.ARRAY_BUFFER, a.vbo)
+	gl.BufferData(gl.ARRAY_BUFFER, vertexBytes, nil, gl.STATIC_DRAW) // initalize but do not copy any data
+	gpuResources.SetBytes(ResourceBuffer, a.vbo, vertexBytes)
+	gl.BindBuffer(gl.ARRAY_BUFFER, 0)
+
+	a.ibo = genBuffer(label + " ibo")
+	gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, a.ibo)
+	gl.BufferData(gl.ELEMENT_ARRAY_BUFFER, indexBytes, nil, gl.STATIC_DRAW)
+	gpuResources.SetBytes(ResourceBuffer, a.ibo, indexBytes)
+	gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, 0)
+
+	return a
+
+}
+
+// Alloc reserves a block for an object's vertices and indices
+func (a *BufferArena) Alloc(vertexBytes, indexBytes int) (ArenaBlock, error) {
+	vertexOffset, ok := takeRange(&a.vertexFree, align(vertexBytes, arenaAlignment))
+	if !ok {
+		return ArenaBlock{}, fmt.Errorf("ARENA: no room for %v vertex bytes", vertexBytes)
+	}
+	indexOffset, ok := takeRange(&a.indexFree, align(indexBytes, arenaAlignment))
+	if !ok {
+		giveRange(&a.vertexFree, arenaRange{vertexOffset, align(vertexBytes, arenaAlignment)})
+		return ArenaBlock{}, fmt.Errorf("ARENA: no room for %v index bytes", indexBytes)
+	}
+	return ArenaBlock{VertexOffset: vertexOffset, VertexBytes: vertexBytes, IndexOffset: indexOffset, IndexBytes: indexBytes}, nil
+}
+
+// Free returns a block to the arena, the GPU may still draw from it this frame
+// but its content is only overwritten by a later Alloc and upload
+func (a *BufferArena) Free(block ArenaBlock) {
+	giveRange(&a.vertexFree, arenaRange{block.VertexOffset, align(block.VertexBytes, arenaAlignment)})
+	giveRange(&a.indexFree, arenaRange{block.IndexOffset, align(block.IndexBytes, arenaAlignment)})
+}
+
+// Upload copies an object's vertex and index data into its block
+func (a *BufferArena) Upload(block ArenaBlock, vertices []byte, indices []uint16) {
+	gl.BindBuffer(gl.ARRAY_BUFFER, a.vbo)
+	gl.BufferSubData(gl.ARRAY_BUFFER, block.VertexOffset, len(vertices), gl.Ptr(vertices))
+	gl.BindBuffer(gl.ARRAY_BUFFER, 0)
+	if len(indices) == 0 {
+		return // e.g. quads, drawn with the shared quad indices
+	}
+	gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, a.ibo)
+	gl.BufferSubData(gl.ELEMENT_ARRAY_BUFFER, block.IndexOffset, len(indices)*bytesUint16, gl.Ptr(indices))
+	gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, 0)
+}
+
+// Bind binds the arena VBO and IBO, once for every object drawn from it
+func (a *BufferArena) Bind() {
+	gl.BindBuffer(gl.ARRAY_BUFFER, a.vbo)
+	gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, a.ibo)
+}
+
+// FreeBytes is the unallocated space of the VBO and IBO
+func (a *BufferArena) FreeBytes() (vertexBytes, indexBytes int) {
+	for _, r := range a.vertexFree {
+		vertexBytes += r.bytes
+	}
+	for _, r := range a.indexFree {
+		indexBytes += r.bytes
+	}
+	return vertexBytes, indexBytes
+}
+
+func (a *BufferArena) destroy() {
+	gpuResources.Release(ResourceBuffer, a.vbo)
+	gpuResources.Release(ResourceBuffer, a.ibo)
+	a.vbo, a.ibo = 0, 0
+}
+
+// takeRange removes bytes from the first free range large enough, and returns their offset
+func takeRange(free *[]arenaRange, bytes int) (int, bool) {
+	for i, r := range *free {
+		if r.bytes < bytes {
+			continue
+		}
+		if r.bytes == bytes {
+			*free = append((*free)[:i], (*free)[i+1:]...)
+		} else {
+			(*free)[i] = arenaRange{r.offset + bytes, r.bytes - bytes}
+		}
+		return r.offset, true
+	}
+	return 0, false
+}
+
+// giveRange adds a range back to the free list, merging it with adjacent ranges
+func giveRange(free *[]arenaRange, r arenaRange) {
+	if r.bytes == 0 {
+		return
+	}
+	ranges := append(*free, r)
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i].offset < ranges[j].offset })
+	merged := ranges[:1]
+	for _, next := range ranges[1:] {
+		last := &merged[len(merged)-1]
+		if last.offset+last.bytes == next.offset {
+			last.bytes += next.bytes
+			continue
+		}
+		merged = append(merged, next)
+	}
+	*free = merged
+}
+
+// align rounds n up to a multiple of alignment (a power of two)
+func align(n, alignment int) int {
+	return (n + alignment - 1) &^ (alignment - 1)
+}
@@ -0,0 +1,117 @@
+package multisample
+
+import (
+	"embed"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	assetPollInterval = time.Second // how often -assets files are checked for changes
+)
+
+//go:embed assets
+var embeddedAssets embed.FS
+
+var (
+	assets = &Assets{}
+)
+
+// Assets loads the files the demo ships with by name relative to the assets
+// directory, e.g. "shaders/water.frag", and the fonts (-fonts) and videos
+// (-video) flags name. They are embedded into the binary, so it runs from
+// any directory.
+//
+// A bundle (-bundle) is searched before the embedded files, so a demo can
+// ship its larger files (textures, fonts) next to the binary, see Bundle.
+//
+// During development -assets points at a directory (usually ./assets)
+// whose files are used instead of the embedded ones. Files read from it are
+// watched, see changed, and the demo rebuilds its GL objects when one is
+// saved (see reloadAssets).
+type Assets struct {
+	dir      string               // override directory, "" for the embedded files only
+	bundle   *Bundle              // nil without -bundle
+	embedded fs.FS                // the assets directory in embeddedAssets
+	modTimes map[string]time.Time // files read from dir and their modification time
+	lastPoll time.Time
+}
+
+// setup selects the override directory and the bundle, "" and nil to use the embedded files only
+func (a *Assets) setup(dir string, bundle *Bundle) {
+	embedded, err := fs.Sub(embeddedAssets, "assets")
+	if err != nil {
+		panic(err)
+	}
+	a.dir = dir
+	a.bundle = bundle
+	a.embedded = embedded
+	a.modTimes = map[string]time.Time{}
+}
+
+// Open opens an asset: from the override directory if it has the file, else
+// from the bundle, else the embedded one. Names that are no asset are opened as a path on disk,
+// so flags like -fonts take either.
+func (a *Assets) Open(name string) (io.ReadCloser, error) {
+
+	if a.dir != "" {
+		path := filepath.Join(a.dir, filepath.FromSlash(name))
+		if info, err := os.Stat(path); err == nil {
+			a.modTimes[path] = info.ModTime()
+			return os.Open(path)
+		}
+	}
+
+	if a.bundle != nil {
+		if file, err := a.bundle.Open(name); err == nil {
+			return file, nil
+		}
+	}
+
+	if file, err := a.embedded.Open(name); err == nil {
+		return file, nil
+	}
+
+	return os.Open(name)
+
+}
+
+// ReadFile reads a whole asset, see Open
+func (a *Assets) ReadFile(name string) ([]byte, error) {
+	file, err := a.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return io.ReadAll(file)
+}
+
+// source is the directory -packbundle packs: the override directory, or the embedded files
+func (a *Assets) source() fs.FS {
+	if a.dir != "" {
+		return os.DirFS(a.dir)
+	}
+	return a.embedded
+}
+
+// changed reports whether a file read from the override directory was
+// modified since it was read. It checks at most once per assetPollInterval,
+// cheap enough to call every frame.
+func (a *Assets) changed() bool {
+
+	if a.dir == "" || time.Since(a.lastPoll) < assetPollInterval {
+		return false
+	}
+	a.lastPoll = time.Now()
+
+	for path, modTime := range a.modTimes {
+		if info, err := os.Stat(path); err == nil && !info.ModTime().Equal(modTime) {
+			return true
+		}
+	}
+	return false
+
+}
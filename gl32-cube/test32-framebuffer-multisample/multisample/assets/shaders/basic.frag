@@ -0,0 +1,12 @@
+#version 150
+
+// input
+in vec3 fragmentColor;
+in vec2 fragmentTexCoord;
+
+// output
+out vec4 FragColor;
+
+void main() {
+	FragColor = vec4(fragmentColor, 1);
+}
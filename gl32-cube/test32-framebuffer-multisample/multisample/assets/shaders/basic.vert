@@ -0,0 +1,19 @@
+#version 150
+
+// input
+in vec2 vertexPosition;
+in vec3 vertexColor;
+in vec2 vertexTexCoord;
+
+// output
+out vec3 fragmentColor;
+out vec2 fragmentTexCoord;
+
+// input
+uniform mat4 transform; // rotation and aspect correction, see basicProgram
+
+void main() {
+	fragmentColor = vertexColor;
+	fragmentTexCoord = vertexTexCoord;
+	gl_Position = transform * vec4(vertexPosition, 0, 1);
+}
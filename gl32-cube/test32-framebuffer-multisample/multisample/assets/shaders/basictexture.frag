@@ -0,0 +1,15 @@
+#version 150
+
+// input
+uniform sampler2D sceneTexture; // what FramebufferScene drew offscreen
+
+// input
+in vec3 fragmentColor;
+in vec2 fragmentTexCoord;
+
+// output
+out vec4 FragColor;
+
+void main() {
+	FragColor = texture(sceneTexture, fragmentTexCoord) * vec4(fragmentColor, 1);
+}
@@ -0,0 +1,40 @@
+#version 150
+
+// input
+uniform sampler2D inputTexture;
+uniform vec2 uResolution;
+uniform float curvature;  // barrel distortion, 0 = flat
+uniform float scanlines;  // scanline darkness, 0 = none, 1 = black
+uniform float aberration; // color fringe width in pixels
+uniform vec2 uRenderScale;
+
+// input
+in vec2 fragmentTexCoord;
+
+// output
+out vec4 FragColor;
+
+void main() {
+	// barrel distortion, push texture coordinates outwards the further from the center
+	vec2 centered = (fragmentTexCoord / uRenderScale) * 2.0 - 1.0;
+	centered *= 1.0 + curvature * dot(centered, centered);
+	vec2 uv = centered * 0.5 + 0.5;
+	if (uv.x < 0.0 || uv.x > 1.0 || uv.y < 0.0 || uv.y > 1.0) {
+		FragColor = vec4(0, 0, 0, 1); // outside the glass
+		return;
+	}
+
+	// chromatic aberration, red and blue sampled slightly apart
+	// (uv is 0..1 across the image, scale back to the part of the texture in use)
+	vec2 fringe = centered * aberration / uResolution;
+	vec2 st = uv * uRenderScale;
+	vec4 color = texture(inputTexture, st);
+	color.r = texture(inputTexture, st + fringe).r;
+	color.b = texture(inputTexture, st - fringe).b;
+
+	// scanlines, every other pixel row darker
+	float line = 0.5 + 0.5 * cos(uv.y * uResolution.y * 3.14159);
+	color.rgb *= 1.0 - scanlines * line;
+
+	FragColor = color;
+}
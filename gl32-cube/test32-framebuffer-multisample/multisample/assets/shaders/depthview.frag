@@ -0,0 +1,27 @@
+#version 150
+
+// input
+uniform sampler2D depthTexture;
+uniform float near;
+uniform float far;
+uniform float perspective; // 1 = perspective, 0 = orthographic
+
+// input
+in vec2 fragmentTexCoord;
+
+// output
+out vec4 FragColor;
+
+void main() {
+	float depth = texture(depthTexture, fragmentTexCoord).r;
+
+	// eye distance between near (0) and far (1), orthographic depth is linear already
+	float linear = depth;
+	if (perspective > 0.5) {
+		float ndc = depth * 2.0 - 1.0;
+		float distance = 2.0 * near * far / (far + near - ndc * (far - near));
+		linear = clamp((distance - near) / (far - near), 0.0, 1.0);
+	}
+
+	FragColor = vec4(vec3(linear), 1.0);
+}
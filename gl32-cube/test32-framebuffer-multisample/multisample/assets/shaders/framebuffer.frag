@@ -0,0 +1,196 @@
+#version 150
+
+// input
+uniform int uEffect; // see Effect
+uniform float uTime;
+uniform float uAlphaCutoff; // see Material.SetAlphaTest, 0 = disabled
+uniform bool uAlphaToCoverage;
+uniform sampler2D spriteTexture;  // palette indices in the red channel
+uniform sampler2D paletteTexture; // one palette per row
+uniform int uPalette;             // palette row, see PaletteSwap
+uniform samplerCube reflectionMap; // surroundings, see CubemapProbe
+uniform sampler2D patternTexture;  // generated debug texture, see Pattern
+uniform sampler2D canvasTexture;   // painted on the CPU, see Canvas
+uniform sampler2D videoTexture;    // latest video frame, see VideoTexture
+uniform sampler2D passTexture;     // output of a pass, see PassOutputs
+uniform vec2 uRenderScale;         // part of the pass texture in use, see DynamicResolution
+uniform sampler2D droppedTexture;  // image dropped onto the window, see DroppedImage
+uniform sampler2D shapeTexture;    // size, radius, stroke, dashes and gradient of each quad, see ShapeTexture
+uniform sampler2D gradientTexture; // one gradient per row, see GradientRamps
+uniform bool uGradients;           // quads may have gradients, off for the arena objects
+uniform vec3 uEyePosition;         // camera position in world coordinates
+uniform int uFogMode;              // see FogMode, 0 = no fog
+uniform vec3 uFogColor;
+uniform vec2 uFogRange;            // linear fog start and end
+uniform float uFogDensity;         // exponential fog
+uniform bool uOpaqueAlpha;         // write alpha 1, for a transparent window (see WindowOptions)
+uniform bool uOverdraw;            // add 1 per fragment into the counter, see Overdraw
+
+// input
+in vec2 fragmentTexCoord;
+in vec4 fragmentColor;
+in vec3 worldPosition;
+in vec3 worldNormal;
+in float viewDepth;
+flat in int fragmentQuad;
+in vec2 localPosition;
+
+// output
+out vec4 FragColor;
+
+// signed distance of p to a rectangle centered at 0, 0 with rounded corners,
+// negative inside
+float roundedRectDistance(vec2 p, vec2 halfSize, float radius) {
+	radius = min(radius, min(halfSize.x, halfSize.y));
+	vec2 q = abs(p) - halfSize + radius;
+	return length(max(q, 0.0)) + min(max(q.x, q.y), 0.0) - radius;
+}
+
+// fill color of the quad, its gradient (see SetGradient) times the vertex color
+vec4 fillColor() {
+	if (!uGradients) {
+		return fragmentColor;
+	}
+	ivec2 texel = ivec2(fragmentQuad % 256 * 5 + 3, fragmentQuad / 256);
+	vec4 row = texelFetch(shapeTexture, texel + ivec2(1, 0), 0); // row, kind
+	if (row.x < 0.0) {
+		return fragmentColor;
+	}
+	vec4 geometry = texelFetch(shapeTexture, texel, 0);
+	float t;
+	if (row.y == 1.0) {
+		t = length(localPosition - geometry.xy) / geometry.z; // radial
+	} else {
+		vec2 direction = geometry.zw - geometry.xy;
+		t = dot(localPosition - geometry.xy, direction) / dot(direction, direction); // linear
+	}
+	vec2 size = vec2(textureSize(gradientTexture, 0));
+	vec2 uv = vec2((clamp(t, 0.0, 1.0) * (size.x - 1.0) + 0.5) / size.x, (row.x + 0.5) / size.y);
+	return texture(gradientTexture, uv) * fragmentColor;
+}
+
+// coverage of a pixel by the shape whose edge is at distance 0
+float edgeCoverage(float distance) {
+	float pixel = max(fwidth(distance), 0.0001); // one pixel in the units of the quad
+	return clamp(0.5 - distance / pixel, 0.0, 1.0);
+}
+
+void main() {
+	if (uEffect == 1) {
+		// stripes
+		float stripe = step(0.5, fract((gl_FragCoord.x + gl_FragCoord.y) / 16.0 - uTime));
+		FragColor = vec4(fragmentColor.rgb * (0.6 + 0.4 * stripe), fragmentColor.a);
+	} else if (uEffect == 2) {
+		// pulse
+		FragColor = vec4(fragmentColor.rgb * (0.75 + 0.25 * sin(uTime * 4.0)), fragmentColor.a);
+	} else if (uEffect == 3) {
+		// checker
+		vec2 cell = floor(fragmentTexCoord * 8.0);
+		float checker = mod(cell.x + cell.y, 2.0);
+		FragColor = vec4(mix(fragmentColor.rgb, vec3(1), 0.5 * checker), fragmentColor.a);
+	} else if (uEffect == 4) {
+		// palette
+		int index = int(texture(spriteTexture, fragmentTexCoord).r * 255.0 + 0.5);
+		FragColor = texelFetch(paletteTexture, ivec2(index, uPalette), 0);
+		if (FragColor.a == 0.0) {
+			discard; // transparent index
+		}
+	} else if (uEffect == 5) {
+		// reflection, the view ray mirrored at the surface looks up the surroundings
+		vec3 view = normalize(worldPosition - uEyePosition);
+		vec3 reflected = reflect(view, normalize(worldNormal));
+		FragColor = vec4(mix(fragmentColor.rgb, texture(reflectionMap, reflected).rgb, 0.8), fragmentColor.a);
+	} else if (uEffect == 6) {
+		// pattern
+		FragColor = texture(patternTexture, fragmentTexCoord) * fragmentColor;
+	} else if (uEffect == 7) {
+		// canvas
+		FragColor = texture(canvasTexture, fragmentTexCoord) * fragmentColor;
+	} else if (uEffect == 8) {
+		// video
+		FragColor = texture(videoTexture, fragmentTexCoord) * fragmentColor;
+	} else if (uEffect == 9) {
+		// pass
+		FragColor = vec4(texture(passTexture, fragmentTexCoord * uRenderScale).rgb, 1) * fragmentColor;
+	} else if (uEffect == 10) {
+		// dropped image
+		FragColor = texture(droppedTexture, fragmentTexCoord) * fragmentColor;
+	} else if (uEffect == 11) {
+		// rounded rectangle, signed distance to the edge in the units of the quad
+		ivec2 texel = ivec2(fragmentQuad % 256 * 5, fragmentQuad / 256);
+		vec4 shape = texelFetch(shapeTexture, texel, 0); // width, height, radius, stroke width
+		vec4 stroke = texelFetch(shapeTexture, texel + ivec2(1, 0), 0);
+		float distance = roundedRectDistance((fragmentTexCoord - 0.5) * shape.xy, shape.xy * 0.5, shape.z);
+		float fill = shape.w > 0.0 ? edgeCoverage(distance + shape.w) : 1.0;
+		FragColor = mix(stroke, fillColor(), fill);
+		FragColor.a *= edgeCoverage(distance);
+		if (FragColor.a == 0.0) {
+			discard; // outside the corners
+		}
+	} else if (uEffect == 12) {
+		// line, x along it from the start of the quad, y across
+		ivec2 texel = ivec2(fragmentQuad % 256 * 5, fragmentQuad / 256);
+		vec4 shape = texelFetch(shapeTexture, texel, 0); // length incl. caps, width, cap radius
+		vec4 dashes = texelFetch(shapeTexture, texel + ivec2(2, 0), 0); // dash, gap, phase, cap
+		vec2 p = (fragmentTexCoord - 0.5) * shape.xy;
+		float halfWidth = shape.y * 0.5;
+		float distance = roundedRectDistance(p, shape.xy * 0.5, shape.z);
+		if (dashes.x > 0.0) {
+			// distance along the line to the nearest dash, caps of butt dashes end there
+			float extend = dashes.w == 0.0 ? 0.0 : halfWidth;
+			float along = mod(p.x + shape.x * 0.5 - extend + dashes.z, dashes.x + dashes.y);
+			float outside = along <= dashes.x ? max(-along, along - dashes.x) : min(along - dashes.x, dashes.x + dashes.y - along);
+			float dash;
+			if (dashes.w == 1.0) {
+				dash = length(vec2(max(outside, 0.0), p.y)) - halfWidth; // round, a capsule
+			} else {
+				dash = max(outside - extend, abs(p.y) - halfWidth);
+			}
+			distance = max(distance, dash);
+		}
+		FragColor = fillColor();
+		FragColor.a *= edgeCoverage(distance);
+		if (FragColor.a == 0.0) {
+			discard; // between dashes
+		}
+	} else {
+		// color
+		FragColor = fillColor();
+	}
+
+	// cutout
+	if (uAlphaCutoff > 0.0) {
+		if (uAlphaToCoverage) {
+			// sharpen alpha to about one pixel wide edge around the cutoff
+			FragColor.a = clamp((FragColor.a - uAlphaCutoff) / max(fwidth(FragColor.a), 0.0001) + 0.5, 0.0, 1.0);
+		} else if (FragColor.a < uAlphaCutoff) {
+			discard;
+		} else {
+			FragColor.a = 1.0;
+		}
+	}
+
+	// fog, see Material.SetFog
+	if (uFogMode != 0) {
+		float fog;
+		if (uFogMode == 1) {
+			fog = clamp((viewDepth - uFogRange.x) / (uFogRange.y - uFogRange.x), 0.0, 1.0);
+		} else if (uFogMode == 2) {
+			fog = 1.0 - exp(-uFogDensity * viewDepth);
+		} else {
+			fog = 1.0 - exp(-pow(uFogDensity * viewDepth, 2.0));
+		}
+		FragColor.rgb = mix(FragColor.rgb, uFogColor, fog);
+	}
+
+	// the vertex alpha is meant for the anti-aliasing clear, not for the desktop
+	// behind the window. Resolving the samples then gives premultiplied edges.
+	if (uOpaqueAlpha) {
+		FragColor.a = 1.0;
+	}
+
+	// count the fragment, cutouts discarded above do not count
+	if (uOverdraw) {
+		FragColor = vec4(1.0, 0.0, 0.0, 0.0);
+	}
+}
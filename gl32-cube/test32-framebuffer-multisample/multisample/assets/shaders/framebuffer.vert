@@ -0,0 +1,40 @@
+#version 150
+
+// input
+uniform mat4 projection;
+uniform mat4 camera;
+uniform mat4 model;
+uniform bool uTinted;          // multiply by the tint of the quad, see QuadTints
+uniform sampler2D tintTexture; // RGBA per quad, 256 quads per row
+uniform int uVertexBase;       // first vertex of the chunk when drawn without base vertex, see QuadIndexBuffer.Draw
+
+// input
+in vec3 vertexPosition;
+in vec2 vertexTexCoord;
+in vec4 vertexColor;
+
+// output
+out vec2 fragmentTexCoord;
+out vec4 fragmentColor;
+out vec3 worldPosition;
+out vec3 worldNormal;
+out float viewDepth; // distance along the view direction, for fog
+flat out int fragmentQuad; // index of the quad, see ShapeTexture
+out vec2 localPosition;    // position in the units of the quad vertices, for gradients
+
+void main() {
+	vec4 world = model * vec4(vertexPosition, 1);
+	vec4 eye = camera * world;
+	fragmentTexCoord = vertexTexCoord;
+	fragmentColor = vertexColor;
+	int quad = (gl_VertexID + uVertexBase) / 4; // quads are 4 consecutive vertices
+	if (uTinted) {
+		fragmentColor *= texelFetch(tintTexture, ivec2(quad % 256, quad / 256), 0);
+	}
+	fragmentQuad = quad;
+	localPosition = vertexPosition.xy;
+	worldPosition = world.xyz;
+	worldNormal = mat3(model) * vec3(0, 0, 1); // quads face +z
+	viewDepth = -eye.z;
+	gl_Position = projection * eye;
+}
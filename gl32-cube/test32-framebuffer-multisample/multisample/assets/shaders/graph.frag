@@ -0,0 +1,11 @@
+#version 150
+
+// input
+in vec4 fragmentColor;
+
+// output
+out vec4 FragColor;
+
+void main() {
+	FragColor = fragmentColor;
+}
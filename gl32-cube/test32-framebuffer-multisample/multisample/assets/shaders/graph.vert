@@ -0,0 +1,13 @@
+#version 150
+
+// input
+in vec2 vertexPosition; // z-axis discarded
+in vec4 vertexColor;
+
+// output
+out vec4 fragmentColor;
+
+void main() {
+	fragmentColor = vertexColor;
+	gl_Position = vec4(vertexPosition, 0, 1);
+}
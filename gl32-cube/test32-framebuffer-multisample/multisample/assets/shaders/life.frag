@@ -0,0 +1,26 @@
+#version 150
+
+// input
+uniform sampler2D state;
+
+// output
+out vec4 FragColor;
+
+void main() {
+	ivec2 size = textureSize(state, 0);
+	ivec2 cell = ivec2(gl_FragCoord.xy);
+
+	int neighbours = 0;
+	for (int y = -1; y <= 1; y++) {
+		for (int x = -1; x <= 1; x++) {
+			if (x != 0 || y != 0) {
+				ivec2 neighbour = (cell + ivec2(x, y) + size) % size; // wraps around
+				neighbours += int(texelFetch(state, neighbour, 0).r > 0.5);
+			}
+		}
+	}
+
+	bool alive = texelFetch(state, cell, 0).r > 0.5;
+	bool next = neighbours == 3 || alive && neighbours == 2;
+	FragColor = vec4(next ? 1.0 : 0.0, 0, 0, 1);
+}
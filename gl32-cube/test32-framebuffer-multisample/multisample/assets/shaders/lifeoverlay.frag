@@ -0,0 +1,18 @@
+#version 150
+
+// input
+uniform sampler2D inputTexture;
+uniform sampler2D lifeTexture;
+uniform vec2 uRenderScale;
+
+// input
+in vec2 fragmentTexCoord;
+
+// output
+out vec4 FragColor;
+
+void main() {
+	vec4 color = texture(inputTexture, fragmentTexCoord);
+	float alive = texture(lifeTexture, fragmentTexCoord / uRenderScale).r;
+	FragColor = vec4(mix(color.rgb, vec3(0.2, 1, 0.4), alive * 0.5), color.a);
+}
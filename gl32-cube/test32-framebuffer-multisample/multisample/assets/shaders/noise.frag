@@ -0,0 +1,46 @@
+#version 150
+
+// input
+uniform float uSeed;
+uniform float uCells;   // lattice cells across the first octave, the period
+uniform float uOctaves;
+uniform float uSize;    // texture size in pixels
+
+// output
+out vec4 FragColor;
+
+// random unit gradient of a lattice point
+vec2 gradient(vec2 lattice, float period, float seed) {
+	lattice = mod(lattice, period);
+	float angle = fract(sin(dot(lattice + seed * 17.0, vec2(127.1, 311.7))) * 43758.5453) * 6.2832;
+	return vec2(cos(angle), sin(angle));
+}
+
+float perlin(vec2 p, float period, float seed) {
+	vec2 i = floor(p);
+	vec2 f = p - i;
+	vec2 u = f * f * f * (f * (f * 6.0 - 15.0) + 10.0);
+	float a = dot(gradient(i, period, seed), f);
+	float b = dot(gradient(i + vec2(1, 0), period, seed), f - vec2(1, 0));
+	float c = dot(gradient(i + vec2(0, 1), period, seed), f - vec2(0, 1));
+	float d = dot(gradient(i + vec2(1, 1), period, seed), f - vec2(1, 1));
+	return mix(mix(a, b, u.x), mix(c, d, u.x), u.y) * 1.4142;
+}
+
+float fbm(vec2 p, float seed) {
+	float sum = 0.0, amplitude = 1.0, total = 0.0, period = uCells;
+	for (int octave = 0; octave < int(uOctaves); octave++) {
+		sum += perlin(p, period, seed) * amplitude;
+		total += amplitude;
+		p *= 2.0;
+		period *= 2.0;
+		amplitude *= 0.5;
+	}
+	return sum / total;
+}
+
+void main() {
+	vec2 p = gl_FragCoord.xy / uSize * uCells;
+	vec3 noise = vec3(fbm(p, uSeed), fbm(p, uSeed + 1.0), fbm(p, uSeed + 2.0));
+	FragColor = vec4(noise * 0.5 + 0.5, 1);
+}
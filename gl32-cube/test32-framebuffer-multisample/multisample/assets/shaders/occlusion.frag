@@ -0,0 +1,55 @@
+#version 150
+
+// input
+uniform sampler2D depthTexture;
+uniform sampler2D noiseTexture; // random rotations, 4x4 texels
+uniform vec3 kernel[16];        // hemisphere samples, z along the normal
+uniform mat4 projection;
+uniform mat4 inverseProjection;
+uniform float radius;
+uniform vec2 uResolution;
+uniform vec2 uRenderScale;
+
+// input
+in vec2 fragmentTexCoord;
+
+// output
+out vec4 FragColor;
+
+// view position of the surface at texture coordinates st
+vec3 viewPosition(vec2 st) {
+	float depth = texture(depthTexture, st).r;
+	vec4 ndc = vec4(st / uRenderScale * 2.0 - 1.0, depth * 2.0 - 1.0, 1);
+	vec4 view = inverseProjection * ndc;
+	return view.xyz / view.w;
+}
+
+void main() {
+	if (texture(depthTexture, fragmentTexCoord).r == 1.0) {
+		FragColor = vec4(1); // background, nothing drawn
+		return;
+	}
+
+	// the normal is perpendicular to the surface's change towards the neighbouring pixels
+	vec3 position = viewPosition(fragmentTexCoord);
+	vec3 normal = normalize(cross(dFdx(position), dFdy(position)));
+
+	// turn the kernel around the normal by the noise
+	vec3 random = vec3(texture(noiseTexture, fragmentTexCoord * uResolution / 4.0).xy * 2.0 - 1.0, 0);
+	vec3 tangent = normalize(random - normal * dot(random, normal));
+	mat3 tbn = mat3(tangent, cross(normal, tangent), normal);
+
+	float occlusion = 0.0;
+	for (int i = 0; i < 16; i++) {
+		// project the sample point, compare its depth with the surface drawn there
+		vec3 probe = position + tbn * kernel[i] * radius;
+		vec4 clip = projection * vec4(probe, 1);
+		vec2 st = (clip.xy / clip.w * 0.5 + 0.5) * uRenderScale;
+		float surface = viewPosition(st).z;
+
+		// surfaces far in front are other objects, not a crease, fade them out
+		float range = smoothstep(0.0, 1.0, radius / abs(position.z - surface));
+		occlusion += (surface >= probe.z + 0.02 * radius ? 1.0 : 0.0) * range;
+	}
+	FragColor = vec4(vec3(1.0 - occlusion / 16.0), 1);
+}
@@ -0,0 +1,21 @@
+#version 150
+
+// input
+uniform sampler2D inputTexture;
+uniform vec2 uResolution;
+
+// input
+in vec2 fragmentTexCoord;
+
+// output
+out vec4 FragColor;
+
+void main() {
+	float sum = 0.0;
+	for (int y = -2; y < 2; y++) {
+		for (int x = -2; x < 2; x++) {
+			sum += texture(inputTexture, fragmentTexCoord + vec2(x, y) / uResolution).r;
+		}
+	}
+	FragColor = vec4(vec3(sum / 16.0), 1);
+}
@@ -0,0 +1,36 @@
+#version 150
+
+// input
+uniform sampler2D inputTexture;
+uniform vec2 uResolution;
+
+// input
+in vec2 fragmentTexCoord;
+
+// output
+out vec4 FragColor;
+
+float luminance(vec2 offset) {
+	vec3 c = texture(inputTexture, fragmentTexCoord + offset / uResolution).rgb;
+	return dot(c, vec3(0.299, 0.587, 0.114));
+}
+
+void main() {
+	// 3x3 neighbourhood
+	float tl = luminance(vec2(-1, 1));
+	float t  = luminance(vec2( 0, 1));
+	float tr = luminance(vec2( 1, 1));
+	float l  = luminance(vec2(-1, 0));
+	float r  = luminance(vec2( 1, 0));
+	float bl = luminance(vec2(-1,-1));
+	float b  = luminance(vec2( 0,-1));
+	float br = luminance(vec2( 1,-1));
+
+	// Sobel gradients
+	float gx = (tr + 2.0 * r + br) - (tl + 2.0 * l + bl);
+	float gy = (tl + 2.0 * t + tr) - (bl + 2.0 * b + br);
+	float edge = smoothstep(0.1, 0.4, length(vec2(gx, gy)));
+
+	vec4 color = texture(inputTexture, fragmentTexCoord);
+	FragColor = vec4(mix(color.rgb, vec3(0), edge), color.a);
+}
@@ -0,0 +1,28 @@
+#version 150
+
+// input
+uniform sampler2D countTexture; // fragments per pixel, see Overdraw
+uniform float maxCount;         // count shown white
+
+// input
+in vec2 fragmentTexCoord;
+
+// output
+out vec4 FragColor;
+
+// heat palette, evenly spaced from 0 to maxCount
+const vec3 heat[6] = vec3[6](
+	vec3(0.0, 0.0, 0.0),
+	vec3(0.0, 0.0, 1.0),
+	vec3(0.0, 1.0, 0.0),
+	vec3(1.0, 1.0, 0.0),
+	vec3(1.0, 0.0, 0.0),
+	vec3(1.0, 1.0, 1.0)
+);
+
+void main() {
+	float count = texture(countTexture, fragmentTexCoord).r;
+	float t = clamp(count / maxCount, 0.0, 1.0) * 5.0;
+	int i = int(min(floor(t), 4.0));
+	FragColor = vec4(mix(heat[i], heat[i + 1], t - float(i)), 1.0);
+}
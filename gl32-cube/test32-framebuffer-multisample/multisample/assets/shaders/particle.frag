@@ -0,0 +1,20 @@
+#version 150
+
+// input
+uniform float uLifetime;
+
+// input
+in vec2 fragmentTexCoord;
+in float fragmentAge;
+
+// output
+out vec4 FragColor;
+
+void main() {
+	// round spark, soft edge
+	float alpha = 1.0 - smoothstep(0.3, 0.5, length(fragmentTexCoord - 0.5));
+
+	// cools from yellow to red and fades out
+	float t = fragmentAge / uLifetime;
+	FragColor = vec4(mix(vec3(1, 0.9, 0.4), vec3(1, 0.2, 0.1), t), alpha * (1.0 - t));
+}
@@ -0,0 +1,16 @@
+#version 150
+
+// input
+uniform mat4 camera;
+
+// input
+in vec3 vertexPosition;
+in float vertexAge;
+
+// output
+out float geometryAge;
+
+void main() {
+	geometryAge = vertexAge;
+	gl_Position = camera * vec4(vertexPosition, 1); // eye coordinates, the geometry shader projects
+}
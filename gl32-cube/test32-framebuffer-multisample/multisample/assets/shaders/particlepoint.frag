@@ -0,0 +1,19 @@
+#version 150
+
+// input
+uniform float uLifetime;
+
+// input
+in float fragmentAge;
+
+// output
+out vec4 FragColor;
+
+void main() {
+	// round spark, soft edge, gl_PointCoord runs 0..1 across the point like the quad's texture coordinates
+	float alpha = 1.0 - smoothstep(0.3, 0.5, length(gl_PointCoord - 0.5));
+
+	// cools from yellow to red and fades out
+	float t = fragmentAge / uLifetime;
+	FragColor = vec4(mix(vec3(1, 0.9, 0.4), vec3(1, 0.2, 0.1), t), alpha * (1.0 - t));
+}
@@ -0,0 +1,23 @@
+#version 150
+
+// input
+uniform mat4 projection;
+uniform mat4 camera;
+uniform float uSize;
+uniform float uViewportHeight; // pixels, see viewportSize
+
+// input
+in vec3 vertexPosition;
+in float vertexAge;
+
+// output
+out float fragmentAge;
+
+void main() {
+	fragmentAge = vertexAge;
+	gl_Position = projection * camera * vec4(vertexPosition, 1);
+
+	// uSize world units in pixels at the particle's distance: projection[1][1]
+	// maps eye units to NDC at distance 1, w is the distance (1 orthographic)
+	gl_PointSize = uSize * projection[1][1] * uViewportHeight * 0.5 / gl_Position.w;
+}
@@ -0,0 +1,38 @@
+#version 150
+
+// input
+uniform float uDelta;
+uniform float uSeed;
+uniform vec3 uEmitter;
+uniform float uGravity;
+uniform float uLifetime;
+
+// input
+in vec3 vertexPosition;
+in vec3 vertexVelocity;
+in float vertexAge;
+
+// output
+out vec3 outPosition;
+out vec3 outVelocity;
+out float outAge;
+
+// random 0..1, differs per particle, update and n
+float random(float n) {
+	return fract(sin(dot(vec2(float(gl_VertexID), uSeed * 7.0 + n), vec2(12.9898, 78.233))) * 43758.5453);
+}
+
+void main() {
+	vec3 position = vertexPosition;
+	vec3 velocity = vertexVelocity;
+	float age = vertexAge + uDelta;
+	if (age >= uLifetime) {
+		position = uEmitter;
+		velocity = vec3((random(0.0) * 2.0 - 1.0) * 0.2, 0.8 + random(1.0) * 0.3, (random(2.0) * 2.0 - 1.0) * 0.2);
+		age = 0.0;
+	}
+	velocity.y -= uGravity * uDelta;
+	outPosition = position + velocity * uDelta;
+	outVelocity = velocity;
+	outAge = age;
+}
@@ -0,0 +1,19 @@
+#version 150
+
+// input
+uniform sampler2D downsampledTexture; // the left eye in stereo modes
+uniform sampler2D rightTexture;       // the right eye, see Stereo
+uniform vec3 uLeftMask;               // color channels taken from each eye
+uniform vec3 uRightMask;
+
+// input
+in vec2 fragmentTexCoord;
+
+// output
+out vec4 FragColor;
+
+void main() {
+	vec4 left = texture(downsampledTexture, fragmentTexCoord);
+	vec3 right = texture(rightTexture, fragmentTexCoord).rgb;
+	FragColor = vec4(left.rgb * uLeftMask + right * uRightMask, left.a);
+}
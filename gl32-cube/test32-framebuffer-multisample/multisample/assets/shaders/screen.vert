@@ -0,0 +1,16 @@
+#version 150
+
+// input
+in vec2 vertexPosition; // z-axis discarded
+in vec2 vertexTexCoord;
+
+// output
+out vec2 fragmentTexCoord;
+
+// input
+uniform vec2 uRenderScale; // part of the texture in use, see DynamicResolution
+
+void main() {
+	fragmentTexCoord = vertexTexCoord * uRenderScale;
+	gl_Position = vec4(vertexPosition, 0, 1);
+}
@@ -0,0 +1,5 @@
+#version 150
+
+// depth only, written by the rasterizer
+void main() {
+}
@@ -0,0 +1,11 @@
+#version 150
+
+// input
+uniform mat4 lightMatrix; // world to the clip coordinates of a cascade
+
+// input
+in vec3 vertexPosition;
+
+void main() {
+	gl_Position = lightMatrix * vec4(vertexPosition, 1);
+}
@@ -0,0 +1,18 @@
+#version 150
+
+// input
+uniform sampler2D inputTexture;
+uniform sampler2D occlusionTexture;
+uniform float strength;
+
+// input
+in vec2 fragmentTexCoord;
+
+// output
+out vec4 FragColor;
+
+void main() {
+	vec4 color = texture(inputTexture, fragmentTexCoord);
+	float occlusion = texture(occlusionTexture, fragmentTexCoord).r;
+	FragColor = vec4(color.rgb * mix(1.0, occlusion, strength), color.a);
+}
@@ -0,0 +1,90 @@
+#version 150
+
+// input
+uniform sampler2D splatMap; // layer weights (r = sand, g = grass, b = rock, a = snow)
+uniform sampler2D layerSand;
+uniform sampler2D layerGrass;
+uniform sampler2D layerRock;
+uniform sampler2D layerSnow;
+uniform float tiling;
+uniform bool decodeSRGB; // layers are uploaded as linear RGBA, see TextureColor
+uniform vec3 sunDirection; // towards the sun, normalized
+
+// sun shadows, see ShadowCascades
+uniform sampler2DArrayShadow shadowMap; // one depth layer per cascade
+uniform mat4 uShadowMatrices[4];        // world to shadow map coordinates
+uniform float uCascadeSplits[4];        // view depth where each cascade ends
+uniform int uCascades;                  // 0 = no shadows
+uniform bool uCascadeDebug;             // tint by cascade
+
+const vec3 cascadeColors[4] = vec3[4](vec3(1, 0.3, 0.3), vec3(0.3, 1, 0.3), vec3(0.3, 0.3, 1), vec3(1, 1, 0.3));
+
+// input
+in vec2 fragmentTexCoord;
+in vec3 fragmentNormal;
+in vec3 fragmentWorld;
+in float fragmentDepth;
+
+// output
+out vec4 FragColor;
+
+// sRGB <-> linear, the usual 2.2 gamma approximation
+vec3 toLinear(vec3 c) {
+	return decodeSRGB ? pow(c, vec3(2.2)) : c;
+}
+vec3 toSRGB(vec3 c) {
+	return pow(c, vec3(1.0 / 2.2));
+}
+
+// cascade covering the fragment, -1 beyond the last one
+int cascade() {
+	for (int i = 0; i < uCascades; i++) {
+		if (fragmentDepth <= uCascadeSplits[i]) {
+			return i;
+		}
+	}
+	return -1;
+}
+
+// sunlight reaching the fragment, 0 = in shadow, 1 = lit
+// 3x3 samples soften the edges, each already averages 4 comparisons (linear filter)
+float sunlight(int cascade) {
+	if (cascade < 0) {
+		return 1.0;
+	}
+	vec4 p = uShadowMatrices[cascade] * vec4(fragmentWorld, 1);
+	if (p.z > 1.0) {
+		return 1.0; // beyond the far plane of the cascade
+	}
+	vec2 texel = 1.0 / vec2(textureSize(shadowMap, 0).xy);
+	float lit = 0.0;
+	for (int y = -1; y <= 1; y++) {
+		for (int x = -1; x <= 1; x++) {
+			lit += texture(shadowMap, vec4(p.xy + vec2(x, y) * texel, cascade, p.z));
+		}
+	}
+	return lit / 9.0;
+}
+
+void main() {
+	vec4 weights = texture(splatMap, fragmentTexCoord);
+	weights /= max(dot(weights, vec4(1)), 0.001);
+
+	// blend and light in linear space
+	vec2 tiled = fragmentTexCoord * tiling;
+	vec3 ground = toLinear(texture(layerSand, tiled).rgb) * weights.r
+		+ toLinear(texture(layerGrass, tiled).rgb) * weights.g
+		+ toLinear(texture(layerRock, tiled).rgb) * weights.b
+		+ toLinear(texture(layerSnow, tiled).rgb) * weights.a;
+
+	// simple directional light, so the relief is visible
+	int c = cascade();
+	float light = 0.35 + 0.65 * max(dot(normalize(fragmentNormal), sunDirection), 0.0) * sunlight(c);
+	vec3 color = ground * light;
+	if (uCascadeDebug && c >= 0) {
+		color *= cascadeColors[c];
+	}
+
+	// the proxy screen is not an sRGB framebuffer, encode ourselves
+	FragColor = vec4(toSRGB(color), 1);
+}
@@ -0,0 +1,29 @@
+#version 150
+
+// input
+uniform mat4 projection;
+uniform mat4 camera;
+uniform mat4 model;
+uniform vec4 uClipPlane; // see ContextTerrain.drawFrom
+
+// input
+in vec3 vertexPosition;
+in vec3 vertexNormal;
+in vec2 vertexTexCoord;
+
+// output
+out vec2 fragmentTexCoord;
+out vec3 fragmentNormal;
+out vec3 fragmentWorld; // position in world coordinates, for the shadow maps
+out float fragmentDepth; // distance along the view direction, picks the shadow cascade
+
+void main() {
+	vec4 world = model * vec4(vertexPosition, 1);
+	vec4 eye = camera * world;
+	fragmentTexCoord = vertexTexCoord;
+	fragmentNormal = mat3(model) * vertexNormal;
+	fragmentWorld = world.xyz;
+	fragmentDepth = -eye.z;
+	gl_ClipDistance[0] = dot(world, uClipPlane);
+	gl_Position = projection * eye;
+}
@@ -0,0 +1,15 @@
+#version 150
+
+// input
+in vec2 fragmentTexCoord;
+in vec4 fragmentColor;
+
+uniform sampler2D fontAtlas; // coverage in the red channel
+
+// output
+out vec4 FragColor;
+
+void main() {
+	float coverage = texture(fontAtlas, fragmentTexCoord).r;
+	FragColor = vec4(fragmentColor.rgb, fragmentColor.a * coverage);
+}
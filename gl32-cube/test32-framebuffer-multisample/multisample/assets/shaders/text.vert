@@ -0,0 +1,20 @@
+#version 150
+
+// input, corner of the unit quad and the glyph it is part of
+in vec2 corner;
+in vec4 glyphRect; // x, y, width, height in pixels, top-left origin
+in vec4 glyphUV;   // u, v, width, height in the atlas
+in vec4 glyphColor;
+
+uniform vec2 uScreenSize;
+
+// output
+out vec2 fragmentTexCoord;
+out vec4 fragmentColor;
+
+void main() {
+	vec2 pixel = glyphRect.xy + corner * glyphRect.zw;
+	fragmentTexCoord = glyphUV.xy + corner * glyphUV.zw;
+	fragmentColor = glyphColor;
+	gl_Position = vec4(pixel.x / uScreenSize.x * 2 - 1, 1 - pixel.y / uScreenSize.y * 2, 0, 1);
+}
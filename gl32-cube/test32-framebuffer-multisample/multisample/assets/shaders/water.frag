@@ -0,0 +1,39 @@
+#version 150
+
+// input
+uniform sampler2D reflectionTexture; // mirrored terrain, see ContextWater.drawReflection
+uniform sampler2D rippleTexture;     // tileable noise, 0.5 = flat
+uniform float uTime;
+uniform vec3 uEyePosition;
+uniform vec3 sunDirection;
+uniform vec2 uRenderScale;
+
+// input
+in vec3 worldPosition;
+in vec3 worldNormal;
+in vec4 clipPosition;
+
+// output
+out vec4 FragColor;
+
+void main() {
+	// two ripple layers drifting in different directions, so the pattern never repeats visibly
+	vec2 ripple = texture(rippleTexture, worldPosition.xz * 1.5 + uTime * vec2(0.03, 0.01)).rg
+	            + texture(rippleTexture, worldPosition.xz * 2.3 - uTime * vec2(0.01, 0.04)).rg - 1.0;
+	vec3 normal = normalize(worldNormal + vec3(ripple.x, 0, ripple.y) * 0.2);
+	vec3 view = normalize(uEyePosition - worldPosition);
+
+	// the mirrored camera saw the reflected point at the same screen position, ripples distort it
+	vec2 st = clipPosition.xy / clipPosition.w * 0.5 + 0.5 + normal.xz * 0.03;
+	vec3 reflection = texture(reflectionTexture, clamp(st, 0.001, 0.999) * uRenderScale).rgb;
+
+	// Fresnel (Schlick's approximation), water mirrors at grazing angles and is clear from above
+	float fresnel = 0.02 + 0.98 * pow(1.0 - max(dot(normal, view), 0.0), 5.0);
+	vec3 color = mix(vec3(0.05, 0.2, 0.3), reflection, fresnel);
+
+	// sun glint
+	vec3 halfway = normalize(view + sunDirection);
+	color += vec3(pow(max(dot(normal, halfway), 0.0), 200.0));
+
+	FragColor = vec4(color, mix(0.7, 1.0, fresnel));
+}
@@ -0,0 +1,41 @@
+#version 150
+
+// input
+uniform mat4 projection;
+uniform mat4 camera;
+uniform float uTime;
+uniform float uWaterLevel;
+
+// input
+in vec3 vertexPosition; // flat grid, y is replaced
+
+// output
+out vec3 worldPosition;
+out vec3 worldNormal;
+out vec4 clipPosition;
+
+// sine waves: direction (x, z), wavelength and amplitude in world units
+const vec4 waves[3] = vec4[3](
+	vec4(1.0, 0.3, 0.35, 0.006),
+	vec4(-0.4, 1.0, 0.21, 0.004),
+	vec4(0.7, -0.8, 0.13, 0.002)
+);
+
+void main() {
+	vec3 p = vec3(vertexPosition.x, uWaterLevel, vertexPosition.z);
+
+	// sum the waves, the slope of each is its derivative
+	vec2 slope = vec2(0);
+	for (int i = 0; i < 3; i++) {
+		vec2 direction = normalize(waves[i].xy);
+		float k = 6.2832 / waves[i].z;                              // wave number
+		float phase = k * dot(direction, p.xz) - uTime * sqrt(k); // long waves travel faster
+		p.y += waves[i].w * sin(phase);
+		slope += direction * waves[i].w * k * cos(phase);
+	}
+
+	worldPosition = p;
+	worldNormal = normalize(vec3(-slope.x, 1, -slope.y));
+	clipPosition = projection * camera * vec4(p, 1);
+	gl_Position = clipPosition;
+}
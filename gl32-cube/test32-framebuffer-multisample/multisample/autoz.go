@@ -0,0 +1,45 @@
+package multisample
+
+import (
+	"fmt"
+)
+
+// autoZ hands out increasing z values, see ElementQuads.EnableAutoZ
+type autoZ struct {
+	back  float32 // z of the first quad
+	front float32 // z the last slot approaches, closer to the camera than back
+	slots int     // number of quads that fit between back and front
+	next  int     // slot of the next quad
+}
+
+// EnableAutoZ makes the Draw* methods ignore their z argument and give every
+// new quad a z in front of the previous one, from back towards front. With
+// depth testing enabled, draw order becomes stacking order (like a painter's
+// algorithm), yet quads can still be reordered into batches freely.
+//
+// slots is the most quads expected, the range is split evenly between them.
+// Keep back-front wide enough for the depth buffer to tell slots apart: a
+// 24 bit depth buffer with an orthographic projection resolves about
+// (far-near)/2^24 world units.
+func (q *ElementQuads) EnableAutoZ(back, front float32, slots int) {
+	q.autoZ = &autoZ{back: back, front: front, slots: slots}
+}
+
+// DisableAutoZ goes back to the z passed to the Draw* methods
+func (q *ElementQuads) DisableAutoZ() {
+	q.autoZ = nil
+}
+
+// zFor returns the z of the next quad, z itself unless auto z is enabled
+func (q *ElementQuads) zFor(z float32) float32 {
+	if q.autoZ == nil {
+		return z
+	}
+	a := q.autoZ
+	if a.next >= a.slots {
+		panic(fmt.Sprintf("AUTO_Z: more than %v quads, increase slots of EnableAutoZ", a.slots))
+	}
+	z = a.back + (a.front-a.back)*float32(a.next)/float32(a.slots)
+	a.next++
+	return z
+}
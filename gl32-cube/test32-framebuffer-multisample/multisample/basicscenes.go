@@ -0,0 +1,269 @@
+package multisample
+
+import (
+	"github.com/go-gl/gl/v3.2-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+const (
+	basicSpinSpeed = 0.5 // radians per second the basic scenes rotate
+)
+
+// basicMesh is an indexed 2D mesh of the basic scenes, vertices are
+// interleaved x,y, r,g,b, u,v
+type basicMesh struct {
+	layout        *Layout
+	vao, vbo, ibo uint32
+	count         int32
+}
+
+// newBasicMesh uploads vertices and indices into a new VAO
+func newBasicMesh(label string, vertices []float32, indices []uint16) *basicMesh {
+
+	m := &basicMesh{count: int32(len(indices))}
+	m.layout = NewLayout().Float32("position", 2).Float32("color", 3).Float32("texcoord", 2).Interleave().Compute(len(vertices) / 7)
+
+	m.vao = genVertexArray(label + " vao")
+	gl.BindVertexArray(m.vao)
+
+	m.vbo = genBuffer(label + " vbo")
+	gl.BindBuffer(gl.ARRAY_BUFFER, m.vbo)
+	gl.BufferData(gl.ARRAY_BUFFER, len(vertices)*bytesFloat32, gl.Ptr(vertices), gl.STATIC_DRAW)
+	gpuResources.SetBytes(ResourceBuffer, m.vbo, len(vertices)*bytesFloat32)
+
+	// the element buffer binding is part of the VAO
+	m.ibo = genBuffer(label + " ibo")
+	gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, m.ibo)
+	gl.BufferData(gl.ELEMENT_ARRAY_BUFFER, len(indices)*bytesUint16, gl.Ptr(indices), gl.STATIC_DRAW)
+	gpuResources.SetBytes(ResourceBuffer, m.ibo, len(indices)*bytesUint16)
+
+	gl.BindVertexArray(0)
+	gl.BindBuffer(gl.ARRAY_BUFFER, 0)
+	return m
+
+}
+
+// draw draws the mesh with program, transformed by transform
+func (m *basicMesh) draw(program *basicProgram, transform mgl32.Mat4) {
+
+	gl.UseProgram(program.program)
+	gl.UniformMatrix4fv(program.uniformTransform, 1, false, &transform[0])
+	program.material.Bind()
+
+	// gl.Begin()
+	gl.BindVertexArray(m.vao)
+	gl.BindBuffer(gl.ARRAY_BUFFER, m.vbo)
+	m.layout.Enable(program.attribVertexPosition, program.attribVertexColor, program.attribVertexTexCoord)
+	validateDraw(program.name)
+	gl.DrawElements(gl.TRIANGLES, m.count, gl.UNSIGNED_SHORT, nil)
+
+	// gl.End()
+	m.layout.Disable(program.attribVertexPosition, program.attribVertexColor, program.attribVertexTexCoord)
+	gl.BindBuffer(gl.ARRAY_BUFFER, 0)
+	gl.BindVertexArray(0)
+	program.material.Unbind()
+	gl.UseProgram(0)
+
+}
+
+func (m *basicMesh) destroy() {
+	gpuResources.Release(ResourceVertexArray, m.vao)
+	gpuResources.Release(ResourceBuffer, m.vbo)
+	gpuResources.Release(ResourceBuffer, m.ibo)
+	m.vao, m.vbo, m.ibo = 0, 0, 0
+}
+
+// basicProgram is vertexShaderBasic with a fragment shader, plain colors or a texture
+type basicProgram struct {
+	name     string
+	program  uint32
+	material *Material
+
+	attribVertexPosition uint32
+	attribVertexColor    uint32
+	attribVertexTexCoord uint32
+	uniformTransform     int32
+}
+
+func newBasicProgram(name string, fragmentShader string) *basicProgram {
+
+	program, err := newProgram(vertexShaderBasic, fragmentShader)
+	if err != nil {
+		panic(err)
+	}
+	gpuResources.SetLabel(ResourceProgram, program, name+" program")
+
+	info := programInfos.Get(program)
+	return &basicProgram{
+		name:                 name,
+		program:              program,
+		material:             NewMaterial(program),
+		attribVertexPosition: uint32(gl.GetAttribLocation(program, gl.Str("vertexPosition\x00"))),
+		attribVertexColor:    uint32(gl.GetAttribLocation(program, gl.Str("vertexColor\x00"))),
+		attribVertexTexCoord: uint32(gl.GetAttribLocation(program, gl.Str("vertexTexCoord\x00"))),
+		uniformTransform:     info.UniformLocation("transform"),
+	}
+
+}
+
+func (p *basicProgram) destroy() {
+	gpuResources.Release(ResourceProgram, p.program)
+	p.program = 0
+}
+
+// basicTransform rotates by angle about the center of a target of the given
+// size, keeping shapes square whatever its aspect ratio
+func basicTransform(angle float64, width, height int32) mgl32.Mat4 {
+	aspect := float32(width) / float32(height)
+	return mgl32.Scale3D(1/aspect, 1, 1).Mul4(mgl32.HomogRotate3DZ(float32(angle)))
+}
+
+// clearScreen binds the default framebuffer over the whole window and clears it
+func clearScreen() (width, height int32) {
+	width, height = screenSize()
+	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+	setViewport(0, 0, width, height)
+	gl.ClearColor(0.2, 0.2, 0.2, 1)
+	gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
+	return width, height
+}
+
+// TriangleScene is the simplest demo: one spinning triangle with a color
+// per corner, blended across it by the rasterizer
+type TriangleScene struct {
+	program *basicProgram
+	mesh    *basicMesh
+	angle   float64
+}
+
+func (s *TriangleScene) Name() string {
+	return "triangle"
+}
+
+func (s *TriangleScene) Load() {}
+
+func (s *TriangleScene) Setup() {
+	s.program = newBasicProgram("triangle", fragmentShaderBasic)
+	s.mesh = newBasicMesh("triangle", []float32{
+		0, 0.6, 1, 0, 0, 0.5, 1,
+		-0.52, -0.3, 0, 1, 0, 0, 0,
+		0.52, -0.3, 0, 0, 1, 1, 0,
+	}, []uint16{0, 1, 2})
+}
+
+func (s *TriangleScene) Update(now, dt float64) {
+	s.angle += dt * basicSpinSpeed
+}
+
+func (s *TriangleScene) Draw() {
+	width, height := clearScreen()
+	s.mesh.draw(s.program, basicTransform(s.angle, width, height))
+}
+
+func (s *TriangleScene) Unload() {
+	s.mesh.destroy()
+	s.program.destroy()
+}
+
+// QuadScene draws a quad from a vertex buffer and an index buffer, the four
+// corners are shared by its two triangles
+type QuadScene struct {
+	program *basicProgram
+	mesh    *basicMesh
+	angle   float64
+}
+
+func (s *QuadScene) Name() string {
+	return "quad"
+}
+
+func (s *QuadScene) Load() {}
+
+func (s *QuadScene) Setup() {
+	s.program = newBasicProgram("quad", fragmentShaderBasic)
+	s.mesh = newBasicMesh("quad", []float32{
+		-0.5, -0.5, 1, 0.5, 0, 0, 0,
+		0.5, -0.5, 0, 0.5, 1, 1, 0,
+		0.5, 0.5, 1, 1, 1, 1, 1,
+		-0.5, 0.5, 0.5, 0, 1, 0, 1,
+	}, []uint16{0, 1, 2, 2, 3, 0})
+}
+
+func (s *QuadScene) Update(now, dt float64) {
+	s.angle += dt * basicSpinSpeed
+}
+
+func (s *QuadScene) Draw() {
+	width, height := clearScreen()
+	s.mesh.draw(s.program, basicTransform(s.angle, width, height))
+}
+
+func (s *QuadScene) Unload() {
+	s.mesh.destroy()
+	s.program.destroy()
+}
+
+// FramebufferScene renders the spinning triangle into a texture (a Pass the
+// size of the screen, without multisampling) and shows that texture on a
+// quad turning the other way, the render-to-texture round trip the proxy
+// screen of the msaa scene is built on
+type FramebufferScene struct {
+	pass     *Pass
+	triangle TriangleScene
+	screen   *basicProgram
+	quad     *basicMesh
+	angle    float64
+}
+
+func (s *FramebufferScene) Name() string {
+	return "framebuffer"
+}
+
+func (s *FramebufferScene) Load() {}
+
+func (s *FramebufferScene) Setup() {
+
+	var err error
+	s.pass, err = renderer.Pass("framebuffer scene", PassOptions{Size: SizeScreen, Color: gl.RGBA8, Filter: gl.LINEAR, Depth: gl.DEPTH_COMPONENT24})
+	if err != nil {
+		panic(err)
+	}
+
+	s.triangle.Setup()
+	s.screen = newBasicProgram("framebuffer screen", fragmentShaderBasicTexture)
+	s.screen.material.SetTexture("sceneTexture", gl.TEXTURE_2D, s.pass.ColorTexture())
+	s.quad = newBasicMesh("framebuffer screen", []float32{
+		-0.8, -0.8, 1, 1, 1, 0, 0,
+		0.8, -0.8, 1, 1, 1, 1, 0,
+		0.8, 0.8, 1, 1, 1, 1, 1,
+		-0.8, 0.8, 1, 1, 1, 0, 1,
+	}, []uint16{0, 1, 2, 2, 3, 0})
+
+}
+
+func (s *FramebufferScene) Update(now, dt float64) {
+	s.triangle.Update(now, dt)
+	s.angle -= dt * basicSpinSpeed / 4
+}
+
+func (s *FramebufferScene) Draw() {
+
+	// the triangle into the texture, without aspect correction: the texture
+	// is shown on a square quad
+	s.pass.Bind()
+	gl.ClearColor(0.1, 0.1, 0.3, 1)
+	gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
+	s.triangle.mesh.draw(s.triangle.program, basicTransform(s.triangle.angle, 1, 1))
+
+	// the texture onto the screen
+	width, height := clearScreen()
+	s.quad.draw(s.screen, basicTransform(s.angle, width, height))
+
+}
+
+func (s *FramebufferScene) Unload() {
+	s.quad.destroy()
+	s.screen.destroy()
+	s.triangle.Unload()
+	s.pass.destroy()
+}
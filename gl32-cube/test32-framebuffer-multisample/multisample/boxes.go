@@ -0,0 +1,120 @@
+package multisample
+
+import (
+	"image/color"
+	"math"
+)
+
+const (
+	boxSprites      = 2  // filled box, frame
+	boxSpriteSize   = 16 // texels per side of each sprite in the text atlas
+	boxSpriteCorner = 6  // texels of the corners, kept at their size by the 9-slice
+	boxFrameWidth   = 1  // texels of the outline of the frame sprite
+)
+
+// drawBoxSprites renders the UI sprites into the atlas row below the fonts,
+// side by side: a rounded box and its outline as coverage, anti-aliased by
+// the distance of each texel center to the rounded edge
+func (ctx *ContextText) drawBoxSprites() {
+	half, radius := float64(boxSpriteSize)/2, float64(boxSpriteCorner)
+	for y := 0; y < boxSpriteSize; y++ {
+		for x := 0; x < boxSpriteSize; x++ {
+
+			// distance to the rounded rectangle filling the sprite, negative inside
+			qx := math.Abs(float64(x)+0.5-half) - (half - radius)
+			qy := math.Abs(float64(y)+0.5-half) - (half - radius)
+			distance := math.Hypot(math.Max(qx, 0), math.Max(qy, 0)) + math.Min(math.Max(qx, qy), 0) - radius
+
+			fill := saturate(0.5 - distance)
+			frame := fill * saturate(0.5+distance+boxFrameWidth)
+			row := (ctx.spriteY + y) * ctx.atlasWidth
+			ctx.atlas[row+x] = uint8(fill*255 + 0.5)
+			ctx.atlas[row+boxSpriteSize+x] = uint8(frame*255 + 0.5)
+		}
+	}
+}
+
+// DrawBox queues a rounded rectangle filled with clr, drawn as a 9-slice of
+// the box sprite: the corners keep their size (textScale pixels per texel),
+// the edges stretch along and the middle both ways. Boxes go into the text
+// batch, drawn before the text queued after them in the same draw call.
+func (ctx *ContextText) DrawBox(rect TextRect, clr color.NRGBA) {
+	ctx.drawNineSlice(rect, 0, clr)
+}
+
+// DrawFrame queues the rounded outline of a rectangle, see DrawBox
+func (ctx *ContextText) DrawFrame(rect TextRect, clr color.NRGBA) {
+	ctx.drawNineSlice(rect, 1, clr)
+}
+
+// FillRect queues a rectangle with square corners, the solid middle of the box sprite stretched
+func (ctx *ContextText) FillRect(rect TextRect, clr color.NRGBA) {
+	ctx.addGlyph(rect.X, rect.Y, rect.Width, rect.Height, boxSpriteCorner, ctx.spriteY+boxSpriteCorner, 1, 1, clr)
+}
+
+// drawNineSlice queues the 9 pieces of a sprite stretched over rect, corners
+// shrink evenly when rect is smaller than two of them
+func (ctx *ContextText) drawNineSlice(rect TextRect, sprite int, clr color.NRGBA) {
+	corner := minFloat32(boxSpriteCorner*textScale, minFloat32(rect.Width, rect.Height)/2)
+	xs := [4]float32{rect.X, rect.X + corner, rect.X + rect.Width - corner, rect.X + rect.Width}
+	ys := [4]float32{rect.Y, rect.Y + corner, rect.Y + rect.Height - corner, rect.Y + rect.Height}
+	texels := [4]int{0, boxSpriteCorner, boxSpriteSize - boxSpriteCorner, boxSpriteSize}
+	for row := 0; row < 3; row++ {
+		for column := 0; column < 3; column++ {
+			width, height := xs[column+1]-xs[column], ys[row+1]-ys[row]
+			if width <= 0 || height <= 0 {
+				continue
+			}
+			ctx.addGlyph(xs[column], ys[row], width, height,
+				sprite*boxSpriteSize+texels[column], ctx.spriteY+texels[row],
+				texels[column+1]-texels[column], texels[row+1]-texels[row], clr)
+		}
+	}
+}
+
+// PushClip cuts the glyphs and boxes queued until PopClip to rect (within
+// the current clip, clips nest), e.g. the inside of a scroll view.
+//
+// Clipping happens on the CPU as they are queued, not with the stencil
+// buffer: every glyph is a rectangle, so cutting it to another rectangle is
+// exact (see addGlyph) and the text stays a single draw call.
+func (ctx *ContextText) PushClip(rect TextRect) {
+	if n := len(ctx.clips); n > 0 {
+		outer := ctx.clips[n-1]
+		left, top := maxFloat32(rect.X, outer.X), maxFloat32(rect.Y, outer.Y)
+		right := minFloat32(rect.X+rect.Width, outer.X+outer.Width)
+		bottom := minFloat32(rect.Y+rect.Height, outer.Y+outer.Height)
+		rect = TextRect{X: left, Y: top, Width: maxFloat32(right-left, 0), Height: maxFloat32(bottom-top, 0)}
+	}
+	ctx.clips = append(ctx.clips, rect)
+}
+
+// PopClip goes back to the clip before the last PushClip
+func (ctx *ContextText) PopClip() {
+	if n := len(ctx.clips); n > 0 {
+		ctx.clips = ctx.clips[:n-1]
+	}
+}
+
+// Contains reports whether a point (pixels) is inside the rectangle
+func (r TextRect) Contains(x, y float32) bool {
+	return x >= r.X && x < r.X+r.Width && y >= r.Y && y < r.Y+r.Height
+}
+
+func saturate(v float64) float64 {
+	return math.Min(math.Max(v, 0), 1)
+}
+
+func minFloat32(a, b float32) float32 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxFloat32(a, b float32) float32 {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,221 @@
+package multisample
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/go-gl/gl/v3.2-core/gl"
+)
+
+const (
+	bufferDumpMaxVertices = 64 // vertices printed per buffer, the rest is only compared
+)
+
+// bufferSection is one planar block of an ElementQuads VBO
+type bufferSection struct {
+	name   string
+	offset int
+	bytes  int
+}
+
+// dumpQuadBuffers reads back the VBO and IBO of an ElementQuads context and
+// prints the layout (offset and size of each planar block, or the stride and
+// attribute offsets of an interleaved vertex) and the vertex
+// data as the GPU sees it, next to what the CPU meant to upload. Layout
+// mistakes show up as overlapping blocks, blocks past BytesTotal, or vertices
+// that differ from the CPU copy.
+func dumpQuadBuffers(w io.Writer, label string, vbo, ibo uint32, quads *ElementQuads, layout *Layout) {
+
+	indexCount := int(quadIndices.Count(quads.QuadCount()))
+	fmt.Fprintf(w, "BUFFERS -- %v: vbo %v (%v), ibo %v (%v used)\n", label, vbo, formatBytes(quads.BytesTotal), ibo, formatBytes(indexCount*bytesUint16))
+	if vbo == 0 || ibo == 0 {
+		fmt.Fprintln(w, "  not uploaded")
+		return
+	}
+
+	// layout, blocks (or attributes within a vertex) in the order they are stored
+	var sections []bufferSection
+	limit := quads.BytesTotal
+	if layout.Interleaved {
+		for _, attrib := range layout.Attribs {
+			sections = append(sections, bufferSection{attrib.Name, attrib.Offset, attrib.vertexBytes()})
+		}
+		limit = int(layout.Stride)
+		fmt.Fprintf(w, "  interleaved, stride %v\n", layout.Stride)
+	} else {
+		sections = []bufferSection{
+			{"vertices", quads.OffsetVertices, len(quads.QuadVertices) * bytesFloat32},
+			{"texcoords", quads.OffsetTexCoords, len(quads.QuadTexCoords) * bytesUint8},
+			{"colors", quads.OffsetColors, len(quads.QuadColors) * bytesUint8},
+		}
+	}
+	end := 0
+	for _, section := range sections {
+		if section.bytes == 0 {
+			continue
+		}
+		problem := ""
+		switch {
+		case section.offset < end:
+			problem = "  <-- overlaps previous block"
+		case section.offset+section.bytes > limit:
+			problem = "  <-- past BytesTotal (or stride)"
+		}
+		fmt.Fprintf(w, "  %-9v offset %6v  bytes %6v%v\n", section.name, section.offset, section.bytes, problem)
+		end = section.offset + section.bytes
+	}
+
+	// read back what the GPU holds
+	var size int32
+	gl.BindBuffer(gl.ARRAY_BUFFER, vbo)
+	gl.GetBufferParameteriv(gl.ARRAY_BUFFER, gl.BUFFER_SIZE, &size)
+	vertexData := make([]byte, size)
+	if size > 0 {
+		gl.GetBufferSubData(gl.ARRAY_BUFFER, 0, int(size), gl.Ptr(vertexData))
+	}
+	gl.BindBuffer(gl.ARRAY_BUFFER, 0)
+	if int(size) < quads.BytesTotal {
+		fmt.Fprintf(w, "  vbo holds %v bytes, BytesTotal is %v\n", size, quads.BytesTotal)
+	}
+
+	gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, ibo)
+	gl.GetBufferParameteriv(gl.ELEMENT_ARRAY_BUFFER, gl.BUFFER_SIZE, &size)
+	indexData := make([]byte, size)
+	if size > 0 {
+		gl.GetBufferSubData(gl.ELEMENT_ARRAY_BUFFER, 0, int(size), gl.Ptr(indexData))
+	}
+	gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, 0)
+
+	// vertices, GPU values with the CPU copy where they differ
+	vertices := len(quads.QuadVertices) / vertexPositionSize
+	mismatches := 0
+	for v := 0; v < vertices; v++ {
+		position := readFloats(vertexData, layout.VertexOffset("position", v), vertexPositionSize)
+		line := fmt.Sprintf("  %4v  pos %v", v, formatFloats(position))
+		differs := !equalFloats(position, quads.QuadVertices[v*vertexPositionSize:(v+1)*vertexPositionSize])
+		if layout.Has("texcoord") {
+			gpu := readBytes(vertexData, layout.VertexOffset("texcoord", v), vertexTexCoordSize)
+			line += fmt.Sprintf("  uv %v", gpu)
+			differs = differs || !equalBytes(gpu, readBytes(quads.QuadTexCoords, v*vertexTexCoordSize, vertexTexCoordSize))
+		}
+		if layout.Has("color") {
+			gpu := readBytes(vertexData, layout.VertexOffset("color", v), vertexColorSize)
+			line += fmt.Sprintf("  rgba %v", gpu)
+			differs = differs || !equalBytes(gpu, readBytes(quads.QuadColors, v*vertexColorSize, vertexColorSize))
+		}
+		if differs {
+			mismatches++
+			line += "  <-- differs from CPU copy"
+		}
+		if v < bufferDumpMaxVertices || differs {
+			fmt.Fprintln(w, line)
+		}
+	}
+	if vertices > bufferDumpMaxVertices {
+		fmt.Fprintf(w, "  ... %v more vertices\n", vertices-bufferDumpMaxVertices)
+	}
+
+	// indices, one triangle per line, the shared quad indices follow a fixed pattern
+	indexMismatches := 0
+	for i := 0; i+2 < indexCount; i += 3 {
+		gpu := [3]uint16{readUint16(indexData, i*bytesUint16), readUint16(indexData, (i+1)*bytesUint16), readUint16(indexData, (i+2)*bytesUint16)}
+		first := uint16(i / indicesPerQuad * verticesPerQuad)
+		expected := [3]uint16{first, first + 1, first + 2} // first triangle
+		if i%indicesPerQuad != 0 {
+			expected = [3]uint16{first, first + 2, first + 3} // second triangle
+		}
+		problem := ""
+		if gpu != expected {
+			indexMismatches++
+			problem = fmt.Sprintf("  <-- expected %v", expected)
+		}
+		for _, index := range gpu {
+			if int(index) >= vertices {
+				problem += "  <-- index past the last vertex"
+				break
+			}
+		}
+		if i/3 < bufferDumpMaxVertices || problem != "" {
+			fmt.Fprintf(w, "  tri %4v  %v%v\n", i/3, gpu, problem)
+		}
+	}
+
+	fmt.Fprintf(w, "  %v vertices (%v differ), %v indices (%v triangles differ)\n", vertices, mismatches, indexCount, indexMismatches)
+
+}
+
+// dumpBuffers prints the buffers of every ElementQuads context
+func dumpBuffers(w io.Writer) {
+	dumpQuadBuffers(w, "screen", ctxScreen.vbo, ctxScreen.ibo, ctxScreen.quads, ctxScreen.layout)
+	dumpQuadBuffers(w, "multisample", ctxFramebufferMultisample.vbo, ctxFramebufferMultisample.ibo, ctxFramebufferMultisample.quads, ctxFramebufferMultisample.layout)
+	dumpQuadBuffers(w, "graph", ctxGraph.vbo, ctxGraph.ibo, ctxGraph.quads, ctxGraph.layout)
+}
+
+// readFloats decodes n float32 at a byte offset, missing values (offset past the data) are NaN
+func readFloats(data []byte, offset, n int) []float32 {
+	values := make([]float32, n)
+	for i := range values {
+		at := offset + i*bytesFloat32
+		if at < 0 || at+bytesFloat32 > len(data) {
+			values[i] = float32(math.NaN())
+			continue
+		}
+		values[i] = math.Float32frombits(binary.LittleEndian.Uint32(data[at:]))
+	}
+	return values
+}
+
+// readBytes copies n bytes at an offset, clipped to the data (also used for the CPU copies, they may be short too)
+func readBytes(data []byte, offset, n int) []uint8 {
+	if offset < 0 || offset >= len(data) {
+		return nil
+	}
+	if offset+n > len(data) {
+		n = len(data) - offset
+	}
+	return append([]uint8(nil), data[offset:offset+n]...)
+}
+
+func readUint16(data []byte, offset int) uint16 {
+	if offset < 0 || offset+bytesUint16 > len(data) {
+		return math.MaxUint16
+	}
+	return binary.LittleEndian.Uint16(data[offset:])
+}
+
+func formatFloats(values []float32) string {
+	s := "("
+	for i, value := range values {
+		if i > 0 {
+			s += ", "
+		}
+		s += fmt.Sprintf("%6.3f", value)
+	}
+	return s + ")"
+}
+
+func equalFloats(a, b []float32) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func equalBytes(a, b []uint8) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
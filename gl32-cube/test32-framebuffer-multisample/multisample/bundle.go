@@ -0,0 +1,96 @@
+package multisample
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"sort"
+)
+
+// Bundle is a single file holding the assets of a demo (shaders, textures,
+// fonts, meshes), so it ships as a binary plus one asset file. The format is
+// a zip archive: its central directory is the index, entries are named like
+// assets, e.g. "shaders/water.frag", and any zip tool can list or edit it.
+// -packbundle writes one from the assets directory.
+//
+// A bundle given with -bundle is searched by Assets after the -assets
+// directory and before the embedded files.
+type Bundle struct {
+	path    string
+	archive *zip.ReadCloser
+}
+
+// OpenBundle opens a bundle and reads its index
+func OpenBundle(path string) (*Bundle, error) {
+	archive, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, fmt.Errorf("bundle %v: %v", path, err)
+	}
+	return &Bundle{path: path, archive: archive}, nil
+}
+
+// Open opens the file called name, fs.ErrNotExist if the bundle has none
+func (b *Bundle) Open(name string) (fs.File, error) {
+	return b.archive.Open(name)
+}
+
+// Names lists the files of the bundle, sorted
+func (b *Bundle) Names() []string {
+	var names []string
+	for _, file := range b.archive.File {
+		if !file.FileInfo().IsDir() {
+			names = append(names, file.Name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Close closes the bundle file
+func (b *Bundle) Close() error {
+	return b.archive.Close()
+}
+
+// writeBundle packs every file of assets into a new bundle at path
+func writeBundle(path string, assets fs.FS) error {
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	archive := zip.NewWriter(file)
+	count := 0
+	err = fs.WalkDir(assets, ".", func(name string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		src, err := assets.Open(name)
+		if err != nil {
+			return err
+		}
+		defer src.Close()
+		dst, err := archive.Create(name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(dst, src); err != nil {
+			return err
+		}
+		count++
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if err := archive.Close(); err != nil {
+		return err
+	}
+
+	fmt.Printf("BUNDLE -- packed %v files into %v\n", count, path)
+	return file.Close()
+
+}
@@ -0,0 +1,311 @@
+package multisample
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/go-gl/gl/v3.2-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Object Space -> Eye/World Space -> Clip Space -> NDC Space -> Viewport/Window Space
+//
+// Transform 1: [ Object Coordinates ] transformed by [ ModelView ] matrix produces [ Eye/World Coordinates ]
+// Transform 2: [ Eye/World Coordinates ] transformed by [ Projection ] matrix produces [ Clip Coordinates ]
+// Transform 3: [ Clip Coordinates ] X, Y, Z divided by W produces [ Normalized Device Coordinates ] (aka. NDC)
+// Transform 4: [ NDC ] scaled and translated by [ viewport ] parameters produces [ Window Coordinates ]
+//
+// The coordinate system on 3D space defined by the viewer.
+// In eye coordinates in OpenGL, the viewer is located
+// at the origin, looking in the direction of the negative
+// z-axis, with the positive y-axis pointing upwards, and
+// the positive x-axis pointing to the right. The modelview
+// transformation maps objects into the eye coordinate system,
+// and the projection transform maps eye coordinates to
+// clip coordinates which is divided by "w" to produce
+// normalized device coordinates ranging from (-1, 1) in
+// all 3 axis (google "unit cube ndc"). Normally, it's
+// convenient to set up the projection so one world coordinate
+// unit (e.g. 1 meter) is equal to one screen pixel.
+//
+// Finally, by mapping NDC cube to window coordinates, screen
+// graphics is produced. This final tranformation is a result
+// of scaling and translating the NDC by viewport parameters
+// given to gl.Viewport() and gl.DepthRange() functions.
+//
+// http://www.opengl-tutorial.org/beginners-tutorials/tutorial-3-matrices/#the-model-matrix
+// https://www.opengl.org/archives/resources/faq/technical/transformations.htm
+// http://math.hws.edu/graphicsbook/c3/s3.html (INTERACTIVE)
+// https://stackoverflow.com/questions/15588860/what-exactly-are-eye-space-coordinates
+// https://stackoverflow.com/questions/23309930/what-do-the-arguments-for-frustum-in-opengl-mean
+// http://relativity.net.au/gaming/java/Frustum.html (INTERACTIVE)
+// http://relativity.net.au/gaming/java/ProjectionMatrix.html
+// https://www.sciencedirect.com/topics/computer-science/device-coordinate
+// https://learnopengl.com/Getting-started/Coordinate-Systems
+// https://learnopengl.com/Getting-started/Camera
+// https://stackoverflow.com/questions/59262874/how-can-i-use-screen-space-coordinates-directly-with-opengl
+// https://www.codeguru.com/cpp/misc/misc/graphics/article.php/c10123/Deriving-Projection-Matrices.htm#page-2
+//
+// Camera holds the projection (fov, aspect) and view (position, target) of the
+// viewer. Setters only mark the camera dirty, Update uploads the "projection"
+// and "camera" uniforms once per change, so moving the camera every frame is cheap.
+type Camera struct {
+	program           uint32 // program owning the "projection" and "camera" uniforms
+	projectionUniform int32
+	cameraUniform     int32
+
+	fov      float32    // vertical field of view in degrees
+	aspect   float32    // width / height of the viewport
+	position mgl32.Vec3 // eye position in world coordinates
+	target   mgl32.Vec3 // point the eye looks at
+	up       mgl32.Vec3 // which way is up, usually +y
+	near     float32    // distance to the near clipping plane
+	far      float32    // distance to the far clipping plane
+
+	// projection blend, 0 = perspective, 1 = orthographic
+	// values in between interpolate both matrices, so switching is animated
+	orthoBlend  float32
+	orthoTarget float32
+
+	// stereo eye, see SetEye
+	eye         float32 // offset along the view's right axis in world units, 0 = center
+	convergence float32 // distance of the plane both eyes see at the same place (zero parallax)
+
+	dirty bool // matrices changed since last Update
+}
+
+const (
+	projectionBlendTime = 0.5  // seconds to morph between perspective and orthographic
+	defaultNear         = 0.1  // near clipping plane until SetClipPlanes or FitClipPlanes
+	defaultFar          = 10.0 // far clipping plane until SetClipPlanes or FitClipPlanes
+	minNear             = 0.01 // closer near planes waste depth precision (perspective only)
+)
+
+// NewCamera creates a perspective camera for program, looking from position at target
+func NewCamera(program uint32, fov float32, position mgl32.Vec3, target mgl32.Vec3) *Camera {
+	return &Camera{
+		program:           program,
+		projectionUniform: gl.GetUniformLocation(program, gl.Str("projection\x00")),
+		cameraUniform:     gl.GetUniformLocation(program, gl.Str("camera\x00")),
+		fov:               fov,
+		aspect:            renderAspect(),
+		position:          position,
+		target:            target,
+		up:                mgl32.Vec3{0, 1, 0},
+		near:              defaultNear,
+		far:               defaultFar,
+		dirty:             true,
+	}
+}
+
+// SetFOV changes the vertical field of view (in degrees)
+func (c *Camera) SetFOV(fov float32) {
+	c.fov = fov
+	c.dirty = true
+}
+
+// SetAspect changes the aspect ratio, e.g. after the window was resized
+func (c *Camera) SetAspect(aspect float32) {
+	c.aspect = aspect
+	c.dirty = true
+}
+
+// SetPosition moves the eye, it keeps looking at the same target
+func (c *Camera) SetPosition(position mgl32.Vec3) {
+	c.position = position
+	c.dirty = true
+}
+
+// LookAt turns the eye towards target
+func (c *Camera) LookAt(target mgl32.Vec3) {
+	c.target = target
+	c.dirty = true
+}
+
+// SetClipPlanes sets the distances to the near and far clipping planes.
+// Depth precision depends mostly on the near plane, keep it as far as possible.
+func (c *Camera) SetClipPlanes(near, far float32) error {
+	if err := validateClipPlanes(near, far, true); err != nil {
+		return err
+	}
+	c.near, c.far = near, far
+	c.dirty = true
+	return nil
+}
+
+// FitClipPlanes sets the tightest clipping planes that contain a bounding box
+// (world coordinates), see clipPlanesForBox
+func (c *Camera) FitClipPlanes(min, max mgl32.Vec3) error {
+	near, far, err := clipPlanesForBox(c.View(), min, max)
+	if err != nil {
+		return err
+	}
+	return c.SetClipPlanes(mgl32.Clamp(near, minNear, far), far)
+}
+
+func (c *Camera) ClipPlanes() (near, far float32) { return c.near, c.far }
+
+// SetOrthographic switches to orthographic (true) or perspective (false) projection,
+// the change is animated by Animate
+func (c *Camera) SetOrthographic(ortho bool) {
+	c.orthoTarget = 0
+	if ortho {
+		c.orthoTarget = 1
+	}
+}
+
+// SetEye moves the eye sideways by offset (world units, >0 = right) for
+// stereo. The eyes stay parallel, the frustum is shifted instead (off-axis)
+// so points at the convergence distance project to the same screen position
+// for every offset. Toe-in (turning the eyes) would add vertical parallax.
+func (c *Camera) SetEye(offset, convergence float32) {
+	c.eye = offset
+	c.convergence = convergence
+	c.dirty = true
+}
+
+// ToggleProjection switches between perspective and orthographic projection
+func (c *Camera) ToggleProjection() {
+	c.SetOrthographic(c.orthoTarget == 0)
+}
+
+// Orthographic reports the projection the camera is switching (or switched) to
+func (c *Camera) Orthographic() bool {
+	return c.orthoTarget == 1
+}
+
+// Animate advances the projection blend by dt seconds, call it once per frame
+func (c *Camera) Animate(dt float64) {
+	if c.orthoBlend == c.orthoTarget {
+		return
+	}
+	step := float32(dt / projectionBlendTime)
+	if c.orthoBlend < c.orthoTarget {
+		c.orthoBlend = mgl32.Clamp(c.orthoBlend+step, 0, c.orthoTarget)
+	} else {
+		c.orthoBlend = mgl32.Clamp(c.orthoBlend-step, c.orthoTarget, 1)
+	}
+	c.dirty = true
+}
+
+// Animating reports whether the camera is morphing between perspective and orthographic
+func (c *Camera) Animating() bool {
+	return c.orthoBlend != c.orthoTarget
+}
+
+// Camera2D creates a 2D camera framing the same view as the orthographic projection,
+// it takes over once the camera is orthographic so flat scenes can be panned and zoomed
+func (c *Camera) Camera2D() *Camera2D {
+	distance := c.target.Sub(c.position).Len()
+	height := 2 * distance * float32(math.Tan(float64(mgl32.DegToRad(c.fov))/2))
+	camera2D := NewCamera2D(c.program, c.position.Vec2(), c.position.Z(), height)
+	camera2D.near, camera2D.far = c.near, c.far
+	return camera2D
+}
+
+func (c *Camera) FOV() float32         { return c.fov }
+func (c *Camera) Position() mgl32.Vec3 { return c.position }
+func (c *Camera) Target() mgl32.Vec3   { return c.target }
+
+// Projection is the matrix to transform from eye to clip coordinates
+func (c *Camera) Projection() mgl32.Mat4 {
+	projection := c.blendedProjection()
+	if c.eye != 0 && c.convergence > 0 {
+		// shift clip x by the eye offset scaled to the convergence plane, x_clip is divided by -z
+		projection[8] -= projection[0] * c.eye / c.convergence
+	}
+	return projection
+}
+
+// blendedProjection is perspective, orthographic or the morph between them
+func (c *Camera) blendedProjection() mgl32.Mat4 {
+	switch c.orthoBlend {
+	case 0:
+		return c.perspective()
+	case 1:
+		return c.orthographic()
+	}
+
+	// smoothstep the blend, so the morph eases in and out
+	t := c.orthoBlend * c.orthoBlend * (3 - 2*c.orthoBlend)
+	return c.perspective().Mul(1 - t).Add(c.orthographic().Mul(t))
+}
+
+func (c *Camera) perspective() mgl32.Mat4 {
+	return mgl32.Perspective(mgl32.DegToRad(c.fov), c.aspect, c.near, c.far)
+}
+
+// orthographic matches the perspective frustum at the target distance,
+// so objects around the target keep their size when switching
+func (c *Camera) orthographic() mgl32.Mat4 {
+	distance := c.target.Sub(c.position).Len()
+	top := distance * float32(math.Tan(float64(mgl32.DegToRad(c.fov))/2))
+	right := top * c.aspect
+	return mgl32.Ortho(-right, right, -top, top, c.near, c.far)
+}
+
+// View is the matrix to transform from world to eye coordinates
+func (c *Camera) View() mgl32.Mat4 {
+	view := mgl32.LookAtV(c.position, c.target, c.up)
+	if c.eye != 0 {
+		view = mgl32.Translate3D(-c.eye, 0, 0).Mul4(view) // eye coordinates, x is right
+	}
+	return view
+}
+
+// Invalidate forces the next Update to upload, e.g. after another camera used the same uniforms
+func (c *Camera) Invalidate() {
+	c.dirty = true
+}
+
+// Update uploads the matrices if the camera changed, the camera's program must be in use
+func (c *Camera) Update() {
+
+	if !c.dirty {
+		return
+	}
+
+	// CREATE (PRESPECTIVE OR ORTHOGRAPHIC) PROJECTION MATRIX
+	// a matrix to transform from eye to NDC coordinates
+	projection := c.Projection()
+	gl.UniformMatrix4fv(c.projectionUniform, 1, false, &projection[0])
+
+	// CREATE (CAMERA) VIEW MATRIX
+	// a matrix to transform from world to eye coordinates
+	camera := c.View()
+	gl.UniformMatrix4fv(c.cameraUniform, 1, false, &camera[0])
+
+	c.dirty = false
+
+}
+
+// validateClipPlanes checks near and far, a perspective projection divides by
+// the distance so its near plane must be in front of the eye
+func validateClipPlanes(near, far float32, perspective bool) error {
+	switch {
+	case perspective && near <= 0:
+		return fmt.Errorf("near plane must be > 0 for perspective projection, got %v", near)
+	case far <= near:
+		return fmt.Errorf("far plane (%v) must be beyond near plane (%v)", far, near)
+	}
+	return nil
+}
+
+// clipPlanesForBox computes the near and far distances enclosing a bounding
+// box (world coordinates) seen through a view matrix.
+//
+// The box is wrapped in a sphere so the range stays valid while the model
+// rotates around the box center (see Arcball), at the cost of a bit of
+// precision. Fails if the box is completely behind the eye.
+func clipPlanesForBox(view mgl32.Mat4, min, max mgl32.Vec3) (near, far float32, err error) {
+	center := min.Add(max).Mul(0.5)
+	radius := max.Sub(min).Len() / 2
+
+	// the eye looks down the negative z-axis, the distance is -z in eye coordinates
+	distance := -view.Mul4x1(center.Vec4(1)).Z()
+	near, far = distance-radius, distance+radius
+	if far <= 0 {
+		return 0, 0, fmt.Errorf("bounding box %v-%v is behind the camera", min, max)
+	}
+	return near, far, nil
+}
@@ -0,0 +1,174 @@
+package multisample
+
+import (
+	"math"
+
+	"github.com/go-gl/gl/v3.2-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+const (
+	camera2DMinZoom = 0.1
+	camera2DMaxZoom = 64
+)
+
+// Camera2D is an orthographic camera for flat scenes (tilemaps, sprites, UI).
+// It looks down the negative z-axis and is moved by panning and zooming,
+// instead of a position and target like Camera.
+//
+// With pixel snapping the view is moved in whole framebuffer pixels only,
+// so pixel-art textures do not shimmer while panning.
+type Camera2D struct {
+	program           uint32 // program owning the "projection" and "camera" uniforms
+	projectionUniform int32
+	cameraUniform     int32
+
+	center mgl32.Vec2 // world position shown in the middle of the viewport
+	eyeZ   float32    // eye distance along the z-axis, objects are in front of it
+	height float32    // world units visible vertically at zoom 1
+	zoom   float32    // 2 = everything twice as large
+	snap   bool       // snap translation to framebuffer pixels
+	near   float32    // distance to the near clipping plane
+	far    float32    // distance to the far clipping plane
+
+	dirty bool // matrices changed since last Update
+}
+
+// NewCamera2D creates a 2D camera for program, showing height world units
+// around center, with the eye at eyeZ
+func NewCamera2D(program uint32, center mgl32.Vec2, eyeZ float32, height float32) *Camera2D {
+	return &Camera2D{
+		program:           program,
+		projectionUniform: gl.GetUniformLocation(program, gl.Str("projection\x00")),
+		cameraUniform:     gl.GetUniformLocation(program, gl.Str("camera\x00")),
+		center:            center,
+		eyeZ:              eyeZ,
+		height:            height,
+		zoom:              1,
+		near:              defaultNear,
+		far:               defaultFar,
+		dirty:             true,
+	}
+}
+
+// SetPixelSnap turns pixel snapping on or off
+func (c *Camera2D) SetPixelSnap(snap bool) {
+	c.snap = snap
+	c.dirty = true
+}
+
+// SetCenter moves the view to show center in the middle of the viewport
+func (c *Camera2D) SetCenter(center mgl32.Vec2) {
+	c.center = center
+	c.dirty = true
+}
+
+// SetZoom sets the zoom factor, clamped to camera2DMinZoom..camera2DMaxZoom
+func (c *Camera2D) SetZoom(zoom float32) {
+	c.zoom = mgl32.Clamp(zoom, camera2DMinZoom, camera2DMaxZoom)
+	c.dirty = true
+}
+
+// SetClipPlanes sets the distances to the near and far clipping planes,
+// orthographic projections allow a near plane behind the eye
+func (c *Camera2D) SetClipPlanes(near, far float32) error {
+	if err := validateClipPlanes(near, far, false); err != nil {
+		return err
+	}
+	c.near, c.far = near, far
+	c.dirty = true
+	return nil
+}
+
+// FitClipPlanes sets the tightest clipping planes that contain a bounding box (world coordinates)
+func (c *Camera2D) FitClipPlanes(min, max mgl32.Vec3) error {
+	near, far, err := clipPlanesForBox(c.View(), min, max)
+	if err != nil {
+		return err
+	}
+	return c.SetClipPlanes(near, far)
+}
+
+func (c *Camera2D) ClipPlanes() (near, far float32) { return c.near, c.far }
+func (c *Camera2D) Center() mgl32.Vec2              { return c.center }
+func (c *Camera2D) Zoom() float32                   { return c.zoom }
+func (c *Camera2D) PixelSnap() bool                 { return c.snap }
+
+// Pan moves the view by a distance in window coordinates (e.g. a mouse drag),
+// dragging right moves the scene right, so the view moves left
+func (c *Camera2D) Pan(dx, dy float64) {
+	scale := c.worldPerWindowUnit()
+	c.center = c.center.Sub(mgl32.Vec2{float32(dx) * scale, -float32(dy) * scale}) // window y points down
+	c.dirty = true
+}
+
+// ZoomAt multiplies the zoom by factor, keeping the world position under the
+// cursor (in window coordinates) in place
+func (c *Camera2D) ZoomAt(factor float32, x, y float64) {
+	before := c.WindowToWorld(x, y)
+	oldZoom := c.zoom
+	c.SetZoom(c.zoom * factor)
+	c.center = before.Sub(before.Sub(c.center).Mul(oldZoom / c.zoom))
+}
+
+// WindowToWorld maps window coordinates (origin top-left, e.g. the cursor position) to world coordinates
+func (c *Camera2D) WindowToWorld(x, y float64) mgl32.Vec2 {
+	scale := c.worldPerWindowUnit()
+	return mgl32.Vec2{
+		c.center.X() + (float32(x)-windowWidth/2)*scale,
+		c.center.Y() - (float32(y)-windowHeight/2)*scale,
+	}
+}
+
+// worldPerWindowUnit is the size of one window coordinate unit (screen point) in world units
+func (c *Camera2D) worldPerWindowUnit() float32 {
+	return c.height / c.zoom / windowHeight
+}
+
+// Projection is the matrix to transform from eye to clip coordinates
+func (c *Camera2D) Projection() mgl32.Mat4 {
+	top := c.height / c.zoom / 2
+	right := top * renderAspect()
+	return mgl32.Ortho(-right, right, -top, top, c.near, c.far)
+}
+
+// View is the matrix to transform from world to eye coordinates
+func (c *Camera2D) View() mgl32.Mat4 {
+	center := c.center
+	if c.snap {
+		// one rendered pixel in world units, high-dpi screens have more pixels than window units
+		_, renderHeight := renderSize()
+		pixel := c.height / c.zoom / float32(renderHeight)
+		center = mgl32.Vec2{
+			float32(math.Round(float64(center.X()/pixel))) * pixel,
+			float32(math.Round(float64(center.Y()/pixel))) * pixel,
+		}
+	}
+	return mgl32.Translate3D(-center.X(), -center.Y(), -c.eyeZ)
+}
+
+// Invalidate forces the next Update to upload, e.g. after another camera used the same uniforms
+func (c *Camera2D) Invalidate() {
+	c.dirty = true
+}
+
+// Update uploads the matrices if the camera changed, the camera's program must be in use
+func (c *Camera2D) Update() {
+
+	if !c.dirty {
+		return
+	}
+
+	// CREATE (ORTHOGRAPHIC) PROJECTION MATRIX
+	// a matrix to transform from eye to NDC coordinates
+	projection := c.Projection()
+	gl.UniformMatrix4fv(c.projectionUniform, 1, false, &projection[0])
+
+	// CREATE (CAMERA) VIEW MATRIX
+	// a matrix to transform from world to eye coordinates
+	camera := c.View()
+	gl.UniformMatrix4fv(c.cameraUniform, 1, false, &camera[0])
+
+	c.dirty = false
+
+}
@@ -0,0 +1,76 @@
+package multisample
+
+import (
+	"image"
+	"image/color"
+	imagedraw "image/draw" // draw is the frame function of quad.go
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+const (
+	canvasSize       = 128 // canvas width and height in pixels
+	canvasBrushSize  = 6   // brush width and height in pixels
+	canvasBrushSpeed = 40  // pixels per second
+)
+
+// Canvas is an image painted on the CPU with image/draw and shown on a quad
+// (-canvas, see EffectCanvas). A brush bounces around and paints a trail,
+// each frame only the rectangle it painted is uploaded (see Texture.Update).
+type Canvas struct {
+	img      *image.NRGBA
+	texture  *Texture
+	brush    mgl32.Vec2 // top-left of the brush in pixels
+	velocity mgl32.Vec2 // pixels per second
+	time     float64    // painting time, cycles the brush color
+}
+
+// NewCanvas creates a blank canvas and its texture, requires a current GL context
+func NewCanvas(label string) *Canvas {
+	c := &Canvas{
+		img:      image.NewNRGBA(image.Rect(0, 0, canvasSize, canvasSize)),
+		brush:    mgl32.Vec2{10, 20},
+		velocity: mgl32.Vec2{canvasBrushSpeed, canvasBrushSpeed * 0.7},
+	}
+	imagedraw.Draw(c.img, c.img.Bounds(), image.NewUniform(color.NRGBA{250, 250, 240, 255}), image.Point{}, imagedraw.Src)
+	c.texture = NewTexture(label, canvasSize, canvasSize, TextureColor)
+	c.texture.Update(c.img, c.img.Bounds())
+	return c
+}
+
+// Texture is the canvas texture, for a sampler2D
+func (c *Canvas) Texture() uint32 {
+	return c.texture.ID
+}
+
+// update moves the brush by dt seconds, bouncing off the edges, and paints
+func (c *Canvas) update(dt float64) {
+
+	c.time += dt
+	c.brush = c.brush.Add(c.velocity.Mul(float32(dt)))
+	limit := float32(canvasSize - canvasBrushSize)
+	for i := range c.brush {
+		if c.brush[i] < 0 || c.brush[i] > limit {
+			c.velocity[i] = -c.velocity[i]
+			c.brush[i] = mgl32.Clamp(c.brush[i], 0, limit)
+		}
+	}
+
+	// the color cycles through the hues over about six seconds
+	paint := color.NRGBA{
+		uint8(128 + 127*math.Sin(c.time)),
+		uint8(128 + 127*math.Sin(c.time+2.1)),
+		uint8(128 + 127*math.Sin(c.time+4.2)),
+		255,
+	}
+	min := image.Pt(int(c.brush.X()), int(c.brush.Y()))
+	rect := image.Rectangle{min, min.Add(image.Pt(canvasBrushSize, canvasBrushSize))}
+	imagedraw.Draw(c.img, rect, image.NewUniform(paint), image.Point{}, imagedraw.Src)
+	c.texture.Update(c.img, rect)
+
+}
+
+func (c *Canvas) destroy() {
+	c.texture.destroy()
+}
@@ -0,0 +1,29 @@
+package multisample
+
+import (
+	"image"
+
+	"github.com/go-gl/example/internal/capture"
+	"github.com/go-gl/gl/v3.2-core/gl"
+)
+
+// readFramebuffer reads a rectangle of an FBO (0 = default framebuffer) into an image
+func readFramebuffer(fbo uint32, x, y, width, height int32) *image.NRGBA {
+	gl.BindFramebuffer(gl.READ_FRAMEBUFFER, fbo)
+	img := capture.ReadImage(int(x), int(y), int(width), int(height), gl.ReadPixels)
+	gl.BindFramebuffer(gl.READ_FRAMEBUFFER, 0)
+	return img
+}
+
+// renderToImage reads back the last rendered frame (the downsampled output of the multisample
+// framebuffer, without overlays) at render resolution, call it after draw()
+func renderToImage() *image.NRGBA {
+	width, height := viewportSize()
+	img := readFramebuffer(renderGraph.Framebuffer("scene"), 0, 0, width, height)
+
+	// the framebuffer is cleared with ALPHA = 0 (needed for anti-aliasing), make the image opaque
+	for i := 3; i < len(img.Pix); i += 4 {
+		img.Pix[i] = 0xff
+	}
+	return img
+}
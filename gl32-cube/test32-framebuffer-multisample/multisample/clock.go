@@ -0,0 +1,67 @@
+package multisample
+
+import (
+	"fmt"
+)
+
+const (
+	clockStepTime = 1.0 / 60 // seconds a single step advances, when the frame rate is unlimited
+)
+
+var (
+	clock = &UpdateClock{}
+)
+
+// UpdateClock is the time animations and shaders see (uTime, palette
+// flashes, camera transitions). It follows the wall clock, but stands still
+// while paused, so a frame can be inspected, and moves exactly one frame per
+// step. Drawing goes on while paused, only updates are frozen.
+type UpdateClock struct {
+	now    float64 // update time in seconds
+	paused bool
+	step   bool // advance one frame while paused
+}
+
+// advance moves the clock by the wall time elapsed since the previous frame,
+// and returns the new update time and how far it moved
+func (c *UpdateClock) advance(elapsed float64) (now, dt float64) {
+
+	switch {
+	case !c.paused:
+		dt = elapsed
+	case c.step:
+		dt = clockStepTime
+		if target := frameLimiter.Target(); target > 0 {
+			dt = target
+		}
+		c.step = false
+	}
+
+	c.now += dt
+	return c.now, dt
+
+}
+
+// Now is the current update time in seconds
+func (c *UpdateClock) Now() float64 {
+	return c.now
+}
+
+// TogglePause freezes or resumes the clock
+func (c *UpdateClock) TogglePause() {
+	c.paused = !c.paused
+	c.step = false
+	if c.paused {
+		fmt.Printf("paused at %.3fs\n", c.now)
+	} else {
+		fmt.Println("resumed")
+	}
+}
+
+// Step advances a paused clock by exactly one frame, on the next advance
+func (c *UpdateClock) Step() {
+	if !c.paused {
+		return
+	}
+	c.step = true
+}
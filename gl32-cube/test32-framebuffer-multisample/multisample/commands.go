@@ -0,0 +1,153 @@
+package multisample
+
+import (
+	"image/color"
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+const (
+	workerRate     = 30 // simulation steps per second of the -worker goroutine
+	workerQuadStep = 30 // steps between the quads it adds
+)
+
+var (
+	commandQueue = &CommandQueue{}
+)
+
+// command is a recorded call, it runs on the main thread with the GL context current
+type command interface {
+	execute(state *commandState)
+}
+
+// commandState is carried from command to command while a buffer executes
+type commandState struct {
+	effect Effect
+	color  color.NRGBA
+}
+
+// CommandBuffer records draw commands without calling GL, so game logic can
+// build it on any goroutine (GL calls must come from the thread locked in
+// init). A buffer belongs to one goroutine until it is submitted.
+type CommandBuffer struct {
+	commands []command
+}
+
+// NewCommandBuffer starts a buffer with the default material, plain white quads
+func NewCommandBuffer() *CommandBuffer {
+	return &CommandBuffer{}
+}
+
+// SetMaterial selects the effect and color of the quads drawn after it
+func (b *CommandBuffer) SetMaterial(effect Effect, clr color.NRGBA) {
+	b.commands = append(b.commands, setMaterialCommand{effect, clr})
+}
+
+// DrawQuad adds a rectangle to the scene, like ContextFramebufferMultisample.AddQuad
+func (b *CommandBuffer) DrawQuad(x, y, w, h, z float32) {
+	b.commands = append(b.commands, drawQuadCommand{x, y, w, h, z})
+}
+
+// SetCamera moves the 3D camera to position, looking at target
+func (b *CommandBuffer) SetCamera(position, target mgl32.Vec3) {
+	b.commands = append(b.commands, setCameraCommand{position, target})
+}
+
+type setMaterialCommand struct {
+	effect Effect
+	color  color.NRGBA
+}
+
+func (c setMaterialCommand) execute(state *commandState) {
+	state.effect, state.color = c.effect, c.color
+}
+
+type drawQuadCommand struct {
+	x, y, w, h, z float32
+}
+
+func (c drawQuadCommand) execute(state *commandState) {
+	ctx := ctxFramebufferMultisample
+	ctx.AddQuad(c.x, c.y, c.w, c.h, c.z, state.color)
+	ctx.quads.SetEffect(ctx.quads.QuadCount()-1, state.effect)
+}
+
+type setCameraCommand struct {
+	position, target mgl32.Vec3
+}
+
+func (c setCameraCommand) execute(_ *commandState) {
+	ctxFramebufferMultisample.camera.SetPosition(c.position)
+	ctxFramebufferMultisample.camera.LookAt(c.target)
+}
+
+// CommandQueue passes submitted buffers from worker goroutines to the main
+// thread, which executes them once per frame before drawing. Buffers execute
+// in the order they were submitted, each starting from the default material.
+type CommandQueue struct {
+	mu      sync.Mutex
+	pending []*CommandBuffer
+}
+
+// Submit hands a buffer over to the main thread, the caller must not touch it afterwards
+func (q *CommandQueue) Submit(b *CommandBuffer) {
+	q.mu.Lock()
+	q.pending = append(q.pending, b)
+	q.mu.Unlock()
+}
+
+// execute runs the buffers submitted since the last call, on the main thread
+func (q *CommandQueue) execute() {
+
+	q.mu.Lock()
+	pending := q.pending
+	q.pending = nil
+	q.mu.Unlock()
+
+	for _, b := range pending {
+		state := &commandState{effect: EffectColor, color: color.NRGBA{255, 255, 255, 255}}
+		for _, c := range b.commands {
+			c.execute(state)
+		}
+	}
+
+}
+
+// runWorker is game logic off the main thread (-worker): it orbits the
+// camera around the quads and now and then adds a quad, at workerRate steps
+// per second whatever the frame rate, until stop is closed
+func runWorker(queue *CommandQueue, stop <-chan struct{}) {
+
+	ticker := time.NewTicker(time.Second / workerRate)
+	defer ticker.Stop()
+
+	rng := rand.New(rand.NewSource(1))
+	effects := []Effect{EffectColor, EffectStripes, EffectPulse, EffectChecker}
+	for step := 0; ; step++ {
+
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+
+		b := NewCommandBuffer()
+
+		angle := float64(step) / workerRate * 0.5
+		b.SetCamera(mgl32.Vec3{float32(math.Sin(angle)) * 0.5, 0, float32(math.Cos(angle)) * 0.5}, mgl32.Vec3{0, 0, -1})
+
+		if step%workerQuadStep == 0 {
+			clr := color.NRGBA{uint8(rng.Intn(256)), uint8(rng.Intn(256)), uint8(rng.Intn(256)), 255}
+			b.SetMaterial(effects[rng.Intn(len(effects))], clr)
+			b.DrawQuad(rng.Float32()*2-1, rng.Float32()*2-1, 0.1, 0.1, -1.05)
+		}
+
+		queue.Submit(b)
+
+	}
+
+}
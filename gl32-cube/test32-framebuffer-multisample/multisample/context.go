@@ -0,0 +1,86 @@
+package multisample
+
+import (
+	"fmt"
+
+	"github.com/go-gl/gl/v3.2-core/gl"
+	"github.com/paperboard/glfw/v3.3/glfw"
+)
+
+var (
+	contextRecovery = &ContextRecovery{}
+)
+
+// ContextRecovery detects a lost OpenGL context. A context is lost when the
+// GPU is reset (driver crash, driver update, TDR on windows); every GL object
+// is gone and all GL calls are ignored, GL_CONTEXT_LOST is reported instead.
+//
+// The lost context can not be repaired, we need a new context (and with glfw
+// a new window). To rebuild all GL objects we rely on setup() being the one
+// place that creates them: it is the recipe for every program, buffer, texture
+// and FBO, and it only needs the CPU-side data prepared by load().
+type ContextRecovery struct {
+	resetStatus func() uint32 // glGetGraphicsResetStatus, nil if the driver does not support robustness
+	lost        bool          // set when GL_CONTEXT_LOST was seen, see isLost
+}
+
+// setup looks for robustness support on the current context
+func (c *ContextRecovery) setup() {
+	c.lost = false
+	c.resetStatus = nil
+	switch {
+	case glInfo.Has("GL_KHR_robustness"):
+		c.resetStatus = gl.GetGraphicsResetStatusKHR
+	case glInfo.Has("GL_ARB_robustness"):
+		c.resetStatus = gl.GetGraphicsResetStatusARB
+	default:
+		fmt.Println("context loss detection polls glGetError for GL_CONTEXT_LOST (no robustness extension)")
+	}
+}
+
+// markLost is called when GL_CONTEXT_LOST is reported by glGetError
+func (c *ContextRecovery) markLost() {
+	c.lost = true
+}
+
+// isLost checks if the context was lost, cheap enough to call every frame.
+// Without robustness it drains glGetError, other errors than GL_CONTEXT_LOST
+// are dropped (CheckGLError is not called per frame either).
+func (c *ContextRecovery) isLost() bool {
+	switch {
+	case c.lost:
+	case c.resetStatus != nil:
+		c.lost = c.resetStatus() != gl.NO_ERROR
+	default:
+		for glerr := gl.GetError(); glerr != gl.NO_ERROR; glerr = gl.GetError() {
+			if glerr == gl.CONTEXT_LOST {
+				c.markLost()
+				break
+			}
+		}
+	}
+	return c.lost
+}
+
+// recoverContext replaces the window with a new one (and a new context) and rebuilds every GL object
+func recoverContext(window *glfw.Window) *glfw.Window {
+
+	fmt.Println("OpenGL context lost, rebuilding GL objects")
+
+	// GL objects died with the old context, forget them without calling glDelete*
+	sharedWindows.forget()
+	renderer.forget()
+	window.Destroy()
+	gpuResources.forget()
+	deletionQueue.forget()
+	shaderGlobals.forget()
+	programInfos.forget()
+
+	// new context, replay object creation
+	window = createWindow()
+	launcher.setup(window)
+	sharedWindows.open(window, *windowCount-1)
+
+	return window
+
+}
@@ -0,0 +1,106 @@
+package multisample
+
+import (
+	"github.com/paperboard/glfw/v3.3/glfw"
+)
+
+const (
+	controlPanelMargin = 16 // pixels between the control panel and the top-right corner of the window
+)
+
+var (
+	controlPanel = &ControlPanel{}
+)
+
+// ControlPanel is the UI of the msaa scene (-panel), in the top-right corner:
+// pause, the stats page, the render scale, the post effects and a few key
+// actions. Keys change the same settings, the widgets follow them every frame.
+type ControlPanel struct {
+	ui      *UI
+	pause   *Checkbox
+	page    *Checkbox
+	scale   *Slider
+	effects []*Checkbox // one per post effect, in the order they run
+}
+
+// load builds the widgets, after the post effects are registered, and
+// subscribes to the mouse: after the cameras, so it is asked first
+func (p *ControlPanel) load() {
+
+	p.pause = &Checkbox{Text: "pause", OnChange: func(bool) { clock.TogglePause() }}
+	p.page = &Checkbox{Text: "stats page", OnChange: func(checked bool) { stats.page = checked }}
+	p.scale = &Slider{Text: "render scale", Min: renderScaleMin, Max: renderScaleMax, Step: renderScaleStep, Format: "%.2fx", OnChange: setRenderScale}
+
+	// more effects than fit scroll
+	effects := &Column{}
+	p.effects = nil
+	for _, effect := range postProcessing.effects {
+		effect := effect
+		checkbox := &Checkbox{Text: effect.name, OnChange: func(checked bool) { effect.enabled = checked }}
+		p.effects = append(p.effects, checkbox)
+		effects.Children = append(effects.Children, checkbox)
+	}
+
+	p.ui = NewUI(&Panel{Child: &Column{Children: []Widget{
+		&Label{Text: "{b}controls{/}"},
+		p.pause,
+		p.page,
+		p.scale,
+		&Label{Text: "post effects"},
+		&ScrollView{Child: effects, Height: 3*uiControl + 2*uiSpacing},
+		&Row{Children: []Widget{
+			&Button{Text: "add quad", OnClick: addRandomQuad},
+			&Button{Text: "reset", OnClick: func() { ctxFramebufferMultisample.arcball.Reset() }},
+		}},
+	}}}, 0, controlPanelMargin)
+
+	events.OnMouseButton(p.handleMouseButton)
+	events.OnCursor(p.handleCursor)
+	events.OnScroll(p.handleScroll)
+
+}
+
+// handleMouseButton takes the left button pressed over the panel, and its release
+func (p *ControlPanel) handleMouseButton(e MouseButtonEvent) bool {
+	if e.Button != glfw.MouseButtonLeft || launcher.Current() != msaaScene {
+		return false
+	}
+	x, y := WindowToScreen(e.X, e.Y)
+	return p.ui.MouseButton(e.Action == glfw.Press, x, y)
+}
+
+// handleCursor moves the pressed widget, e.g. the knob of a slider
+func (p *ControlPanel) handleCursor(e CursorEvent) {
+	if launcher.Current() == msaaScene {
+		p.ui.MouseMove(WindowToScreen(e.X, e.Y))
+	}
+}
+
+// handleScroll takes the scroll wheel over the panel
+func (p *ControlPanel) handleScroll(e ScrollEvent) bool {
+	if launcher.Current() != msaaScene {
+		return false
+	}
+	x, y := WindowToScreen(e.X, e.Y)
+	return p.ui.Scroll(x, y, float32(e.OffsetY))
+}
+
+// draw updates the widgets to the current settings and queues the panel
+func (p *ControlPanel) draw() {
+	if p.ui == nil {
+		return
+	}
+	p.pause.Checked = clock.paused
+	p.page.Checked = stats.page
+	p.scale.Value = renderScale
+	for i, effect := range postProcessing.effects {
+		if i < len(p.effects) {
+			p.effects[i].Checked = effect.enabled
+		}
+	}
+
+	width, _ := p.ui.Root.Measure(p.ui)
+	screenWidth, _ := screenSize()
+	p.ui.X = float32(screenWidth) - width - controlPanelMargin
+	p.ui.Draw()
+}
@@ -0,0 +1,98 @@
+package multisample
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Coordinates used by the helpers below (see camera.go for the pipeline):
+//
+//	window  GLFW window coordinates, screen points with origin top-left (cursor positions)
+//	screen  pixels of the default framebuffer with origin top-left (DrawText), window * dpi scale
+//	NDC     -1..1 on all axes, y up, of the viewport the scene is presented in
+//	world   coordinates the camera looks at, object coordinates once a model matrix is given
+//
+// The scene covers presentViewport() of the screen, letterboxed in -pixelart mode,
+// screen points outside of it map to NDC beyond -1..1.
+
+// WindowToScreen scales window coordinates to screen pixels, by the ratio
+// screenSize has to the window size (the content scale, see createWindow)
+func WindowToScreen(x, y float64) (float32, float32) {
+	screenWidth, screenHeight := screenSize()
+	return float32(x) * float32(screenWidth) / windowWidth, float32(y) * float32(screenHeight) / windowHeight
+}
+
+// CursorToFramebuffer maps a cursor position (window coordinates) to pixels
+// of the offscreen framebuffers the scene is drawn into, with origin
+// bottom-left like gl.ReadPixels (picking) and fractional inside a pixel.
+// It accounts for the content scale, the letterbox of -pixelart, the render
+// scale, dynamic resolution and the eye views of split stereo (both eyes map
+// to the same framebuffer pixels). Outside the presented image inside is false.
+func CursorToFramebuffer(x, y float64) (fx, fy float32, inside bool) {
+	screenX, screenY := WindowToScreen(x, y)
+	_, screenHeight := screenSize()
+	view, _ := stereo.viewAt(screenX, screenY)
+	top := screenHeight - view.y - view.height // views have origin bottom-left
+	u := (screenX - float32(view.x)) / float32(view.width)
+	v := (screenY - float32(top)) / float32(view.height)
+
+	// the presented image is the viewport part of the framebuffers, stretched
+	viewWidth, viewHeight := viewportSize()
+	fx, fy = u*float32(viewWidth), (1-v)*float32(viewHeight)
+	return fx, fy, u >= 0 && u < 1 && v >= 0 && v < 1
+}
+
+// ScreenToNDC maps screen pixels into the presented viewport's NDC
+func ScreenToNDC(x, y float32) mgl32.Vec2 {
+	vx, vy, width, height := presentViewport()
+	return mgl32.Vec2{
+		(x-float32(vx))/float32(width)*2 - 1,
+		1 - (y-float32(vy))/float32(height)*2, // screen y points down
+	}
+}
+
+// NDCToScreen maps NDC of the presented viewport to screen pixels
+func NDCToScreen(ndc mgl32.Vec2) (float32, float32) {
+	vx, vy, width, height := presentViewport()
+	return float32(vx) + (ndc.X()+1)/2*float32(width), float32(vy) + (1-ndc.Y())/2*float32(height)
+}
+
+// Project maps a point in object coordinates to screen pixels (x, y) and its
+// depth (z, 0 at the near plane and 1 at the far plane). Points behind the
+// eye or outside the clip planes are not visible, e.g. to hide their labels.
+func Project(point mgl32.Vec3, model, view, projection mgl32.Mat4) (screen mgl32.Vec3, visible bool) {
+	clip := projection.Mul4(view).Mul4(model).Mul4x1(point.Vec4(1))
+	if clip.W() <= 0 {
+		return mgl32.Vec3{}, false
+	}
+	ndc := clip.Vec3().Mul(1 / clip.W())
+	x, y := NDCToScreen(ndc.Vec2())
+	return mgl32.Vec3{x, y, (ndc.Z() + 1) / 2}, ndc.Z() >= -1 && ndc.Z() <= 1
+}
+
+// Unproject maps screen pixels (x, y) and a depth (z, 0..1 as from Project or
+// the depth buffer) back to object coordinates. It fails for matrices that
+// cannot be inverted.
+func Unproject(screen mgl32.Vec3, model, view, projection mgl32.Mat4) (mgl32.Vec3, error) {
+	vx, vy, width, height := presentViewport()
+	_, screenHeight := screenSize()
+
+	// mgl32 expects window coordinates with origin bottom-left, like gl.Viewport
+	win := mgl32.Vec3{screen.X(), float32(screenHeight) - screen.Y(), screen.Z()}
+	return mgl32.UnProject(win, view.Mul4(model), projection, int(vx), int(screenHeight-vy-height), int(width), int(height))
+}
+
+// ScreenRay is the ray through screen pixels x, y in world coordinates,
+// starting at the near plane. In perspective all rays start near the eye,
+// in orthographic they are parallel.
+func ScreenRay(x, y float32, view, projection mgl32.Mat4) (origin, direction mgl32.Vec3, err error) {
+	model := mgl32.Ident4()
+	near, err := Unproject(mgl32.Vec3{x, y, 0}, model, view, projection)
+	if err != nil {
+		return mgl32.Vec3{}, mgl32.Vec3{}, err
+	}
+	far, err := Unproject(mgl32.Vec3{x, y, 1}, model, view, projection)
+	if err != nil {
+		return mgl32.Vec3{}, mgl32.Vec3{}, err
+	}
+	return near, far.Sub(near).Normalize(), nil
+}
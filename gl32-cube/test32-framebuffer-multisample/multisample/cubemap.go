@@ -0,0 +1,126 @@
+package multisample
+
+import (
+	"github.com/go-gl/gl/v3.2-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// cubemapFaces are the view directions of the cubemap faces, with the up
+// vectors that match the orientation GL samples them in
+var cubemapFaces = [6]struct {
+	target    uint32
+	direction mgl32.Vec3
+	up        mgl32.Vec3
+}{
+	{gl.TEXTURE_CUBE_MAP_POSITIVE_X, mgl32.Vec3{1, 0, 0}, mgl32.Vec3{0, -1, 0}},
+	{gl.TEXTURE_CUBE_MAP_NEGATIVE_X, mgl32.Vec3{-1, 0, 0}, mgl32.Vec3{0, -1, 0}},
+	{gl.TEXTURE_CUBE_MAP_POSITIVE_Y, mgl32.Vec3{0, 1, 0}, mgl32.Vec3{0, 0, 1}},
+	{gl.TEXTURE_CUBE_MAP_NEGATIVE_Y, mgl32.Vec3{0, -1, 0}, mgl32.Vec3{0, 0, -1}},
+	{gl.TEXTURE_CUBE_MAP_POSITIVE_Z, mgl32.Vec3{0, 0, 1}, mgl32.Vec3{0, -1, 0}},
+	{gl.TEXTURE_CUBE_MAP_NEGATIVE_Z, mgl32.Vec3{0, 0, -1}, mgl32.Vec3{0, -1, 0}},
+}
+
+// CubemapProbe renders the scene around a point into the 6 faces of a
+// cubemap, e.g. for reflections (see EffectMirror). Each face is a 90
+// degree view, so the probe costs 6 scene draws: by default it renders
+// every frame, set UpdateEvery higher for slowly changing scenes or to 0
+// to render only when requested.
+type CubemapProbe struct {
+	Position    mgl32.Vec3 // world position the faces are rendered from
+	UpdateEvery int        // frames between renders, 0 = only after Request
+
+	size      int32 // face width and height in pixels
+	near, far float32
+	fbo       uint32
+	texture   uint32 // TEXTURE_CUBE_MAP
+	depth     uint32 // renderbuffer shared by the faces, cleared per face
+	frames    int    // since the last render
+	requested bool
+}
+
+// NewCubemapProbe creates the cubemap and its framebuffer, requires a current GL context.
+// It renders on the first Update.
+func NewCubemapProbe(label string, position mgl32.Vec3, size int32, near, far float32) *CubemapProbe {
+
+	p := &CubemapProbe{Position: position, UpdateEvery: 1, size: size, near: near, far: far, requested: true}
+
+	// color faces, linear filtered so reflections of small probes are not blocky
+	p.texture = genTexture(label + " cubemap")
+	gl.BindTexture(gl.TEXTURE_CUBE_MAP, p.texture)
+	for _, face := range cubemapFaces {
+		gl.TexImage2D(face.target, 0, gl.RGBA8, size, size, 0, gl.RGBA, gl.UNSIGNED_BYTE, nil)
+	}
+	gpuResources.SetBytes(ResourceTexture, p.texture, 6*int(size)*int(size)*4)
+	gl.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_MIN_FILTER, gl.LINEAR)
+	gl.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
+	gl.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
+	gl.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
+	gl.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_WRAP_R, gl.CLAMP_TO_EDGE)
+	gl.BindTexture(gl.TEXTURE_CUBE_MAP, 0)
+
+	// depth, the faces are rendered one after the other
+	p.depth = genRenderbuffer(label + " depth")
+	gl.BindRenderbuffer(gl.RENDERBUFFER, p.depth)
+	gl.RenderbufferStorage(gl.RENDERBUFFER, gl.DEPTH_COMPONENT24, size, size)
+	gpuResources.SetBytes(ResourceRenderbuffer, p.depth, int(size)*int(size)*4)
+	gl.BindRenderbuffer(gl.RENDERBUFFER, 0)
+
+	// framebuffer, the color attachment is switched to each face while rendering
+	p.fbo = genFramebuffer(label + " fbo")
+	gl.BindFramebuffer(gl.FRAMEBUFFER, p.fbo)
+	gl.FramebufferRenderbuffer(gl.FRAMEBUFFER, gl.DEPTH_ATTACHMENT, gl.RENDERBUFFER, p.depth)
+	gl.FramebufferTexture2D(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT0, cubemapFaces[0].target, p.texture, 0)
+	CheckGLFramebufferStatus()
+	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+
+	return p
+
+}
+
+// Texture is the cubemap, for a samplerCube
+func (p *CubemapProbe) Texture() uint32 {
+	return p.texture
+}
+
+// Request renders the probe on the next Update, e.g. after the scene changed
+func (p *CubemapProbe) Request() {
+	p.requested = true
+}
+
+// Update renders the probe if requested or UpdateEvery frames passed, see Render.
+// Call it once per frame, before the scene is drawn.
+func (p *CubemapProbe) Update(drawFace func(view, projection mgl32.Mat4)) {
+	p.frames++
+	if p.requested || (p.UpdateEvery > 0 && p.frames >= p.UpdateEvery) {
+		p.Render(drawFace)
+	}
+}
+
+// Render draws every face: drawFace draws the scene with the given world to
+// eye and eye to clip matrices into the bound framebuffer, which is cleared
+func (p *CubemapProbe) Render(drawFace func(view, projection mgl32.Mat4)) {
+
+	projection := mgl32.Perspective(mgl32.DegToRad(90), 1, p.near, p.far)
+
+	gl.BindFramebuffer(gl.FRAMEBUFFER, p.fbo)
+	gl.Viewport(0, 0, p.size, p.size)
+	gl.Enable(gl.DEPTH_TEST)
+	for _, face := range cubemapFaces {
+		gl.FramebufferTexture2D(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT0, face.target, p.texture, 0)
+		gl.ClearColor(0.5, 0.5, 0.5, 1) // same gray as the proxy screen
+		gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
+		drawFace(mgl32.LookAtV(p.Position, p.Position.Add(face.direction), face.up), projection)
+	}
+	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+
+	p.frames, p.requested = 0, false
+
+}
+
+// release GL objects owned by the probe
+func (p *CubemapProbe) destroy() {
+	gpuResources.Release(ResourceFramebuffer, p.fbo)
+	gpuResources.Release(ResourceTexture, p.texture)
+	gpuResources.Release(ResourceRenderbuffer, p.depth)
+	p.fbo, p.texture, p.depth = 0, 0, 0
+}
@@ -0,0 +1,112 @@
+package multisample
+
+import (
+	"github.com/go-gl/gl/v3.2-core/gl"
+)
+
+const (
+	deletionFrameDelay = 3 // frames to wait before deleting an object when fences are not available
+)
+
+var (
+	deletionQueue = &DeletionQueue{}
+)
+
+// deletionBatch holds every GL object released during one frame
+type deletionBatch struct {
+	frame   uint64        // frame number when objects were released
+	fence   uintptr       // signaled once the GPU finished every command of that frame (0 = no fence)
+	objects []resourceKey // objects to delete
+}
+
+// DeletionQueue delays deleting GL objects until the GPU is done with the
+// frames that may still reference them. The driver keeps commands queued for
+// a few frames, so deleting (or reusing) a buffer right after a draw call that
+// used it can stall or, with buffer orphaning tricks, corrupt that draw.
+//
+// With OpenGL 3.2 (ARB_sync) a fence is inserted at the end of each frame and
+// objects are deleted once it is signaled, otherwise we simply wait a fixed
+// number of frames (deletionFrameDelay).
+type DeletionQueue struct {
+	useFences bool
+	frame     uint64          // current frame number
+	current   []resourceKey   // objects released during the current frame
+	batches   []deletionBatch // frames waiting for the GPU, oldest first
+}
+
+// setup decides between fence and frame-count mode, requires a current GL context
+func (q *DeletionQueue) setup() {
+	q.useFences = glInfo.AtLeast(3, 2) || glInfo.Has("GL_ARB_sync")
+}
+
+// enqueue schedules a GL object for deletion, it stays alive (and in the
+// resource registry) until the frames that might use it are finished
+func (q *DeletionQueue) enqueue(kind ResourceKind, id uint32) {
+	q.current = append(q.current, resourceKey{kind, id})
+}
+
+// endFrame closes the current frame and deletes objects the GPU is done with
+// call it once per frame, right after SwapBuffers
+func (q *DeletionQueue) endFrame() {
+
+	// close current frame
+	if len(q.current) > 0 {
+		batch := deletionBatch{frame: q.frame, objects: q.current}
+		if q.useFences {
+			batch.fence = gl.FenceSync(gl.SYNC_GPU_COMMANDS_COMPLETE, 0)
+		}
+		q.batches = append(q.batches, batch)
+		q.current = nil
+	}
+	q.frame++
+
+	// delete every batch that is done, batches complete in order
+	for len(q.batches) > 0 && q.done(q.batches[0]) {
+		q.free(q.batches[0])
+		q.batches = q.batches[1:]
+	}
+
+}
+
+// done checks (without blocking) whether the GPU finished with a batch
+func (q *DeletionQueue) done(batch deletionBatch) bool {
+	if batch.fence == 0 {
+		return q.frame-batch.frame > deletionFrameDelay
+	}
+	status := gl.ClientWaitSync(batch.fence, 0, 0) // timeout = 0, just poll
+	return status == gl.ALREADY_SIGNALED || status == gl.CONDITION_SATISFIED
+}
+
+func (q *DeletionQueue) free(batch deletionBatch) {
+	if batch.fence != 0 {
+		gl.DeleteSync(batch.fence)
+	}
+	for _, key := range batch.objects {
+		deleteResource(key.kind, key.id)
+		gpuResources.remove(key.kind, key.id)
+	}
+}
+
+// forget drops everything queued without deleting, used when the GL context (and its objects) is lost
+func (q *DeletionQueue) forget() {
+	q.current = nil
+	q.batches = nil
+}
+
+// flush waits for the GPU and deletes everything queued, used at shutdown
+func (q *DeletionQueue) flush() {
+
+	if len(q.current) > 0 {
+		q.batches = append(q.batches, deletionBatch{frame: q.frame, objects: q.current})
+		q.current = nil
+	}
+
+	// wait until all commands are completed
+	gl.Finish()
+
+	for _, batch := range q.batches {
+		q.free(batch)
+	}
+	q.batches = nil
+
+}
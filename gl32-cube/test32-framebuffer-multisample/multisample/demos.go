@@ -0,0 +1,79 @@
+// Package multisample is the OpenGL 3.2 multisample quad demo and the
+// demos it grew from, hosted as scenes in one window (see SceneLauncher).
+// The cmd/demos binary lists, runs and benchmarks them:
+//
+//	go run ./cmd/demos list
+//	go run ./cmd/demos run msaa -fps 0
+//	go run ./cmd/demos bench quad -benchframes 1000
+package multisample
+
+import (
+	"fmt"
+	"sort"
+)
+
+const (
+	benchWarmupFrames = 60 // frames a benchmark skips, while shaders compile lazily and caches fill
+)
+
+// Run opens the window and runs the scene called name, -scene when name is
+// "", until the window is closed. Parse the flags first, e.g. flag.Parse.
+func Run(name string) {
+	if name == "" {
+		name = *sceneName
+	}
+	run(name, nil)
+}
+
+// Bench runs the scene called name unthrottled for -benchframes frames,
+// after a warmup, and prints frame time statistics
+func Bench(name string) {
+	*targetFPS = 0 // as fast as the scene draws
+	run(name, &SceneBenchmark{frames: *benchFrames})
+}
+
+// SceneBenchmark collects the frame times of Bench
+type SceneBenchmark struct {
+	frames  int       // frames to measure, after benchWarmupFrames
+	skipped int       // warmup frames so far
+	cpu     []float64 // seconds per frame, from frame start to frame start
+	gpu     []float64 // seconds of GPU work per frame, when timer queries are supported
+}
+
+// record adds the time of the previous frame, done is true once enough frames were measured
+func (b *SceneBenchmark) record(elapsed float64) (done bool) {
+	if b.skipped < benchWarmupFrames {
+		b.skipped++
+		return false
+	}
+	b.cpu = append(b.cpu, elapsed)
+	if gpuTime, ok := gpuTimer.result(); ok {
+		b.gpu = append(b.gpu, gpuTime)
+	}
+	return len(b.cpu) >= b.frames
+}
+
+// report prints the average and percentiles of the frame times
+func (b *SceneBenchmark) report(scene string) {
+	fmt.Printf("BENCH -- %v, %v frames at %vx%v\n", scene, len(b.cpu), framebufferWidth, framebufferHeight)
+	benchmarkLine("frame", b.cpu)
+	if len(b.gpu) > 0 {
+		benchmarkLine("gpu", b.gpu)
+	}
+}
+
+// benchmarkLine prints one row of the report, times in milliseconds
+func benchmarkLine(name string, seconds []float64) {
+	sorted := append([]float64(nil), seconds...)
+	sort.Float64s(sorted)
+	sum := 0.0
+	for _, s := range sorted {
+		sum += s
+	}
+	average := sum / float64(len(sorted))
+	percentile := func(p float64) float64 {
+		return sorted[int(p*float64(len(sorted)-1))] * 1000
+	}
+	fmt.Printf("  %-6v avg %6.2f ms (%6.1f fps)  p50 %6.2f  p95 %6.2f  p99 %6.2f  max %6.2f\n",
+		name, average*1000, 1/average, percentile(0.5), percentile(0.95), percentile(0.99), sorted[len(sorted)-1]*1000)
+}
@@ -0,0 +1,95 @@
+package multisample
+
+import (
+	"github.com/go-gl/gl/v3.2-core/gl"
+)
+
+var (
+	depthView = &DepthView{}
+)
+
+// DepthView replaces the image by the depth buffer of the scene, near black
+// and far white, to check that depth is written and tested at all: the
+// multisample DEPTH24_STENCIL8 renderbuffer can't be sampled, so it is
+// resolved into a texture first (like SSAO does), then linearized with the
+// clip planes of the camera that drew the frame (see depthCamera).
+//
+// Perspective depth crowds near 1 (it is 1/z distributed), without
+// linearizing almost everything would be white.
+type DepthView struct {
+	effect *PostEffect // post-processing pass drawing the depth, the last in the chain
+
+	fbo     uint32
+	texture uint32 // multisample depth resolved for sampling
+}
+
+// load registers the effect after every other one, so nothing draws over the depth
+func (d *DepthView) load() {
+	d.effect = postProcessing.add("depth", &fragmentShaderDepthView, *depthViewMode)
+	d.effect.prepare = d.render
+}
+
+// setupBuffers creates the depth texture at the current renderSize, after postProcessing.setupProgram
+func (d *DepthView) setupBuffers() {
+
+	width, height := renderSize()
+
+	// the format must match the multisample depth for the resolving blit
+	d.fbo = genFramebuffer("depth view fbo")
+	gl.BindFramebuffer(gl.FRAMEBUFFER, d.fbo)
+	d.texture = genTexture("depth view depth")
+	gl.BindTexture(gl.TEXTURE_2D, d.texture)
+	gl.TexImage2D(gl.TEXTURE_2D, 0, gl.DEPTH24_STENCIL8, width, height, 0, gl.DEPTH_STENCIL, gl.UNSIGNED_INT_24_8, nil)
+	gpuResources.SetBytes(ResourceTexture, d.texture, int(width)*int(height)*4)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.NEAREST)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.NEAREST)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
+	gl.BindTexture(gl.TEXTURE_2D, 0)
+	gl.FramebufferTexture2D(gl.FRAMEBUFFER, gl.DEPTH_STENCIL_ATTACHMENT, gl.TEXTURE_2D, d.texture, 0)
+	gl.DrawBuffer(gl.NONE)
+	gl.ReadBuffer(gl.NONE)
+	CheckGLFramebufferStatus()
+	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+
+	d.effect.material.SetTexture("depthTexture", gl.TEXTURE_2D, d.texture)
+
+}
+
+// render resolves the depth of the scene just drawn and uploads the clip
+// planes, called by PostProcessing.apply before the pass
+func (d *DepthView) render() {
+
+	width, height := viewportSize()
+
+	// depth blits must use NEAREST
+	gl.BindFramebuffer(gl.READ_FRAMEBUFFER, ctxFramebufferMultisample.fbo)
+	gl.BindFramebuffer(gl.DRAW_FRAMEBUFFER, d.fbo)
+	gl.BlitFramebuffer(0, 0, width, height, 0, 0, width, height, gl.DEPTH_BUFFER_BIT, gl.NEAREST)
+
+	near, far, perspective := depthCamera()
+	d.effect.SetParam("near", near)
+	d.effect.SetParam("far", far)
+	d.effect.SetParam("perspective", 0)
+	if perspective {
+		d.effect.SetParam("perspective", 1)
+	}
+
+}
+
+// resize recreates the depth texture at the current renderSize
+func (d *DepthView) resize() {
+	d.releaseBuffers()
+	d.setupBuffers()
+}
+
+func (d *DepthView) releaseBuffers() {
+	gpuResources.Release(ResourceFramebuffer, d.fbo)
+	gpuResources.Release(ResourceTexture, d.texture)
+	d.fbo, d.texture = 0, 0
+}
+
+// destroy releases the buffers, the program belongs to postProcessing
+func (d *DepthView) destroy() {
+	d.releaseBuffers()
+}
@@ -0,0 +1,108 @@
+package multisample
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	imagedraw "image/draw" // draw is the frame function of quad.go
+	_ "image/jpeg"         // decoders for dropped images, png and gif are registered elsewhere
+	"log"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-gl/gl/v3.2-core/gl"
+	"github.com/paperboard/glfw/v3.3/glfw"
+)
+
+var (
+	dropped = &DroppedImage{quad: -1}
+)
+
+// DroppedImage shows an image file dragged onto the window on a quad
+// (EffectDropped), a quick way to try the texture pipeline with any png,
+// jpeg or gif. GLFW's clipboard only holds text, so Ctrl+V loads the image
+// at a pasted path instead of pasted pixels. Each image replaces the last.
+type DroppedImage struct {
+	texture uint32
+	quad    int // quad index when it was added, -1 until the first image
+}
+
+// handleDrop loads the dropped files, the last one that loads stays. The
+// quad is part of the msaa scene, drops onto other scenes are ignored.
+func handleDrop(e DropEvent) {
+	if launcher.Current() != msaaScene {
+		return
+	}
+	for _, name := range e.Names {
+		dropped.load(name)
+	}
+}
+
+// paste loads the image at the path in the clipboard, e.g. copied from a file manager
+func (d *DroppedImage) paste(window *glfw.Window) {
+	path := strings.TrimSpace(window.GetClipboardString())
+	path = strings.TrimPrefix(path, "file://")
+	if path == "" {
+		return
+	}
+	d.load(path)
+}
+
+// load decodes the image at path into the quad's texture, adding the quad on the first image
+func (d *DroppedImage) load(path string) {
+
+	img, err := loadSquareImage(path)
+	if err != nil {
+		log.Println("failed to load dropped image:", err)
+		return
+	}
+
+	gpuResources.Release(ResourceTexture, d.texture)
+	d.texture = newImageTexture("dropped "+filepath.Base(path), img, TextureColor, gl.CLAMP_TO_EDGE)
+	ctxFramebufferMultisample.material.SetTexture("droppedTexture", gl.TEXTURE_2D, d.texture)
+
+	if d.quad == -1 {
+		ctx := ctxFramebufferMultisample
+		ctx.AddQuad(0, 0, 0.8, 0.8, -1.0, color.NRGBA{255, 255, 255, 255})
+		d.quad = ctx.quads.QuadCount() - 1
+		ctx.quads.SetEffect(d.quad, EffectDropped)
+	}
+
+	fmt.Printf("DROP -- %v (%vx%v)\n", path, img.Rect.Dx(), img.Rect.Dy())
+
+}
+
+func (d *DroppedImage) destroy() {
+	gpuResources.Release(ResourceTexture, d.texture)
+	d.texture = 0
+}
+
+// loadSquareImage decodes an image and centers it in a transparent square,
+// so it keeps its aspect ratio on a square quad. Rows are flipped, texture
+// row 0 is the bottom of the quad. path is a file or an asset, see Assets.
+func loadSquareImage(path string) (*image.NRGBA, error) {
+
+	file, err := assets.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	img, _, err := image.Decode(file)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %v", path, err)
+	}
+
+	bounds := img.Bounds()
+	size := bounds.Dx()
+	if bounds.Dy() > size {
+		size = bounds.Dy()
+	}
+	square := image.NewNRGBA(image.Rect(0, 0, size, size))
+	left, top := (size-bounds.Dx())/2, (size-bounds.Dy())/2
+	for y := 0; y < bounds.Dy(); y++ {
+		row := image.Rect(left, size-1-top-y, left+bounds.Dx(), size-top-y)
+		imagedraw.Draw(square, row, img, image.Pt(bounds.Min.X, bounds.Min.Y+y), imagedraw.Src)
+	}
+	return square, nil
+
+}
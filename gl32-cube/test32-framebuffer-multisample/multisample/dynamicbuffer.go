@@ -0,0 +1,59 @@
+package multisample
+
+import (
+	"github.com/go-gl/gl/v3.2-core/gl"
+)
+
+const (
+	dynamicBufferCount = 3 // buffers per DynamicBuffer, frames the GPU may lag behind
+)
+
+// DynamicBuffer is a set of VBOs for data rewritten every frame (streamed),
+// e.g. the frame-time graph's bars or the randomized quad colors. Each frame
+// writes the next buffer in turn, the ones written in the previous frames
+// may still be read by the GPU. Writing a buffer the GPU is reading makes
+// the driver either stall until the draw is done or secretly copy it.
+//
+// This is the fallback for PersistentRing, which needs OpenGL 4.4.
+type DynamicBuffer struct {
+	buffers [dynamicBufferCount]uint32
+	bytes   int
+	current int
+}
+
+// NewDynamicBuffer creates the buffers, requires a current GL context
+func NewDynamicBuffer(label string, bytes int) *DynamicBuffer {
+	b := &DynamicBuffer{bytes: bytes}
+	for i := range b.buffers {
+		b.buffers[i] = genBuffer(label)
+		gl.BindBuffer(gl.ARRAY_BUFFER, b.buffers[i])
+		gl.BufferData(gl.ARRAY_BUFFER, bytes, nil, gl.STREAM_DRAW) // initalize but do not copy any data
+		gpuResources.SetBytes(ResourceBuffer, b.buffers[i], bytes)
+	}
+	gl.BindBuffer(gl.ARRAY_BUFFER, 0)
+	return b
+}
+
+// Next moves to the next buffer and binds it to gl.ARRAY_BUFFER, call it once per frame before writing
+func (b *DynamicBuffer) Next() uint32 {
+	b.current = (b.current + 1) % len(b.buffers)
+	gl.BindBuffer(gl.ARRAY_BUFFER, b.buffers[b.current])
+	return b.buffers[b.current]
+}
+
+// Current is the buffer returned by the last Next
+func (b *DynamicBuffer) Current() uint32 {
+	return b.buffers[b.current]
+}
+
+// Bytes is the size of each buffer
+func (b *DynamicBuffer) Bytes() int {
+	return b.bytes
+}
+
+func (b *DynamicBuffer) destroy() {
+	for i := range b.buffers {
+		gpuResources.Release(ResourceBuffer, b.buffers[i])
+		b.buffers[i] = 0
+	}
+}
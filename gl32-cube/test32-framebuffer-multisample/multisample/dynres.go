@@ -0,0 +1,60 @@
+package multisample
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+const (
+	dynamicResolutionMin      = 0.5  // lowest render scale
+	dynamicResolutionMax      = 1.0  // highest render scale, the full offscreen framebuffer
+	dynamicResolutionStep     = 0.05 // scale change per adjustment
+	dynamicResolutionCooldown = 15   // frames to wait after an adjustment, so its effect is measured
+	dynamicResolutionSmooth   = 0.1  // weight of the newest frame time in the running average
+	dynamicResolutionHeadroom = 0.8  // scale up only when frames take less than this share of the budget
+)
+
+var (
+	dynamicResolution = &DynamicResolution{scale: dynamicResolutionMax}
+)
+
+// DynamicResolution lowers the share of the offscreen framebuffers the scene
+// is drawn into when frames take too long, and raises it again when there is
+// time to spare. The framebuffers keep their size, only the viewport shrinks,
+// so changing scale is free; the screen pass stretches the used part over
+// the screen (see uRenderScale).
+type DynamicResolution struct {
+	budget   float64 // target frame time in seconds, 0 = disabled
+	scale    float32 // share of the offscreen framebuffers in use (per axis)
+	average  float64 // running average of the frame time in seconds
+	cooldown int     // frames until the next adjustment
+}
+
+// enable starts scaling to hold a frame rate
+func (d *DynamicResolution) enable(fps float64) {
+	d.budget = 1 / fps
+	d.average = d.budget
+}
+
+// update adjusts the scale after a frame took frameTime seconds to render
+func (d *DynamicResolution) update(frameTime float64) {
+
+	if d.budget == 0 {
+		return
+	}
+
+	d.average += (frameTime - d.average) * dynamicResolutionSmooth
+	if d.cooldown > 0 {
+		d.cooldown--
+		return
+	}
+
+	switch {
+	case d.average > d.budget && d.scale > dynamicResolutionMin:
+		d.scale = mgl32.Clamp(d.scale-dynamicResolutionStep, dynamicResolutionMin, dynamicResolutionMax)
+		d.cooldown = dynamicResolutionCooldown
+	case d.average < d.budget*dynamicResolutionHeadroom && d.scale < dynamicResolutionMax:
+		d.scale = mgl32.Clamp(d.scale+dynamicResolutionStep, dynamicResolutionMin, dynamicResolutionMax)
+		d.cooldown = dynamicResolutionCooldown
+	}
+
+}
@@ -0,0 +1,26 @@
+package multisample
+
+// Effect selects the fragment shader effect of a quad, values must match
+// the uEffect branches in fragmentShaderFramebuffer
+type Effect int32
+
+const (
+	EffectColor       Effect = iota // plain vertex color
+	EffectStripes                   // scrolling diagonal stripes (animated by uTime)
+	EffectPulse                     // brightness pulsing over time (animated by uTime)
+	EffectChecker                   // checkerboard from texture coordinates
+	EffectPalette                   // indexed sprite texture colored by a palette, see PaletteSwap
+	EffectMirror                    // mirror of the surroundings, from the cubemap of a CubemapProbe (see -reflection)
+	EffectPattern                   // generated debug texture times the vertex color (see -pattern)
+	EffectCanvas                    // image painted on the CPU, updated every frame (see Canvas)
+	EffectVideo                     // frames of a video or animated GIF (see VideoTexture)
+	EffectPass                      // output of an offscreen pass, picture in picture (see PassOutputs and -pip)
+	EffectDropped                   // image file dropped onto the window (see DroppedImage)
+	EffectRoundedRect               // rounded rectangle with an outline, cut from a distance field (see DrawRoundedRect)
+	EffectLine                      // anti-aliased line with caps and dashes, from a distance field (see DrawLine)
+)
+
+// SetEffect changes the effect of a quad (by draw order), call SortBatches afterwards
+func (q *ElementQuads) SetEffect(quad int, effect Effect) {
+	q.QuadEffects[quad] = effect
+}
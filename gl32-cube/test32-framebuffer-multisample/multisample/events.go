@@ -0,0 +1,298 @@
+package multisample
+
+import (
+	"github.com/paperboard/glfw/v3.3/glfw"
+)
+
+var (
+	events = &EventBus{}
+)
+
+// ResizeEvent is a new framebuffer size of the main window, in pixels
+type ResizeEvent struct {
+	Window        *glfw.Window
+	Width, Height int
+}
+
+// KeyEvent is a key pressed, repeated or released, in any window
+type KeyEvent struct {
+	Window *glfw.Window
+	Key    glfw.Key
+	Action glfw.Action
+	Mods   glfw.ModifierKey
+}
+
+// MouseButtonEvent is a mouse button pressed or released at X, Y (window coordinates)
+type MouseButtonEvent struct {
+	Window *glfw.Window
+	Button glfw.MouseButton
+	Action glfw.Action
+	Mods   glfw.ModifierKey
+	X, Y   float64
+}
+
+// CursorEvent is the cursor moving to X, Y (window coordinates)
+type CursorEvent struct {
+	Window *glfw.Window
+	X, Y   float64
+}
+
+// ScrollEvent is the scroll wheel turning with the cursor at X, Y (window coordinates)
+type ScrollEvent struct {
+	Window           *glfw.Window
+	X, Y             float64
+	OffsetX, OffsetY float64 // wheel steps, positive up and right
+}
+
+// GamepadEventKind is what happened to a gamepad
+type GamepadEventKind int
+
+const (
+	GamepadConnected    GamepadEventKind = iota // plugged in, or present at the first poll
+	GamepadDisconnected                         // unplugged
+	GamepadPressed                              // Button went down
+	GamepadReleased                             // Button went up
+)
+
+// GamepadEvent is a gamepad (a joystick with a gamepad mapping) connecting,
+// disconnecting or changing a button
+type GamepadEvent struct {
+	Joystick glfw.Joystick
+	Kind     GamepadEventKind
+	Button   glfw.GamepadButton // GamepadPressed and GamepadReleased only
+}
+
+// DropEvent is files dropped onto the main window
+type DropEvent struct {
+	Window *glfw.Window
+	Names  []string
+}
+
+// SceneChangeEvent is the launcher switching from one scene to another, after To is set up
+type SceneChangeEvent struct {
+	From, To Scene
+}
+
+// Subscription identifies a handler of the event bus, see Unsubscribe
+type Subscription int
+
+// eventSubscriber is a handler wrapped to take any event, it returns true
+// when it used the event (only input events stop there, see EventBus)
+type eventSubscriber struct {
+	id      Subscription
+	handle  func(event interface{}) bool
+	removed bool // unsubscribed, publish skips it also for the event being delivered
+}
+
+// EventBus delivers the window events to the modules using them, so the GLFW
+// callbacks are registered in one place (attach) and modules subscribe to the
+// events they need, typed by event: OnResize, OnKey, OnMouseButton and so on.
+//
+// Input events (key, mouse button, scroll, gamepad) go to the subscribers
+// newest first, until a handler returns true: an overlay subscribing after
+// the scene, like the control panel, takes the clicks on it before the
+// cameras. The other events go to every subscriber.
+type EventBus struct {
+	subscribers []*eventSubscriber // in the order subscribed
+	last        Subscription
+
+	gamepads map[glfw.Joystick][]glfw.Action // button states of the last poll, see pollGamepads
+}
+
+// attach registers the callbacks of window, again for a window replacing a lost one (see recoverContext)
+func (b *EventBus) attach(window *glfw.Window) {
+	window.SetFramebufferSizeCallback(b.resizeCallback)
+	window.SetKeyCallback(b.keyCallback)
+	window.SetMouseButtonCallback(b.mouseButtonCallback)
+	window.SetCursorPosCallback(b.cursorPosCallback)
+	window.SetScrollCallback(b.scrollCallback)
+	window.SetDropCallback(b.dropCallback)
+	glfw.SetJoystickCallback(b.joystickCallback)
+}
+
+// OnResize calls fn when the framebuffer of the main window changes size
+func (b *EventBus) OnResize(fn func(ResizeEvent)) Subscription {
+	return b.subscribe(func(event interface{}) bool {
+		if e, ok := event.(ResizeEvent); ok {
+			fn(e)
+		}
+		return false
+	})
+}
+
+// OnKey calls fn with the keys of every window, true stops older subscribers from seeing the key
+func (b *EventBus) OnKey(fn func(KeyEvent) bool) Subscription {
+	return b.subscribe(func(event interface{}) bool {
+		e, ok := event.(KeyEvent)
+		return ok && fn(e)
+	})
+}
+
+// OnMouseButton calls fn with the mouse buttons, true stops older subscribers from seeing the button
+func (b *EventBus) OnMouseButton(fn func(MouseButtonEvent) bool) Subscription {
+	return b.subscribe(func(event interface{}) bool {
+		e, ok := event.(MouseButtonEvent)
+		return ok && fn(e)
+	})
+}
+
+// OnCursor calls fn when the cursor moves
+func (b *EventBus) OnCursor(fn func(CursorEvent)) Subscription {
+	return b.subscribe(func(event interface{}) bool {
+		if e, ok := event.(CursorEvent); ok {
+			fn(e)
+		}
+		return false
+	})
+}
+
+// OnScroll calls fn with the scroll wheel, true stops older subscribers from seeing it
+func (b *EventBus) OnScroll(fn func(ScrollEvent) bool) Subscription {
+	return b.subscribe(func(event interface{}) bool {
+		e, ok := event.(ScrollEvent)
+		return ok && fn(e)
+	})
+}
+
+// OnGamepad calls fn with the gamepads connecting and their buttons, true
+// stops older subscribers from seeing the event
+func (b *EventBus) OnGamepad(fn func(GamepadEvent) bool) Subscription {
+	return b.subscribe(func(event interface{}) bool {
+		e, ok := event.(GamepadEvent)
+		return ok && fn(e)
+	})
+}
+
+// OnDrop calls fn with the files dropped onto the main window
+func (b *EventBus) OnDrop(fn func(DropEvent)) Subscription {
+	return b.subscribe(func(event interface{}) bool {
+		if e, ok := event.(DropEvent); ok {
+			fn(e)
+		}
+		return false
+	})
+}
+
+// OnSceneChange calls fn when the launcher switches scenes
+func (b *EventBus) OnSceneChange(fn func(SceneChangeEvent)) Subscription {
+	return b.subscribe(func(event interface{}) bool {
+		if e, ok := event.(SceneChangeEvent); ok {
+			fn(e)
+		}
+		return false
+	})
+}
+
+// Unsubscribe removes a handler, it gets no more events (also from within the handler)
+func (b *EventBus) Unsubscribe(id Subscription) {
+	for i, s := range b.subscribers {
+		if s.id == id {
+			s.removed = true
+			b.subscribers = append(b.subscribers[:i:i], b.subscribers[i+1:]...)
+			return
+		}
+	}
+}
+
+func (b *EventBus) subscribe(handle func(event interface{}) bool) Subscription {
+	b.last++
+	b.subscribers = append(b.subscribers, &eventSubscriber{id: b.last, handle: handle})
+	return b.last
+}
+
+// publish passes an event to the subscribers newest first, until one used
+// it. Handlers may subscribe, that counts from the next event, and
+// unsubscribe, a removed handler is skipped at once.
+func (b *EventBus) publish(event interface{}) {
+	subscribers := b.subscribers
+	for i := len(subscribers) - 1; i >= 0; i-- {
+		if subscribers[i].removed {
+			continue
+		}
+		if subscribers[i].handle(event) {
+			return
+		}
+	}
+}
+
+// publishInput publishes an event from GLFW, recorded while recording and
+// dropped while a recording plays (see Replay)
+func (b *EventBus) publishInput(event interface{}) {
+	if replay.playing() {
+		return
+	}
+	replay.write(event)
+	b.publish(event)
+}
+
+// pollGamepads publishes the buttons that changed since the last poll, call
+// it after glfw.PollEvents (GLFW has no callback for gamepad buttons)
+func (b *EventBus) pollGamepads() {
+	if b.gamepads == nil {
+		b.gamepads = map[glfw.Joystick][]glfw.Action{}
+	}
+	for joystick := glfw.Joystick1; joystick <= glfw.JoystickLast; joystick++ {
+		previous, known := b.gamepads[joystick]
+		if !joystick.Present() || !joystick.IsGamepad() {
+			if known {
+				delete(b.gamepads, joystick)
+				b.publishInput(GamepadEvent{Joystick: joystick, Kind: GamepadDisconnected})
+			}
+			continue
+		}
+		state := joystick.GetGamepadState()
+		if state == nil {
+			continue
+		}
+		if !known {
+			b.publishInput(GamepadEvent{Joystick: joystick, Kind: GamepadConnected})
+			previous = make([]glfw.Action, len(state.Buttons))
+		}
+		for i, action := range state.Buttons {
+			if action == previous[i] {
+				continue
+			}
+			kind := GamepadReleased
+			if action == glfw.Press {
+				kind = GamepadPressed
+			}
+			b.publishInput(GamepadEvent{Joystick: joystick, Kind: kind, Button: glfw.GamepadButton(i)})
+		}
+		b.gamepads[joystick] = append(previous[:0], state.Buttons[:]...)
+	}
+}
+
+func (b *EventBus) resizeCallback(window *glfw.Window, width int, height int) {
+	b.publish(ResizeEvent{Window: window, Width: width, Height: height})
+}
+
+func (b *EventBus) keyCallback(window *glfw.Window, key glfw.Key, _ int, action glfw.Action, mods glfw.ModifierKey) {
+	b.publishInput(KeyEvent{Window: window, Key: key, Action: action, Mods: mods})
+}
+
+func (b *EventBus) mouseButtonCallback(window *glfw.Window, button glfw.MouseButton, action glfw.Action, mods glfw.ModifierKey) {
+	x, y := window.GetCursorPos()
+	b.publishInput(MouseButtonEvent{Window: window, Button: button, Action: action, Mods: mods, X: x, Y: y})
+}
+
+func (b *EventBus) cursorPosCallback(window *glfw.Window, x float64, y float64) {
+	b.publishInput(CursorEvent{Window: window, X: x, Y: y})
+}
+
+func (b *EventBus) scrollCallback(window *glfw.Window, xoff float64, yoff float64) {
+	x, y := window.GetCursorPos()
+	b.publishInput(ScrollEvent{Window: window, X: x, Y: y, OffsetX: xoff, OffsetY: yoff})
+}
+
+func (b *EventBus) dropCallback(window *glfw.Window, names []string) {
+	b.publishInput(DropEvent{Window: window, Names: names})
+}
+
+// joystickCallback forgets a disconnected gamepad at once, the next poll
+// would too, but a joystick reconnecting in between must count as new
+func (b *EventBus) joystickCallback(joystick glfw.Joystick, event glfw.PeripheralEvent) {
+	if _, known := b.gamepads[joystick]; known && event == glfw.Disconnected {
+		delete(b.gamepads, joystick)
+		b.publishInput(GamepadEvent{Joystick: joystick, Kind: GamepadDisconnected})
+	}
+}
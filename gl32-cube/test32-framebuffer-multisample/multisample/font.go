@@ -0,0 +1,221 @@
+package multisample
+
+import (
+	"unicode"
+)
+
+const (
+	fontFirstRune   = ' ' // first glyph of font5x7, the font covers printable ASCII
+	fontGlyphWidth  = 5   // glyph size in texels
+	fontGlyphHeight = 7
+	fontCellWidth   = 6  // glyph plus one texel of spacing, also the advance
+	fontCellHeight  = 9  // glyph plus two texels of line spacing
+	fontAtlasRow    = 16 // glyphs per atlas row
+)
+
+var (
+	builtinFont = newBitmapFont()
+)
+
+// Font is a bitmap font, its glyphs are packed into one 8 bit coverage texture (the atlas)
+type Font struct {
+	name        string
+	glyphs      map[rune]fontGlyph
+	atlas       []uint8 // coverage, 0 or 255 per texel
+	atlasWidth  int
+	atlasHeight int
+	lineHeight  int // texels from the top of one line to the next
+	ascent      int // texels from the top of a line to the baseline
+
+	// drawn for runes no font has, see ContextText.lookup (built-in font only)
+	replacement     fontGlyph
+	wideReplacement fontGlyph
+}
+
+// fontGlyph is where a glyph is stored in the atlas and where it is drawn, in texels
+type fontGlyph struct {
+	x, y             int // atlas position
+	width, height    int // size in the atlas
+	offsetX, offsetY int // position relative to the pen, which is at the top of the line
+	advance          int // texels to the next glyph, 0 for combining marks
+}
+
+// newBitmapFont builds the atlas of font5x7, followed by the replacement boxes
+func newBitmapFont() *Font {
+
+	cells := len(font5x7) + 3 // box and wide box (two cells)
+	f := &Font{
+		name:        "built-in 5x7",
+		glyphs:      map[rune]fontGlyph{},
+		atlasWidth:  fontAtlasRow * fontCellWidth,
+		atlasHeight: (cells + fontAtlasRow - 1) / fontAtlasRow * fontCellHeight,
+		lineHeight:  fontCellHeight,
+		ascent:      fontGlyphHeight,
+	}
+	f.atlas = make([]uint8, f.atlasWidth*f.atlasHeight)
+	cell := func(i int) fontGlyph {
+		return fontGlyph{x: i % fontAtlasRow * fontCellWidth, y: i / fontAtlasRow * fontCellHeight, width: fontGlyphWidth, height: fontGlyphHeight, advance: fontCellWidth}
+	}
+
+	for i, columns := range font5x7 {
+		glyph := cell(i)
+		for x, column := range columns {
+			for y := 0; y < fontGlyphHeight; y++ {
+				if column&(1<<uint(y)) != 0 {
+					f.atlas[(glyph.y+y)*f.atlasWidth+glyph.x+x] = 0xff
+				}
+			}
+		}
+		f.glyphs[fontFirstRune+rune(i)] = glyph
+	}
+
+	// hollow boxes, for runes without glyph (the wide one spans two cells, keep them on one row)
+	f.replacement = cell(len(font5x7))
+	f.wideReplacement = cell(len(font5x7) + 1)
+	if f.wideReplacement.x+2*fontCellWidth > f.atlasWidth {
+		f.wideReplacement = cell(len(font5x7) + 2)
+	}
+	f.wideReplacement.width, f.wideReplacement.advance = fontCellWidth+fontGlyphWidth, 2*fontCellWidth
+	for _, box := range []fontGlyph{f.replacement, f.wideReplacement} {
+		for y := 0; y < box.height; y++ {
+			for x := 0; x < box.width; x++ {
+				if x == 0 || y == 0 || x == box.width-1 || y == box.height-1 {
+					f.atlas[(box.y+y)*f.atlasWidth+box.x+x] = 0xff
+				}
+			}
+		}
+	}
+
+	return f
+
+}
+
+// Glyph looks up a rune
+func (f *Font) Glyph(r rune) (fontGlyph, bool) {
+	glyph, ok := f.glyphs[r]
+	return glyph, ok
+}
+
+// isCombiningMark is true for runes drawn over the previous glyph, e.g. U+0301 (acute accent)
+func isCombiningMark(r rune) bool {
+	return unicode.In(r, unicode.Mn, unicode.Me)
+}
+
+// isWideRune is true for runes taking two cells in a monospaced font (CJK,
+// Hangul, fullwidth forms, emoji), the main East Asian Wide ranges
+func isWideRune(r rune) bool {
+	switch {
+	case r >= 0x1100 && r <= 0x115F, // Hangul Jamo
+		r >= 0x2E80 && r <= 0xA4CF && r != 0x303F, // CJK radicals ... Yi
+		r >= 0xAC00 && r <= 0xD7A3,                // Hangul syllables
+		r >= 0xF900 && r <= 0xFAFF,                // CJK compatibility ideographs
+		r >= 0xFE30 && r <= 0xFE4F,                // CJK compatibility forms
+		r >= 0xFF00 && r <= 0xFF60,                // fullwidth forms
+		r >= 0xFFE0 && r <= 0xFFE6,
+		r >= 0x1F300 && r <= 0x1F64F, // emoji
+		r >= 0x1F900 && r <= 0x1F9FF,
+		r >= 0x20000 && r <= 0x3FFFD: // CJK extensions
+		return true
+	}
+	return false
+}
+
+// font5x7 is a 5x7 pixel font for printable ASCII, one byte per column
+// (left to right), bit 0 is the top row
+var font5x7 = [...][fontGlyphWidth]uint8{
+	{0x00, 0x00, 0x00, 0x00, 0x00}, // space
+	{0x00, 0x00, 0x5F, 0x00, 0x00}, // !
+	{0x00, 0x07, 0x00, 0x07, 0x00}, // "
+	{0x14, 0x7F, 0x14, 0x7F, 0x14}, // #
+	{0x24, 0x2A, 0x7F, 0x2A, 0x12}, // $
+	{0x23, 0x13, 0x08, 0x64, 0x62}, // %
+	{0x36, 0x49, 0x55, 0x22, 0x50}, // &
+	{0x00, 0x05, 0x03, 0x00, 0x00}, // '
+	{0x00, 0x1C, 0x22, 0x41, 0x00}, // (
+	{0x00, 0x41, 0x22, 0x1C, 0x00}, // )
+	{0x14, 0x08, 0x3E, 0x08, 0x14}, // *
+	{0x08, 0x08, 0x3E, 0x08, 0x08}, // +
+	{0x00, 0x50, 0x30, 0x00, 0x00}, // ,
+	{0x08, 0x08, 0x08, 0x08, 0x08}, // -
+	{0x00, 0x60, 0x60, 0x00, 0x00}, // .
+	{0x20, 0x10, 0x08, 0x04, 0x02}, // /
+	{0x3E, 0x51, 0x49, 0x45, 0x3E}, // 0
+	{0x00, 0x42, 0x7F, 0x40, 0x00}, // 1
+	{0x42, 0x61, 0x51, 0x49, 0x46}, // 2
+	{0x21, 0x41, 0x45, 0x4B, 0x31}, // 3
+	{0x18, 0x14, 0x12, 0x7F, 0x10}, // 4
+	{0x27, 0x45, 0x45, 0x45, 0x39}, // 5
+	{0x3C, 0x4A, 0x49, 0x49, 0x30}, // 6
+	{0x01, 0x71, 0x09, 0x05, 0x03}, // 7
+	{0x36, 0x49, 0x49, 0x49, 0x36}, // 8
+	{0x06, 0x49, 0x49, 0x29, 0x1E}, // 9
+	{0x00, 0x36, 0x36, 0x00, 0x00}, // :
+	{0x00, 0x56, 0x36, 0x00, 0x00}, // ;
+	{0x08, 0x14, 0x22, 0x41, 0x00}, // <
+	{0x14, 0x14, 0x14, 0x14, 0x14}, // =
+	{0x00, 0x41, 0x22, 0x14, 0x08}, // >
+	{0x02, 0x01, 0x51, 0x09, 0x06}, // ?
+	{0x32, 0x49, 0x79, 0x41, 0x3E}, // @
+	{0x7E, 0x11, 0x11, 0x11, 0x7E}, // A
+	{0x7F, 0x49, 0x49, 0x49, 0x36}, // B
+	{0x3E, 0x41, 0x41, 0x41, 0x22}, // C
+	{0x7F, 0x41, 0x41, 0x22, 0x1C}, // D
+	{0x7F, 0x49, 0x49, 0x49, 0x41}, // E
+	{0x7F, 0x09, 0x09, 0x09, 0x01}, // F
+	{0x3E, 0x41, 0x49, 0x49, 0x7A}, // G
+	{0x7F, 0x08, 0x08, 0x08, 0x7F}, // H
+	{0x00, 0x41, 0x7F, 0x41, 0x00}, // I
+	{0x20, 0x40, 0x41, 0x3F, 0x01}, // J
+	{0x7F, 0x08, 0x14, 0x22, 0x41}, // K
+	{0x7F, 0x40, 0x40, 0x40, 0x40}, // L
+	{0x7F, 0x02, 0x0C, 0x02, 0x7F}, // M
+	{0x7F, 0x04, 0x08, 0x10, 0x7F}, // N
+	{0x3E, 0x41, 0x41, 0x41, 0x3E}, // O
+	{0x7F, 0x09, 0x09, 0x09, 0x06}, // P
+	{0x3E, 0x41, 0x51, 0x21, 0x5E}, // Q
+	{0x7F, 0x09, 0x19, 0x29, 0x46}, // R
+	{0x46, 0x49, 0x49, 0x49, 0x31}, // S
+	{0x01, 0x01, 0x7F, 0x01, 0x01}, // T
+	{0x3F, 0x40, 0x40, 0x40, 0x3F}, // U
+	{0x1F, 0x20, 0x40, 0x20, 0x1F}, // V
+	{0x3F, 0x40, 0x38, 0x40, 0x3F}, // W
+	{0x63, 0x14, 0x08, 0x14, 0x63}, // X
+	{0x07, 0x08, 0x70, 0x08, 0x07}, // Y
+	{0x61, 0x51, 0x49, 0x45, 0x43}, // Z
+	{0x00, 0x7F, 0x41, 0x41, 0x00}, // [
+	{0x02, 0x04, 0x08, 0x10, 0x20}, // \
+	{0x00, 0x41, 0x41, 0x7F, 0x00}, // ]
+	{0x04, 0x02, 0x01, 0x02, 0x04}, // ^
+	{0x40, 0x40, 0x40, 0x40, 0x40}, // _
+	{0x00, 0x01, 0x02, 0x04, 0x00}, // `
+	{0x20, 0x54, 0x54, 0x54, 0x78}, // a
+	{0x7F, 0x48, 0x44, 0x44, 0x38}, // b
+	{0x38, 0x44, 0x44, 0x44, 0x20}, // c
+	{0x38, 0x44, 0x44, 0x48, 0x7F}, // d
+	{0x38, 0x54, 0x54, 0x54, 0x18}, // e
+	{0x08, 0x7E, 0x09, 0x01, 0x02}, // f
+	{0x0C, 0x52, 0x52, 0x52, 0x3E}, // g
+	{0x7F, 0x08, 0x04, 0x04, 0x78}, // h
+	{0x00, 0x44, 0x7D, 0x40, 0x00}, // i
+	{0x20, 0x40, 0x44, 0x3D, 0x00}, // j
+	{0x7F, 0x10, 0x28, 0x44, 0x00}, // k
+	{0x00, 0x41, 0x7F, 0x40, 0x00}, // l
+	{0x7C, 0x04, 0x18, 0x04, 0x78}, // m
+	{0x7C, 0x08, 0x04, 0x04, 0x78}, // n
+	{0x38, 0x44, 0x44, 0x44, 0x38}, // o
+	{0x7C, 0x14, 0x14, 0x14, 0x08}, // p
+	{0x08, 0x14, 0x14, 0x18, 0x7C}, // q
+	{0x7C, 0x08, 0x04, 0x04, 0x08}, // r
+	{0x48, 0x54, 0x54, 0x54, 0x20}, // s
+	{0x04, 0x3F, 0x44, 0x40, 0x20}, // t
+	{0x3C, 0x40, 0x40, 0x20, 0x7C}, // u
+	{0x1C, 0x20, 0x40, 0x20, 0x1C}, // v
+	{0x3C, 0x40, 0x30, 0x40, 0x3C}, // w
+	{0x44, 0x28, 0x10, 0x28, 0x44}, // x
+	{0x0C, 0x50, 0x50, 0x50, 0x3C}, // y
+	{0x44, 0x64, 0x54, 0x4C, 0x44}, // z
+	{0x00, 0x08, 0x36, 0x41, 0x00}, // {
+	{0x00, 0x00, 0x7F, 0x00, 0x00}, // |
+	{0x00, 0x41, 0x36, 0x08, 0x00}, // }
+	{0x08, 0x04, 0x08, 0x10, 0x08}, // ~
+}
@@ -0,0 +1,151 @@
+package multisample
+
+import (
+	"bufio"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	bdfAtlasWidth = 2048 // GNU Unifont (~57000 glyphs) packs into 2048x5000 texels
+)
+
+// loadBDFFont loads a bitmap font in the Glyph Bitmap Distribution Format,
+// e.g. GNU Unifont, which covers the Basic Multilingual Plane at 8x16 and
+// 16x16 (wide) pixels. Glyphs are shelf packed into the atlas in file order.
+func loadBDFFont(path string) (*Font, error) {
+
+	file, err := assets.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	f := &Font{name: filepath.Base(path), glyphs: map[rune]fontGlyph{}, atlasWidth: bdfAtlasWidth}
+	type bdfGlyph struct {
+		r    rune
+		bits [][]byte // rows, most significant bit is the leftmost texel
+		fontGlyph
+	}
+	var (
+		glyphs  []bdfGlyph
+		glyph   bdfGlyph
+		descent int
+		bitmap  bool
+		boxW    int // FONTBOUNDINGBOX, advance of glyphs without DWIDTH
+	)
+
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if bitmap && fields[0] != "ENDCHAR" {
+			row := make([]byte, len(fields[0])/2)
+			for i := range row {
+				b, err := strconv.ParseUint(fields[0][2*i:2*i+2], 16, 8)
+				if err != nil {
+					return nil, fmt.Errorf("%s:%d: bad bitmap row %q", path, line, fields[0])
+				}
+				row[i] = byte(b)
+			}
+			glyph.bits = append(glyph.bits, row)
+			continue
+		}
+
+		values := make([]int, len(fields)-1)
+		for i := range values {
+			values[i], _ = strconv.Atoi(fields[i+1])
+		}
+		need := func(n int) error {
+			if len(values) < n {
+				return fmt.Errorf("%s:%d: %s needs %d values", path, line, fields[0], n)
+			}
+			return nil
+		}
+
+		switch fields[0] {
+		case "FONTBOUNDINGBOX":
+			if err := need(4); err != nil {
+				return nil, err
+			}
+			boxW = values[0]
+		case "FONT_ASCENT":
+			if err := need(1); err != nil {
+				return nil, err
+			}
+			f.ascent = values[0]
+		case "FONT_DESCENT":
+			if err := need(1); err != nil {
+				return nil, err
+			}
+			descent = values[0]
+		case "STARTCHAR":
+			glyph = bdfGlyph{r: -1}
+			glyph.advance = boxW
+		case "ENCODING":
+			if err := need(1); err != nil {
+				return nil, err
+			}
+			glyph.r = rune(values[0]) // -1 for glyphs without code point
+		case "DWIDTH":
+			if err := need(1); err != nil {
+				return nil, err
+			}
+			glyph.advance = values[0]
+		case "BBX":
+			if err := need(4); err != nil {
+				return nil, err
+			}
+			glyph.width, glyph.height = values[0], values[1]
+			glyph.offsetX = values[2]
+			glyph.offsetY = f.ascent - (values[3] + values[1]) // BBX is relative to the baseline, y up
+		case "BITMAP":
+			bitmap = true
+		case "ENDCHAR":
+			bitmap = false
+			if glyph.r >= 0 && len(glyph.bits) >= glyph.height {
+				glyphs = append(glyphs, glyph)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(glyphs) == 0 {
+		return nil, fmt.Errorf("%s: no glyphs", path)
+	}
+	f.lineHeight = f.ascent + descent
+
+	// shelf packing, one texel of space around glyphs so nearest sampling never bleeds
+	x, y, shelf := 0, 0, 0
+	for i := range glyphs {
+		g := &glyphs[i]
+		if x+g.width+1 > f.atlasWidth {
+			x, y, shelf = 0, y+shelf, 0
+		}
+		g.x, g.y = x, y
+		x += g.width + 1
+		if g.height+1 > shelf {
+			shelf = g.height + 1
+		}
+	}
+	f.atlasHeight = y + shelf
+	f.atlas = make([]uint8, f.atlasWidth*f.atlasHeight)
+	for _, g := range glyphs {
+		for row := 0; row < g.height; row++ {
+			for col := 0; col < g.width && col/8 < len(g.bits[row]); col++ {
+				if g.bits[row][col/8]&(0x80>>uint(col%8)) != 0 {
+					f.atlas[(g.y+row)*f.atlasWidth+g.x+col] = 0xff
+				}
+			}
+		}
+		f.glyphs[g.r] = g.fontGlyph
+	}
+
+	return f, nil
+
+}
@@ -0,0 +1,138 @@
+package multisample
+
+import (
+	"fmt"
+	"image"
+	"log"
+	"math"
+	"path/filepath"
+
+	"github.com/go-gl/example/internal/capture"
+	"github.com/go-gl/gl/v3.2-core/gl"
+)
+
+var (
+	frameDump = &FrameDump{}
+)
+
+// FrameDump writes the state of a frame to png files, for debugging what
+// each stage of the pipeline produced:
+//
+//	frame-N-color.png           multisample color, resolved (by the render graph)
+//	frame-N-depth.png           multisample depth, resolved and linearized (near = black, far = white)
+//	frame-N-post-I-<name>.png   output of each enabled post effect, in order
+//
+// Request one with D, it is written right after the next frame is drawn.
+type FrameDump struct {
+	requested bool
+	count     int // frames dumped so far, numbers the files
+}
+
+// request schedules a dump of the next frame
+func (d *FrameDump) request() {
+	d.requested = true
+}
+
+// write dumps the frame drawn last, if requested, call it after draw()
+func (d *FrameDump) write(dir string) {
+
+	if !d.requested {
+		return
+	}
+	d.requested = false
+	d.count++
+	prefix := filepath.Join(dir, fmt.Sprintf("frame-%v", d.count))
+
+	d.save(prefix+"-color.png", renderToImage())
+	d.save(prefix+"-depth.png", readDepth())
+
+	// run the post effects again, reading each intermediate before the next pass overwrites it
+	pass := 0
+	postProcessing.onPass = func(effect *PostEffect, fbo uint32) {
+		pass++
+		width, height := viewportSize()
+		d.save(fmt.Sprintf("%v-post-%v-%v.png", prefix, pass, effect.name), readFramebuffer(fbo, 0, 0, width, height))
+	}
+	postProcessing.apply(passOutputs.mustTexture("scene").ID)
+	postProcessing.onPass = nil
+
+}
+
+func (d *FrameDump) save(path string, img image.Image) {
+	if err := capture.SavePNG(path, img); err != nil {
+		log.Println("failed to write frame dump:", err)
+		return
+	}
+	fmt.Println("frame dump written to", path)
+}
+
+// readDepth resolves the depth buffer of the multisample framebuffer and reads it back linearized.
+// Multisample renderbuffers can not be read directly, so depth is blitted into a single
+// sample framebuffer of the same format first.
+func readDepth() *image.Gray {
+
+	width, height := viewportSize()
+	renderWidth, renderHeight := renderSize()
+
+	fbo := genFramebuffer("depth dump fbo")
+	renderbuffer := genRenderbuffer("depth dump depth/stencil")
+	gl.BindRenderbuffer(gl.RENDERBUFFER, renderbuffer)
+	gl.RenderbufferStorage(gl.RENDERBUFFER, gl.DEPTH24_STENCIL8, renderWidth, renderHeight)
+	gl.BindRenderbuffer(gl.RENDERBUFFER, 0)
+	gl.BindFramebuffer(gl.FRAMEBUFFER, fbo)
+	gl.FramebufferRenderbuffer(gl.FRAMEBUFFER, gl.DEPTH_STENCIL_ATTACHMENT, gl.RENDERBUFFER, renderbuffer)
+	CheckGLFramebufferStatus()
+
+	// resolve depth, depth blits must use NEAREST
+	gl.BindFramebuffer(gl.READ_FRAMEBUFFER, ctxFramebufferMultisample.fbo)
+	gl.BindFramebuffer(gl.DRAW_FRAMEBUFFER, fbo)
+	gl.BlitFramebuffer(0, 0, width, height, 0, 0, width, height, gl.DEPTH_BUFFER_BIT, gl.NEAREST)
+
+	// read back, rows bottom to top
+	depth := make([]float32, int(width)*int(height))
+	gl.BindFramebuffer(gl.READ_FRAMEBUFFER, fbo)
+	gl.PixelStorei(gl.PACK_ALIGNMENT, 1)
+	gl.ReadPixels(0, 0, width, height, gl.DEPTH_COMPONENT, gl.FLOAT, gl.Ptr(depth))
+	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+
+	gpuResources.Release(ResourceFramebuffer, fbo)
+	gpuResources.Release(ResourceRenderbuffer, renderbuffer)
+
+	// linearize with the clip planes of the camera that drew the frame
+	near, far, perspective := depthCamera()
+	img := image.NewGray(image.Rect(0, 0, int(width), int(height)))
+	for y := 0; y < int(height); y++ {
+		row := depth[(int(height)-1-y)*int(width):]
+		for x := 0; x < int(width); x++ {
+			img.Pix[y*img.Stride+x] = uint8(255 * linearizeDepth(row[x], near, far, perspective))
+		}
+	}
+
+	return img
+
+}
+
+// depthCamera is the clip planes and projection of the camera used by the last frame
+func depthCamera() (near, far float32, perspective bool) {
+	switch {
+	case *terrainMode:
+		near, far = ctxTerrain.camera.ClipPlanes()
+		return near, far, !ctxTerrain.camera.Orthographic()
+	case ctxFramebufferMultisample.using2D:
+		near, far = ctxFramebufferMultisample.camera2D.ClipPlanes()
+		return near, far, false
+	}
+	near, far = ctxFramebufferMultisample.camera.ClipPlanes()
+	return near, far, !ctxFramebufferMultisample.camera.Orthographic()
+}
+
+// linearizeDepth maps a depth buffer value (0..1) to the eye distance between near (0) and far (1).
+// Orthographic depth is linear already, perspective depth is 1/z distributed.
+func linearizeDepth(depth, near, far float32, perspective bool) float32 {
+	if !perspective {
+		return depth
+	}
+	ndc := depth*2 - 1
+	distance := 2 * near * far / (far + near - ndc*(far-near))
+	return float32(math.Max(0, math.Min(1, float64((distance-near)/(far-near)))))
+}
@@ -0,0 +1,64 @@
+package multisample
+
+import (
+	"time"
+
+	"github.com/paperboard/glfw/v3.3/glfw"
+)
+
+const (
+	frameLimiterSpin = 0.002 // seconds spent spinning instead of sleeping, sleep may overshoot by about this much
+)
+
+var (
+	frameLimiter = &FrameLimiter{}
+)
+
+// FrameLimiter holds the render loop to a target frame rate. time.Sleep is
+// only accurate to a millisecond or two (more on some systems), so it sleeps
+// until shortly before the deadline and spins for the rest.
+type FrameLimiter struct {
+	target   float64 // target frame time in seconds, 0 = unlimited
+	deadline float64 // when the current frame should end, in glfw time
+}
+
+// setFPS sets the target frame rate, 0 or less disables the limiter
+func (f *FrameLimiter) setFPS(fps float64) {
+	f.target = 0
+	if fps > 0 {
+		f.target = 1 / fps
+	}
+	f.deadline = glfw.GetTime() + f.target
+}
+
+// Target is the target frame time in seconds, 0 if unlimited
+func (f *FrameLimiter) Target() float64 {
+	return f.target
+}
+
+// wait blocks until the current frame used up its target frame time, call it once per frame
+func (f *FrameLimiter) wait() {
+
+	if f.target == 0 {
+		return
+	}
+
+	// coarse sleep, wake up a little early
+	now := glfw.GetTime()
+	if remaining := f.deadline - now - frameLimiterSpin; remaining > 0 {
+		time.Sleep(time.Duration(remaining * float64(time.Second)))
+	}
+
+	// spin the rest for accuracy
+	for now = glfw.GetTime(); now < f.deadline; now = glfw.GetTime() {
+	}
+
+	// schedule the next frame from the deadline, not from now, so the small
+	// overshoots do not add up. A frame that ran more than a whole frame late
+	// starts over instead of rushing to catch up.
+	f.deadline += f.target
+	if f.deadline < now {
+		f.deadline = now + f.target
+	}
+
+}
@@ -0,0 +1,162 @@
+package multisample
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-gl/gl/v3.2-core/gl"
+)
+
+var (
+	glInfo = &GLInfo{} // the current context, filled by createWindow
+)
+
+// GLInfo describes the driver behind the current context: version, limits
+// and extensions. Feature checks read it instead of asking GL again, e.g.
+//
+//	if glInfo.AtLeast(4, 4) || glInfo.Has("GL_ARB_buffer_storage") { ... }
+type GLInfo struct {
+	Version         string // GL_VERSION, e.g. "4.6.0 NVIDIA 535.54" or "OpenGL ES 3.2 Mesa 23.1"
+	Vendor          string // GL_VENDOR
+	Renderer        string // GL_RENDERER, the GPU
+	ShadingLanguage string // GL_SHADING_LANGUAGE_VERSION, e.g. "4.60 NVIDIA"
+	Major, Minor    int    // parsed from Version
+	GLSL            int    // shading language version as in #version, e.g. 150 or 460
+	ES              bool   // OpenGL ES context
+
+	MaxTextureSize      int32 // width and height of the largest 2D texture
+	MaxRenderbufferSize int32
+	MaxSamples          int32 // MSAA samples of renderbuffers
+	MaxColorSamples     int32 // MSAA samples of color textures (TEXTURE_2D_MULTISAMPLE)
+	MaxDepthSamples     int32 // MSAA samples of depth textures
+	MaxTextureUnits     int32 // combined texture image units of all stages
+	MaxVertexAttribs    int32
+	MaxDrawBuffers      int32 // color attachments drawn at once
+
+	// the default framebuffer as created, see WindowOptions
+	ScreenAlphaBits int32
+	ScreenDepthBits int32
+	ScreenSRGB      bool
+
+	extensions map[string]bool
+}
+
+// newGLInfo queries the current context, requires a current GL context
+func newGLInfo() *GLInfo {
+
+	info := &GLInfo{
+		Version:         glString(gl.VERSION),
+		Vendor:          glString(gl.VENDOR),
+		Renderer:        glString(gl.RENDERER),
+		ShadingLanguage: glString(gl.SHADING_LANGUAGE_VERSION),
+		extensions:      map[string]bool{},
+	}
+	info.ES = strings.HasPrefix(info.Version, "OpenGL ES")
+	info.Major, info.Minor = parseGLVersion(info.Version)
+	glslMajor, glslMinor := parseGLVersion(info.ShadingLanguage)
+	info.GLSL = glslMajor*100 + glslMinor
+
+	limits := []struct {
+		name  uint32
+		value *int32
+	}{
+		{gl.MAX_TEXTURE_SIZE, &info.MaxTextureSize},
+		{gl.MAX_RENDERBUFFER_SIZE, &info.MaxRenderbufferSize},
+		{gl.MAX_SAMPLES, &info.MaxSamples},
+		{gl.MAX_COLOR_TEXTURE_SAMPLES, &info.MaxColorSamples},
+		{gl.MAX_DEPTH_TEXTURE_SAMPLES, &info.MaxDepthSamples},
+		{gl.MAX_COMBINED_TEXTURE_IMAGE_UNITS, &info.MaxTextureUnits},
+		{gl.MAX_VERTEX_ATTRIBS, &info.MaxVertexAttribs},
+		{gl.MAX_DRAW_BUFFERS, &info.MaxDrawBuffers},
+	}
+	for _, limit := range limits {
+		gl.GetIntegerv(limit.name, limit.value)
+	}
+
+	// the default framebuffer is bound after the context was created
+	var encoding int32
+	gl.GetFramebufferAttachmentParameteriv(gl.FRAMEBUFFER, gl.BACK_LEFT, gl.FRAMEBUFFER_ATTACHMENT_ALPHA_SIZE, &info.ScreenAlphaBits)
+	gl.GetFramebufferAttachmentParameteriv(gl.FRAMEBUFFER, gl.BACK_LEFT, gl.FRAMEBUFFER_ATTACHMENT_COLOR_ENCODING, &encoding)
+	gl.GetFramebufferAttachmentParameteriv(gl.FRAMEBUFFER, gl.DEPTH, gl.FRAMEBUFFER_ATTACHMENT_DEPTH_SIZE, &info.ScreenDepthBits)
+	info.ScreenSRGB = encoding == gl.SRGB
+
+	var count int32
+	gl.GetIntegerv(gl.NUM_EXTENSIONS, &count)
+	for i := int32(0); i < count; i++ {
+		info.extensions[gl.GoStr(gl.GetStringi(gl.EXTENSIONS, uint32(i)))] = true
+	}
+
+	return info
+
+}
+
+// AtLeast checks the context version, e.g. AtLeast(3, 2)
+func (info *GLInfo) AtLeast(major, minor int) bool {
+	return info.Major > major || (info.Major == major && info.Minor >= minor)
+}
+
+// Has checks for an extension, e.g. Has("GL_ARB_timer_query")
+func (info *GLInfo) Has(extension string) bool {
+	return info.extensions[extension]
+}
+
+// Extensions is the number of extensions
+func (info *GLInfo) Extensions() int {
+	return len(info.extensions)
+}
+
+// MultisampleTextures is true if multisampled textures can be rendered into
+// and resolved by blitting (TEXTURE_2D_MULTISAMPLE, core in OpenGL 3.2 and
+// OpenGL ES 3.1), the path this demo takes
+func (info *GLInfo) MultisampleTextures() bool {
+	return info.AtLeast(3, 2) && !info.ES || info.AtLeast(3, 1) && info.ES || info.Has("GL_ARB_texture_multisample")
+}
+
+// MultisampledRenderToTexture is true if FramebufferTexture2DMultisampleEXT
+// is viable, which renders MSAA into a plain texture and resolves on tile
+// memory (mobile GPUs). Desktop drivers rarely have it.
+func (info *GLInfo) MultisampledRenderToTexture() bool {
+	return info.Has("GL_EXT_multisampled_render_to_texture")
+}
+
+// Samples clamps a requested MSAA sample count to what color textures and
+// renderbuffers support
+func (info *GLInfo) Samples(requested int32) int32 {
+	samples := requested
+	for _, max := range []int32{info.MaxSamples, info.MaxColorSamples} {
+		if max > 0 && samples > max {
+			samples = max
+		}
+	}
+	return samples
+}
+
+func (info *GLInfo) String() string {
+	return fmt.Sprintf("OpenGL %v.%v (%v)\n  renderer %v, %v\n  GLSL %v, %v extensions\n  max texture %v, max samples %v (color textures %v)\n  screen alpha %v bits, depth %v bits, sRGB %v",
+		info.Major, info.Minor, info.Version,
+		info.Renderer, info.Vendor,
+		info.GLSL, info.Extensions(),
+		info.MaxTextureSize, info.MaxSamples, info.MaxColorSamples,
+		info.ScreenAlphaBits, info.ScreenDepthBits, info.ScreenSRGB,
+	)
+}
+
+// glString reads a GL string, empty if the driver has none
+func glString(name uint32) string {
+	s := gl.GetString(name)
+	if s == nil {
+		return ""
+	}
+	return gl.GoStr(s)
+}
+
+// parseGLVersion reads major and minor of the first "major.minor" in a
+// version string, vendor prefixes like "OpenGL ES GLSL ES " are skipped
+func parseGLVersion(version string) (major, minor int) {
+	start := strings.IndexAny(version, "0123456789")
+	if start < 0 {
+		return 0, 0
+	}
+	fmt.Sscanf(version[start:], "%d.%d", &major, &minor)
+	return major, minor
+}
@@ -0,0 +1,82 @@
+package multisample
+
+import (
+	"github.com/go-gl/gl/v3.2-core/gl"
+)
+
+var (
+	shaderGlobals = &ShaderGlobals{programs: map[uint32]globalUniforms{}}
+)
+
+// globalUniforms are the locations of the global uniforms in one program, -1 if unused
+type globalUniforms struct {
+	time        int32 // uniform float uTime; seconds since glfw.Init
+	resolution  int32 // uniform vec2 uResolution; offscreen framebuffer size in pixels, see renderSize
+	renderScale int32 // uniform vec2 uRenderScale; share of the offscreen framebuffer in use, see viewportSize
+}
+
+// ShaderGlobals updates uniforms shared by every program once per frame, so
+// shaders can animate without CPU-side vertex updates, e.g.
+//
+//	uniform float uTime;
+//	uniform vec2 uResolution;
+//	uniform vec2 uRenderScale;
+//	...
+//	FragColor = fragmentColor * (0.75 + 0.25 * sin(uTime * 4));
+//
+// Every program created by newProgram is registered. Programs that do not
+// declare (or do not use) the uniforms are skipped, the GLSL compiler removes
+// unused uniforms.
+type ShaderGlobals struct {
+	programs map[uint32]globalUniforms
+}
+
+// register looks up the global uniforms of a linked program
+func (g *ShaderGlobals) register(program uint32) {
+	uniforms := globalUniforms{
+		time:        gl.GetUniformLocation(program, gl.Str("uTime\x00")),
+		resolution:  gl.GetUniformLocation(program, gl.Str("uResolution\x00")),
+		renderScale: gl.GetUniformLocation(program, gl.Str("uRenderScale\x00")),
+	}
+	if uniforms.time == -1 && uniforms.resolution == -1 && uniforms.renderScale == -1 {
+		return
+	}
+	g.programs[program] = uniforms
+}
+
+// unregister stops updating a program, called when the program is deleted
+func (g *ShaderGlobals) unregister(program uint32) {
+	delete(g.programs, program)
+}
+
+// forget drops every program without touching GL, used when the GL context is lost
+func (g *ShaderGlobals) forget() {
+	g.programs = map[uint32]globalUniforms{}
+}
+
+// update uploads the global uniforms to every registered program, call it once per frame before drawing
+func (g *ShaderGlobals) update(now float64) {
+
+	if len(g.programs) == 0 {
+		return
+	}
+
+	width, height := renderSize()
+	viewportWidth, viewportHeight := viewportSize()
+
+	// uniforms are per program state, each program must be in use to set them
+	for program, uniforms := range g.programs {
+		gl.UseProgram(program)
+		if uniforms.time != -1 {
+			gl.Uniform1f(uniforms.time, float32(now))
+		}
+		if uniforms.resolution != -1 {
+			gl.Uniform2f(uniforms.resolution, float32(width), float32(height))
+		}
+		if uniforms.renderScale != -1 {
+			gl.Uniform2f(uniforms.renderScale, float32(viewportWidth)/float32(width), float32(viewportHeight)/float32(height))
+		}
+	}
+	gl.UseProgram(0)
+
+}
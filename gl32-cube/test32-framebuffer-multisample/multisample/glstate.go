@@ -0,0 +1,145 @@
+package multisample
+
+import (
+	"github.com/go-gl/gl/v3.2-core/gl"
+)
+
+// glStateTextureUnits are the texture units whose 2D binding is saved,
+// materials allocate units from 0 (see TextureUnits)
+const glStateTextureUnits = 4
+
+// glStateCaps are the enables saved by GLState
+var glStateCaps = []uint32{
+	gl.BLEND,
+	gl.CULL_FACE,
+	gl.DEPTH_TEST,
+	gl.MULTISAMPLE,
+	gl.SCISSOR_TEST,
+	gl.STENCIL_TEST,
+	gl.SAMPLE_ALPHA_TO_COVERAGE,
+	gl.FRAMEBUFFER_SRGB,
+	gl.PRIMITIVE_RESTART,
+	gl.RASTERIZER_DISCARD,
+}
+
+// GLState is a snapshot of the GL state this renderer relies on: bindings,
+// enables, blend and depth settings, viewport and scissor box. Code drawing
+// with another GL library in the same context (a UI toolkit, a video
+// player) changes state behind our back, e.g.
+//
+//	state := saveGLState()
+//	thirdParty.Render()
+//	state.restore()
+//
+// Everything else (uniforms, texture parameters, pixel store, stencil
+// functions) is not saved. Reading state stalls some drivers, save around
+// foreign code only, not around our own draws.
+type GLState struct {
+	program          int32
+	vertexArray      int32
+	arrayBuffer      int32
+	drawFramebuffer  int32
+	readFramebuffer  int32
+	renderbuffer     int32
+	activeTexture    int32
+	textures         [glStateTextureUnits]int32
+	viewport         [4]int32
+	scissor          [4]int32
+	caps             []bool // per glStateCaps
+	blendSrcRGB      int32
+	blendDstRGB      int32
+	blendSrcAlpha    int32
+	blendDstAlpha    int32
+	blendEquationRGB int32
+	blendEquationA   int32
+	depthFunc        int32
+	depthMask        bool
+	colorMask        [4]bool
+	clearColor       [4]float32
+}
+
+// saveGLState reads the current state, requires a current GL context
+func saveGLState() *GLState {
+
+	s := &GLState{}
+	gl.GetIntegerv(gl.CURRENT_PROGRAM, &s.program)
+	gl.GetIntegerv(gl.VERTEX_ARRAY_BINDING, &s.vertexArray)
+	gl.GetIntegerv(gl.ARRAY_BUFFER_BINDING, &s.arrayBuffer)
+	gl.GetIntegerv(gl.DRAW_FRAMEBUFFER_BINDING, &s.drawFramebuffer)
+	gl.GetIntegerv(gl.READ_FRAMEBUFFER_BINDING, &s.readFramebuffer)
+	gl.GetIntegerv(gl.RENDERBUFFER_BINDING, &s.renderbuffer)
+
+	gl.GetIntegerv(gl.ACTIVE_TEXTURE, &s.activeTexture)
+	for unit := range s.textures {
+		gl.ActiveTexture(gl.TEXTURE0 + uint32(unit))
+		gl.GetIntegerv(gl.TEXTURE_BINDING_2D, &s.textures[unit])
+	}
+	gl.ActiveTexture(uint32(s.activeTexture))
+
+	gl.GetIntegerv(gl.VIEWPORT, &s.viewport[0])
+	gl.GetIntegerv(gl.SCISSOR_BOX, &s.scissor[0])
+
+	s.caps = make([]bool, len(glStateCaps))
+	for i, capability := range glStateCaps {
+		s.caps[i] = gl.IsEnabled(capability)
+	}
+
+	gl.GetIntegerv(gl.BLEND_SRC_RGB, &s.blendSrcRGB)
+	gl.GetIntegerv(gl.BLEND_DST_RGB, &s.blendDstRGB)
+	gl.GetIntegerv(gl.BLEND_SRC_ALPHA, &s.blendSrcAlpha)
+	gl.GetIntegerv(gl.BLEND_DST_ALPHA, &s.blendDstAlpha)
+	gl.GetIntegerv(gl.BLEND_EQUATION_RGB, &s.blendEquationRGB)
+	gl.GetIntegerv(gl.BLEND_EQUATION_ALPHA, &s.blendEquationA)
+	gl.GetIntegerv(gl.DEPTH_FUNC, &s.depthFunc)
+	gl.GetBooleanv(gl.DEPTH_WRITEMASK, &s.depthMask)
+	gl.GetBooleanv(gl.COLOR_WRITEMASK, &s.colorMask[0])
+	gl.GetFloatv(gl.COLOR_CLEAR_VALUE, &s.clearColor[0])
+
+	return s
+
+}
+
+// restore sets the saved state again. The vertex array is bound before the
+// array buffer, the element buffer binding is part of the vertex array.
+func (s *GLState) restore() {
+
+	gl.UseProgram(uint32(s.program))
+	gl.BindVertexArray(uint32(s.vertexArray))
+	gl.BindBuffer(gl.ARRAY_BUFFER, uint32(s.arrayBuffer))
+	gl.BindFramebuffer(gl.DRAW_FRAMEBUFFER, uint32(s.drawFramebuffer))
+	gl.BindFramebuffer(gl.READ_FRAMEBUFFER, uint32(s.readFramebuffer))
+	gl.BindRenderbuffer(gl.RENDERBUFFER, uint32(s.renderbuffer))
+
+	for unit, texture := range s.textures {
+		gl.ActiveTexture(gl.TEXTURE0 + uint32(unit))
+		gl.BindTexture(gl.TEXTURE_2D, uint32(texture))
+	}
+	gl.ActiveTexture(uint32(s.activeTexture))
+
+	gl.Viewport(s.viewport[0], s.viewport[1], s.viewport[2], s.viewport[3])
+	gl.Scissor(s.scissor[0], s.scissor[1], s.scissor[2], s.scissor[3])
+
+	for i, capability := range glStateCaps {
+		if s.caps[i] {
+			gl.Enable(capability)
+		} else {
+			gl.Disable(capability)
+		}
+	}
+
+	gl.BlendFuncSeparate(uint32(s.blendSrcRGB), uint32(s.blendDstRGB), uint32(s.blendSrcAlpha), uint32(s.blendDstAlpha))
+	gl.BlendEquationSeparate(uint32(s.blendEquationRGB), uint32(s.blendEquationA))
+	gl.DepthFunc(uint32(s.depthFunc))
+	gl.DepthMask(s.depthMask)
+	gl.ColorMask(s.colorMask[0], s.colorMask[1], s.colorMask[2], s.colorMask[3])
+	gl.ClearColor(s.clearColor[0], s.clearColor[1], s.clearColor[2], s.clearColor[3])
+
+}
+
+// withGLState runs draw, e.g. a third-party renderer, and restores the
+// state it found afterwards
+func withGLState(draw func()) {
+	state := saveGLState()
+	defer state.restore()
+	draw()
+}
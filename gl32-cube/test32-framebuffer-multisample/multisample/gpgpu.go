@@ -0,0 +1,166 @@
+package multisample
+
+import (
+	"math/rand"
+
+	"github.com/go-gl/gl/v3.2-core/gl"
+)
+
+const (
+	lifeSize    = 256  // cells across and down, the grid wraps around at the edges
+	lifeDensity = 0.25 // share of cells alive at the start
+	lifeSeed    = 11   // start pattern seed, same pattern every run
+)
+
+var (
+	life = &Life{}
+)
+
+// ComputePass runs a "compute" step as a full screen fragment shader (GPGPU
+// without compute shaders): every pixel of a float texture is one element of
+// the state, the shader reads the last state (sampler2D state) and writes the
+// next one. The state lives in two RGBA32F textures used in turns (ping-pong),
+// like PostProcessing, and the pass draws the screen quad of ContextScreen.
+//
+// Shaders address their element with gl_FragCoord and texelFetch, fragment
+// texture coordinates follow the render scale and do not fit a state of its
+// own size. Float render targets need OpenGL 3.0 or GLES 3.2.
+type ComputePass struct {
+	width    int32
+	height   int32
+	effect   *PostEffect // program and material of the step
+	fbos     [2]uint32
+	textures [2]uint32 // RGBA32F state, one pixel per element
+	current  int       // index of the texture holding the latest state
+}
+
+// newComputePass creates the state textures, filled with initial (4 floats per
+// element, row by row from the bottom, nil for zeros), and the step program.
+// Requires a current GL context.
+func newComputePass(name string, width, height int32, fragmentShader *string, initial []float32) *ComputePass {
+
+	c := &ComputePass{width: width, height: height}
+	c.effect = &PostEffect{name: name, fragmentShader: fragmentShader}
+	c.effect.setupProgram()
+
+	var pixels interface{}
+	if initial != nil {
+		pixels = initial
+	}
+
+	for i := range c.fbos {
+
+		c.fbos[i] = genFramebuffer(name + " fbo")
+		gl.BindFramebuffer(gl.FRAMEBUFFER, c.fbos[i])
+
+		// exact values, float textures are not filterable everywhere
+		c.textures[i] = genTexture(name + " state")
+		gl.BindTexture(gl.TEXTURE_2D, c.textures[i])
+		gl.TexImage2D(gl.TEXTURE_2D, 0, gl.RGBA32F, width, height, 0, gl.RGBA, gl.FLOAT, gl.Ptr(pixels))
+		gpuResources.SetBytes(ResourceTexture, c.textures[i], int(width)*int(height)*4*bytesFloat32)
+		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.NEAREST)
+		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.NEAREST)
+		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
+		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
+		gl.BindTexture(gl.TEXTURE_2D, 0)
+		gl.FramebufferTexture2D(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT0, gl.TEXTURE_2D, c.textures[i], 0)
+
+		CheckGLFramebufferStatus()
+
+	}
+
+	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+
+	return c
+
+}
+
+// SetParam sets a float uniform of the step shader, e.g. a time step
+func (c *ComputePass) SetParam(name string, value float32) {
+	c.effect.SetParam(name, value)
+}
+
+// Texture is the latest state, for a sampler2D (use texelFetch or NEAREST coordinates)
+func (c *ComputePass) Texture() uint32 {
+	return c.textures[c.current]
+}
+
+// Step runs the shader steps times, each reading the state the previous one
+// wrote. It leaves the screen quad's buffers bound (as PostProcessing.apply
+// expects from a prepare function), the framebuffer and viewport are left to
+// the caller to restore.
+func (c *ComputePass) Step(steps int) {
+
+	gl.Disable(gl.DEPTH_TEST)
+	gl.Viewport(0, 0, c.width, c.height)
+	gl.BindBuffer(gl.ARRAY_BUFFER, ctxScreen.vbo)
+	gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, quadIndices.Buffer())
+
+	for i := 0; i < steps; i++ {
+		target := 1 - c.current
+		gl.BindFramebuffer(gl.FRAMEBUFFER, c.fbos[target])
+		c.effect.material.SetTexture("state", gl.TEXTURE_2D, c.textures[c.current])
+		c.effect.draw()
+		c.current = target
+	}
+
+	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+
+}
+
+func (c *ComputePass) destroy() {
+	for i := range c.fbos {
+		gpuResources.Release(ResourceFramebuffer, c.fbos[i])
+		gpuResources.Release(ResourceTexture, c.textures[i])
+		c.fbos[i], c.textures[i] = 0, 0
+	}
+	gpuResources.Release(ResourceProgram, c.effect.program)
+	c.effect.program = 0
+}
+
+// Life runs Conway's Game of Life on a ComputePass (-life), one generation
+// per frame, and a post effect ("life") lays the cells over the image.
+type Life struct {
+	pass    *ComputePass
+	overlay *PostEffect
+}
+
+// load registers the overlay effect, before postProcessing.setupProgram
+func (l *Life) load() {
+	l.overlay = postProcessing.add("life", &fragmentShaderLifeOverlay, true)
+	l.overlay.prepare = l.step
+}
+
+// setupBuffers seeds the grid, after postProcessing.setupProgram
+func (l *Life) setupBuffers() {
+
+	rng := rand.New(rand.NewSource(lifeSeed))
+	cells := make([]float32, lifeSize*lifeSize*4)
+	for i := 0; i < lifeSize*lifeSize; i++ {
+		if rng.Float32() < lifeDensity {
+			cells[i*4] = 1
+		}
+	}
+
+	l.pass = newComputePass("life", lifeSize, lifeSize, &fragmentShaderLife, cells)
+
+}
+
+// step advances a generation, called by PostProcessing.apply before the overlay pass
+func (l *Life) step() {
+
+	l.pass.Step(1)
+	l.overlay.material.SetTexture("lifeTexture", gl.TEXTURE_2D, l.pass.Texture())
+
+	// back to the viewport of the post effects
+	width, height := viewportSize()
+	gl.Viewport(0, 0, width, height)
+
+}
+
+func (l *Life) destroy() {
+	if l.pass != nil {
+		l.pass.destroy()
+		l.pass = nil
+	}
+}
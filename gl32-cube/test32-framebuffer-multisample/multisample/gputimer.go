@@ -0,0 +1,94 @@
+package multisample
+
+import (
+	"github.com/go-gl/gl/v3.2-core/gl"
+)
+
+const (
+	gpuTimerQueries = 4 // frames in flight, results are read a few frames late so we never wait for the GPU
+)
+
+var (
+	gpuTimer = &GPUTimer{}
+)
+
+// GPUTimer measures how long the GPU takes to render a frame with timer
+// queries (OpenGL 3.3 or ARB_timer_query). Unlike CPU timing it includes the
+// actual drawing, which happens asynchronously after the draw calls return.
+type GPUTimer struct {
+	supported bool
+	queries   [gpuTimerQueries]uint32
+	pending   [gpuTimerQueries]bool // query issued, result not read yet
+	next      int                   // query used by the next frame
+	running   bool                  // between begin and end
+	last      float64               // latest result in seconds
+	valid     bool                  // last holds a result
+}
+
+// setup checks for timer query support and creates the queries, requires a current GL context
+func (t *GPUTimer) setup() {
+	*t = GPUTimer{supported: glInfo.AtLeast(3, 3) || glInfo.Has("GL_ARB_timer_query")}
+	if !t.supported {
+		return
+	}
+	for i := range t.queries {
+		t.queries[i] = genQuery("gpu timer")
+	}
+}
+
+// begin starts timing the GPU commands of a frame
+func (t *GPUTimer) begin() {
+
+	if !t.supported {
+		return
+	}
+
+	// collect finished results, without waiting
+	for i, query := range t.queries {
+		if !t.pending[i] {
+			continue
+		}
+		var available int32
+		gl.GetQueryObjectiv(query, gl.QUERY_RESULT_AVAILABLE, &available)
+		if available == gl.FALSE {
+			continue
+		}
+		var nanoseconds uint64
+		gl.GetQueryObjectui64v(query, gl.QUERY_RESULT, &nanoseconds)
+		t.last, t.valid = float64(nanoseconds)/1e9, true
+		t.pending[i] = false
+	}
+
+	// GPU is more than gpuTimerQueries frames behind, skip timing this frame
+	if t.pending[t.next] {
+		return
+	}
+
+	gl.BeginQuery(gl.TIME_ELAPSED, t.queries[t.next])
+	t.running = true
+
+}
+
+// end stops timing the frame started by begin
+func (t *GPUTimer) end() {
+	if !t.running {
+		return
+	}
+	gl.EndQuery(gl.TIME_ELAPSED)
+	t.pending[t.next] = true
+	t.next = (t.next + 1) % len(t.queries)
+	t.running = false
+}
+
+// result is the GPU time of a recent frame in seconds, ok is false until the first result arrived
+func (t *GPUTimer) result() (seconds float64, ok bool) {
+	return t.last, t.valid
+}
+
+func (t *GPUTimer) destroy() {
+	for i := range t.queries {
+		gpuResources.Release(ResourceQuery, t.queries[i])
+		t.queries[i] = 0
+	}
+	t.supported = false
+}
@@ -0,0 +1,164 @@
+package multisample
+
+import (
+	"image/color"
+	"math"
+	"sort"
+
+	"github.com/go-gl/gl/v3.2-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+const (
+	gradientRampSize = 256 // texels per gradient, stops closer than 1/256 blur together
+)
+
+// GradientKind is how the position in a gradient follows the position in the shape
+type GradientKind int32
+
+const (
+	GradientLinear GradientKind = iota // along the line from From to To
+	GradientRadial                     // outwards from Center, reaching the last stop at Radius
+)
+
+// GradientStop is the color at a position of a gradient, 0 (start) to 1 (end)
+type GradientStop struct {
+	Offset float32
+	Color  color.NRGBA
+}
+
+// Gradient is a fill changing color with the position, in the units of the
+// quad vertices. Add it with AddGradient, fill quads with SetGradient.
+// Before the first and after the last stop the color stays the same.
+type Gradient struct {
+	Kind     GradientKind
+	From, To mgl32.Vec2 // linear
+	Center   mgl32.Vec2 // radial
+	Radius   float32    // radial
+	Stops    []GradientStop
+}
+
+// LinearGradient runs across rect at an angle (in degrees, 0 is left to
+// right, 90 bottom to top), from corner to corner like CSS gradients: the
+// first stop is at the corner the gradient starts in, the last at the opposite
+func LinearGradient(rect Rect, angle float32, stops ...GradientStop) Gradient {
+	direction := mgl32.Vec2{float32(math.Cos(float64(mgl32.DegToRad(angle)))), float32(math.Sin(float64(mgl32.DegToRad(angle))))}
+	reach := (float32(math.Abs(float64(direction.X())))*rect.W + float32(math.Abs(float64(direction.Y())))*rect.H) * 0.5
+	center := mgl32.Vec2{rect.X, rect.Y}
+	return Gradient{Kind: GradientLinear, From: center.Sub(direction.Mul(reach)), To: center.Add(direction.Mul(reach)), Stops: stops}
+}
+
+// RadialGradient runs outwards from center to radius
+func RadialGradient(center mgl32.Vec2, radius float32, stops ...GradientStop) Gradient {
+	return Gradient{Kind: GradientRadial, Center: center, Radius: radius, Stops: stops}
+}
+
+// AddGradient adds a gradient quads can be filled with, see SetGradient
+func (q *ElementQuads) AddGradient(gradient Gradient) int {
+	q.Gradients = append(q.Gradients, gradient)
+	q.gradientsChanged = true
+	return len(q.Gradients) - 1
+}
+
+// SetGradient fills the quads from first up to (not including) last with a
+// gradient, multiplied with their vertex colors and tint like a texture (draw
+// them white for the colors of the stops), -1 to go back to the vertex
+// colors. It works with plain quads, DrawRoundedRect (the fill, not the
+// stroke), FillPolygon and DrawLine, e.g.:
+//
+//	first := quads.QuadCount()
+//	quads.FillPolygon(points, z, white)
+//	quads.SetGradient(first, quads.QuadCount(), gradient)
+func (q *ElementQuads) SetGradient(first, last, gradient int) {
+	for quad := first; quad < last; quad++ {
+		q.QuadShapes[quad].Gradient = gradient + 1
+	}
+	q.shapesChanged = true
+}
+
+// ramp is the color of the gradient at size evenly spaced positions
+func (g Gradient) ramp(size int) []uint8 {
+	stops := append([]GradientStop{}, g.Stops...)
+	sort.SliceStable(stops, func(i, j int) bool { return stops[i].Offset < stops[j].Offset })
+	pix := make([]uint8, 0, size*4)
+	for i := 0; i < size; i++ {
+		t := float32(i) / float32(size-1)
+		clr := color.NRGBA{255, 255, 255, 255}
+		switch {
+		case len(stops) == 0:
+		case t <= stops[0].Offset:
+			clr = stops[0].Color
+		case t >= stops[len(stops)-1].Offset:
+			clr = stops[len(stops)-1].Color
+		default:
+			next := sort.Search(len(stops), func(j int) bool { return stops[j].Offset > t })
+			a, b := stops[next-1], stops[next]
+			f := (t - a.Offset) / (b.Offset - a.Offset)
+			clr = color.NRGBA{lerpUint8(a.Color.R, b.Color.R, f), lerpUint8(a.Color.G, b.Color.G, f), lerpUint8(a.Color.B, b.Color.B, f), lerpUint8(a.Color.A, b.Color.A, f)}
+		}
+		pix = append(pix, clr.R, clr.G, clr.B, clr.A)
+	}
+	return pix
+}
+
+func lerpUint8(a, b uint8, f float32) uint8 {
+	return uint8(float32(a) + (float32(b)-float32(a))*f + 0.5)
+}
+
+// GradientRamps is a texture with the colors of every gradient of the quads,
+// gradientRampSize texels per row and one row per gradient. The fragment
+// shader turns the position of a fragment into a position along the gradient
+// (the geometry of each quad's gradient is in ShapeTexture) and samples the
+// row with linear filtering, exact to the texel for any number of stops.
+type GradientRamps struct {
+	texture uint32
+	rows    int // allocated rows
+}
+
+// NewGradientRamps creates the texture, storage is allocated by the first update
+func NewGradientRamps(label string) *GradientRamps {
+	r := &GradientRamps{texture: genTexture(label)}
+	gl.BindTexture(gl.TEXTURE_2D, r.texture)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
+	gl.BindTexture(gl.TEXTURE_2D, 0)
+	return r
+}
+
+// Texture is the texture, see GradientRamps
+func (r *GradientRamps) Texture() uint32 {
+	return r.texture
+}
+
+// update uploads the ramps if a gradient was added since the last update,
+// call it before binding the material (it binds the texture to the active unit)
+func (r *GradientRamps) update(quads *ElementQuads) {
+	if !quads.gradientsChanged || len(quads.Gradients) == 0 {
+		return
+	}
+	quads.gradientsChanged = false
+
+	rows := nextPowerOfTwo(len(quads.Gradients))
+	pix := make([]uint8, 0, rows*gradientRampSize*4)
+	for _, gradient := range quads.Gradients {
+		pix = append(pix, gradient.ramp(gradientRampSize)...)
+	}
+	pix = pix[:cap(pix)]
+
+	gl.BindTexture(gl.TEXTURE_2D, r.texture)
+	if rows > r.rows {
+		r.rows = rows
+		gl.TexImage2D(gl.TEXTURE_2D, 0, gl.RGBA8, gradientRampSize, int32(rows), 0, gl.RGBA, gl.UNSIGNED_BYTE, gl.Ptr(pix))
+		gpuResources.SetBytes(ResourceTexture, r.texture, len(pix))
+	} else {
+		gl.TexSubImage2D(gl.TEXTURE_2D, 0, 0, 0, gradientRampSize, int32(rows), gl.RGBA, gl.UNSIGNED_BYTE, gl.Ptr(pix))
+	}
+	gl.BindTexture(gl.TEXTURE_2D, 0)
+}
+
+func (r *GradientRamps) destroy() {
+	gpuResources.Release(ResourceTexture, r.texture)
+	r.texture, r.rows = 0, 0
+}
@@ -0,0 +1,213 @@
+package multisample
+
+import (
+	"fmt"
+	"image/color"
+
+	"github.com/go-gl/gl/v3.2-core/gl"
+)
+
+const (
+	graphSamples     = 300   // number of recent frame times shown by the graph (one bar each)
+	graphMaxFrameMs  = 50.0  // frame time (in milliseconds) that fills the full graph height
+	graphTargetMs60  = 16.6  // guide line for 60 fps
+	graphTargetMs30  = 33.3  // guide line for 30 fps
+	graphLeft        = -0.95 // graph position in NDC (left edge)
+	graphBottom      = -0.95 // graph position in NDC (bottom edge)
+	graphWidth       = 0.9   // graph size in NDC (width)
+	graphHeight      = 0.4   // graph size in NDC (height)
+	graphGuideHeight = 0.005 // thickness of the guide lines in NDC
+)
+
+var (
+	ctxGraph = &ContextGraph{}
+)
+
+// ContextGraph is an overlay drawn ontop the real screen, it shows a
+// scrolling bar graph of the most recent frame times to spot stutters.
+type ContextGraph struct {
+	quads                *ElementQuads
+	layout               *Layout
+	program              uint32 // connects vertex and fragment shaders (Graph shaders)
+	vbo                  uint32 // stores vertex position and color array data
+	ibo                  uint32 // the shared quad indices (see QuadIndexBuffer), not owned by this context
+	vao                  uint32 // only need to initalize it, we never use it
+	attribVertexPosition uint32 // reference to position input for shader variable (Graph shaders)
+	attribVertexColor    uint32 // reference to color input for shader variable (Graph shaders)
+
+	vertexBuffers *DynamicBuffer // vbo is the current one of these
+
+	frameTimes []float64 // ring buffer of frame durations in seconds
+	frameNext  int       // ring buffer position where the next frame time is written
+}
+
+// record stores the duration (in seconds) of the last frame
+func (ctx *ContextGraph) record(elapsed float64) {
+	ctx.frameTimes[ctx.frameNext] = elapsed
+	ctx.frameNext = (ctx.frameNext + 1) % len(ctx.frameTimes)
+}
+
+func (ctx *ContextGraph) load() {
+
+	// initalize graph quads
+	ctx.quads = &ElementQuads{
+		QuadVertices:  []float32{},
+		QuadTexCoords: []uint8{}, // unused, graph is not textured
+		QuadColors:    []uint8{},
+	}
+
+	// initalize frame time ring buffer
+	ctx.frameTimes = make([]float64, graphSamples)
+
+	// fill draw queue once, so the number of quads (and buffer sizes) never change
+	ctx.rebuild()
+
+}
+
+// rebuild regenerates the graph quads from the frame time ring buffer
+// quad order is: background, one bar per frame (oldest to newest), 60 fps guide, 30 fps guide
+func (ctx *ContextGraph) rebuild() {
+
+	ctx.quads.Reset()
+
+	// translucent background
+	ctx.quads.DrawRectangleAt(graphLeft+graphWidth*0.5, graphBottom+graphHeight*0.5, graphWidth, graphHeight, 0, color.NRGBA{0, 0, 0, 160})
+
+	// one bar per frame, oldest frame on the left
+	barWidth := float32(graphWidth) / graphSamples
+	for i := 0; i < graphSamples; i++ {
+		ms := ctx.frameTimes[(ctx.frameNext+i)%graphSamples] * 1000
+		h := graphHeightFor(ms)
+		x := graphLeft + barWidth*(float32(i)+0.5)
+		ctx.quads.DrawRectangleAt(x, graphBottom+h*0.5, barWidth, h, 0, graphColorFor(ms))
+	}
+
+	// guide lines at 16.6ms (60 fps) and 33.3ms (30 fps)
+	ctx.quads.DrawRectangleAt(graphLeft+graphWidth*0.5, graphBottom+graphHeightFor(graphTargetMs60), graphWidth, graphGuideHeight, 0, color.NRGBA{255, 255, 255, 200})
+	ctx.quads.DrawRectangleAt(graphLeft+graphWidth*0.5, graphBottom+graphHeightFor(graphTargetMs30), graphWidth, graphGuideHeight, 0, color.NRGBA{255, 255, 255, 120})
+
+}
+
+// graphHeightFor converts a frame time (in milliseconds) into a bar height in NDC
+func graphHeightFor(ms float64) float32 {
+	if ms > graphMaxFrameMs {
+		ms = graphMaxFrameMs
+	}
+	return float32(ms/graphMaxFrameMs) * graphHeight
+}
+
+// graphColorFor picks the bar color, green = 60 fps, yellow = 30 fps, red = stutter
+func graphColorFor(ms float64) color.NRGBA {
+	switch {
+	case ms <= graphTargetMs60:
+		return color.NRGBA{0, 255, 0, 220}
+	case ms <= graphTargetMs30:
+		return color.NRGBA{255, 255, 0, 220}
+	default:
+		return color.NRGBA{255, 0, 0, 220}
+	}
+}
+
+func (ctx *ContextGraph) setupProgram() {
+
+	var err error
+
+	// configure program, load shaders, and link attributes
+	ctx.program, err = newProgram(vertexShaderGraph, fragmentShaderGraph)
+	if err != nil {
+		panic(err)
+	}
+	gpuResources.SetLabel(ResourceProgram, ctx.program, "graph program")
+	gl.UseProgram(ctx.program)
+
+	// get attribute index for later use
+	ctx.attribVertexPosition = uint32(gl.GetAttribLocation(ctx.program, gl.Str("vertexPosition\x00")))
+	ctx.attribVertexColor = uint32(gl.GetAttribLocation(ctx.program, gl.Str("vertexColor\x00")))
+
+	// debug print
+	fmt.Printf("attribVertexPosition: %v attribVertexColor: %v\n", ctx.attribVertexPosition, ctx.attribVertexColor)
+
+	// unbind program
+	gl.UseProgram(0)
+
+}
+
+// release GL objects owned by the graph overlay
+func (ctx *ContextGraph) destroy() {
+	gpuResources.Release(ResourceVertexArray, ctx.vao)
+	if ctx.vertexBuffers != nil {
+		ctx.vertexBuffers.destroy()
+		ctx.vertexBuffers = nil
+	}
+	gpuResources.Release(ResourceProgram, ctx.program)
+	ctx.vao, ctx.vbo, ctx.ibo, ctx.program = 0, 0, 0, 0
+}
+
+func (ctx *ContextGraph) setupBuffers() {
+
+	// use GRAPH program
+	gl.UseProgram(ctx.program)
+
+	// vertices position are in float32 and color is in uint8 (texture coordinates are not used)
+	ctx.layout = NewLayout().Float32("position", vertexPositionSize).UInt8("color", vertexColorSize, true)
+	ctx.quads.SetLayout(ctx.layout)
+
+	// create and bind VAO
+	ctx.vao = genVertexArray("graph vao")
+	gl.BindVertexArray(ctx.vao)
+
+	// create VBOs, vertex data is copied every frame during draw, into the next of several VBOs
+	ctx.vertexBuffers = NewDynamicBuffer("graph vbo", ctx.quads.BytesTotal) // buffers for vertex position and color
+	ctx.vbo = ctx.vertexBuffers.Current()
+	ctx.ibo = quadIndices.Buffer() // shared quad indices
+
+	// unbind GRAPH program
+	gl.UseProgram(0)
+
+}
+
+// draw the graph ontop of whatever is on the real screen
+func (ctx *ContextGraph) bind() {
+
+	// bind Graph program
+	gl.UseProgram(ctx.program)
+
+	// graph covers the whole window, also outside the letterboxed image (the viewport of the screen pass)
+
+	// graph is an overlay, ignore depth and blend with the screen underneath (premultiplied alpha, see ContextText.bind)
+	gl.Disable(gl.DEPTH_TEST)
+	gl.Enable(gl.BLEND)
+	gl.BlendFuncSeparate(gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA, gl.ONE, gl.ONE_MINUS_SRC_ALPHA)
+
+}
+
+func (ctx *ContextGraph) draw() {
+
+	// regenerate bars from latest frame times
+	ctx.rebuild()
+
+	// gl.Begin()
+	ctx.vbo = ctx.vertexBuffers.Next()              // bind vertex buffer, not the one drawn last frame
+	gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, ctx.ibo) // bind indices buffer
+
+	// copy latest vertex data to VBO
+	gl.BufferSubData(gl.ARRAY_BUFFER, ctx.quads.OffsetVertices, len(ctx.quads.QuadVertices)*bytesFloat32, gl.Ptr(ctx.quads.QuadVertices)) // copy vertices starting from 0 offest
+	gl.BufferSubData(gl.ARRAY_BUFFER, ctx.quads.OffsetColors, len(ctx.quads.QuadColors)*bytesUint8, gl.Ptr(ctx.quads.QuadColors))         // copy colors after vertices
+
+	// configure and enable vertex position and color
+	ctx.layout.Enable(ctx.attribVertexPosition, ctx.attribVertexColor)
+
+	// draw rectangles
+	quadIndices.Draw(0, ctx.quads.QuadCount(), nil)
+
+	// gl.End()
+	gl.BindBuffer(gl.ARRAY_BUFFER, 0)         // unbind vertex buffer
+	gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, 0) // unbind indices buffer
+
+	// disable vertex attributes
+	ctx.layout.Disable(ctx.attribVertexPosition, ctx.attribVertexColor)
+
+	// restore blending state
+	gl.Disable(gl.BLEND)
+
+}
@@ -0,0 +1,83 @@
+package multisample
+
+import (
+	"github.com/go-gl/gl/v3.2-core/gl"
+)
+
+const (
+	growableBufferMin = 256 // smallest capacity in bytes
+)
+
+// GrowableBuffer is a VBO or IBO with spare capacity. Storage is allocated
+// in powers of two, so a buffer that keeps growing is reallocated only
+// log2(n) times and the copying amortizes to a constant per byte added.
+//
+// Growing creates a new buffer object (the old one is released through the
+// deletion queue, draws in flight may still use it), so read ID again after
+// Reserve.
+type GrowableBuffer struct {
+	ID       uint32
+	target   uint32 // gl.ARRAY_BUFFER or gl.ELEMENT_ARRAY_BUFFER
+	usage    uint32 // e.g. gl.STATIC_DRAW
+	label    string
+	capacity int // allocated bytes
+}
+
+// NewGrowableBuffer prepares a buffer, storage is allocated by the first Reserve
+func NewGrowableBuffer(target, usage uint32, label string) *GrowableBuffer {
+	return &GrowableBuffer{target: target, usage: usage, label: label}
+}
+
+// Capacity is the allocated size in bytes
+func (b *GrowableBuffer) Capacity() int {
+	return b.capacity
+}
+
+// Reserve makes room for at least bytes and returns the buffer object.
+// When it has to grow, the first preserve bytes of the old content are
+// copied into the new buffer on the GPU (glCopyBufferSubData), the rest is
+// undefined until uploaded. Leaves no buffer bound to target.
+func (b *GrowableBuffer) Reserve(bytes, preserve int) uint32 {
+
+	if b.ID != 0 && bytes <= b.capacity {
+		return b.ID
+	}
+
+	capacity := nextPowerOfTwo(bytes)
+	if capacity < growableBufferMin {
+		capacity = growableBufferMin
+	}
+
+	id := genBuffer(b.label)
+	gl.BindBuffer(b.target, id)
+	gl.BufferData(b.target, capacity, nil, b.usage) // initalize but do not copy any data
+	gpuResources.SetBytes(ResourceBuffer, id, capacity)
+	gl.BindBuffer(b.target, 0)
+
+	if b.ID != 0 {
+		if preserve > b.capacity {
+			preserve = b.capacity
+		}
+		if preserve > 0 {
+			gl.BindBuffer(gl.COPY_READ_BUFFER, b.ID)
+			gl.BindBuffer(gl.COPY_WRITE_BUFFER, id)
+			gl.CopyBufferSubData(gl.COPY_READ_BUFFER, gl.COPY_WRITE_BUFFER, 0, 0, preserve)
+			gl.BindBuffer(gl.COPY_READ_BUFFER, 0)
+			gl.BindBuffer(gl.COPY_WRITE_BUFFER, 0)
+		}
+		gpuResources.Release(ResourceBuffer, b.ID)
+	}
+
+	b.ID, b.capacity = id, capacity
+	return id
+
+}
+
+// nextPowerOfTwo is the smallest power of two >= n (1 for n <= 1)
+func nextPowerOfTwo(n int) int {
+	p := 1
+	for p < n {
+		p <<= 1
+	}
+	return p
+}
@@ -0,0 +1,131 @@
+package multisample
+
+import (
+	"fmt"
+	"unsafe"
+
+	"github.com/go-gl/gl/v3.2-core/gl"
+)
+
+var (
+	quadDrawCalls = &DrawCallStats{}
+)
+
+// DrawElementsIndirectCommand is one draw of an indirect buffer, the layout
+// glDrawElementsIndirect reads (5 uint32, 20 bytes)
+type DrawElementsIndirectCommand struct {
+	Count         uint32 // indices
+	InstanceCount uint32
+	FirstIndex    uint32 // in indices, not bytes
+	BaseVertex    int32
+	BaseInstance  uint32 // must be 0 before OpenGL 4.2
+}
+
+// IndirectBuffer submits many draws of the same state with few calls: the
+// draw parameters are written into a GL_DRAW_INDIRECT_BUFFER and the GPU
+// reads them from there. With multi-draw-indirect (OpenGL 4.3 or
+// ARB_multi_draw_indirect) all commands are one call, with plain indirect
+// draws (OpenGL 4.0 or ARB_draw_indirect) one call each, which still saves
+// the driver validating every draw's parameters on the CPU.
+//
+// Commands are collected with Add and drawn by Submit, the buffer is
+// respecified on every Submit (orphaned), so draws in flight keep theirs.
+type IndirectBuffer struct {
+	buffer   uint32
+	multi    bool // glMultiDrawElementsIndirect is supported
+	commands []DrawElementsIndirectCommand
+	bytes    int // size of the buffer storage
+}
+
+// indirectSupported reports whether glDrawElementsIndirect can be used
+func indirectSupported() bool {
+	return glInfo.AtLeast(4, 0) || glInfo.Has("GL_ARB_draw_indirect")
+}
+
+// NewIndirectBuffer creates the buffer, requires a current GL context and indirectSupported
+func NewIndirectBuffer(label string) *IndirectBuffer {
+	return &IndirectBuffer{
+		buffer: genBuffer(label),
+		multi:  glInfo.AtLeast(4, 3) || glInfo.Has("GL_ARB_multi_draw_indirect"),
+	}
+}
+
+// Reset drops the commands of the last Submit
+func (b *IndirectBuffer) Reset() {
+	b.commands = b.commands[:0]
+}
+
+// Add queues a command
+func (b *IndirectBuffer) Add(command DrawElementsIndirectCommand) {
+	b.commands = append(b.commands, command)
+}
+
+// Submit draws the queued commands as triangles with 16 bit indices from the
+// bound VAO, VBO and IBO, then resets
+func (b *IndirectBuffer) Submit() {
+
+	if len(b.commands) == 0 {
+		return
+	}
+	validateDraw("quads")
+
+	const stride = int(unsafe.Sizeof(DrawElementsIndirectCommand{}))
+	bytes := len(b.commands) * stride
+	gl.BindBuffer(gl.DRAW_INDIRECT_BUFFER, b.buffer)
+	gl.BufferData(gl.DRAW_INDIRECT_BUFFER, bytes, gl.Ptr(&b.commands[0]), gl.STREAM_DRAW)
+	if bytes != b.bytes {
+		gpuResources.SetBytes(ResourceBuffer, b.buffer, bytes)
+		b.bytes = bytes
+	}
+
+	// the indirect pointer is an offset into the bound buffer
+	if b.multi {
+		gl.MultiDrawElementsIndirect(gl.TRIANGLES, gl.UNSIGNED_SHORT, nil, int32(len(b.commands)), 0)
+		quadDrawCalls.add(1, len(b.commands))
+	} else {
+		for i := range b.commands {
+			gl.DrawElementsIndirect(gl.TRIANGLES, gl.UNSIGNED_SHORT, gl.PtrOffset(i*stride))
+		}
+		quadDrawCalls.add(len(b.commands), len(b.commands))
+	}
+	gl.BindBuffer(gl.DRAW_INDIRECT_BUFFER, 0)
+
+	b.Reset()
+
+}
+
+// String is the path used, e.g. "multi-draw-indirect"
+func (b *IndirectBuffer) String() string {
+	if b.multi {
+		return "multi-draw-indirect"
+	}
+	return "draw-indirect"
+}
+
+func (b *IndirectBuffer) destroy() {
+	gpuResources.Release(ResourceBuffer, b.buffer)
+	b.buffer = 0
+}
+
+// DrawCallStats counts the draw calls of quads per frame and the draws they
+// submitted, the same without indirect draws (see -indirect)
+type DrawCallStats struct {
+	calls, draws         int // this frame so far
+	lastCalls, lastDraws int // the frame before
+}
+
+func (s *DrawCallStats) add(calls, draws int) {
+	s.calls += calls
+	s.draws += draws
+}
+
+// endFrame keeps the counts of the frame just drawn for String
+func (s *DrawCallStats) endFrame() {
+	s.lastCalls, s.lastDraws = s.calls, s.draws
+	s.calls, s.draws = 0, 0
+}
+
+// String is e.g. "3 calls for 4100 draws"
+func (s *DrawCallStats) String() string {
+	return fmt.Sprintf("%v calls for %v draws", s.lastCalls, s.lastDraws)
+}
@@ -0,0 +1,230 @@
+package multisample
+
+import (
+	"fmt"
+	"image/color"
+	"os"
+
+	"github.com/paperboard/glfw/v3.3/glfw"
+)
+
+const (
+	scrollZoomFactor = 1.1 // zoom change per scroll wheel step
+)
+
+var (
+	mouse = &MouseState{}
+)
+
+// MouseState tracks dragging between cursor callbacks
+type MouseState struct {
+	dragging bool    // left button is held down
+	x, y     float64 // last cursor position in window coordinates
+}
+
+// handleKey handles keyboard shortcuts
+//
+//	F1-F4  switch scene: msaa, triangle, quad, framebuffer (see SceneLauncher)
+//	Space  pause / resume updates
+//	.      advance one frame while paused
+//	[ ]    lower / raise the window opacity
+//	Ctrl+T keep the window above other windows, or not
+//	Ctrl+B show / hide the title bar and border
+//
+// and in the msaa scene
+//
+//	P      toggle perspective / orthographic projection
+//	S      toggle pixel snapping of the 2D camera
+//	R      reset model rotation
+//	O      toggle outline post effect
+//	C      toggle CRT post effect
+//	A      toggle ambient occlusion post effect
+//	Z      toggle depth buffer view (see DepthView)
+//	H      toggle overdraw heat map (see Overdraw)
+//	Shift+H print the overdraw of the next frame, while the heat map is shown
+//	Ctrl+H count hidden fragments too, or only those passing the depth test
+//	F      flash the damage palette of the indexed sprite
+//	-      lower the render scale (undersample)
+//	=      raise the render scale (supersample)
+//	D      dump color, depth and post-processing stages of the next frame to png files
+//	B      print the contents and layout of the vertex and index buffers
+//	N      add a small quad at a random position (buffers grow as needed)
+//	T      show / hide the stats page
+//	V      tint the terrain by shadow cascade (see -cascades)
+//	Ctrl+V load the image at the path in the clipboard (see DroppedImage)
+func handleKey(e KeyEvent) bool {
+
+	if e.Action != glfw.Press {
+		return false
+	}
+	window, key, mods := e.Window, e.Key, e.Mods
+
+	// keys of every scene
+	switch {
+	case key >= glfw.KeyF1 && key <= glfw.KeyF4:
+		launcher.switchTo(window, int(key-glfw.KeyF1))
+		return true
+	case key == glfw.KeySpace:
+		clock.TogglePause()
+		return true
+	case key == glfw.KeyPeriod:
+		clock.Step()
+		return true
+	case key == glfw.KeyLeftBracket:
+		windowAttributes.SetOpacity(window, windowAttributes.Opacity-opacityStep)
+		return true
+	case key == glfw.KeyRightBracket:
+		windowAttributes.SetOpacity(window, windowAttributes.Opacity+opacityStep)
+		return true
+	case key == glfw.KeyT && mods&glfw.ModControl != 0:
+		windowAttributes.SetFloating(window, !windowAttributes.Floating)
+		return true
+	case key == glfw.KeyB && mods&glfw.ModControl != 0:
+		windowAttributes.SetDecorated(window, !windowAttributes.Decorated)
+		return true
+	case launcher.Current() != msaaScene:
+		return false
+	}
+
+	switch key {
+	case glfw.KeyP:
+		ctxFramebufferMultisample.camera.ToggleProjection()
+	case glfw.KeyS:
+		camera2D := ctxFramebufferMultisample.camera2D
+		camera2D.SetPixelSnap(!camera2D.PixelSnap())
+	case glfw.KeyR:
+		ctxFramebufferMultisample.arcball.Reset()
+	case glfw.KeyO:
+		postProcessing.toggle("outline")
+	case glfw.KeyC:
+		postProcessing.toggle("crt")
+	case glfw.KeyA:
+		postProcessing.toggle("ssao")
+	case glfw.KeyZ:
+		postProcessing.toggle("depth")
+	case glfw.KeyH:
+		switch {
+		case mods&glfw.ModControl != 0:
+			overdraw.toggleDepthTest()
+		case mods&glfw.ModShift != 0:
+			overdraw.request()
+		default:
+			postProcessing.toggle("overdraw")
+		}
+	case glfw.KeyF:
+		ctxFramebufferMultisample.palette.Flash(clock.Now())
+	case glfw.KeyMinus:
+		setRenderScale(renderScale - renderScaleStep)
+	case glfw.KeyEqual:
+		setRenderScale(renderScale + renderScaleStep)
+	case glfw.KeyD:
+		frameDump.request()
+	case glfw.KeyB:
+		dumpBuffers(os.Stdout)
+	case glfw.KeyN:
+		addRandomQuad()
+	case glfw.KeyT:
+		stats.page = !stats.page
+	case glfw.KeyV:
+		if mods&glfw.ModControl != 0 {
+			dropped.paste(window)
+		} else if ctxTerrain.shadows != nil {
+			ctxTerrain.shadows.ToggleDebug()
+		}
+	default:
+		return false
+	}
+	return true
+
+}
+
+// subscribeInput subscribes the keyboard shortcuts, mouse navigation and
+// gamepad buttons of the scenes to the event bus, once at startup
+func subscribeInput() {
+	events.OnKey(handleKey)
+	events.OnMouseButton(handleMouseButton)
+	events.OnCursor(handleCursor)
+	events.OnScroll(handleScroll)
+	events.OnGamepad(handleGamepad)
+	events.OnSceneChange(func(SceneChangeEvent) { mouse.dragging = false })
+}
+
+// handleMouseButton starts and stops dragging with the left mouse button, the right
+// one probes the pixel under the cursor
+func handleMouseButton(e MouseButtonEvent) bool {
+	if launcher.Current() != msaaScene {
+		return false
+	}
+
+	// right click prints the pixel under the cursor (see PixelProbe)
+	if e.Button == glfw.MouseButtonRight && e.Action == glfw.Press {
+		pixelProbe.request(e.X, e.Y)
+		return true
+	}
+	if e.Button != glfw.MouseButtonLeft {
+		return false
+	}
+	mouse.dragging = e.Action == glfw.Press
+	mouse.x, mouse.y = e.X, e.Y
+
+	// in 3D dragging rotates the model
+	arcball := ctxFramebufferMultisample.arcball
+	switch {
+	case mouse.dragging && !ctxFramebufferMultisample.using2D:
+		arcball.Begin(mouse.x, mouse.y)
+	case !mouse.dragging:
+		arcball.End()
+	}
+	return true
+}
+
+// handleCursor pans the 2D camera or rotates the model while dragging
+func handleCursor(e CursorEvent) {
+	if mouse.dragging && launcher.Current() == msaaScene {
+		if ctxFramebufferMultisample.using2D {
+			ctxFramebufferMultisample.camera2D.Pan(e.X-mouse.x, e.Y-mouse.y)
+		} else {
+			ctxFramebufferMultisample.arcball.Drag(e.X, e.Y)
+		}
+	}
+	mouse.x, mouse.y = e.X, e.Y
+}
+
+// handleScroll zooms the 2D camera about the cursor (orthographic mode only)
+func handleScroll(e ScrollEvent) bool {
+	if launcher.Current() != msaaScene || !ctxFramebufferMultisample.using2D || e.OffsetY == 0 {
+		return false
+	}
+	factor := float32(scrollZoomFactor)
+	if e.OffsetY < 0 {
+		factor = 1 / factor
+	}
+	ctxFramebufferMultisample.camera2D.ZoomAt(factor, e.X, e.Y)
+	return true
+}
+
+// handleGamepad maps gamepad buttons to keys
+//
+//	Start  pause / resume updates, like Space
+//	A      add a small quad at a random position, like N (msaa scene)
+func handleGamepad(e GamepadEvent) bool {
+	switch {
+	case e.Kind == GamepadConnected:
+		fmt.Printf("gamepad %q connected\n", e.Joystick.GetGamepadName())
+	case e.Kind != GamepadPressed:
+	case e.Button == glfw.ButtonStart:
+		clock.TogglePause()
+		return true
+	case e.Button == glfw.ButtonA && launcher.Current() == msaaScene:
+		addRandomQuad()
+		return true
+	}
+	return false
+}
+
+// addRandomQuad adds a small quad at a random position, see the N key
+func addRandomQuad() {
+	x, y := inputRand.Float32()*2-1, inputRand.Float32()*2-1
+	clr := color.NRGBA{uint8(inputRand.Intn(0xff)), uint8(inputRand.Intn(0xff)), uint8(inputRand.Intn(0xff)), 1} // like RandomColorInRGBA
+	ctxFramebufferMultisample.AddQuad(x, y, 0.1, 0.1, -1.05, clr)
+}
@@ -0,0 +1,162 @@
+package multisample
+
+import (
+	"image/color"
+	"sort"
+
+	"github.com/go-gl/gl/v3.2-core/gl"
+)
+
+// Layer decides when a quad is drawn and with which depth/blend state.
+// Layers are drawn in order, whatever order their quads were submitted in.
+type Layer int
+
+const (
+	LayerBackground Layer = iota // drawn first, behind everything, does not write depth
+	LayerWorld                   // depth tested scene, the default
+	LayerUI                      // ontop the world, alpha blended
+	LayerDebug                   // ontop everything, alpha blended
+)
+
+// layerState is the depth and blend state of a layer
+type layerState struct {
+	name       string
+	depthTest  bool
+	depthWrite bool
+	blend      bool
+}
+
+var layerStates = [...]layerState{
+	LayerBackground: {name: "background", depthTest: false, depthWrite: false, blend: false},
+	LayerWorld:      {name: "world", depthTest: true, depthWrite: true, blend: false},
+	LayerUI:         {name: "ui", depthTest: false, depthWrite: false, blend: true},
+	LayerDebug:      {name: "debug", depthTest: false, depthWrite: false, blend: true},
+}
+
+func (l Layer) String() string {
+	return layerStates[l].name
+}
+
+// apply sets the layer's depth and blend state, or the additive state of the
+// overdraw counter while it draws (see Overdraw)
+func (l Layer) apply() {
+	state := layerStates[l]
+	if overdraw.counting {
+		overdraw.countState(state)
+		return
+	}
+	if state.depthTest {
+		gl.Enable(gl.DEPTH_TEST)
+	} else {
+		gl.Disable(gl.DEPTH_TEST)
+	}
+	gl.DepthMask(state.depthWrite)
+	if state.blend {
+		gl.Enable(gl.BLEND)
+		gl.BlendFunc(gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA)
+	} else {
+		gl.Disable(gl.BLEND)
+	}
+}
+
+// quadBatch is a range of quads drawn with one layer and effect
+type quadBatch struct {
+	layer  Layer
+	effect Effect
+	first  int // first quad
+	count  int // number of quads
+}
+
+// SetLayer moves a quad (by draw order) to another layer, call SortBatches afterwards
+func (q *ElementQuads) SetLayer(quad int, layer Layer) {
+	q.QuadLayers[quad] = layer
+}
+
+// SetOrder changes where a quad (by draw order) stacks within its layer:
+// quads of a lower order draw first whatever their effect, those of the same
+// order are batched by effect. Quads start at order 0, take higher ones from
+// NextOrder. Call SortBatches afterwards.
+func (q *ElementQuads) SetOrder(quad int, order int) {
+	q.QuadOrders[quad] = order
+}
+
+// NextOrder is an order above every order taken before, see SetOrder
+func (q *ElementQuads) NextOrder() int {
+	q.lastOrder++
+	return q.lastOrder
+}
+
+// SortBatches groups the quads by layer, then by order (see SetOrder), then
+// by effect, and returns one batch per group, so each group costs a single
+// state change and draw call.
+// Quads are drawn from the shared quad indices (see QuadIndexBuffer), in
+// vertex order, so their vertices are moved. The sort is stable, quads in the
+// same group keep their draw order, but quads with different effects within
+// a layer may now draw in another order (matters only for blended layers).
+// unchanged is the number of leading quads that kept their place, their
+// vertices need no upload.
+func (q *ElementQuads) SortBatches() (batches []quadBatch, unchanged int) {
+
+	// sort quad numbers, then move vertex data, layers and effects together
+	order := make([]int, len(q.QuadEffects))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		a, b := order[i], order[j]
+		if q.QuadLayers[a] != q.QuadLayers[b] {
+			return q.QuadLayers[a] < q.QuadLayers[b]
+		}
+		if q.QuadOrders[a] != q.QuadOrders[b] {
+			return q.QuadOrders[a] < q.QuadOrders[b]
+		}
+		return q.QuadEffects[a] < q.QuadEffects[b]
+	})
+	for unchanged < len(order) && order[unchanged] == unchanged {
+		unchanged++
+	}
+	if unchanged < len(order) {
+		vertices := make([]float32, 0, len(q.QuadVertices))
+		texCoords := make([]uint8, 0, len(q.QuadTexCoords))
+		colors := make([]uint8, 0, len(q.QuadColors))
+		layers := make([]Layer, 0, len(q.QuadLayers))
+		orders := make([]int, 0, len(q.QuadOrders))
+		effects := make([]Effect, 0, len(q.QuadEffects))
+		tints := make([]color.NRGBA, 0, len(q.QuadTints))
+		randomTints := make([]bool, 0, len(q.QuadRandomTints))
+		shapes := make([]QuadShape, 0, len(q.QuadShapes))
+		for _, quad := range order {
+			vertices = append(vertices, q.QuadVertices[quad*verticesPerQuad*vertexPositionSize:(quad+1)*verticesPerQuad*vertexPositionSize]...)
+			texCoords = append(texCoords, q.QuadTexCoords[quad*verticesPerQuad*vertexTexCoordSize:(quad+1)*verticesPerQuad*vertexTexCoordSize]...)
+			colors = append(colors, q.QuadColors[quad*verticesPerQuad*vertexColorSize:(quad+1)*verticesPerQuad*vertexColorSize]...)
+			layers = append(layers, q.QuadLayers[quad])
+			orders = append(orders, q.QuadOrders[quad])
+			effects = append(effects, q.QuadEffects[quad])
+			tints = append(tints, q.QuadTints[quad])
+			randomTints = append(randomTints, q.QuadRandomTints[quad])
+			shapes = append(shapes, q.QuadShapes[quad])
+		}
+		q.QuadVertices = vertices
+		q.QuadTexCoords = texCoords
+		q.QuadColors = colors
+		q.QuadLayers = layers
+		q.QuadOrders = orders
+		q.QuadEffects = effects
+		q.QuadTints = tints
+		q.QuadRandomTints = randomTints
+		q.markTints(unchanged, len(tints))
+		q.QuadShapes = shapes
+		q.shapesChanged = true
+	}
+
+	// one batch per run of equal layer and effect
+	for quad, effect := range q.QuadEffects {
+		layer := q.QuadLayers[quad]
+		if len(batches) == 0 || batches[len(batches)-1].layer != layer || batches[len(batches)-1].effect != effect {
+			batches = append(batches, quadBatch{layer: layer, effect: effect, first: quad})
+		}
+		batches[len(batches)-1].count++
+	}
+	return batches, unchanged
+
+}
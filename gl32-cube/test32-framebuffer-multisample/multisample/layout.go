@@ -0,0 +1,213 @@
+package multisample
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/go-gl/gl/v3.2-core/gl"
+)
+
+// VertexAttrib is one vertex attribute of a Layout
+type VertexAttrib struct {
+	Name       string
+	Type       uint32 // component type, gl.FLOAT or gl.UNSIGNED_BYTE
+	Size       int32  // components per vertex, e.g. 3 for x,y,z
+	Normalized bool   // integer components are mapped to 0..1 (e.g. colors)
+	Offset     int    // byte offset of the attribute's block in the VBO, or within a vertex when interleaved
+	bytes      int    // bytes per component
+}
+
+// Layout describes how vertex attributes are stored in a VBO, and does the
+// offset arithmetic and attribute pointer calls every context needs:
+//
+//	layout := NewLayout().Float32("position", 3).UInt8("texcoord", 2, false).UInt8("color", 4, true)
+//	layout.Compute(vertices)          // compute Offset of every attribute and BytesTotal
+//	layout.Enable(position, uv, rgba) // with the VBO bound, before drawing
+//	layout.Disable(position, uv, rgba)
+//
+// By default attributes are stored one block after the other (planar), in
+// the order they were declared. Interleave stores them vertex by vertex
+// instead (pos+uv+color, pos+uv+color, ...).
+//
+// Which is faster depends on how the buffer is updated. Interleaved keeps
+// the attributes of a vertex together, which suits the GPU's vertex fetch
+// for static geometry. Planar keeps each attribute contiguous, so updating
+// one attribute (e.g. the colors ContextFramebufferMultisample changes every
+// frame) is a single BufferSubData of just that block; interleaved has to
+// re-upload every vertex. Run with -benchlayout to measure both on a GPU.
+type Layout struct {
+	Attribs     []VertexAttrib
+	BytesTotal  int   // total bytes required for VBO buffer
+	Interleaved bool  // attributes stored per vertex, see Interleave
+	Stride      int32 // bytes per vertex when interleaved, 0 when planar (tightly packed blocks)
+}
+
+// NewLayout starts an empty layout, add attributes with Float32 and UInt8
+func NewLayout() *Layout {
+	return &Layout{}
+}
+
+// Float32 adds an attribute of size float32 components
+func (l *Layout) Float32(name string, size int32) *Layout {
+	l.Attribs = append(l.Attribs, VertexAttrib{Name: name, Type: gl.FLOAT, Size: size, bytes: bytesFloat32})
+	return l
+}
+
+// UInt8 adds an attribute of size uint8 components, normalized maps 0..255 to 0..1 in the shader
+func (l *Layout) UInt8(name string, size int32, normalized bool) *Layout {
+	l.Attribs = append(l.Attribs, VertexAttrib{Name: name, Type: gl.UNSIGNED_BYTE, Size: size, Normalized: normalized, bytes: bytesUint8})
+	return l
+}
+
+// Interleave stores the attributes vertex by vertex instead of in blocks
+func (l *Layout) Interleave() *Layout {
+	l.Interleaved = true
+	return l
+}
+
+// Compute computes the attribute offsets, Stride and BytesTotal for a number of vertices
+func (l *Layout) Compute(vertices int) *Layout {
+
+	if !l.Interleaved {
+		offset := 0
+		for i := range l.Attribs {
+			l.Attribs[i].Offset = offset
+			offset += vertices * l.Attribs[i].vertexBytes()
+		}
+		l.Stride = 0
+		l.BytesTotal = offset
+		return l
+	}
+
+	// attributes start on 4 byte boundaries, unaligned attributes are slow or unsupported on some GPUs
+	offset := 0
+	for i := range l.Attribs {
+		l.Attribs[i].Offset = offset
+		offset = align(offset+l.Attribs[i].vertexBytes(), 4)
+	}
+	l.Stride = int32(offset)
+	l.BytesTotal = vertices * offset
+	return l
+
+}
+
+// VertexOffset is the byte offset of an attribute of vertex v
+func (l *Layout) VertexOffset(name string, v int) int {
+	for _, attrib := range l.Attribs {
+		if attrib.Name != name {
+			continue
+		}
+		if l.Interleaved {
+			return v*int(l.Stride) + attrib.Offset
+		}
+		return attrib.Offset + v*attrib.vertexBytes()
+	}
+	panic(fmt.Sprintf("LAYOUT: no vertex attribute %q", name))
+}
+
+// Pack lays out per attribute data (tightly packed, as stored in ElementQuads or Mesh)
+// as the VBO expects it, attributes without data are left zero
+func (l *Layout) Pack(vertices int, data map[string][]byte) []byte {
+	buffer := make([]byte, l.BytesTotal)
+	for _, attrib := range l.Attribs {
+		src := data[attrib.Name]
+		size := attrib.vertexBytes()
+		if !l.Interleaved {
+			copy(buffer[attrib.Offset:attrib.Offset+vertices*size], src)
+			continue
+		}
+		for v := 0; v < vertices && (v+1)*size <= len(src); v++ {
+			at := v*int(l.Stride) + attrib.Offset
+			copy(buffer[at:at+size], src[v*size:(v+1)*size])
+		}
+	}
+	return buffer
+}
+
+// Has reports whether the layout has an attribute
+func (l *Layout) Has(name string) bool {
+	for _, attrib := range l.Attribs {
+		if attrib.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Offset is the byte offset of an attribute's block
+func (l *Layout) Offset(name string) int {
+	for _, attrib := range l.Attribs {
+		if attrib.Name == name {
+			return attrib.Offset
+		}
+	}
+	panic(fmt.Sprintf("LAYOUT: no vertex attribute %q", name))
+}
+
+// Enable enables the attribute arrays and points them into the bound VBO,
+// locations are the shader attribute locations in declaration order
+func (l *Layout) Enable(locations ...uint32) {
+	l.EnableAt(0, locations...)
+}
+
+// EnableAt is Enable for vertex data starting at a byte offset of the VBO, e.g. a BufferArena block
+func (l *Layout) EnableAt(base int, locations ...uint32) {
+	l.EnableFrom(base, 0, locations...)
+}
+
+// EnableFrom is EnableAt with vertex as the first vertex, vertex 0 for the
+// GPU (see QuadIndexBuffer.Draw, without base vertex support)
+func (l *Layout) EnableFrom(base int, vertex int, locations ...uint32) {
+	l.checkLocations(locations)
+	for i, location := range locations {
+		attrib := l.Attribs[i]
+		gl.EnableVertexAttribArray(location)
+		gl.VertexAttribPointer(location, attrib.Size, attrib.Type, attrib.Normalized, l.Stride, gl.PtrOffset(base+l.VertexOffset(attrib.Name, vertex)))
+	}
+}
+
+// EnableAttrib enables a single attribute array by name, for programs reading
+// only some of the attributes, e.g. a depth pass needing positions only.
+// Disable it with gl.DisableVertexAttribArray.
+func (l *Layout) EnableAttrib(name string, location uint32) {
+	for _, attrib := range l.Attribs {
+		if attrib.Name == name {
+			gl.EnableVertexAttribArray(location)
+			gl.VertexAttribPointer(location, attrib.Size, attrib.Type, attrib.Normalized, l.Stride, gl.PtrOffset(l.VertexOffset(name, 0)))
+			return
+		}
+	}
+	panic(fmt.Sprintf("LAYOUT: no vertex attribute %q", name))
+}
+
+// Disable disables the attribute arrays enabled by Enable
+func (l *Layout) Disable(locations ...uint32) {
+	l.checkLocations(locations)
+	for _, location := range locations {
+		gl.DisableVertexAttribArray(location)
+	}
+}
+
+// vertexBytes is the size of the attribute for one vertex
+func (a VertexAttrib) vertexBytes() int {
+	return int(a.Size) * a.bytes
+}
+
+// float32Bytes converts float32 values to their little endian bytes, as uploaded by BufferData
+func float32Bytes(values []float32) []byte {
+	data := make([]byte, len(values)*bytesFloat32)
+	for i, value := range values {
+		bits := math.Float32bits(value)
+		data[i*4+0] = byte(bits)
+		data[i*4+1] = byte(bits >> 8)
+		data[i*4+2] = byte(bits >> 16)
+		data[i*4+3] = byte(bits >> 24)
+	}
+	return data
+}
+
+func (l *Layout) checkLocations(locations []uint32) {
+	if len(locations) != len(l.Attribs) {
+		panic(fmt.Sprintf("LAYOUT: %v attribute locations for %v attributes", len(locations), len(l.Attribs)))
+	}
+}
@@ -0,0 +1,67 @@
+package multisample
+
+import (
+	"fmt"
+	"image/color"
+	"math/rand"
+
+	"github.com/go-gl/gl/v3.2-core/gl"
+	"github.com/paperboard/glfw/v3.3/glfw"
+)
+
+const (
+	benchLayoutQuads  = 10000 // quads per layout, 40000 vertices (uint16 indices allow at most 16383 quads)
+	benchLayoutFrames = 300   // color updates timed per layout
+)
+
+// benchmarkLayouts times the per frame color update of ContextFramebufferMultisample
+// (UploadColors) with a planar and an interleaved layout, see Layout. Each update waits
+// for the GPU (glFinish), so the time includes the transfer, not just the call.
+func benchmarkLayouts() {
+
+	quads := &ElementQuads{}
+	for i := 0; i < benchLayoutQuads; i++ {
+		quads.DrawRectangleAt(rand.Float32()*2-1, rand.Float32()*2-1, 0.01, 0.01, -1, color.NRGBA{255, 255, 255, 255})
+	}
+
+	layouts := []*Layout{
+		NewLayout().Float32("position", vertexPositionSize).UInt8("texcoord", vertexTexCoordSize, false).UInt8("color", vertexColorSize, true),
+		NewLayout().Float32("position", vertexPositionSize).UInt8("texcoord", vertexTexCoordSize, false).UInt8("color", vertexColorSize, true).Interleave(),
+	}
+
+	fmt.Printf("BENCH_LAYOUT -- %v quads, %v color updates each\n", benchLayoutQuads, benchLayoutFrames)
+	for _, layout := range layouts {
+
+		quads.SetLayout(layout)
+		vbo := genBuffer("layout benchmark vbo")
+		gl.BindBuffer(gl.ARRAY_BUFFER, vbo)
+		gl.BufferData(gl.ARRAY_BUFFER, quads.BytesTotal, nil, gl.DYNAMIC_DRAW)
+		gpuResources.SetBytes(ResourceBuffer, vbo, quads.BytesTotal)
+		quads.Upload(layout)
+		gl.Finish()
+
+		// new colors every update, generated outside the timed part
+		var elapsed float64
+		for frame := 0; frame < benchLayoutFrames; frame++ {
+			for i := range quads.QuadColors {
+				quads.QuadColors[i] = uint8(rand.Intn(0x100))
+			}
+			start := glfw.GetTime()
+			quads.UploadColors(layout)
+			gl.Finish()
+			elapsed += glfw.GetTime() - start
+		}
+
+		uploaded := len(quads.QuadColors)
+		name := "planar"
+		if layout.Interleaved {
+			uploaded = quads.BytesTotal
+			name = "interleaved"
+		}
+		fmt.Printf("  %-11v  %6.3f ms per update  %v uploaded per update\n", name, elapsed/benchLayoutFrames*1000, formatBytes(uploaded))
+
+		gl.BindBuffer(gl.ARRAY_BUFFER, 0)
+		gpuResources.Release(ResourceBuffer, vbo)
+	}
+
+}
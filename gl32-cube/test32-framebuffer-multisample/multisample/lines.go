@@ -0,0 +1,115 @@
+package multisample
+
+import (
+	"image/color"
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// LineCap is the shape of the ends of a line and of each of its dashes
+type LineCap int32
+
+const (
+	LineCapButt   LineCap = iota // ends exactly at the end points
+	LineCapRound                 // half circle past the end points
+	LineCapSquare                // half the width past the end points
+)
+
+// LineStyle is how DrawLine and DrawPolyline draw, widths and lengths are in
+// the units of the quad vertices
+type LineStyle struct {
+	Width float32
+	Cap   LineCap
+	Dash  float32 // length of the dashes, 0 = solid
+	Gap   float32 // length of the gaps between dashes
+
+	// RoundJoins rounds the corners of DrawPolyline where the cap doesn't
+	// already (LineCapRound overlaps into round joins by itself)
+	RoundJoins bool
+}
+
+// DrawLine adds a line from x0, y0 to x1, y1. It is a single quad along the
+// line, cut to its caps and dashes by the fragment shader (EffectLine) from a
+// distance field like DrawRoundedRect, the edges are anti-aliased by their
+// screen size without MSAA.
+//
+// The quad goes on LayerUI, blended over the world, change it with SetLayer.
+func (q *ElementQuads) DrawLine(x0, y0, x1, y1, z float32, clr color.NRGBA, style LineStyle) {
+	q.drawLine(mgl32.Vec2{x0, y0}, mgl32.Vec2{x1, y1}, z, clr, style, 0)
+}
+
+// DrawPolyline adds a line through points, one quad per segment. Dashes run
+// on across the corners, they don't restart at every point.
+//
+// Segments overlap at the corners, a translucent color shows the overlap.
+func (q *ElementQuads) DrawPolyline(points []mgl32.Vec2, z float32, clr color.NRGBA, style LineStyle) {
+	phase := float32(0)
+	for i := 1; i < len(points); i++ {
+		q.drawLine(points[i-1], points[i], z, clr, style, phase)
+		phase += points[i].Sub(points[i-1]).Len()
+	}
+	if style.RoundJoins && style.Cap != LineCapRound {
+		dot := LineStyle{Width: style.Width, Cap: LineCapRound}
+		for i := 1; i < len(points)-1; i++ {
+			q.drawLine(points[i], points[i], z, clr, dot, 0)
+		}
+	}
+}
+
+// drawLine adds the quad of a line, dashes start phase units into the pattern
+func (q *ElementQuads) drawLine(from, to mgl32.Vec2, z float32, clr color.NRGBA, style LineStyle, phase float32) {
+
+	// direction along and across the line, to the right for a point
+	along := to.Sub(from)
+	length := along.Len()
+	if length > 0 {
+		along = along.Mul(1 / length)
+	} else {
+		along = mgl32.Vec2{1, 0}
+	}
+	across := mgl32.Vec2{-along.Y(), along.X()}
+
+	// butt caps end at the end points, the others reach half the width past them
+	extend := float32(0)
+	if style.Cap != LineCapButt {
+		extend = style.Width * 0.5
+	}
+	halfLength, halfWidth := length*0.5+extend, style.Width*0.5
+
+	// corners in the order of makeQuadVertices, right is along the line, top across
+	center := from.Add(to).Mul(0.5)
+	right, top := along.Mul(halfLength), across.Mul(halfWidth)
+	q.addQuad([verticesPerQuad]mgl32.Vec2{
+		center.Add(right).Add(top),
+		center.Sub(right).Add(top),
+		center.Sub(right).Sub(top),
+		center.Add(right).Sub(top),
+	}, z, clr, EffectLine, LayerUI)
+
+	shape := QuadShape{Width: halfLength * 2, Height: style.Width, Cap: style.Cap}
+	if style.Cap == LineCapRound {
+		shape.Radius = halfWidth
+	}
+	if style.Dash > 0 {
+		shape.Dash, shape.Gap = style.Dash, style.Gap
+		shape.Phase = float32(math.Mod(float64(phase), float64(style.Dash+style.Gap)))
+	}
+	q.SetShape(q.QuadCount()-1, shape)
+}
+
+// addQuad adds a quad with any corners, in the order of makeQuadVertices
+// (top-right, top-left, bottom-left, bottom-right of the texture)
+func (q *ElementQuads) addQuad(corners [verticesPerQuad]mgl32.Vec2, z float32, clr color.NRGBA, effect Effect, layer Layer) {
+	z = q.zFor(z)
+	for _, corner := range corners {
+		q.QuadVertices = append(q.QuadVertices, corner.X(), corner.Y(), z)
+	}
+	q.QuadTexCoords = append(q.QuadTexCoords, makeQuadTextureCoord()...)
+	q.QuadColors = append(q.QuadColors, makeQuadColors(clr)...)
+	q.QuadEffects = append(q.QuadEffects, effect)
+	q.QuadLayers = append(q.QuadLayers, layer)
+	q.QuadOrders = append(q.QuadOrders, 0)
+	q.addTint()
+	q.addShape()
+}
@@ -0,0 +1,127 @@
+package multisample
+
+import (
+	"sort"
+
+	"github.com/go-gl/gl/v3.2-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// MeshLOD is a coarser level of detail of a Mesh. It reuses the mesh
+// vertices, only the triangles are fewer, so all levels share one VBO.
+type MeshLOD struct {
+	Indices  []uint16 // 3 per triangle
+	Distance float32  // camera distance (world units) from which this level is used
+}
+
+// AddLOD adds a given level of detail, levels are kept sorted by distance
+func (m *Mesh) AddLOD(indices []uint16, distance float32) {
+	m.LODs = append(m.LODs, MeshLOD{Indices: indices, Distance: distance})
+	sort.SliceStable(m.LODs, func(i, j int) bool { return m.LODs[i].Distance < m.LODs[j].Distance })
+}
+
+// GenerateLODs adds levels of detail made by vertex clustering: the bounding
+// box is split into cells, the vertices of a cell collapse into one of them
+// and triangles that lost their area are dropped. Every level halves the
+// cells per axis (starting at cells) and doubles the distance (starting at
+// distance). Good enough for terrain seen from afar, not for close-ups.
+func (m *Mesh) GenerateLODs(levels int, cells int, distance float32) {
+	triangles := m.triangles()
+	for level := 0; level < levels && cells >= 1; level++ {
+		m.AddLOD(m.decimate(triangles, cells), distance)
+		cells /= 2
+		distance *= 2
+	}
+}
+
+// SelectLOD picks the level for a camera distance, 0 is the full mesh (Indices)
+// and i is LODs[i-1]
+func (m *Mesh) SelectLOD(distance float32) int {
+	level := 0
+	for i, lod := range m.LODs {
+		if distance >= lod.Distance {
+			level = i + 1
+		}
+	}
+	return level
+}
+
+// LODIndices are the indices and primitive type of a level, see SelectLOD
+func (m *Mesh) LODIndices(level int) ([]uint16, uint32) {
+	if level == 0 {
+		return m.Indices, m.Mode
+	}
+	return m.LODs[level-1].Indices, gl.TRIANGLES
+}
+
+// triangles returns Indices as 3 indices per triangle, strips are unrolled
+// (in the winding of their first triangle) and degenerate triangles dropped
+func (m *Mesh) triangles() []uint16 {
+
+	if m.Mode != gl.TRIANGLE_STRIP {
+		return m.Indices
+	}
+
+	var triangles []uint16
+	start := 0
+	for i := 0; i+2 < len(m.Indices); i++ {
+		a, b, c := m.Indices[i], m.Indices[i+1], m.Indices[i+2]
+		if a == primitiveRestartIndex {
+			start = i + 1
+			continue
+		}
+		if b == primitiveRestartIndex || c == primitiveRestartIndex || a == b || b == c || a == c {
+			continue
+		}
+		if (i-start)%2 == 1 {
+			a, b = b, a
+		}
+		triangles = append(triangles, a, b, c)
+	}
+	return triangles
+
+}
+
+// decimate collapses the vertices of each of cells x cells x cells bounding
+// box cells into the first vertex found in it
+func (m *Mesh) decimate(triangles []uint16, cells int) []uint16 {
+
+	min, max := m.Bounds()
+	size := max.Sub(min)
+	cellOf := func(v uint16) [3]int {
+		var cell [3]int
+		for axis := 0; axis < 3; axis++ {
+			if size[axis] > 0 {
+				t := (m.Positions[int(v)*vertexPositionSize+axis] - min[axis]) / size[axis]
+				cell[axis] = clampInt(int(t*float32(cells)), 0, cells-1)
+			}
+		}
+		return cell
+	}
+
+	representative := map[[3]int]uint16{}
+	collapse := func(v uint16) uint16 {
+		cell := cellOf(v)
+		if r, ok := representative[cell]; ok {
+			return r
+		}
+		representative[cell] = v
+		return v
+	}
+
+	var indices []uint16
+	for i := 0; i+2 < len(triangles); i += 3 {
+		a, b, c := collapse(triangles[i]), collapse(triangles[i+1]), collapse(triangles[i+2])
+		if a == b || b == c || a == c {
+			continue
+		}
+		indices = append(indices, a, b, c)
+	}
+	return indices
+
+}
+
+// lodDistance is the distance from a camera position to a mesh center, scaled by -lodbias
+func lodDistance(position, center mgl32.Vec3) float32 {
+	return position.Sub(center).Len() * float32(*lodBias)
+}
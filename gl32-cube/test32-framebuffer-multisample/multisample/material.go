@@ -0,0 +1,313 @@
+package multisample
+
+import (
+	"fmt"
+
+	"github.com/go-gl/gl/v3.2-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+var (
+	textureUnits = &TextureUnits{}
+)
+
+// TextureUnits hands out texture units, so several textures can be bound at
+// once without two owners silently sharing the same unit. Units are handed out
+// like a stack: whatever is bound last must be released first.
+type TextureUnits struct {
+	max  uint32 // GL_MAX_COMBINED_TEXTURE_IMAGE_UNITS
+	next uint32 // first free unit
+}
+
+// setup queries the number of texture units, requires a current GL context
+func (u *TextureUnits) setup() {
+	u.max = uint32(glInfo.MaxTextureUnits)
+	u.next = 0
+}
+
+// allocate reserves n consecutive units and returns the first
+func (u *TextureUnits) allocate(n int) uint32 {
+	if u.next+uint32(n) > u.max {
+		panic(fmt.Sprintf("TEXTURE_UNITS: need %v units, only %v of %v are free", n, u.max-u.next, u.max))
+	}
+	first := u.next
+	u.next += uint32(n)
+	return first
+}
+
+// release frees the last n allocated units
+func (u *TextureUnits) release(n int) {
+	u.next -= uint32(n)
+}
+
+// CullMode selects which faces are not drawn, see Material.SetCulling
+type CullMode int
+
+const (
+	CullNone  CullMode = iota // draw both sides (quads, the default)
+	CullBack                  // skip faces pointing away from the camera (closed meshes like the cube)
+	CullFront                 // skip faces pointing to the camera (e.g. inside of a skybox)
+)
+
+// FogMode is how fog thickens with the view distance, see Material.SetFog
+type FogMode int32
+
+const (
+	FogNone   FogMode = iota // no fog (the default)
+	FogLinear                // none at Start, full at End
+	FogExp                   // 1 - e^(-density * distance), never quite full
+	FogExp2                  // 1 - e^(-(density * distance)^2), clear close up, then quickly thick
+)
+
+// Fog blends fragments into a color by their distance from the eye along
+// the view direction (view-space depth), so far objects fade out
+type Fog struct {
+	Mode       FogMode
+	Color      mgl32.Vec3 // RGB, usually the clear color so far objects vanish
+	Start, End float32    // FogLinear range in world units
+	Density    float32    // FogExp and FogExp2 thickness per world unit
+}
+
+// parseFogMode reads the -fog flag
+func parseFogMode(name string) (FogMode, error) {
+	switch name {
+	case "", "off":
+		return FogNone, nil
+	case "linear":
+		return FogLinear, nil
+	case "exp":
+		return FogExp, nil
+	case "exp2":
+		return FogExp2, nil
+	}
+	return FogNone, fmt.Errorf("unknown fog mode %q, use off, linear, exp or exp2", name)
+}
+
+// materialTexture is a texture bound to a sampler uniform
+type materialTexture struct {
+	sampler  string // sampler uniform name, e.g. "diffuseTexture"
+	location int32  // sampler uniform location, -1 if unused by the program
+	target   uint32 // e.g. gl.TEXTURE_2D or gl.TEXTURE_2D_MULTISAMPLE
+	texture  uint32
+	pass     string // output of this pass replaces texture when bound, see SetPassTexture
+}
+
+// Material is the set of textures a program samples during a draw call, e.g.
+// a diffuse texture and a mask. Bind assigns each texture its own texture unit
+// and points the sampler uniform at it. Every sampler of the program (see
+// ProgramInfo) gets a unit, also those no texture was set for: they sample
+// black instead of whatever is bound to unit 0, which fails the draw when
+// it is of another type.
+type Material struct {
+	program  uint32
+	info     *ProgramInfo
+	textures []materialTexture
+	unit     uint32 // first unit used while bound
+
+	// cutout rendering, see SetAlphaTest
+	alphaCutoff            float32
+	alphaToCoverage        bool
+	uniformAlphaCutoff     int32 // uAlphaCutoff, -1 if unused by the program
+	uniformAlphaToCoverage int32 // uAlphaToCoverage, -1 if unused by the program
+
+	// decals, see SetPolygonOffset
+	polygonOffset             bool
+	offsetFactor, offsetUnits float32
+
+	// face culling, see SetCulling
+	cull      CullMode
+	frontFace uint32 // winding of front faces, gl.CCW or gl.CW
+
+	// distance fog, see SetFog
+	fog               Fog
+	uniformFogMode    int32 // uFogMode, -1 if unused by the program
+	uniformFogColor   int32 // uFogColor
+	uniformFogRange   int32 // uFogRange, start and end
+	uniformFogDensity int32 // uFogDensity
+}
+
+// NewMaterial creates an empty material for program
+func NewMaterial(program uint32) *Material {
+	info := programInfos.Get(program)
+	m := &Material{
+		program:                program,
+		info:                   info,
+		uniformAlphaCutoff:     info.UniformLocation("uAlphaCutoff"),
+		uniformAlphaToCoverage: info.UniformLocation("uAlphaToCoverage"),
+		uniformFogMode:         info.UniformLocation("uFogMode"),
+		uniformFogColor:        info.UniformLocation("uFogColor"),
+		uniformFogRange:        info.UniformLocation("uFogRange"),
+		uniformFogDensity:      info.UniformLocation("uFogDensity"),
+		frontFace:              gl.CCW,
+	}
+	for _, sampler := range info.Samplers() {
+		if sampler.Size == 1 { // sampler arrays are bound by their owner
+			m.textures = append(m.textures, materialTexture{sampler: sampler.Name, location: sampler.Location, target: sampler.Target()})
+		}
+	}
+	return m
+}
+
+// SetAlphaTest turns the material into a cutout (foliage, fences): fragments
+// with alpha below cutoff are discarded, the rest is opaque. Hard edges need no
+// blending, so cutouts can be drawn in any order with depth testing.
+//
+// With alphaToCoverage (needs a multisampled framebuffer) alpha decides how many
+// MSAA samples are covered instead, the shader sharpens alpha around the cutoff
+// so the edges are anti-aliased rather than blurry. A cutoff of 0 disables the test.
+func (m *Material) SetAlphaTest(cutoff float32, alphaToCoverage bool) {
+	m.alphaCutoff = cutoff
+	m.alphaToCoverage = alphaToCoverage
+}
+
+// SetTexture binds texture to the sampler uniform named sampler, replacing
+// any texture set before. A target of 0 is the one of the sampler's type.
+func (m *Material) SetTexture(sampler string, target uint32, texture uint32) {
+	if target == 0 {
+		target = gl.TEXTURE_2D
+		if v, ok := m.info.Uniform(sampler); ok && v.IsSampler() {
+			target = v.Target()
+		}
+	}
+	for i := range m.textures {
+		if m.textures[i].sampler == sampler {
+			m.textures[i].target = target
+			m.textures[i].texture = texture
+			m.textures[i].pass = ""
+			return
+		}
+	}
+	m.textures = append(m.textures, materialTexture{
+		sampler:  sampler,
+		location: m.info.UniformLocation(sampler),
+		target:   target,
+		texture:  texture,
+	})
+}
+
+// SetPassTexture binds the color output of a pass (see PassOutputs) to the
+// sampler uniform named sampler. The output is looked up on every Bind, it
+// follows the pass when its texture is recreated; texture 0 while it has none.
+func (m *Material) SetPassTexture(sampler string, pass string) {
+	m.SetTexture(sampler, gl.TEXTURE_2D, 0)
+	for i := range m.textures {
+		if m.textures[i].sampler == sampler {
+			m.textures[i].pass = pass
+		}
+	}
+}
+
+// Bind binds every texture to its own texture unit and sets the samplers, the material's program must be in use
+func (m *Material) Bind() {
+	m.unit = textureUnits.allocate(len(m.textures))
+	for i, t := range m.textures {
+		unit := m.unit + uint32(i)
+		texture := t.texture
+		if t.pass != "" {
+			if output := passOutputs.Texture(t.pass); output != nil {
+				texture = output.ID
+			}
+		}
+		gl.ActiveTexture(gl.TEXTURE0 + unit)
+		gl.BindTexture(t.target, texture)
+		if t.location != -1 {
+			gl.Uniform1i(t.location, int32(unit))
+		}
+	}
+	gl.ActiveTexture(gl.TEXTURE0)
+
+	// cutout state
+	if m.uniformAlphaCutoff != -1 {
+		gl.Uniform1f(m.uniformAlphaCutoff, m.alphaCutoff)
+	}
+	if m.uniformAlphaToCoverage != -1 {
+		gl.Uniform1i(m.uniformAlphaToCoverage, boolToInt32(m.alphaToCoverage))
+	}
+	if m.alphaToCoverage {
+		gl.Enable(gl.SAMPLE_ALPHA_TO_COVERAGE)
+	}
+
+	// fog, computed by the fragment shader
+	if m.uniformFogMode != -1 {
+		gl.Uniform1i(m.uniformFogMode, int32(m.fog.Mode))
+		gl.Uniform3fv(m.uniformFogColor, 1, &m.fog.Color[0])
+		gl.Uniform2f(m.uniformFogRange, m.fog.Start, m.fog.End)
+		gl.Uniform1f(m.uniformFogDensity, m.fog.Density)
+	}
+
+	// decal depth offset
+	if m.polygonOffset {
+		gl.Enable(gl.POLYGON_OFFSET_FILL)
+		gl.PolygonOffset(m.offsetFactor, m.offsetUnits)
+	}
+
+	// face culling
+	switch m.cull {
+	case CullBack:
+		gl.Enable(gl.CULL_FACE)
+		gl.CullFace(gl.BACK)
+	case CullFront:
+		gl.Enable(gl.CULL_FACE)
+		gl.CullFace(gl.FRONT)
+	}
+	gl.FrontFace(m.frontFace)
+}
+
+// Unbind unbinds the textures and frees their texture units
+func (m *Material) Unbind() {
+	for i, t := range m.textures {
+		gl.ActiveTexture(gl.TEXTURE0 + m.unit + uint32(i))
+		gl.BindTexture(t.target, 0)
+	}
+	gl.ActiveTexture(gl.TEXTURE0)
+	textureUnits.release(len(m.textures))
+	if m.alphaToCoverage {
+		gl.Disable(gl.SAMPLE_ALPHA_TO_COVERAGE)
+	}
+	if m.polygonOffset {
+		gl.Disable(gl.POLYGON_OFFSET_FILL)
+	}
+	if m.cull != CullNone {
+		gl.Disable(gl.CULL_FACE)
+	}
+	gl.FrontFace(gl.CCW)
+}
+
+// SetPolygonOffset pushes the material's depth towards the camera, for decals
+// (bullet holes, road markings, overlay quads) lying on a surface at the same
+// depth. Without an offset the depth test randomly picks the surface or the
+// decal per pixel (z-fighting). Negative values move towards the camera,
+// factor scales with the slope of the polygon, units with the depth buffer
+// resolution; -1, -1 is a good start. 0, 0 disables the offset.
+func (m *Material) SetPolygonOffset(factor, units float32) {
+	m.polygonOffset = factor != 0 || units != 0
+	m.offsetFactor, m.offsetUnits = factor, units
+}
+
+// SetCulling skips drawing back or front faces, frontFace is the winding
+// order (gl.CCW or gl.CW, as seen on screen) of front facing triangles.
+// Culling halves the fragments of closed meshes, but makes single sided
+// geometry (like quads seen from behind with the arcball) disappear.
+func (m *Material) SetCulling(cull CullMode, frontFace uint32) {
+	m.cull = cull
+	m.frontFace = frontFace
+}
+
+// SetFog fades the material into the fog color with distance, the program
+// must declare the fog uniforms (uFogMode, uFogColor, uFogRange, uFogDensity)
+// and view-space depth, see fragmentShaderFramebuffer. FogNone disables it.
+func (m *Material) SetFog(fog Fog) {
+	m.fog = fog
+}
+
+// Fog is the fog set by SetFog
+func (m *Material) Fog() Fog {
+	return m.fog
+}
+
+func boolToInt32(b bool) int32 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,121 @@
+package multisample
+
+import (
+	"github.com/go-gl/gl/v3.2-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+const (
+	vertexNormalSize = 3 // x,y,z = surface normal
+)
+
+// Mesh is indexed triangle geometry with normals, e.g. a terrain.
+// Like ElementQuads the attributes are stored one after the other
+// (not interleaved) in a single VBO, Offset* are their byte offsets.
+type Mesh struct {
+	Positions []float32 // x,y,z per vertex
+	Normals   []float32 // x,y,z per vertex
+	TexCoords []float32 // u,v per vertex
+	Indices   []uint16  // 3 per triangle, or strips separated by primitiveRestartIndex (see Mode)
+	Mode      uint32    // gl.TRIANGLES or gl.TRIANGLE_STRIP
+
+	OffsetPositions int
+	OffsetNormals   int
+	OffsetTexCoords int
+	BytesTotal      int // total bytes required for VBO buffer
+
+	Layout *Layout // attribute blocks, see computeOffsets
+
+	LODs []MeshLOD // coarser levels of detail sorted by distance, see GenerateLODs
+}
+
+// computeOffsets fills Layout, the Offset* fields and BytesTotal for the current vertex data
+func (m *Mesh) computeOffsets() {
+	m.Layout = NewLayout().Float32("position", vertexPositionSize).Float32("normal", vertexNormalSize).Float32("texcoord", vertexTexCoordSize)
+	m.Layout.Compute(len(m.Positions) / vertexPositionSize)
+	m.OffsetPositions = m.Layout.Offset("position")
+	m.OffsetNormals = m.Layout.Offset("normal")
+	m.OffsetTexCoords = m.Layout.Offset("texcoord")
+	m.BytesTotal = m.Layout.BytesTotal
+}
+
+// Bounds returns the bounding box of all vertices (object coordinates)
+func (m *Mesh) Bounds() (min, max mgl32.Vec3) {
+	if len(m.Positions) < vertexPositionSize {
+		return min, max
+	}
+	min = mgl32.Vec3{m.Positions[0], m.Positions[1], m.Positions[2]}
+	max = min
+	for i := vertexPositionSize; i+vertexPositionSize <= len(m.Positions); i += vertexPositionSize {
+		for axis := 0; axis < vertexPositionSize; axis++ {
+			v := m.Positions[i+axis]
+			if v < min[axis] {
+				min[axis] = v
+			}
+			if v > max[axis] {
+				max[axis] = v
+			}
+		}
+	}
+	return min, max
+}
+
+// makeHeightmapMesh builds a grid mesh on the xz-plane from a square heightmap
+// (size x size samples, row-major), centered at the origin and width units wide.
+// Texture coordinates run 0..1 across the whole grid.
+// With strips the cells are connected by one triangle strip per row instead of triangles.
+func makeHeightmapMesh(heights []float32, size int, width float32, height float32, strips bool) *Mesh {
+
+	m := &Mesh{Mode: gl.TRIANGLES}
+	step := width / float32(size-1)
+	at := func(x, z int) float32 {
+		x = clampInt(x, 0, size-1)
+		z = clampInt(z, 0, size-1)
+		return heights[z*size+x] * height
+	}
+
+	for z := 0; z < size; z++ {
+		for x := 0; x < size; x++ {
+			m.Positions = append(m.Positions, float32(x)*step-width/2, at(x, z), float32(z)*step-width/2)
+			m.TexCoords = append(m.TexCoords, float32(x)/float32(size-1), float32(z)/float32(size-1))
+
+			// normal from central differences of the neighbouring heights
+			normal := mgl32.Vec3{at(x-1, z) - at(x+1, z), 2 * step, at(x, z-1) - at(x, z+1)}.Normalize()
+			m.Normals = append(m.Normals, normal[0], normal[1], normal[2])
+		}
+	}
+
+	// one strip per row of cells
+	if strips {
+		m.Mode = gl.TRIANGLE_STRIP
+		m.Indices = gridStripIndices(size)
+		m.computeOffsets()
+		return m
+	}
+
+	// two triangles per grid cell, counter-clockwise seen from above
+	for z := 0; z < size-1; z++ {
+		for x := 0; x < size-1; x++ {
+			i := uint16(z*size + x)
+			below := i + uint16(size)
+			m.Indices = append(m.Indices,
+				i, below, i+1, // first triangle
+				i+1, below, below+1, // second triangle
+			)
+		}
+	}
+
+	m.computeOffsets()
+	return m
+
+}
+
+func clampInt(v, min, max int) int {
+	if v < min {
+		return min
+	}
+	if v > max {
+		return max
+	}
+	return v
+}
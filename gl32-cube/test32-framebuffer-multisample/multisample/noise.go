@@ -0,0 +1,150 @@
+package multisample
+
+import (
+	"image"
+	"math"
+	"math/rand"
+
+	"github.com/go-gl/gl/v3.2-core/gl"
+)
+
+// https://mrl.cs.nyu.edu/~perlin/paper445.pdf
+// https://thebookofshaders.com/13/
+//
+// Noise is 2D Perlin (gradient) noise: a random unit gradient at every
+// lattice point, blended smoothly across the cells. Unlike the value noise
+// of makeHeightmap it has no blocky lattice artifacts. With a period the
+// lattice repeats, so textures made from it tile without seams.
+type Noise struct {
+	perm [512]int // shuffled 0..255, twice, so lookups need no wrapping
+}
+
+// noiseGradients are the lattice gradients, picked by hash
+var noiseGradients = [8][2]float32{
+	{1, 0}, {-1, 0}, {0, 1}, {0, -1},
+	{0.7071, 0.7071}, {-0.7071, 0.7071}, {0.7071, -0.7071}, {-0.7071, -0.7071},
+}
+
+// NewNoise shuffles the lattice by seed, the same seed gives the same noise
+func NewNoise(seed int64) *Noise {
+	n := &Noise{}
+	for i, p := range rand.New(rand.NewSource(seed)).Perm(256) {
+		n.perm[i], n.perm[i+256] = p, p
+	}
+	return n
+}
+
+// Perlin is the noise at x, y (in lattice cells), about -1..1. A period > 0
+// repeats the lattice every period cells along both axes.
+func (n *Noise) Perlin(x, y float32, period int) float32 {
+
+	x0, y0 := int(math.Floor(float64(x))), int(math.Floor(float64(y)))
+	fx, fy := x-float32(x0), y-float32(y0)
+	x1, y1 := x0+1, y0+1
+	if period > 0 {
+		x0, y0, x1, y1 = wrapInt(x0, period), wrapInt(y0, period), wrapInt(x1, period), wrapInt(y1, period)
+	}
+
+	// gradient of a lattice corner dotted with the offset to it
+	corner := func(cx, cy int, dx, dy float32) float32 {
+		g := noiseGradients[n.perm[n.perm[cx&255]+cy&255]&7]
+		return g[0]*dx + g[1]*dy
+	}
+
+	u, v := fade(fx), fade(fy)
+	bottom := lerp(corner(x0, y0, fx, fy), corner(x1, y0, fx-1, fy), u)
+	top := lerp(corner(x0, y1, fx, fy-1), corner(x1, y1, fx-1, fy-1), u)
+	return lerp(bottom, top, v) * 1.4142 // gradients of length 1 reach ±0.7071
+
+}
+
+// FBM (fractal Brownian motion) sums octaves of Perlin noise, each at twice
+// the frequency and half the amplitude of the one before, normalized to about
+// -1..1. period applies to the first octave and doubles with the frequency.
+func (n *Noise) FBM(x, y float32, octaves int, period int) float32 {
+	sum, amplitude, total := float32(0), float32(1), float32(0)
+	for octave := 0; octave < octaves; octave++ {
+		sum += n.Perlin(x, y, period) * amplitude
+		total += amplitude
+		x, y, period, amplitude = x*2, y*2, period*2, amplitude/2
+	}
+	return sum / total
+}
+
+// makeNoiseImage renders tileable FBM noise into a size x size gray image
+// (0..255 in r, g and b), with cells lattice cells across the first octave
+func makeNoiseImage(noise *Noise, size, cells, octaves int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, size, size))
+	scale := float32(cells) / float32(size)
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			value := noise.FBM(float32(x)*scale, float32(y)*scale, octaves, cells)*0.5 + 0.5
+			gray := uint8(clampInt(int(value*255), 0, 255))
+			i := img.PixOffset(x, y)
+			img.Pix[i+0], img.Pix[i+1], img.Pix[i+2], img.Pix[i+3] = gray, gray, gray, 255
+		}
+	}
+	return img
+}
+
+// newShaderNoiseTexture renders tileable FBM noise into a size x size
+// mipmapped RGBA texture on the GPU, fast enough for large textures at init.
+// The color channels hold independent noise (seed, seed+1, seed+2), e.g. the
+// x and y of a distortion. It draws the screen quad of ContextScreen, the
+// viewport is left to the caller to restore.
+func newShaderNoiseTexture(label string, size int32, seed int64, cells, octaves int) uint32 {
+
+	generator := &PostEffect{name: label + " generator", fragmentShader: &fragmentShaderNoise}
+	generator.setupProgram()
+	generator.SetParam("uSeed", float32(seed%1000)) // the shader hash loses precision on large inputs
+	generator.SetParam("uCells", float32(cells))
+	generator.SetParam("uOctaves", float32(octaves))
+	generator.SetParam("uSize", float32(size))
+
+	texture := genTexture(label)
+	gl.BindTexture(gl.TEXTURE_2D, texture)
+	gl.TexImage2D(gl.TEXTURE_2D, 0, gl.RGBA8, size, size, 0, gl.RGBA, gl.UNSIGNED_BYTE, nil)
+	gpuResources.SetBytes(ResourceTexture, texture, int(size)*int(size)*4*4/3) // mipmaps add a third
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR_MIPMAP_LINEAR)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.REPEAT)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.REPEAT)
+	gl.BindTexture(gl.TEXTURE_2D, 0)
+
+	// render once into the texture, the framebuffer is only needed for that
+	fbo := genFramebuffer(label + " fbo")
+	gl.BindFramebuffer(gl.FRAMEBUFFER, fbo)
+	gl.FramebufferTexture2D(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT0, gl.TEXTURE_2D, texture, 0)
+	CheckGLFramebufferStatus()
+
+	gl.Disable(gl.DEPTH_TEST)
+	gl.Viewport(0, 0, size, size)
+	gl.BindBuffer(gl.ARRAY_BUFFER, ctxScreen.vbo)
+	gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, quadIndices.Buffer())
+	generator.draw()
+	gl.BindBuffer(gl.ARRAY_BUFFER, 0)
+	gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, 0)
+	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+	gl.UseProgram(0)
+
+	gl.BindTexture(gl.TEXTURE_2D, texture)
+	gl.GenerateMipmap(gl.TEXTURE_2D)
+	gl.BindTexture(gl.TEXTURE_2D, 0)
+
+	gpuResources.Release(ResourceFramebuffer, fbo)
+	gpuResources.Release(ResourceProgram, generator.program)
+
+	return texture
+
+}
+
+// fade is Perlin's quintic blend, its first and second derivatives are 0 at
+// the lattice points (smoothstep only has the first), so there are no creases
+func fade(t float32) float32 {
+	return t * t * t * (t*(t*6-15) + 10)
+}
+
+// wrapInt is i modulo n, positive for negative i
+func wrapInt(i, n int) int {
+	return (i%n + n) % n
+}
@@ -0,0 +1,146 @@
+package multisample
+
+import (
+	"fmt"
+
+	"github.com/go-gl/gl/v3.2-core/gl"
+)
+
+// OcclusionQuery tells whether the draws between Begin and End passed the
+// depth test with at least one sample, i.e. whether anything of them is
+// visible. Results arrive a frame or more later, Visible never waits for the
+// GPU. Until the first result the object counts as visible.
+//
+// GL_ANY_SAMPLES_PASSED (OpenGL 3.3 or ARB_occlusion_query2) may stop
+// counting at the first sample, plain 3.2 falls back to GL_SAMPLES_PASSED,
+// which counts them all, nonzero meaning visible.
+//
+// The query also drives conditional rendering (BeginConditional), the GPU
+// skips draws while the last result says hidden, without a round trip to the
+// CPU, e.g. to skip the cubemap of a mirror that is off screen.
+type OcclusionQuery struct {
+	query   uint32
+	target  uint32 // gl.ANY_SAMPLES_PASSED or gl.SAMPLES_PASSED
+	issued  bool   // Begin was called at least once, conditional rendering needs a result
+	pending bool   // ended, result not read yet
+	running bool   // between Begin and End
+	visible bool
+}
+
+// NewOcclusionQuery creates a query, requires a current GL context
+func NewOcclusionQuery(label string) *OcclusionQuery {
+	target := uint32(gl.SAMPLES_PASSED)
+	if glInfo.AtLeast(3, 3) || glInfo.Has("GL_ARB_occlusion_query2") {
+		target = gl.ANY_SAMPLES_PASSED
+	}
+	return &OcclusionQuery{query: genQuery(label), target: target, visible: true}
+}
+
+// Begin starts counting, skipped while the previous result is outstanding
+func (q *OcclusionQuery) Begin() {
+	q.poll()
+	if q.pending {
+		return
+	}
+	gl.BeginQuery(q.target, q.query)
+	q.running, q.issued = true, true
+}
+
+// End stops counting started by Begin
+func (q *OcclusionQuery) End() {
+	if !q.running {
+		return
+	}
+	gl.EndQuery(q.target)
+	q.running, q.pending = false, true
+}
+
+// poll reads the result, if the GPU has it
+func (q *OcclusionQuery) poll() {
+	if !q.pending {
+		return
+	}
+	var available int32
+	gl.GetQueryObjectiv(q.query, gl.QUERY_RESULT_AVAILABLE, &available)
+	if available == gl.FALSE {
+		return
+	}
+	var samples uint32
+	gl.GetQueryObjectuiv(q.query, gl.QUERY_RESULT, &samples)
+	q.visible, q.pending = samples > 0, false
+}
+
+// Visible is the latest result, true until the first one arrives
+func (q *OcclusionQuery) Visible() bool {
+	q.poll()
+	return q.visible
+}
+
+// BeginConditional skips the draws up to EndConditional if the last result
+// of the query is hidden. QUERY_NO_WAIT draws when the result is not
+// ready, stale but never stalling. Without a result nothing is started and
+// false returned, call EndConditional only after true.
+func (q *OcclusionQuery) BeginConditional() bool {
+	if !q.issued {
+		return false
+	}
+	gl.BeginConditionalRender(q.query, gl.QUERY_NO_WAIT)
+	return true
+}
+
+// EndConditional ends conditional rendering started by BeginConditional
+func (q *OcclusionQuery) EndConditional() {
+	gl.EndConditionalRender()
+}
+
+func (q *OcclusionQuery) destroy() {
+	gpuResources.Release(ResourceQuery, q.query)
+	q.query = 0
+}
+
+// OcclusionQueries is one query per draw, e.g. per quad batch, by index
+type OcclusionQueries struct {
+	name    string
+	queries []*OcclusionQuery
+}
+
+// resize keeps one query per draw, they are recreated when the draws changed
+// as the results of the old ones belong to other draws
+func (o *OcclusionQueries) resize(n int) {
+	o.destroy()
+	for i := 0; i < n; i++ {
+		o.queries = append(o.queries, NewOcclusionQuery(fmt.Sprintf("%v occlusion %v", o.name, i)))
+	}
+}
+
+// Get is the query of a draw, nil for indices without one
+func (o *OcclusionQueries) Get(i int) *OcclusionQuery {
+	if o == nil || i < 0 || i >= len(o.queries) {
+		return nil
+	}
+	return o.queries[i]
+}
+
+// Visible is whether draw i was visible, true without a query
+func (o *OcclusionQueries) Visible(i int) bool {
+	query := o.Get(i)
+	return query == nil || query.Visible()
+}
+
+// String is the number of visible draws, e.g. "3 of 4 visible"
+func (o *OcclusionQueries) String() string {
+	visible := 0
+	for _, query := range o.queries {
+		if query.Visible() {
+			visible++
+		}
+	}
+	return fmt.Sprintf("%v of %v visible", visible, len(o.queries))
+}
+
+func (o *OcclusionQueries) destroy() {
+	for _, query := range o.queries {
+		query.destroy()
+	}
+	o.queries = nil
+}
@@ -0,0 +1,149 @@
+package multisample
+
+import (
+	"fmt"
+
+	"github.com/go-gl/gl/v3.2-core/gl"
+)
+
+const (
+	overdrawMax = 8 // fragments per pixel shown white, the top of the heat palette
+)
+
+var (
+	overdraw = &Overdraw{depthTest: true}
+)
+
+// Overdraw shows how often each pixel is shaded: the quads are drawn a second
+// time into a float counter, every fragment adding 1 (additive blending,
+// whatever the layer), and a post effect replaces the image by the counts in
+// a heat palette, from black (0) over blue, green and yellow to red and white
+// (overdrawMax and more).
+//
+// The counter pass has its own depth buffer and tests depth like the layers
+// do, so fragments hidden by quads drawn before them are not counted. That is
+// what depth testing saves, and what drawing front to back would save more
+// of. With depth testing off (Ctrl+H) every fragment counts, the difference
+// between the two is the benefit. Shift+H prints the average of the next frame.
+//
+// Only the quads of the msaa scene are counted, not the -terrain, -water or
+// -particles programs.
+type Overdraw struct {
+	effect  *PostEffect // post-processing pass drawing the heat palette, the last in the chain
+	counter *Pass       // fragments per pixel in the red channel
+
+	counting  bool // the quads are drawn into the counter, see Layer.apply
+	depthTest bool // layers that test depth do so while counting
+	reporting bool // print the counts of the next frame, see request
+}
+
+// load registers the effect after every other one, so nothing draws over the palette
+func (o *Overdraw) load() {
+	o.effect = postProcessing.add("overdraw", &fragmentShaderOverdraw, *overdrawMode)
+	o.effect.SetParam("maxCount", overdrawMax)
+	o.effect.prepare = o.render
+}
+
+// setupBuffers creates the counter, it follows renderSize (see Renderer.resize)
+func (o *Overdraw) setupBuffers() {
+	var err error
+	o.counter, err = renderer.Pass("overdraw", PassOptions{Color: gl.R32F, Filter: gl.NEAREST, Depth: gl.DEPTH_COMPONENT24})
+	if err != nil {
+		panic(err)
+	}
+	o.effect.material.SetTexture("countTexture", gl.TEXTURE_2D, o.counter.ColorTexture())
+}
+
+// request prints the counts of the next frame, while the heat palette is shown
+func (o *Overdraw) request() {
+	o.reporting = true
+}
+
+// toggleDepthTest counts every fragment, or only those passing the depth test
+func (o *Overdraw) toggleDepthTest() {
+	o.depthTest = !o.depthTest
+	fmt.Printf("OVERDRAW -- depth test while counting: %v\n", o.depthTest)
+}
+
+// render draws the quads into the counter, called by PostProcessing.apply
+// before the pass (with the screen quad's buffers bound)
+func (o *Overdraw) render() {
+
+	ctx := ctxFramebufferMultisample
+
+	o.counter.Bind()
+	gl.ClearColor(0, 0, 0, 0)
+	gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
+	gl.UseProgram(ctx.program)
+	gl.Uniform1i(ctx.uniformOverdraw, 1)
+
+	// same quads and colors as the frame, cameras and model are uploaded already
+	sameColors := ctx.sameColors
+	o.counting, ctx.sameColors = true, true
+	ctx.draw()
+	o.counting, ctx.sameColors = false, sameColors
+
+	gl.Uniform1i(ctx.uniformOverdraw, 0)
+	if o.reporting {
+		o.reporting = false
+		o.report()
+	}
+
+	// restore the state of the post-processing pass (see PostProcessing.apply)
+	gl.Disable(gl.DEPTH_TEST)
+	gl.Disable(gl.BLEND)
+	gl.DepthMask(true)
+	gl.BindBuffer(gl.ARRAY_BUFFER, ctxScreen.vbo)
+	gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, quadIndices.Buffer())
+
+}
+
+// countState is the depth and blend state of a layer while counting, see Layer.apply
+func (o *Overdraw) countState(state layerState) {
+	if state.depthTest && o.depthTest {
+		gl.Enable(gl.DEPTH_TEST)
+	} else {
+		gl.Disable(gl.DEPTH_TEST)
+	}
+	gl.DepthMask(state.depthWrite)
+	gl.Enable(gl.BLEND)
+	gl.BlendFunc(gl.ONE, gl.ONE)
+}
+
+// report reads the counter back and prints the average fragments per pixel,
+// over the whole viewport and over the pixels drawn at all
+func (o *Overdraw) report() {
+
+	width, height := o.counter.Viewport()
+	counts := make([]float32, int(width)*int(height))
+	gl.BindFramebuffer(gl.READ_FRAMEBUFFER, o.counter.Framebuffer())
+	gl.ReadPixels(0, 0, width, height, gl.RED, gl.FLOAT, gl.Ptr(counts))
+
+	var total float64
+	var covered int
+	var max float32
+	for _, count := range counts {
+		total += float64(count)
+		if count > 0 {
+			covered++
+		}
+		if count > max {
+			max = count
+		}
+	}
+	perCovered := 0.0
+	if covered > 0 {
+		perCovered = total / float64(covered)
+	}
+	fmt.Printf("OVERDRAW -- %.2f fragments per pixel, %.2f per covered pixel (%v of %v), max %v, depth test %v\n",
+		total/float64(len(counts)), perCovered, covered, len(counts), max, o.depthTest)
+
+}
+
+// destroy releases the counter, the program belongs to postProcessing
+func (o *Overdraw) destroy() {
+	if o.counter != nil {
+		o.counter.destroy()
+		o.counter = nil
+	}
+}
@@ -0,0 +1,121 @@
+package multisample
+
+import (
+	"image/color"
+
+	"github.com/go-gl/gl/v3.2-core/gl"
+)
+
+const (
+	paletteSize       = 256  // colors per palette, one per possible index of an 8 bit sprite texel
+	paletteFlashTime  = 0.15 // seconds the damage palette is shown, see PaletteSwap.Flash
+	paletteNormal     = 0    // palette row used normally
+	paletteDamage     = 1    // palette row shown while flashing
+	spriteTransparent = 0    // index drawn transparent, by convention
+)
+
+// Palette maps sprite indices to colors, unused indices are transparent black
+type Palette []color.NRGBA
+
+// palettes of the indexed sprite, one row of the palette texture each
+var spritePalettes = []Palette{
+	paletteNormal: {
+		spriteTransparent: {0, 0, 0, 0},
+		1:                 {40, 30, 20, 255},    // outline
+		2:                 {250, 200, 60, 255},  // face
+		3:                 {255, 245, 200, 255}, // highlight
+	},
+	paletteDamage: {
+		spriteTransparent: {0, 0, 0, 0},
+		1:                 {255, 255, 255, 255},
+		2:                 {230, 40, 40, 255},
+		3:                 {255, 150, 150, 255},
+	},
+}
+
+// sprite drawn with palettes, 0 transparent, 1 outline, 2 face, 3 highlight
+var spriteIndexRows = []string{
+	"0000011111100000",
+	"0001122222211000",
+	"0012222222222100",
+	"0122332222222210",
+	"0123322222222210",
+	"1222221221222221",
+	"1222221221222221",
+	"1222222222222221",
+	"1222222222222221",
+	"1222122222212221",
+	"1222212222122221",
+	"0122221111222210",
+	"0122222222222210",
+	"0012222222222100",
+	"0001122222211000",
+	"0000011111100000",
+}
+
+// PaletteSwap selects the palette row of indexed sprites at runtime,
+// e.g. flashing a damage palette for a moment
+type PaletteSwap struct {
+	row        int32   // palette row in use
+	flashUntil float64 // glfw time when the damage flash ends
+}
+
+// Flash shows the damage palette for paletteFlashTime seconds
+func (p *PaletteSwap) Flash(now float64) {
+	p.flashUntil = now + paletteFlashTime
+}
+
+// update picks the palette row for the current time
+func (p *PaletteSwap) update(now float64) {
+	p.row = paletteNormal
+	if now < p.flashUntil {
+		p.row = paletteDamage
+	}
+}
+
+// makeSpriteIndices converts spriteIndexRows into texels, bottom row first like OpenGL textures
+func makeSpriteIndices() (indices []uint8, width, height int) {
+	width, height = len(spriteIndexRows[0]), len(spriteIndexRows)
+	for y := height - 1; y >= 0; y-- {
+		for _, c := range spriteIndexRows[y] {
+			indices = append(indices, uint8(c-'0'))
+		}
+	}
+	return indices, width, height
+}
+
+// newIndexTexture uploads 8 bit palette indices as single channel texture.
+// Indices must not be filtered (blending index 1 and 3 is not color 2),
+// so sampling is always nearest.
+func newIndexTexture(label string, indices []uint8, width, height int) uint32 {
+	texture := genTexture(label)
+	gl.BindTexture(gl.TEXTURE_2D, texture)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.NEAREST)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.NEAREST)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
+	gl.PixelStorei(gl.UNPACK_ALIGNMENT, 1) // rows of 1 byte texels are not 4 byte aligned
+	gl.TexImage2D(gl.TEXTURE_2D, 0, gl.R8, int32(width), int32(height), 0, gl.RED, gl.UNSIGNED_BYTE, gl.Ptr(indices))
+	gpuResources.SetBytes(ResourceTexture, texture, width*height)
+	gl.BindTexture(gl.TEXTURE_2D, 0)
+	return texture
+}
+
+// newPaletteTexture uploads palettes as a paletteSize x len(palettes) texture, one palette per row
+func newPaletteTexture(label string, palettes []Palette) uint32 {
+	pix := make([]uint8, paletteSize*len(palettes)*4)
+	for row, palette := range palettes {
+		for i, c := range palette {
+			offset := (row*paletteSize + i) * 4
+			pix[offset+0], pix[offset+1], pix[offset+2], pix[offset+3] = c.R, c.G, c.B, c.A
+		}
+	}
+	texture := genTexture(label)
+	gl.BindTexture(gl.TEXTURE_2D, texture)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.NEAREST)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.NEAREST)
+	gl.TexImage2D(gl.TEXTURE_2D, 0, gl.RGBA8, paletteSize, int32(len(palettes)), 0, gl.RGBA, gl.UNSIGNED_BYTE, gl.Ptr(pix))
+	gpuResources.SetBytes(ResourceTexture, texture, len(pix))
+	gl.BindTexture(gl.TEXTURE_2D, 0)
+	return texture
+}
@@ -0,0 +1,316 @@
+package multisample
+
+import (
+	"fmt"
+	"math/rand"
+
+	"github.com/go-gl/gl/v3.2-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+const (
+	particleCount    = 2048 // particles alive at once, each respawns at the emitter when it dies
+	particleLifetime = 2.0  // seconds from spawn to respawn
+	particleSize     = 0.02 // quad width in world units
+	particleGravity  = 0.8  // downwards acceleration in world units per second²
+	particleSeed     = 5    // spawn velocity seed, same fountain every run
+)
+
+var (
+	ctxParticles = &ContextParticles{}
+)
+
+// particle is one spark of the fountain
+type particle struct {
+	position mgl32.Vec3
+	velocity mgl32.Vec3
+	age      float32 // seconds since spawn
+}
+
+// ContextParticles draws a fountain of sparks (-particles) over the scene.
+// On GL 3.0 and later the simulation runs on the GPU: a vertex shader reads
+// every particle from one state VBO and transform feedback writes the moved
+// particle into the other, the two swap each frame (ping-pong) and nothing
+// is uploaded. Without transform feedback (GLES2, or -cpuparticles) the
+// CPU simulates and each frame uploads one point per particle (position
+// and age). Either way a geometry shader expands every point into a quad
+// facing the camera, so the CPU uploads a quarter of the vertices a quad
+// per particle would need and no indices.
+//
+// Without geometry shaders (before GL 3.2, GLES2) or with -sprites points
+// the points are drawn as point sprites: the vertex shader sets
+// gl_PointSize, shrinking with the distance, and the fragment shader shapes
+// the spark from gl_PointCoord. Sprites are cheaper, but their size is
+// limited (see POINT_SIZE_RANGE) and they are clipped as a whole when the
+// center leaves the screen.
+type ContextParticles struct {
+	particles []particle
+	emitter   mgl32.Vec3 // spawn position in world coordinates
+	rng       *rand.Rand
+	vertices  []float32 // x, y, z, age per particle, see layout
+	gpu       bool      // simulate by transform feedback, see updateGPU
+	points    bool      // draw point sprites instead of quads, see -sprites
+
+	program              uint32 // connects vertex, geometry and fragment shaders (Particle shaders), or the ParticlePoint shaders
+	vao                  uint32 // only need to initalize it, we never use it
+	buffers              *DynamicBuffer
+	layout               *Layout
+	attribVertexPosition uint32 // reference to position input for shader variable (Particle shaders)
+	attribVertexAge      uint32 // reference to age input for shader variable (Particle shaders)
+	uniformProjection    int32
+	uniformCamera        int32
+	uniformViewport      int32 // viewport height, point sprites are sized in pixels
+
+	// GPU simulation, the state VBOs hold position, velocity and age per particle (stateLayout)
+	states               [2]uint32 // read one, write the other
+	state                int       // index of the state holding the last update
+	stateLayout          *Layout
+	updateProgram        uint32 // vertex shader only, outputs captured (ParticleUpdate shader)
+	attribUpdatePosition uint32
+	attribUpdateVelocity uint32
+	attribUpdateAge      uint32
+	uniformDelta         int32
+	uniformSeed          int32
+	seed                 float32 // changes every update, so respawns differ
+}
+
+// load spawns the particles at random ages, so they do not all start at once (CPU only)
+func (ctx *ContextParticles) load() {
+
+	ctx.emitter = mgl32.Vec3{0, -0.4, -1.15} // in front of the quads
+	if *terrainMode {
+		ctx.emitter = mgl32.Vec3{0, terrainHeight * 0.7, 0}
+	}
+
+	ctx.rng = rand.New(rand.NewSource(particleSeed))
+	ctx.particles = make([]particle, particleCount)
+	for i := range ctx.particles {
+		ctx.spawn(&ctx.particles[i])
+		ctx.particles[i].age = ctx.rng.Float32() * particleLifetime
+	}
+	ctx.vertices = make([]float32, particleCount*4)
+	ctx.layout = NewLayout().Float32("position", 3).Float32("age", 1).Interleave().Compute(particleCount)
+	ctx.stateLayout = NewLayout().Float32("position", 3).Float32("velocity", 3).Float32("age", 1).Interleave().Compute(particleCount)
+
+}
+
+// spawn restarts a particle at the emitter, shooting upwards in a narrow cone
+func (ctx *ContextParticles) spawn(p *particle) {
+	p.position = ctx.emitter
+	p.velocity = mgl32.Vec3{(ctx.rng.Float32()*2 - 1) * 0.2, 0.8 + ctx.rng.Float32()*0.3, (ctx.rng.Float32()*2 - 1) * 0.2}
+	p.age = 0
+}
+
+// update moves the particles by dt seconds and respawns the dead ones
+func (ctx *ContextParticles) update(dt float64) {
+	if ctx.gpu {
+		ctx.updateGPU(float32(dt))
+		return
+	}
+	step := float32(dt)
+	for i := range ctx.particles {
+		p := &ctx.particles[i]
+		p.age += step
+		if p.age >= particleLifetime {
+			ctx.spawn(p)
+		}
+		p.velocity[1] -= particleGravity * step
+		p.position = p.position.Add(p.velocity.Mul(step))
+	}
+}
+
+// updateGPU runs the update shader over the current state into the other
+// one, the rasterizer is off since only the captured outputs matter
+func (ctx *ContextParticles) updateGPU(dt float32) {
+
+	ctx.seed++
+	if ctx.seed >= 1000 {
+		ctx.seed = 0 // keeps the shader hash precise
+	}
+
+	gl.UseProgram(ctx.updateProgram)
+	gl.Uniform1f(ctx.uniformDelta, dt)
+	gl.Uniform1f(ctx.uniformSeed, ctx.seed)
+
+	gl.BindBuffer(gl.ARRAY_BUFFER, ctx.states[ctx.state])
+	ctx.stateLayout.Enable(ctx.attribUpdatePosition, ctx.attribUpdateVelocity, ctx.attribUpdateAge)
+	gl.BindBufferBase(gl.TRANSFORM_FEEDBACK_BUFFER, 0, ctx.states[1-ctx.state])
+
+	gl.Enable(gl.RASTERIZER_DISCARD)
+	gl.BeginTransformFeedback(gl.POINTS)
+	gl.DrawArrays(gl.POINTS, 0, particleCount)
+	gl.EndTransformFeedback()
+	gl.Disable(gl.RASTERIZER_DISCARD)
+
+	gl.BindBufferBase(gl.TRANSFORM_FEEDBACK_BUFFER, 0, 0)
+	ctx.stateLayout.Disable(ctx.attribUpdatePosition, ctx.attribUpdateVelocity, ctx.attribUpdateAge)
+	gl.BindBuffer(gl.ARRAY_BUFFER, 0)
+	gl.UseProgram(0)
+
+	ctx.state = 1 - ctx.state
+
+}
+
+func (ctx *ContextParticles) setupProgram() {
+
+	var err error
+	ctx.gpu = glInfo.AtLeast(3, 0) && !*cpuParticles
+	ctx.points, err = particlePoints(*particleSprites)
+	if err != nil {
+		panic(err)
+	}
+
+	// configure program, load shaders, and link attributes
+	if ctx.points {
+		var sizes [2]float32
+		gl.GetFloatv(gl.POINT_SIZE_RANGE, &sizes[0])
+		fmt.Printf("PARTICLES -- point sprites, %v to %v pixels\n", sizes[0], sizes[1])
+		ctx.program, err = newProgram(vertexShaderParticlePoint, fragmentShaderParticlePoint)
+	} else {
+		ctx.program, err = newGeometryProgram(vertexShaderParticle, geometryShaderParticle, fragmentShaderParticle)
+	}
+	if err != nil {
+		panic(err)
+	}
+	gpuResources.SetLabel(ResourceProgram, ctx.program, "particle program")
+	gl.UseProgram(ctx.program)
+
+	// get attribute and uniform index for later use
+	ctx.attribVertexPosition = uint32(gl.GetAttribLocation(ctx.program, gl.Str("vertexPosition\x00")))
+	ctx.attribVertexAge = uint32(gl.GetAttribLocation(ctx.program, gl.Str("vertexAge\x00")))
+	ctx.uniformProjection = gl.GetUniformLocation(ctx.program, gl.Str("projection\x00"))
+	ctx.uniformCamera = gl.GetUniformLocation(ctx.program, gl.Str("camera\x00"))
+	ctx.uniformViewport = gl.GetUniformLocation(ctx.program, gl.Str("uViewportHeight\x00")) // -1 for quads
+	gl.Uniform1f(gl.GetUniformLocation(ctx.program, gl.Str("uSize\x00")), particleSize)
+	gl.Uniform1f(gl.GetUniformLocation(ctx.program, gl.Str("uLifetime\x00")), particleLifetime)
+
+	if ctx.gpu {
+		ctx.updateProgram, err = newFeedbackProgram(vertexShaderParticleUpdate, []string{"outPosition", "outVelocity", "outAge"})
+		if err != nil {
+			panic(err)
+		}
+		gpuResources.SetLabel(ResourceProgram, ctx.updateProgram, "particle update program")
+		gl.UseProgram(ctx.updateProgram)
+
+		ctx.attribUpdatePosition = uint32(gl.GetAttribLocation(ctx.updateProgram, gl.Str("vertexPosition\x00")))
+		ctx.attribUpdateVelocity = uint32(gl.GetAttribLocation(ctx.updateProgram, gl.Str("vertexVelocity\x00")))
+		ctx.attribUpdateAge = uint32(gl.GetAttribLocation(ctx.updateProgram, gl.Str("vertexAge\x00")))
+		ctx.uniformDelta = gl.GetUniformLocation(ctx.updateProgram, gl.Str("uDelta\x00"))
+		ctx.uniformSeed = gl.GetUniformLocation(ctx.updateProgram, gl.Str("uSeed\x00"))
+		gl.Uniform3fv(gl.GetUniformLocation(ctx.updateProgram, gl.Str("uEmitter\x00")), 1, &ctx.emitter[0])
+		gl.Uniform1f(gl.GetUniformLocation(ctx.updateProgram, gl.Str("uGravity\x00")), particleGravity)
+		gl.Uniform1f(gl.GetUniformLocation(ctx.updateProgram, gl.Str("uLifetime\x00")), particleLifetime)
+	}
+
+	// unbind program
+	gl.UseProgram(0)
+
+}
+
+func (ctx *ContextParticles) setupBuffers() {
+
+	// create and bind VAO
+	ctx.vao = genVertexArray("particle vao")
+	gl.BindVertexArray(ctx.vao)
+
+	if !ctx.gpu {
+		// rewritten every frame
+		ctx.buffers = NewDynamicBuffer("particle vbo", ctx.layout.BytesTotal)
+		return
+	}
+
+	// both states start as the CPU spawned particles, the GPU takes over from there
+	state := make([]float32, 0, particleCount*7)
+	for _, p := range ctx.particles {
+		state = append(state, p.position[:]...)
+		state = append(state, p.velocity[:]...)
+		state = append(state, p.age)
+	}
+	for i := range ctx.states {
+		ctx.states[i] = genBuffer("particle state vbo")
+		gl.BindBuffer(gl.ARRAY_BUFFER, ctx.states[i])
+		gl.BufferData(gl.ARRAY_BUFFER, ctx.stateLayout.BytesTotal, gl.Ptr(state), gl.DYNAMIC_COPY)
+		gpuResources.SetBytes(ResourceBuffer, ctx.states[i], ctx.stateLayout.BytesTotal)
+	}
+	gl.BindBuffer(gl.ARRAY_BUFFER, 0)
+
+}
+
+// draw adds the particles to the proxy screen, after the scene
+func (ctx *ContextParticles) draw() {
+
+	gl.UseProgram(ctx.program)
+	projection, view := sceneProjection(), sceneView()
+	gl.UniformMatrix4fv(ctx.uniformProjection, 1, false, &projection[0])
+	gl.UniformMatrix4fv(ctx.uniformCamera, 1, false, &view[0])
+
+	// sparks add light and do not hide each other, so they need no sorting
+	gl.Enable(gl.BLEND)
+	gl.BlendFunc(gl.SRC_ALPHA, gl.ONE)
+	gl.DepthMask(false)
+
+	// the vertex shader sizes the sprites, in pixels of the proxy screen
+	if ctx.points {
+		_, height := viewportSize()
+		gl.Uniform1f(ctx.uniformViewport, float32(height))
+		gl.Enable(gl.PROGRAM_POINT_SIZE)
+	}
+
+	// gl.Begin()
+	if ctx.gpu {
+		// the state written by the last update, the program skips the velocity
+		gl.BindBuffer(gl.ARRAY_BUFFER, ctx.states[ctx.state])
+		ctx.stateLayout.EnableAttrib("position", ctx.attribVertexPosition)
+		ctx.stateLayout.EnableAttrib("age", ctx.attribVertexAge)
+	} else {
+		// pack positions and ages
+		for i, p := range ctx.particles {
+			copy(ctx.vertices[i*4:], p.position[:])
+			ctx.vertices[i*4+3] = p.age
+		}
+		ctx.buffers.Next()
+		gl.BufferSubData(gl.ARRAY_BUFFER, 0, len(ctx.vertices)*bytesFloat32, gl.Ptr(ctx.vertices))
+		ctx.layout.Enable(ctx.attribVertexPosition, ctx.attribVertexAge)
+	}
+	gl.DrawArrays(gl.POINTS, 0, int32(len(ctx.particles)))
+
+	// gl.End()
+	gl.DisableVertexAttribArray(ctx.attribVertexPosition)
+	gl.DisableVertexAttribArray(ctx.attribVertexAge)
+	gl.BindBuffer(gl.ARRAY_BUFFER, 0)
+	gl.DepthMask(true)
+	gl.Disable(gl.BLEND)
+	if ctx.points {
+		gl.Disable(gl.PROGRAM_POINT_SIZE)
+	}
+
+}
+
+// particlePoints reports whether -sprites selects point sprites, auto picks
+// them where there are no geometry shaders to expand quads
+func particlePoints(mode string) (bool, error) {
+	switch mode {
+	case "auto":
+		return !glInfo.AtLeast(3, 2) && !glInfo.Has("GL_ARB_geometry_shader4"), nil
+	case "points":
+		return true, nil
+	case "quads":
+		return false, nil
+	}
+	return false, fmt.Errorf("PARTICLES: unknown -sprites %q, want auto, points or quads", mode)
+}
+
+func (ctx *ContextParticles) destroy() {
+	if ctx.buffers != nil {
+		ctx.buffers.destroy()
+		ctx.buffers = nil
+	}
+	for i, state := range ctx.states {
+		gpuResources.Release(ResourceBuffer, state)
+		ctx.states[i] = 0
+	}
+	gpuResources.Release(ResourceVertexArray, ctx.vao)
+	gpuResources.Release(ResourceProgram, ctx.program)
+	gpuResources.Release(ResourceProgram, ctx.updateProgram)
+	ctx.vao, ctx.program, ctx.updateProgram = 0, 0, 0
+}
@@ -0,0 +1,70 @@
+package multisample
+
+import (
+	"fmt"
+)
+
+var (
+	passOutputs = &PassOutputs{outputs: map[string]func() *Texture{}}
+)
+
+// PassOutputs names the color textures of the offscreen passes, so any
+// material can sample what a pass rendered (feedback effects, picture in
+// picture) without reaching into the pass's context, e.g.
+//
+//	material.SetPassTexture("passTexture", "scene")
+//
+// Passes recreate their textures when the render size changes (see
+// setRenderScale), so outputs are looked up when used, not when set.
+// Sampling a pass before it runs in the frame reads the previous frame.
+type PassOutputs struct {
+	outputs map[string]func() *Texture
+}
+
+// Register makes the texture returned by output available as name,
+// replacing an earlier pass of the same name
+func (p *PassOutputs) Register(name string, output func() *Texture) {
+	p.outputs[name] = output
+}
+
+// Texture is the current output of the pass called name, nil if the pass is
+// unknown or has no texture yet
+func (p *PassOutputs) Texture(name string) *Texture {
+	output, ok := p.outputs[name]
+	if !ok {
+		return nil
+	}
+	texture := output()
+	if texture == nil || texture.ID == 0 {
+		return nil
+	}
+	return texture
+}
+
+// mustTexture is Texture for passes that always exist, e.g. "scene" during a frame
+func (p *PassOutputs) mustTexture(name string) *Texture {
+	texture := p.Texture(name)
+	if texture == nil {
+		panic(fmt.Sprintf("PASS: no output %q", name))
+	}
+	return texture
+}
+
+// ColorTexture is the color attachment of the framebuffer, renderSize large
+func (ctx *ContextFramebuffer) ColorTexture() *Texture {
+	if ctx.pass == nil {
+		return nil
+	}
+	return ctx.pass.Texture()
+}
+
+// registerPassOutputs names the outputs of the built-in passes:
+//
+//	scene  the resolved (single sample) scene, before post-processing
+//	post   the result of post-processing, the scene if no effect is enabled
+//	right  the right eye in stereo modes (see Stereo)
+func registerPassOutputs() {
+	passOutputs.Register("scene", func() *Texture { return renderGraph.OutputTexture("scene") })
+	passOutputs.Register("post", postProcessing.OutputTexture)
+	passOutputs.Register("right", stereo.right.ColorTexture)
+}
@@ -0,0 +1,153 @@
+package multisample
+
+import (
+	"image/color"
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Path is a vector drawing of lines and Bezier curves, like an SVG path. The
+// curves are flattened into polylines as they are added, close enough to the
+// curve that the difference is below tolerance (in the units of the quad
+// vertices, e.g. half a pixel). Draw it with StrokePath.
+type Path struct {
+	tolerance float32
+	subpaths  []pathSubpath
+}
+
+// pathSubpath is a run of connected points, started by MoveTo
+type pathSubpath struct {
+	points []mgl32.Vec2
+	closed bool
+}
+
+// NewPath creates an empty path flattening curves to within tolerance
+func NewPath(tolerance float32) *Path {
+	return &Path{tolerance: tolerance}
+}
+
+// MoveTo starts a new subpath at x, y
+func (p *Path) MoveTo(x, y float32) *Path {
+	p.subpaths = append(p.subpaths, pathSubpath{points: []mgl32.Vec2{{x, y}}})
+	return p
+}
+
+// LineTo adds a straight line from the current point to x, y
+func (p *Path) LineTo(x, y float32) *Path {
+	p.add(mgl32.Vec2{x, y})
+	return p
+}
+
+// QuadTo adds a quadratic Bezier curve from the current point to x, y bent
+// towards the control point cx, cy
+func (p *Path) QuadTo(cx, cy, x, y float32) *Path {
+	p0, p1, p2 := p.current(), mgl32.Vec2{cx, cy}, mgl32.Vec2{x, y}
+
+	// splitting into n even steps leaves the chords |p0-2p1+p2| / (8n^2) off the curve
+	n := p.segments(p0.Sub(p1.Mul(2)).Add(p2).Len() / 8)
+	for i := 1; i <= n; i++ {
+		t := float32(i) / float32(n)
+		p.add(mgl32.QuadraticBezierCurve2D(t, p0, p1, p2))
+	}
+	return p
+}
+
+// CubicTo adds a cubic Bezier curve from the current point to x, y, leaving
+// towards c1 and arriving from c2
+func (p *Path) CubicTo(c1x, c1y, c2x, c2y, x, y float32) *Path {
+	p0, p1, p2, p3 := p.current(), mgl32.Vec2{c1x, c1y}, mgl32.Vec2{c2x, c2y}, mgl32.Vec2{x, y}
+
+	// the second derivative is at most 6 times the larger second difference of the control points
+	d1 := p0.Sub(p1.Mul(2)).Add(p2).Len()
+	d2 := p1.Sub(p2.Mul(2)).Add(p3).Len()
+	n := p.segments(6 * float32(math.Max(float64(d1), float64(d2))) / 8)
+	for i := 1; i <= n; i++ {
+		t := float32(i) / float32(n)
+		p.add(mgl32.CubicBezierCurve2D(t, p0, p1, p2, p3))
+	}
+	return p
+}
+
+// Close connects the current point back to the start of the subpath
+func (p *Path) Close() *Path {
+	if len(p.subpaths) == 0 {
+		return p
+	}
+	subpath := &p.subpaths[len(p.subpaths)-1]
+	if first := subpath.points[0]; !subpath.points[len(subpath.points)-1].ApproxEqual(first) {
+		subpath.points = append(subpath.points, first)
+	}
+	subpath.closed = true
+	return p
+}
+
+// Subpaths returns the flattened subpaths, closed ones end at their first point
+func (p *Path) Subpaths() [][]mgl32.Vec2 {
+	points := make([][]mgl32.Vec2, len(p.subpaths))
+	for i, subpath := range p.subpaths {
+		points[i] = subpath.points
+	}
+	return points
+}
+
+// current is the last point of the path, 0, 0 before the first MoveTo
+func (p *Path) current() mgl32.Vec2 {
+	if len(p.subpaths) == 0 {
+		return mgl32.Vec2{}
+	}
+	points := p.subpaths[len(p.subpaths)-1].points
+	return points[len(points)-1]
+}
+
+// add appends a point to the current subpath, starting one at 0, 0 without MoveTo
+func (p *Path) add(point mgl32.Vec2) {
+	if len(p.subpaths) == 0 || p.subpaths[len(p.subpaths)-1].closed {
+		p.MoveTo(p.current().X(), p.current().Y())
+	}
+	subpath := &p.subpaths[len(p.subpaths)-1]
+	subpath.points = append(subpath.points, point)
+}
+
+// segments is the number of even steps that keep a curve within tolerance,
+// the distance of the chords to the curve shrinks with the square of the steps
+func (p *Path) segments(deviation float32) int {
+	if p.tolerance <= 0 || deviation <= p.tolerance {
+		return 1
+	}
+	n := int(math.Ceil(math.Sqrt(float64(deviation / p.tolerance))))
+	if n > 256 {
+		n = 256
+	}
+	return n
+}
+
+// StrokePath draws the outline of every subpath of path as a polyline, see
+// DrawPolyline. The corner where a closed subpath meets its start is joined
+// like the others.
+func (q *ElementQuads) StrokePath(path *Path, z float32, clr color.NRGBA, style LineStyle) {
+	for _, subpath := range path.subpaths {
+		q.DrawPolyline(subpath.points, z, clr, style)
+		if subpath.closed && style.RoundJoins && style.Cap != LineCapRound {
+			start := subpath.points[0]
+			q.DrawLine(start.X(), start.Y(), start.X(), start.Y(), z, clr, LineStyle{Width: style.Width, Cap: LineCapRound})
+		}
+	}
+}
+
+// DrawArrow draws a line from x0, y0 to x1, y1 with an open arrow head at
+// x1, y1, its sides headLength long at 30 degrees to the line
+func (q *ElementQuads) DrawArrow(x0, y0, x1, y1, z, headLength float32, clr color.NRGBA, style LineStyle) {
+	q.DrawLine(x0, y0, x1, y1, z, clr, style)
+
+	tip := mgl32.Vec2{x1, y1}
+	back := mgl32.Vec2{x0 - x1, y0 - y1}
+	if back.Len() == 0 {
+		return
+	}
+	back = back.Normalize().Mul(headLength)
+	left := mgl32.Rotate2D(mgl32.DegToRad(30)).Mul2x1(back)
+	right := mgl32.Rotate2D(mgl32.DegToRad(-30)).Mul2x1(back)
+	head := LineStyle{Width: style.Width, Cap: style.Cap, RoundJoins: true}
+	q.DrawPolyline([]mgl32.Vec2{tip.Add(left), tip, tip.Add(right)}, z, clr, head)
+}
@@ -0,0 +1,130 @@
+package multisample
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+)
+
+const (
+	patternSize  = 256 // size of the generated pattern textures in texels
+	patternCells = 8   // checker and grid cells per side
+)
+
+// Pattern is a debug texture generated at runtime (-pattern), no image
+// files needed to check the texture pipeline and texture coordinates
+type Pattern int
+
+const (
+	PatternOff      Pattern = iota // no pattern quad (the default)
+	PatternChecker                 // two colors in alternating cells, shows stretching and filtering
+	PatternGradient                // black to white along u, then red to green along v, shows interpolation and sRGB decoding
+	PatternGrid                    // thin lines on cell borders, shows seams and mipmapping
+	PatternUV                      // u in red, v in green, a mark in the cell corner nearest 0, 0, shows flipped or rotated coordinates
+)
+
+// parsePattern reads the -pattern flag
+func parsePattern(name string) (Pattern, error) {
+	switch name {
+	case "", "off":
+		return PatternOff, nil
+	case "checker":
+		return PatternChecker, nil
+	case "gradient":
+		return PatternGradient, nil
+	case "grid":
+		return PatternGrid, nil
+	case "uv":
+		return PatternUV, nil
+	}
+	return PatternOff, fmt.Errorf("unknown pattern %q, use off, checker, gradient, grid or uv", name)
+}
+
+// makePatternImage generates the image of a pattern, size x size texels
+func makePatternImage(pattern Pattern, size int) *image.NRGBA {
+	switch pattern {
+	case PatternChecker:
+		return makeCheckerImage(size, patternCells, color.NRGBA{255, 255, 255, 255}, color.NRGBA{64, 64, 64, 255})
+	case PatternGradient:
+		return makeGradientImage(size)
+	case PatternGrid:
+		return makeGridImage(size, patternCells, color.NRGBA{255, 255, 255, 255}, color.NRGBA{32, 48, 96, 255})
+	case PatternUV:
+		return makeUVImage(size, patternCells)
+	}
+	panic(fmt.Sprintf("PATTERN: no image for pattern %v", pattern))
+}
+
+// makeCheckerImage fills cells x cells squares alternating between a and b,
+// the bottom-left cell (first row of texels, see makeSpriteIndices) is a
+func makeCheckerImage(size, cells int, a, b color.NRGBA) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, size, size))
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			c := a
+			if (x*cells/size+y*cells/size)%2 == 1 {
+				c = b
+			}
+			img.SetNRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+// makeGradientImage ramps gray from black to white along u in the lower
+// half and red to green along v in the upper half. The gray ramp is sRGB
+// encoded, it looks even only if sampling decodes it (see TextureColor).
+func makeGradientImage(size int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, size, size))
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			u, v := uint8(x*255/(size-1)), uint8(y*255/(size-1))
+			if y < size/2 {
+				img.SetNRGBA(x, y, color.NRGBA{u, u, u, 255})
+			} else {
+				img.SetNRGBA(x, y, color.NRGBA{255 - v, v, 0, 255})
+			}
+		}
+	}
+	return img
+}
+
+// makeGridImage draws one texel wide lines on the cell borders, tiling
+// it (wrap REPEAT) continues the grid without doubled lines at the seams
+func makeGridImage(size, cells int, line, background color.NRGBA) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, size, size))
+	cell := size / cells
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			c := background
+			if x%cell == 0 || y%cell == 0 {
+				c = line
+			}
+			img.SetNRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+// makeUVImage colors each texel by its texture coordinates (u red, v
+// green, at the cell centers so cells are flat), with a grid and a white
+// mark in the corner of each cell closest to u, v = 0. A flipped or
+// rotated mapping moves the marks to another corner.
+func makeUVImage(size, cells int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, size, size))
+	cell := size / cells
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			column, row := x/cell, y/cell
+			c := color.NRGBA{uint8((column*2 + 1) * 255 / (cells * 2)), uint8((row*2 + 1) * 255 / (cells * 2)), 64, 255}
+			switch {
+			case x%cell == 0 || y%cell == 0:
+				c = color.NRGBA{0, 0, 0, 255}
+			case x%cell < cell/4 && y%cell < cell/4:
+				c = color.NRGBA{255, 255, 255, 255}
+			}
+			img.SetNRGBA(x, y, c)
+		}
+	}
+	return img
+}
@@ -0,0 +1,97 @@
+package multisample
+
+import (
+	"unsafe"
+
+	"github.com/go-gl/gl/v3.2-core/gl"
+)
+
+const (
+	persistentRegions     = 3       // frames that may be in flight, triple buffering
+	persistentWaitTimeout = 1e9 / 2 // nanoseconds to wait for the GPU to release a region before giving up
+)
+
+var (
+	usePersistentMapping = false // persistent mapping is supported, see setupPersistentMapping
+)
+
+// setupPersistentMapping checks for immutable buffer storage (OpenGL 4.4 or
+// ARB_buffer_storage), required for persistently mapped buffers
+func setupPersistentMapping() {
+	usePersistentMapping = glInfo.AtLeast(4, 4) || glInfo.Has("GL_ARB_buffer_storage")
+}
+
+// PersistentRing is a vertex buffer mapped once and written directly every
+// frame, without BufferSubData copies. It is split into persistentRegions
+// regions used in turns: the CPU writes one while the GPU may still read the
+// others from earlier frames. A fence after the draws of each region tells
+// when the GPU is done with it, Next waits on it before handing the region
+// out again, so a region is never overwritten while in use.
+type PersistentRing struct {
+	buffer      uint32
+	regionBytes int
+	mapped      []byte // whole buffer, coherent: writes are seen by the GPU without flushing
+	fences      [persistentRegions]uintptr
+	region      int // region handed out by the last Next
+}
+
+// NewPersistentRing creates and maps the buffer, only call it when usePersistentMapping is set
+func NewPersistentRing(label string, regionBytes int) *PersistentRing {
+
+	r := &PersistentRing{regionBytes: align(regionBytes, arenaAlignment), region: -1}
+	size := r.regionBytes * persistentRegions
+	flags := uint32(gl.MAP_WRITE_BIT | gl.MAP_PERSISTENT_BIT | gl.MAP_COHERENT_BIT)
+
+	r.buffer = genBuffer(label)
+	gl.BindBuffer(gl.ARRAY_BUFFER, r.buffer)
+	gl.BufferStorage(gl.ARRAY_BUFFER, size, nil, flags)
+	gpuResources.SetBytes(ResourceBuffer, r.buffer, size)
+	r.mapped = unsafe.Slice((*byte)(gl.MapBufferRange(gl.ARRAY_BUFFER, 0, size, flags)), size)
+	gl.BindBuffer(gl.ARRAY_BUFFER, 0)
+
+	return r
+
+}
+
+// RegionBytes is the size of one region
+func (r *PersistentRing) RegionBytes() int {
+	return r.regionBytes
+}
+
+// Next moves to the next region, waits until the GPU is done reading it, and
+// returns it for writing with its byte offset in the buffer
+func (r *PersistentRing) Next() (data []byte, offset int) {
+
+	r.region = (r.region + 1) % persistentRegions
+	if fence := r.fences[r.region]; fence != 0 {
+		gl.ClientWaitSync(fence, gl.SYNC_FLUSH_COMMANDS_BIT, persistentWaitTimeout)
+		gl.DeleteSync(fence)
+		r.fences[r.region] = 0
+	}
+
+	offset = r.region * r.regionBytes
+	return r.mapped[offset : offset+r.regionBytes], offset
+
+}
+
+// Fence marks the region from the last Next as in use, call it after the draws reading it
+func (r *PersistentRing) Fence() {
+	r.fences[r.region] = gl.FenceSync(gl.SYNC_GPU_COMMANDS_COMPLETE, 0)
+}
+
+// Buffer is the buffer object, bind it to point attributes at a region
+func (r *PersistentRing) Buffer() uint32 {
+	return r.buffer
+}
+
+func (r *PersistentRing) destroy() {
+	for i, fence := range r.fences {
+		if fence != 0 {
+			gl.DeleteSync(fence)
+			r.fences[i] = 0
+		}
+	}
+	// deleting a buffer unmaps it, nothing else to do
+	gpuResources.Release(ResourceBuffer, r.buffer)
+	r.buffer, r.mapped = 0, nil
+}
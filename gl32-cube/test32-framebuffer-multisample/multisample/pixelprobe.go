@@ -0,0 +1,117 @@
+package multisample
+
+import (
+	"fmt"
+
+	"github.com/go-gl/gl/v3.2-core/gl"
+)
+
+var (
+	pixelProbe = &PixelProbe{}
+)
+
+// PixelProbe prints what the pixel under the cursor holds at each stage of
+// the frame, to tell a blending mistake from a clear color or post effect
+// one. Right click a pixel in the msaa scene, after the next frame:
+//
+//	PROBE -- window (412, 300) framebuffer (640, 449)
+//	PROBE -- scene  rgba 0.500 0.500 0.500 0.000 (128 128 128 0) depth 1.000000 (linear 1.000) stencil 0
+//	PROBE -- post   rgba 0.500 0.500 0.500 0.000 (128 128 128 0)
+//	PROBE -- screen rgba 0.500 0.500 0.500 1.000 (128 128 128 255)
+//
+// The scene is multisampled, its samples can't be read directly, so color,
+// depth and stencil of the pixel are resolved into a single sample 1x1
+// framebuffer first (depth and stencil take one of the samples, as blits of
+// them must use NEAREST). Post is the output of the enabled post effects, at
+// the same framebuffer pixel, and screen the default framebuffer after the
+// overlays, before it is swapped.
+type PixelProbe struct {
+	requested bool
+	x, y      float64 // cursor in window coordinates
+}
+
+// request probes the pixel at a cursor position after the next frame
+func (p *PixelProbe) request(x, y float64) {
+	p.requested = true
+	p.x, p.y = x, y
+}
+
+// read prints the probed pixel, if requested, call it after draw()
+func (p *PixelProbe) read() {
+
+	if !p.requested {
+		return
+	}
+	p.requested = false
+
+	fx, fy, inside := CursorToFramebuffer(p.x, p.y)
+	if !inside {
+		fmt.Printf("PROBE -- window (%.0f, %.0f) is outside the scene\n", p.x, p.y)
+		return
+	}
+	x, y := int32(fx), int32(fy)
+	fmt.Printf("PROBE -- window (%.0f, %.0f) framebuffer (%v, %v)\n", p.x, p.y, x, y)
+
+	// resolve the pixel of the multisample framebuffer into 1x1 single sample attachments
+	fbo := genFramebuffer("probe fbo")
+	color := genRenderbuffer("probe color")
+	depthStencil := genRenderbuffer("probe depth/stencil")
+	gl.BindRenderbuffer(gl.RENDERBUFFER, color)
+	gl.RenderbufferStorage(gl.RENDERBUFFER, gl.RGBA8, 1, 1) // same format as the multisample texture (RGBA)
+	gl.BindRenderbuffer(gl.RENDERBUFFER, depthStencil)
+	gl.RenderbufferStorage(gl.RENDERBUFFER, gl.DEPTH24_STENCIL8, 1, 1)
+	gl.BindRenderbuffer(gl.RENDERBUFFER, 0)
+	gl.BindFramebuffer(gl.FRAMEBUFFER, fbo)
+	gl.FramebufferRenderbuffer(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT0, gl.RENDERBUFFER, color)
+	gl.FramebufferRenderbuffer(gl.FRAMEBUFFER, gl.DEPTH_STENCIL_ATTACHMENT, gl.RENDERBUFFER, depthStencil)
+	CheckGLFramebufferStatus()
+
+	// a multisample blit must not scale, the 1x1 rectangle is only moved
+	gl.BindFramebuffer(gl.READ_FRAMEBUFFER, ctxFramebufferMultisample.fbo)
+	gl.BindFramebuffer(gl.DRAW_FRAMEBUFFER, fbo)
+	gl.BlitFramebuffer(x, y, x+1, y+1, 0, 0, 1, 1, gl.COLOR_BUFFER_BIT|gl.DEPTH_BUFFER_BIT|gl.STENCIL_BUFFER_BIT, gl.NEAREST)
+
+	var depth float32
+	var stencil uint8
+	gl.BindFramebuffer(gl.READ_FRAMEBUFFER, fbo)
+	gl.PixelStorei(gl.PACK_ALIGNMENT, 1)
+	gl.ReadPixels(0, 0, 1, 1, gl.DEPTH_COMPONENT, gl.FLOAT, gl.Ptr(&depth))
+	gl.ReadPixels(0, 0, 1, 1, gl.STENCIL_INDEX, gl.UNSIGNED_BYTE, gl.Ptr(&stencil))
+	scene := readPixel(fbo, 0, 0)
+
+	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+	gpuResources.Release(ResourceFramebuffer, fbo)
+	gpuResources.Release(ResourceRenderbuffer, color)
+	gpuResources.Release(ResourceRenderbuffer, depthStencil)
+
+	near, far, perspective := depthCamera()
+	fmt.Printf("PROBE -- scene  %v depth %.6f (linear %.3f) stencil %v\n", formatProbeColor(scene), depth, linearizeDepth(depth, near, far, perspective), stencil)
+
+	// the post effects wrote into one of their framebuffers, or none is enabled
+	if post := postProcessing.framebufferOf(postProcessing.output); post != 0 {
+		fmt.Printf("PROBE -- post   %v\n", formatProbeColor(readPixel(post, x, y)))
+	} else {
+		fmt.Println("PROBE -- post   no effect enabled")
+	}
+
+	// the screen has origin top-left for the cursor, bottom-left for glReadPixels
+	screenX, screenY := WindowToScreen(p.x, p.y)
+	_, screenHeight := screenSize()
+	fmt.Printf("PROBE -- screen %v\n", formatProbeColor(readPixel(0, int32(screenX), screenHeight-1-int32(screenY))))
+
+}
+
+// readPixel reads one pixel of a framebuffer's color as floats 0..1
+func readPixel(fbo uint32, x, y int32) [4]float32 {
+	var rgba [4]float32
+	gl.BindFramebuffer(gl.READ_FRAMEBUFFER, fbo)
+	gl.ReadPixels(x, y, 1, 1, gl.RGBA, gl.FLOAT, gl.Ptr(&rgba[0]))
+	gl.BindFramebuffer(gl.READ_FRAMEBUFFER, 0)
+	return rgba
+}
+
+// formatProbeColor prints a color as floats and as the 8 bit values most targets store
+func formatProbeColor(rgba [4]float32) string {
+	return fmt.Sprintf("rgba %.3f %.3f %.3f %.3f (%v %v %v %v)", rgba[0], rgba[1], rgba[2], rgba[3],
+		uint8(rgba[0]*255+0.5), uint8(rgba[1]*255+0.5), uint8(rgba[2]*255+0.5), uint8(rgba[3]*255+0.5))
+}
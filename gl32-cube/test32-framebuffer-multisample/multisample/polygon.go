@@ -0,0 +1,98 @@
+package multisample
+
+import (
+	"image/color"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// FillPolygon fills the inside of a simple polygon (no holes, edges not
+// crossing each other), convex or not, in either winding. The polygon is cut
+// into triangles by ear clipping, each drawn as a quad whose last two
+// vertices are the same (see QuadIndexBuffer, the second triangle of the
+// quad is empty). The edges are not anti-aliased beyond MSAA.
+//
+// The quads go on LayerUI, blended over the world, like DrawLine.
+func (q *ElementQuads) FillPolygon(points []mgl32.Vec2, z float32, clr color.NRGBA) {
+
+	// a closed outline repeats its first point
+	if n := len(points); n > 1 && points[0].ApproxEqual(points[n-1]) {
+		points = points[:n-1]
+	}
+	if len(points) < 3 {
+		return
+	}
+
+	// clip ears counter-clockwise
+	ccw := polygonArea(points) > 0
+	remaining := make([]int, len(points))
+	for i := range remaining {
+		if ccw {
+			remaining[i] = i
+		} else {
+			remaining[i] = len(points) - 1 - i
+		}
+	}
+	for len(remaining) > 3 {
+		ear := -1
+		for i := range remaining {
+			if isEar(points, remaining, i) {
+				ear = i
+				break
+			}
+		}
+		if ear == -1 {
+			break // self-intersecting or degenerate, fill what is left as a fan
+		}
+		n := len(remaining)
+		a, b, c := points[remaining[(ear+n-1)%n]], points[remaining[ear]], points[remaining[(ear+1)%n]]
+		q.addQuad([verticesPerQuad]mgl32.Vec2{a, b, c, c}, z, clr, EffectColor, LayerUI)
+		remaining = append(remaining[:ear], remaining[ear+1:]...)
+	}
+	for i := 1; i+1 < len(remaining); i++ {
+		a, b, c := points[remaining[0]], points[remaining[i]], points[remaining[i+1]]
+		q.addQuad([verticesPerQuad]mgl32.Vec2{a, b, c, c}, z, clr, EffectColor, LayerUI)
+	}
+}
+
+// FillPath fills every subpath of path, closed or not, see FillPolygon
+func (q *ElementQuads) FillPath(path *Path, z float32, clr color.NRGBA) {
+	for _, points := range path.Subpaths() {
+		q.FillPolygon(points, z, clr)
+	}
+}
+
+// polygonArea is the signed area of a polygon, positive when counter-clockwise
+func polygonArea(points []mgl32.Vec2) float32 {
+	area := float32(0)
+	for i, p := range points {
+		next := points[(i+1)%len(points)]
+		area += p.X()*next.Y() - next.X()*p.Y()
+	}
+	return area * 0.5
+}
+
+// isEar reports whether the triangle of remaining[i] and its neighbors (in
+// counter-clockwise order) is convex and holds none of the other points
+func isEar(points []mgl32.Vec2, remaining []int, i int) bool {
+	n := len(remaining)
+	a, b, c := points[remaining[(i+n-1)%n]], points[remaining[i]], points[remaining[(i+1)%n]]
+	if cross2D(b.Sub(a), c.Sub(b)) <= 0 {
+		return false // reflex corner
+	}
+	for j, index := range remaining {
+		if j == i || j == (i+n-1)%n || j == (i+1)%n {
+			continue
+		}
+		p := points[index]
+		if cross2D(b.Sub(a), p.Sub(a)) >= 0 && cross2D(c.Sub(b), p.Sub(b)) >= 0 && cross2D(a.Sub(c), p.Sub(c)) >= 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// cross2D is the z of the cross product, positive when b turns left of a
+func cross2D(a, b mgl32.Vec2) float32 {
+	return a.X()*b.Y() - a.Y()*b.X()
+}
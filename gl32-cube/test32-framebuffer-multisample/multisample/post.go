@@ -0,0 +1,239 @@
+package multisample
+
+import (
+	"github.com/go-gl/gl/v3.2-core/gl"
+)
+
+var (
+	postProcessing = &PostProcessing{}
+)
+
+// PostEffect is a screen pass: a fragment shader reading the image drawn so
+// far (sampler2D inputTexture) and writing a modified image, e.g. outlines.
+// Every effect shares vertexShaderScreen, it covers the screen with one quad.
+type PostEffect struct {
+	name           string
+	fragmentShader *string // read by setupProgram, so reloaded shaders are used (see reloadAssets)
+	enabled        bool
+
+	program              uint32
+	material             *Material
+	attribVertexPosition uint32
+	attribVertexTexCoord uint32
+
+	params  map[string]float32 // float uniforms uploaded before the pass, see SetParam
+	prepare func()             // runs before the pass while the effect is enabled, e.g. to render extra inputs (see SSAO)
+}
+
+// SetParam sets a float uniform of the effect, e.g. the strength of a distortion
+func (e *PostEffect) SetParam(name string, value float32) {
+	if e.params == nil {
+		e.params = map[string]float32{}
+	}
+	e.params[name] = value
+}
+
+// PostProcessing runs the enabled effects one after the other on the
+// downsampled image, before ContextScreen presents it. Effects render into
+// two textures in turns (ping-pong), each reading what the previous one wrote.
+type PostProcessing struct {
+	effects  []*PostEffect
+	fbos     [2]uint32
+	textures [2]uint32
+
+	onPass func(effect *PostEffect, fbo uint32) // called after each pass, while its output is intact (see FrameDump)
+	output uint32                               // result of the last apply, see OutputTexture
+}
+
+// add registers an effect, effects run in the order they were added
+func (p *PostProcessing) add(name string, fragmentShader *string, enabled bool) *PostEffect {
+	effect := &PostEffect{name: name, fragmentShader: fragmentShader, enabled: enabled}
+	p.effects = append(p.effects, effect)
+	return effect
+}
+
+// has is whether an effect called name was added
+func (p *PostProcessing) has(name string) bool {
+	for _, effect := range p.effects {
+		if effect.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// names lists the effects, in the order they run
+func (p *PostProcessing) names() []string {
+	names := make([]string, len(p.effects))
+	for i, effect := range p.effects {
+		names[i] = effect.name
+	}
+	return names
+}
+
+// toggle enables or disables an effect by name
+func (p *PostProcessing) toggle(name string) {
+	for _, effect := range p.effects {
+		if effect.name == name {
+			effect.enabled = !effect.enabled
+		}
+	}
+}
+
+func (p *PostProcessing) setupProgram() {
+	for _, effect := range p.effects {
+		effect.setupProgram()
+	}
+}
+
+func (e *PostEffect) setupProgram() {
+
+	var err error
+
+	// configure program, load shaders, and link attributes
+	e.program, err = newProgram(vertexShaderScreen, *e.fragmentShader)
+	if err != nil {
+		panic(err)
+	}
+	gpuResources.SetLabel(ResourceProgram, e.program, e.name+" program")
+
+	// get attribute index for later use
+	e.attribVertexPosition = uint32(gl.GetAttribLocation(e.program, gl.Str("vertexPosition\x00")))
+	e.attribVertexTexCoord = uint32(gl.GetAttribLocation(e.program, gl.Str("vertexTexCoord\x00")))
+
+	// input texture is set by apply
+	e.material = NewMaterial(e.program)
+
+}
+
+// draw runs the pass into the bound framebuffer, the screen quad's buffers must be bound (see apply)
+func (e *PostEffect) draw() {
+
+	gl.UseProgram(e.program)
+	for name, value := range e.params {
+		gl.Uniform1f(gl.GetUniformLocation(e.program, gl.Str(name+"\x00")), value)
+	}
+
+	// gl.Begin()
+	e.material.Bind()
+	ctxScreen.layout.Enable(e.attribVertexPosition, e.attribVertexTexCoord)
+	quadIndices.Draw(0, ctxScreen.quads.QuadCount(), nil)
+
+	// gl.End()
+	ctxScreen.layout.Disable(e.attribVertexPosition, e.attribVertexTexCoord)
+	e.material.Unbind()
+
+}
+
+func (p *PostProcessing) setupBuffers() {
+
+	width, height := renderSize()
+
+	for i := range p.fbos {
+
+		// create FBO and bind to it
+		p.fbos[i] = genFramebuffer("post fbo")
+		gl.BindFramebuffer(gl.FRAMEBUFFER, p.fbos[i])
+
+		// attach texture to FBO (color buffer component)
+		p.textures[i] = genTexture("post fbo color")
+		gl.BindTexture(gl.TEXTURE_2D, p.textures[i])
+		gl.TexImage2D(gl.TEXTURE_2D, 0, gl.RGBA, width, height, 0, gl.RGBA, gl.UNSIGNED_BYTE, nil)
+		gpuResources.SetBytes(ResourceTexture, p.textures[i], int(width)*int(height)*4)
+		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, renderFilter())
+		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, renderFilter())
+		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
+		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
+		gl.BindTexture(gl.TEXTURE_2D, 0)
+		gl.FramebufferTexture2D(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT0, gl.TEXTURE_2D, p.textures[i], 0)
+
+		// check if FBO is ready and valid
+		CheckGLFramebufferStatus()
+
+	}
+
+	// unbind FBO
+	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+
+}
+
+// apply runs every enabled effect on input and returns the texture holding the result,
+// input itself if no effect is enabled
+func (p *PostProcessing) apply(input uint32) uint32 {
+
+	// effects draw the full screen quad of ContextScreen, into the viewport of the pass (see RenderGraph.execute)
+	gl.Disable(gl.DEPTH_TEST)
+	gl.BindBuffer(gl.ARRAY_BUFFER, ctxScreen.vbo)
+	gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, quadIndices.Buffer())
+
+	target := 0
+	for _, effect := range p.effects {
+		if !effect.enabled {
+			continue
+		}
+
+		if effect.prepare != nil {
+			effect.prepare()
+		}
+
+		// read input, write into the texture not being read
+		gl.BindFramebuffer(gl.FRAMEBUFFER, p.fbos[target])
+		effect.material.SetTexture("inputTexture", gl.TEXTURE_2D, input)
+		effect.draw()
+
+		if p.onPass != nil {
+			p.onPass(effect, p.fbos[target])
+		}
+
+		input = p.textures[target]
+		target = 1 - target
+	}
+
+	gl.BindBuffer(gl.ARRAY_BUFFER, 0)
+	gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, 0)
+	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+	gl.UseProgram(0)
+
+	p.output = input
+	return input
+
+}
+
+// OutputTexture is the result of the last apply, the "post" pass output (see PassOutputs)
+func (p *PostProcessing) OutputTexture() *Texture {
+	width, height := renderSize()
+	return &Texture{ID: p.output, Width: int(width), Height: int(height)}
+}
+
+// framebufferOf is the ping-pong framebuffer a texture returned by apply is attached to, 0 for input textures
+func (p *PostProcessing) framebufferOf(texture uint32) uint32 {
+	for i := range p.textures {
+		if p.textures[i] == texture {
+			return p.fbos[i]
+		}
+	}
+	return 0
+}
+
+// resize recreates the ping-pong framebuffers at the current renderSize
+func (p *PostProcessing) resize() {
+	p.releaseBuffers()
+	p.setupBuffers()
+}
+
+func (p *PostProcessing) releaseBuffers() {
+	p.output = 0 // may be one of the textures
+	for i := range p.fbos {
+		gpuResources.Release(ResourceFramebuffer, p.fbos[i])
+		gpuResources.Release(ResourceTexture, p.textures[i])
+		p.fbos[i], p.textures[i] = 0, 0
+	}
+}
+
+func (p *PostProcessing) destroy() {
+	p.releaseBuffers()
+	for _, effect := range p.effects {
+		gpuResources.Release(ResourceProgram, effect.program)
+		effect.program = 0
+	}
+}
@@ -0,0 +1,2306 @@
+package multisample
+
+//go:generate go run ../../../tools/shadercheck -root .
+
+import (
+	"flag"
+	"fmt"
+	"image/color"
+	"log"
+	"math"
+	"runtime"
+	"strings"
+
+	"github.com/go-gl/example/internal/capture"
+	"github.com/go-gl/gl/v3.2-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/paperboard/glfw/v3.3/glfw"
+)
+
+// TODO: BASIC OPTIMIZATION
+// https://paroj.github.io/gltut/Basic%20Optimization.html
+
+const (
+	windowWidth        = 600 // intended game screen width, but will become larger on high-dpi screens
+	windowHeight       = 400 // intended game screen height, but will become larger on high-dpi screens
+	bytesFloat32       = 4   // a float32 is 4 bytes
+	bytesUint16        = 2   // a uint16 is 2 bytes
+	bytesUint8         = 1   // a uint8 has 1 byte
+	vertexPositionSize = 3   // x,y,z = points in 3D space
+	vertexTexCoordSize = 2   // x,y = texture coordinates
+	vertexColorSize    = 4   // r,g,b,a = color w/ transparency
+	verticesPerQuad    = 4   // a rectangle has 4 vertices
+	indicesPerQuad     = 6   // a rectangle has 6 indices
+	msaaSamples        = 8   // use 8 subsamples per pixel, for multi-sample anti-aliasing (MSAA), to smooth edges
+)
+
+const (
+	reflectionProbeSize = 128 // cubemap face size of the -reflection probe, reflections are blurry anyway
+)
+
+// window title, stats are appended to it at runtime
+const windowTitle = "Quad 3D Multisample"
+
+var (
+	terrainMode     = flag.Bool("terrain", false, "draw a splat-mapped heightmap terrain instead of the quads")
+	pixelArtMode    = flag.Bool("pixelart", false, "render at 320x180 and upscale by a whole factor, for crisp pixel-art")
+	dynamicResFPS   = flag.Float64("dynres", 0, "scale render resolution between 50% and 100% to hold this frame rate, 0 = off")
+	renderScaleArg  = flag.Float64("renderscale", 1, "offscreen resolution relative to the screen, >1 supersamples and <1 undersamples (0.25-2)")
+	targetFPS       = flag.Float64("fps", 60, "limit the frame rate, 0 = unlimited")
+	dumpDir         = flag.String("dumpdir", ".", "directory for frame dumps (see D key)")
+	interleavedMode = flag.Bool("interleaved", false, "store the quads' vertex attributes interleaved (pos+uv+color per vertex) instead of in blocks")
+	benchLayout     = flag.Bool("benchlayout", false, "time color updates with planar and interleaved vertex layouts, print the results and exit")
+	objectCount     = flag.Int("objects", 0, "add this many independent small objects, packed into one buffer arena")
+	terrainStrips   = flag.Bool("strips", false, "draw the -terrain grid as triangle strips (primitive restart) instead of triangles")
+	lodBias         = flag.Float64("lodbias", 1, "scale the camera distance used to pick levels of detail, >1 drops detail sooner (slow GPUs)")
+	noInstancing    = flag.Bool("noinstancing", false, "draw text glyphs as batched quads instead of instances, as without instanced arrays (GL 2.1/GLES2)")
+	fallbackFonts   = flag.String("fonts", "", "comma separated BDF fonts (e.g. GNU Unifont) for the runes the built-in font lacks, tried in order")
+	srgbScreen      = flag.Bool("srgb", false, "ask for an sRGB-capable default framebuffer (see WindowOptions)")
+	transparentMode = flag.Bool("transparent", false, "ask for a transparent window, the desktop shows where the screen alpha is below 1")
+	screenAlphaBits = flag.Int("alphabits", 8, "alpha bits of the default framebuffer, 0 = none")
+	stereoMode      = flag.String("stereo", "off", "draw the scene for two eyes: off, anaglyph (red/cyan glasses), sbs (side-by-side) or tb (top-bottom)")
+	eyeSeparation   = flag.Float64("eyesep", 0.06, "stereo eye separation in world units, larger is deeper")
+	eyeConvergence  = flag.Float64("convergence", 0, "stereo distance that appears at screen depth, 0 = the camera target")
+	reflectionMode  = flag.Bool("reflection", false, "add a mirror quad reflecting the scene, rendered into a cubemap every frame")
+	ssaoMode        = flag.Bool("ssao", false, "darken creases and contact areas by screen-space ambient occlusion (see A key)")
+	fogMode         = flag.String("fog", "off", "fade the quads into the background with distance: off, linear, exp or exp2")
+	fogDensity      = flag.Float64("fogdensity", 0.5, "thickness of -fog exp and exp2 per world unit")
+	waterMode       = flag.Bool("water", false, "fill the -terrain valleys with animated water reflecting the terrain (implies -terrain)")
+	particleMode    = flag.Bool("particles", false, "add a fountain of sparks, expanded from points to quads by a geometry shader or drawn as point sprites (see -sprites)")
+	cpuParticles    = flag.Bool("cpuparticles", false, "simulate -particles on the CPU, as without transform feedback (GLES2)")
+	particleSprites = flag.String("sprites", "auto", "draw -particles as quads (geometry shader) or points (gl_PointSize), auto = quads where geometry shaders are supported")
+	patternName     = flag.String("pattern", "off", "add a quad showing a generated debug texture: off, checker, gradient, grid or uv")
+	canvasMode      = flag.Bool("canvas", false, "add a quad showing an image painted on the CPU, uploaded as it changes")
+	videoSource     = flag.String("video", "", "add a quad playing an animated GIF from this path, or plasma for a synthetic video")
+	pipMode         = flag.Bool("pip", false, "add a quad showing the last frame (picture in picture), which shows itself again and again")
+	lifeMode        = flag.Bool("life", false, "overlay Conway's Game of Life, computed by a fragment shader into float textures")
+	shadowCascades  = flag.Int("cascades", 0, "cast -terrain sun shadows with this many cascaded shadow maps (1-4), 0 = off")
+	graphDumpPath   = flag.String("graphdump", "", "write the render graph (passes, textures, sizes, formats) to this path, as JSON for .json and Graphviz DOT otherwise")
+	workerMode      = flag.Bool("worker", false, "move the camera and add quads from a goroutine, through a command queue run by the main thread")
+	windowCount     = flag.Int("windows", 1, "open this many windows, the extra ones share the GL objects and mirror the screen")
+	windowOpacity   = flag.Float64("opacity", 1, "opacity of the whole window, including its title bar (see [ and ] keys)")
+	alwaysOnTop     = flag.Bool("ontop", false, "keep the window above other windows (see Ctrl+T)")
+	undecorated     = flag.Bool("undecorated", false, "open the window without title bar and border (see Ctrl+B)")
+	assetDir        = flag.String("assets", "", "load shaders from this directory (e.g. ./assets) instead of the embedded ones, and reload them when they change")
+	benchFrames     = flag.Int("benchframes", 600, "frames the bench command measures")
+	sceneName       = flag.String("scene", "msaa", "scene to start with: msaa, triangle, quad or framebuffer (switch with F1 to F4)")
+	bundlePath      = flag.String("bundle", "", "load textures, fonts and shaders from this asset bundle (a zip file, see -packbundle) before the embedded ones")
+	packBundlePath  = flag.String("packbundle", "", "pack the assets (of -assets, or the embedded ones) into this bundle file and exit")
+	validateMode    = flag.Bool("validate", false, "check vertex arrays and texture units against the program before each glDrawElements, print what does not match")
+	depthViewMode   = flag.Bool("depthview", false, "show the depth buffer as grayscale, linearized with the camera clip planes (see Z key)")
+	overdrawMode    = flag.Bool("overdraw", false, "show how often each pixel is shaded as a heat map, black 0 to white 8 or more (see H key)")
+	occlusionMode   = flag.Bool("occlusion", false, "query which quad batches pass the depth test, the -reflection cubemap is skipped while its mirror is hidden")
+	indirectMode    = flag.Bool("indirect", false, "submit the quad batches and -objects as indirect draw commands (OpenGL 4.0+), compare the draw calls on the stats page")
+	vertexColors    = flag.Bool("vertexcolors", false, "randomize the colors of the red and blue rectangles by rewriting and streaming their vertex colors every frame, instead of one tint per quad in a texture")
+	patternTiles    = flag.Int("tiles", 1, "repeat the -pattern texture n x n times on its quad (up to 255), continued by the -wrap mode")
+	patternWrap     = flag.String("wrap", "repeat", "how the -pattern texture continues past its edge: repeat, mirror or clamp")
+	flipSprite      = flag.Bool("flip", false, "mirror the palette sprite left-right, by its texture coordinates")
+	roundedRects    = flag.Bool("rounded", false, "add UI panels drawn as rounded rectangles from a distance field: filled, outlined and both")
+	lineStyles      = flag.Bool("lines", false, "add lines drawn from a distance field: solid, dashed with butt, round and square caps, and a polyline with round joins")
+	curvePaths      = flag.Bool("curves", false, "add vector drawings flattened from Bezier paths: an S curve, a heart and arrows")
+	svgDrawing      = flag.String("svg", "", "add a drawing from an SVG file (a subset: paths, rects, circles, fills, strokes), e.g. drawings/badge.svg")
+	gradientFills   = flag.Bool("gradients", false, "add shapes filled with gradients: a linear rect, a radial rounded rect and a star polygon")
+	controlPanelOn  = flag.Bool("panel", false, "show a control panel (pause, stats page, render scale, post effects) drawn with the UI widgets, in the top-right corner")
+	scriptPath      = flag.String("script", "", "run a demo sequence of camera moves, effects, quads, key presses and screenshots (see loadScript), e.g. scripts/tour.script")
+	recordPath      = flag.String("record", "", "record the keyboard, mouse and gamepad input with the frame times to this file, to replay the session with -replay")
+	replayPath      = flag.String("replay", "", "play back the input recorded with -record, live input is ignored until it ends")
+	noBaseVertex    = flag.Bool("nobasevertex", false, "draw quads past the reach of 16 bit indices by re-pointing vertex attributes, as without glDrawElementsBaseVertex (GL 2.1/GLES2)")
+)
+
+var (
+	dpiScaleX float32 // to adjust width for high dpi/resolution monitors
+	dpiScaleY float32 // to adjust height for high dpi/resolution monitors
+
+	// size of the default framebuffer in pixels, e.g. 1.5 times the window size at 150% scaling (see handleResize)
+	framebufferWidth, framebufferHeight int32
+
+	windowAttributes *WindowAttributes // opacity, floating and decorated, changed at runtime
+)
+
+var (
+	ctxScreen                 = &ContextScreen{}
+	ctxFramebufferMultisample = &ContextFramebufferMultisample{}
+)
+
+// ContextScreen is a real screen
+type ContextScreen struct {
+	quads                *ElementQuads
+	layout               *Layout
+	program              uint32 // connects vertex and fragment shaders (Screen shaders)
+	vbo                  uint32 // stores vertex position, color, texture, and normal array data
+	ibo                  uint32 // the shared quad indices (see QuadIndexBuffer), not owned by this context
+	vao                  uint32 // only need to initalize it, we never use it
+	attribVertexPosition uint32 // reference to position input for shader variable (Screen shaders)
+	attribVertexTexCoord uint32 // reference to texture coordinate input for shader variable (Screen shaders)
+	uniformLeftMask      int32  // reference to uLeftMask uniform, see Stereo
+	uniformRightMask     int32  // reference to uRightMask uniform
+	material             *Material
+}
+
+// ContextFramebufferMultisample is a proxy screen
+type ContextFramebufferMultisample struct {
+	quads                *ElementQuads
+	layout               *Layout
+	program              uint32 // connects vertex and fragment shaders (Framebuffer shaders)
+	fbo                  uint32 // off-screen rendering using framebuffer
+	fboTexture           uint32 // texture attachment for framebuffer color component (to act as proxy for default framebuffer aka. screen)
+	fboRenderbuffer      uint32 // renderbuffer attachment for framebuffer depth & stencil components (to act as proxy for default framebuffer aka. screen)
+	vbo                  uint32 // stores vertex position, color, texture, and normal array data
+	ibo                  uint32 // the shared quad indices (see QuadIndexBuffer), not owned by this context
+	vao                  uint32 // only need to initalize it, we never use it
+	attribVertexPosition uint32 // reference to position input for shader variable (Framebuffer shaders)
+	attribVertexTexCoord uint32 // reference to texture coordinate input for shader variable (Framebuffer shaders)
+	attribVertexColor    uint32 // reference to color input for shader variable (Framebuffer shaders)
+
+	// viewers, both upload the projection and camera uniforms
+	camera   *Camera   // 3D camera
+	camera2D *Camera2D // used instead of camera once it is orthographic, to pan and zoom
+	using2D  bool      // camera2D uploaded the current matrices
+	arcball  *Arcball  // rotates the model matrix with the mouse (3D camera only)
+
+	// fragment shader effects and layers, see Effect and Layer
+	uniformEffect   int32       // reference to uEffect uniform
+	uniformOverdraw int32       // reference to uOverdraw uniform, see Overdraw
+	batches         []quadBatch // index ranges drawn with the same layer and effect
+
+	// textures sampled by the Framebuffer shaders
+	material *Material
+
+	// independent objects packed into one VBO and IBO, see -objects
+	objects         []*arenaObject
+	arena           *BufferArena
+	objectsIndirect bool // objects are interleaved at whole vertices, one indirect call draws them all
+
+	// draw commands of the batches and objects, see -indirect
+	indirect *IndirectBuffer // nil without -indirect or its support
+
+	// colors are rewritten every frame, straight into mapped memory when supported,
+	// otherwise into one of several buffers used in turns (both nil = BufferSubData into the VBO)
+	colorRing    *PersistentRing
+	colorBuffers *DynamicBuffer
+
+	// or the colors stay and a tint per quad changes instead, see QuadTints
+	tints             *QuadTints // nil with -vertexcolors
+	shapes            *ShapeTexture
+	gradients         *GradientRamps
+	uniformGradients  int32 // reference to uGradients uniform, off for the arena objects
+	uniformTinted     int32 // reference to uTinted uniform, off for the arena objects
+	uniformVertexBase int32 // reference to uVertexBase uniform, the rebased first vertex (see QuadIndexBuffer.Draw)
+	sameColors        bool  // keep the colors of the last draw, for the second stereo eye
+
+	// growable VBO, see uploadQuads
+	vertexBuffer     *GrowableBuffer
+	vertexCapacity   int // vertices the layout has room for
+	uploadedVertices int // vertices in the VBO, quads added since are uploaded before the next draw
+
+	// indexed color sprite, see EffectPalette
+	spriteTexture  uint32 // palette indices
+	paletteTexture uint32 // one palette per row
+	uniformPalette int32  // reference to uPalette uniform
+	palette        PaletteSwap
+
+	// generated debug texture, see EffectPattern
+	pattern        Pattern
+	patternTexture uint32        // 0 without -pattern
+	canvas         *Canvas       // nil without -canvas
+	video          *VideoTexture // nil without -video
+
+	// dynamic reflections, see -reflection
+	reflection         *CubemapProbe // nil without reflective quads
+	probing            bool          // the probe is drawing, its matrices replace the cameras' (see drawFrom)
+	uniformEyePosition int32         // reference to uEyePosition uniform
+
+	// visibility of each batch, see -occlusion and BatchVisible
+	occlusion *OcclusionQueries // nil without -occlusion
+}
+
+// ContextFramebuffer is a single-sampled intermediate between
+// multisampled proxy screen and single sampled real screen.
+// Its function is to recieve the blitz operations downscaled pixels.
+// The main scene is resolved by the render graph, this is the right eye's (see Stereo).
+type ContextFramebuffer struct {
+	name string // prefix of the GL object labels
+	pass *Pass  // color texture at renderSize, resized by the renderer
+}
+
+// ElementQuads hold draw elements used by both "real screen" (ContextScreen) and "proxy screen" (ContextFramebuffer)
+type ElementQuads struct {
+	QuadVertices    []float32
+	QuadTexCoords   []uint8
+	OffsetVertices  int
+	OffsetTexCoords int
+
+	// this is total bytes required for VBO buffer
+	// e.g. ContextScreen will add up bytes for both QuadVertices + QuadTexCoords.
+	//      ContextFramebuffer will add up bytes for QuadVertices + QuadTexCoords + QuadColors.
+	BytesTotal int
+
+	// QuadColors is only used by ContextFramebuffer
+	QuadColors   []uint8
+	OffsetColors int
+
+	// QuadEffects and QuadLayers hold the fragment shader effect and layer of each quad, see SortBatches
+	QuadEffects []Effect
+	QuadLayers  []Layer
+
+	// QuadOrders stack the quads within a layer, see SetOrder
+	QuadOrders []int
+	lastOrder  int // of the last NextOrder
+
+	// QuadTints multiply the vertex colors of each quad, see SetTint and QuadTints
+	QuadTints          []color.NRGBA
+	tintsFrom, tintsTo int // quads whose tints changed since the last upload
+
+	// QuadRandomTints mark the quads whose tints change every frame, see SetRandomTint
+	QuadRandomTints []bool
+
+	// QuadShapes are the outlines of EffectRoundedRect quads, see SetShape and ShapeTexture
+	QuadShapes    []QuadShape
+	shapesChanged bool // since the last upload
+
+	// Gradients the quads can be filled with, see SetGradient and GradientRamps
+	Gradients        []Gradient
+	gradientsChanged bool // since the last upload
+
+	// texture coordinates changed by SetUV since the last upload
+	texCoordsDirty bool
+
+	// autoZ assigns increasing z values in draw order, see EnableAutoZ
+	autoZ *autoZ
+}
+
+func init() {
+	// glfw must be on main thread
+	runtime.LockOSThread()
+}
+
+// run opens the window and runs the scene called name until the window is
+// closed, see Run and Bench. The flags are parsed already.
+func run(name string, benchmark *SceneBenchmark) {
+
+	if *waterMode {
+		*terrainMode = true // the lake fills the terrain's valleys
+	}
+	if *dynamicResFPS > 0 {
+		dynamicResolution.enable(*dynamicResFPS)
+	}
+	fog, err := parseFogMode(*fogMode)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	msaaScene.fog = fog
+	if *patternTiles < 1 || *patternTiles > 255 {
+		log.Fatalln("-tiles", *patternTiles, "out of range, use 1 to 255")
+	}
+	if *shadowCascades < 0 || *shadowCascades > shadowCascadesMax {
+		log.Fatalln("-cascades", *shadowCascades, "out of range, use 1 to", shadowCascadesMax, "or 0 for no shadows")
+	}
+	setRenderScale(float32(*renderScaleArg))
+	windowAttributes = newWindowAttributes()
+	var bundle *Bundle
+	if *bundlePath != "" {
+		var err error
+		if bundle, err = OpenBundle(*bundlePath); err != nil {
+			log.Fatalln("failed to open asset bundle:", err)
+		}
+		defer bundle.Close()
+	}
+	assets.setup(*assetDir, bundle)
+	if *packBundlePath != "" {
+		if err := writeBundle(*packBundlePath, assets.source()); err != nil {
+			log.Fatalln("failed to write asset bundle:", err)
+		}
+		return
+	}
+	if err := loadShaders(); err != nil {
+		log.Fatalln("failed to load shaders:", err)
+	}
+
+	// initalize glfw
+	err = glfw.Init()
+	if err != nil {
+		log.Fatalln("failed to initialize glfw:", err)
+	}
+	defer glfw.Terminate()
+
+	// window events reach the scenes through the event bus (see EventBus): keep
+	// the framebuffer and screen at the window size, e.g. after moving to a
+	// screen of another dpi, show dropped image files on a quad, and the input
+	subscribeInput()
+	events.OnResize(handleResize)
+	events.OnDrop(handleDrop)
+
+	// create window and its OpenGL context
+	window := createWindow()
+
+	// demo sequence, e.g. a visual test ending in a screenshot (see Script), before the
+	// scene loads and the recording, a replay seeds the random colors instead
+	var script *ScriptFile
+	if *scriptPath != "" {
+		var err error
+		if script, err = loadScript(*scriptPath); err != nil {
+			log.Fatalln("failed to load script:", err)
+		}
+		scripts.Start(*scriptPath, script.run)
+	}
+
+	// record or replay the input of a session, e.g. for a bug report or a golden image (see Replay),
+	// before the scene loads as it seeds the random colors of the scene
+	switch {
+	case *recordPath != "" && *replayPath != "":
+		log.Fatalln("-record and -replay can not be used together")
+	case *recordPath != "":
+		if err := replay.record(*recordPath, window); err != nil {
+			log.Fatalln("failed to record input:", err)
+		}
+		defer replay.close()
+	case *replayPath != "":
+		if err := replay.play(*replayPath, window); err != nil {
+			log.Fatalln("failed to replay input:", err)
+		}
+	case capture.Enabled():
+		seedRandom(captureSeed) // screenshots of two runs compare (see tools/imagediff)
+	}
+
+	// load game objects and set them up, of the first scene (see SceneLauncher)
+	if err := launcher.start(window, name); err != nil {
+		log.Fatalln(err)
+	}
+	if launcher.Current() != msaaScene && (*benchLayout || capture.Enabled()) {
+		log.Fatalln("-benchlayout, -thumbnail and -screenshot need -scene msaa")
+	}
+
+	// the script runs from the first frame on, its effects exist now that the scene loaded
+	if script != nil {
+		if err := script.check(); err != nil {
+			log.Fatalln("failed to load script:", err)
+		}
+	}
+
+	// compare vertex layouts instead of running, see Layout
+	if *benchLayout {
+		benchmarkLayouts()
+		destroy()
+		gpuTimer.destroy()
+		gpuResources.Close()
+		return
+	}
+
+	// extra windows on the same GL objects (see -windows)
+	sharedWindows.open(window, *windowCount-1)
+
+	// game logic off the main thread, recording into the command queue (see -worker)
+	stopWorker := make(chan struct{})
+	if *workerMode {
+		go runWorker(commandQueue, stopWorker)
+	}
+
+	// run gameloop
+	frameLimiter.setFPS(*targetFPS)
+	previousTime := glfw.GetTime()
+	for !window.ShouldClose() {
+
+		// GPU was reset (driver crash/update), recreate context and rebuild all GL objects
+		if contextRecovery.isLost() {
+			window = recoverContext(window)
+		}
+
+		// rebuild with the shaders edited in the -assets directory
+		if assets.changed() {
+			reloadAssets()
+		}
+
+		// record how long the previous frame took (for frame-time graph)
+		now := glfw.GetTime()
+		elapsed := now - previousTime
+		ctxGraph.record(elapsed)
+		stats.update(window, now)
+		previousTime = now
+
+		// a captured frame is drawn at update time 0, whenever it is drawn
+		if capture.Enabled() && !replay.playing() {
+			elapsed = 0
+		}
+
+		// updates follow the update clock, it stands still while paused (see handleKey)
+		updateTime, dt := clock.advance(replay.frameTime(elapsed))
+		scene := launcher.Current()
+		scene.Update(updateTime, dt)
+
+		// update uTime and uResolution of every program
+		shaderGlobals.update(updateTime)
+
+		// draw into buffer, timing how long the GPU takes
+		drawStart := glfw.GetTime()
+		gpuTimer.begin()
+		scene.Draw()
+		gpuTimer.end()
+
+		// scale render resolution to the time the last frames took, CPU time if the GPU can not be timed
+		if gpuTime, ok := gpuTimer.result(); ok {
+			dynamicResolution.update(gpuTime)
+		} else {
+			dynamicResolution.update(glfw.GetTime() - drawStart)
+		}
+
+		// bench command, measure the frames and quit
+		if benchmark != nil && benchmark.record(elapsed) {
+			benchmark.report(launcher.Current().Name())
+			break
+		}
+
+		// draw calls of the frame, for the stats page
+		quadDrawCalls.endFrame()
+
+		// write color, depth and post-processing stages to png files, if requested (see handleKey)
+		frameDump.write(*dumpDir)
+
+		// print the pixel under the cursor at each stage, if requested (see handleMouseButton)
+		pixelProbe.read()
+
+		// run the scripts up to their next frame, they see the frame just drawn (see Script)
+		scripts.step(window, updateTime)
+
+		// thumbnail and screenshot mode, save the first frame (after a replay, the first after it) and quit
+		if capture.Enabled() && !replay.playing() {
+			capture.Save(renderToImage())
+			break
+		}
+
+		// hold the target frame rate
+		frameLimiter.wait()
+
+		// render buffer to screen
+		window.SwapBuffers()
+
+		// the same image in the extra windows (see -windows)
+		sharedWindows.draw(window)
+
+		// delete GL objects released in earlier frames, once the GPU is done with them
+		deletionQueue.endFrame()
+
+		// glfw events?
+		glfw.PollEvents()
+		events.pollGamepads()
+		replay.input(window)
+
+	}
+
+	// free GL objects owned by each context, then report and delete anything left behind
+	close(stopWorker)
+	sharedWindows.destroy(window)
+	launcher.Current().Unload()
+	gpuTimer.destroy()
+	gpuResources.Close()
+
+}
+
+// createWindow opens the window, makes its OpenGL context current and initializes OpenGL
+func createWindow() *glfw.Window {
+
+	// suggest glfw to use OpenGL v3.2 -- NOTE: minimum required for proper support for texture anti-aliasing (multisample)
+	// https://www.khronos.org/opengl/wiki/Multisampling
+	// https://www.khronos.org/opengl/wiki/Framebuffer#Multisampling_Considerations
+	// https://developer.apple.com/opengl/OpenGL-Capabilities-Tables.pdf
+	// https://developer.nvidia.com/sites/default/files/akamai/gamedev/docs/Porting%20Source%20to%20Linux.pdf
+	// https://www.khronos.org/opengl/wiki/Common_Mistakes
+	glfw.WindowHint(glfw.ContextVersionMajor, 3)
+	glfw.WindowHint(glfw.ContextVersionMinor, 2)
+	glfw.WindowHint(glfw.OpenGLProfile, glfw.OpenGLCoreProfile)
+
+	// suggest glfw to disable window resizing
+	glfw.WindowHint(glfw.Resizable, glfw.False)
+
+	// ask for a robust context, so a GPU reset is reported instead of crashing (see ContextRecovery)
+	glfw.WindowHint(glfw.ContextRobustness, glfw.LoseContextOnReset)
+
+	// default framebuffer format, sRGB and transparency (see WindowOptions)
+	newWindowOptions().hint()
+
+	// thumbnail and screenshot mode render offscreen, no need to show the window
+	if capture.Enabled() {
+		glfw.WindowHint(glfw.Visible, glfw.False)
+	}
+
+	// create window handle
+	window, err := glfw.CreateWindow(windowWidth, windowHeight, windowTitle, nil, nil)
+	if err != nil {
+		panic(err)
+	}
+	window.MakeContextCurrent()
+
+	// unlimited frame rate (-fps 0, bench) must not wait for vsync either
+	if *targetFPS <= 0 {
+		glfw.SwapInterval(0)
+	}
+
+	// opacity, always on top and decorations, as last set (see WindowAttributes)
+	windowAttributes.apply(window)
+
+	// pixel dimension and texel dimensions are not the same in high resolution monitors
+	// so we must account for that in many of the functions we use.
+	// e.g. gl.Viewport, gl.Scissor, gl.ReadPixels, gl.LineWidth, gl.RenderbufferStorage, and gl.TexImage2D
+	dpiScaleX, dpiScaleY = window.GetContentScale()
+	width, height := window.GetFramebufferSize()
+	framebufferWidth, framebufferHeight = int32(width), int32(height)
+
+	// resize, keyboard, mouse and dropped files are published to the subscribers of the event bus
+	events.attach(window)
+
+	// initialize OpenGL
+	err = gl.Init()
+	if err != nil {
+		panic(err)
+	}
+
+	// version, limits and extensions, every feature check below reads them
+	glInfo = newGLInfo()
+	fmt.Println(glInfo)
+	if !glInfo.MultisampleTextures() {
+		panic("multisample textures (OpenGL 3.2 or ARB_texture_multisample) are required")
+	}
+
+	// choose between fences and frame counting for deferred deletes
+	deletionQueue.setup()
+
+	// count texture units, for materials
+	textureUnits.setup()
+
+	// sRGB texture support, for color textures
+	setupTextureFormats()
+
+	// timer queries, for dynamic resolution
+	gpuTimer.setup()
+
+	// persistently mapped buffers, for the colors updated every frame
+	setupPersistentMapping()
+
+	// restarting triangle strips, for the terrain grid (see -strips)
+	setupPrimitiveRestart()
+
+	// watch for context loss, if the driver supports it
+	contextRecovery.setup()
+
+	return window
+
+}
+
+// on window size change (by OS or user resize) this handler executes, width
+// and height are in pixels: larger than the window size on high-dpi screens
+func handleResize(e ResizeEvent) {
+	window, width, height := e.Window, e.Width, e.Height
+
+	// minimized, or no change
+	if width == 0 || height == 0 || (int32(width) == framebufferWidth && int32(height) == framebufferHeight) {
+		return
+	}
+	framebufferWidth, framebufferHeight = int32(width), int32(height)
+	dpiScaleX, dpiScaleY = window.GetContentScale()
+
+	// the other scenes only have passes, see SceneLauncher
+	if launcher.Current() != msaaScene {
+		renderer.resize()
+		return
+	}
+
+	// GL objects do not exist yet, setup will use the new size
+	if ctxFramebufferMultisample.fbo == 0 {
+		return
+	}
+
+	// the offscreen framebuffers follow the screen, viewports follow the
+	// attachments of each pass (see RenderGraph.execute and Pass.Bind)
+	resizeOffscreen()
+	fmt.Printf("screen %vx%v\n", width, height)
+
+}
+
+// setup creates the GL objects of the msaa scene, fog is the -fog mode
+func setup(fog FogMode) {
+
+	// fill the index buffer all quads are drawn with
+	quadIndices.setup()
+
+	// prepare screen program and buffers (vbo, ibo)
+	ctxScreen.setupProgram()
+	ctxScreen.setupBuffers()
+
+	// prepare framebuffer program and buffers (vbo, ibo, fbo), camera and model rotation
+	ctxFramebufferMultisample.setupProgram()
+	ctxFramebufferMultisample.setupBuffers()
+	ctxFramebufferMultisample.camera = NewCamera(ctxFramebufferMultisample.program, 90, mgl32.Vec3{0, 0, 0.5}, mgl32.Vec3{0.1, 0.1, -1})
+	ctxFramebufferMultisample.camera2D = ctxFramebufferMultisample.camera.Camera2D()
+	ctxFramebufferMultisample.arcball = NewArcball(ctxFramebufferMultisample.program, mgl32.Vec3{0, 0, -1.15})
+
+	// fit depth range to the scene for best depth precision
+	sceneMin, sceneMax := ctxFramebufferMultisample.quads.Bounds()
+	if err := ctxFramebufferMultisample.camera.FitClipPlanes(sceneMin, sceneMax); err != nil {
+		panic(err)
+	}
+	if err := ctxFramebufferMultisample.camera2D.FitClipPlanes(sceneMin, sceneMax); err != nil {
+		panic(err)
+	}
+
+	// fog into the gray of the proxy screen, linear fog spans the depth range of the scene
+	near, far := ctxFramebufferMultisample.camera.ClipPlanes()
+	ctxFramebufferMultisample.material.SetFog(Fog{Mode: fog, Color: mgl32.Vec3{0.5, 0.5, 0.5}, Start: near, End: far, Density: float32(*fogDensity)})
+
+	// prepare terrain program and buffers (vbo, ibo, textures)
+	if *terrainMode {
+		ctxTerrain.setupProgram()
+		ctxTerrain.setupBuffers()
+	}
+
+	// prepare water program and buffers (vbo, ibo, reflection fbo)
+	if *waterMode {
+		ctxWater.setupProgram()
+		ctxWater.setupBuffers()
+	}
+
+	// prepare particle program and buffers (streamed vbo)
+	if *particleMode {
+		ctxParticles.setupProgram()
+		ctxParticles.setupBuffers()
+	}
+
+	// prepare the right eye framebuffer, if drawing in stereo
+	stereo.setup()
+
+	// prepare post-processing effects programs and ping-pong framebuffers
+	postProcessing.setupProgram()
+	postProcessing.setupBuffers()
+
+	// order the passes of a frame and allocate the textures between them, e.g. the resolved scene
+	setupRenderGraph()
+
+	// prepare ambient occlusion programs and textures, composited by a post effect
+	ssao.setupProgram()
+	ssao.setupBuffers()
+
+	// prepare the depth texture of the depth view, drawn by its post effect
+	depthView.setupBuffers()
+
+	// prepare the counter of the overdraw heat map, drawn by its post effect
+	overdraw.setupBuffers()
+
+	// prepare the Game of Life grid, stepped by its post effect
+	if *lifeMode {
+		life.setupBuffers()
+	}
+
+	// prepare frame-time graph overlay program and buffers (vbo, ibo)
+	ctxGraph.setupProgram()
+	ctxGraph.setupBuffers()
+
+	// prepare text overlay program and buffers (vbo, font atlas)
+	ctxText.setupProgram()
+	ctxText.setupBuffers()
+
+}
+
+// unit cube
+//
+//    v6----- v5
+//   /|      /|
+//  v1------v0|
+//  | |     | |
+//  | v7----|-v4
+//  |/      |/
+//  v2------v3
+//
+func makeQuadVertices(w, h, z float32) []float32 {
+	return []float32{
+		(w * 0.5), (h * 0.5), z, // v0 position = top-right
+		-(w * 0.5), (h * 0.5), z, // v1 position = top-left
+		-(w * 0.5), -(h * 0.5), z, // v2 position = bottom-left
+		(w * 0.5), -(h * 0.5), z, // v3 position = bottom-right
+	}
+}
+
+// same as makeQuadVertices, but quad is centered at x,y instead of origin
+func makeQuadVerticesAt(x, y, w, h, z float32) []float32 {
+	return []float32{
+		x + (w * 0.5), y + (h * 0.5), z, // v0 position = top-right
+		x - (w * 0.5), y + (h * 0.5), z, // v1 position = top-left
+		x - (w * 0.5), y - (h * 0.5), z, // v2 position = bottom-left
+		x + (w * 0.5), y - (h * 0.5), z, // v3 position = bottom-right
+	}
+}
+
+// texture 2D unit quad
+//
+// (0,1)    (1,1)
+//  v1------v0
+//  |       |
+//  |       |
+//  |       |
+//  v2------v3
+// (0,0)    (1,0)
+//
+// https://web.cse.ohio-state.edu/~shen.94/581/Site/Slides_files/texture.pdf
+func makeQuadTextureCoord() []uint8 {
+	return []uint8{
+		1, 1, // v0 = texel @ top-right in texture coordinate system
+		0, 1, // v1 = texel @ top-left in texture coordinate system
+		0, 0, // v2 = texel @ bottom-left in texture coordinate system
+		1, 0, // v3 = texel @ bottom-right in texture coordinate system
+	}
+}
+
+func makeQuadColors(clr color.NRGBA) []uint8 {
+	// all 4 vertex (v0, v1, v2, v3) should have same color
+	return []uint8{
+		clr.R, clr.G, clr.B, clr.A, // v0
+		clr.R, clr.G, clr.B, clr.A, // v1
+		clr.R, clr.G, clr.B, clr.A, // v2
+		clr.R, clr.G, clr.B, clr.A, // v3
+	}
+}
+
+func (q *ElementQuads) DebugPrint() {
+	fmt.Printf("RECT_COUNT -- Rectangles: %v\n", q.QuadCount())
+	fmt.Printf("RAW_LENGTH -- Rectangle has %v vertex\nVertices   %v (%v-per-vertex)\nTexCoord   %v (%v-per-vertex)\nColors     %v (%v-per-vertex)\nIndices    %v (%v-per-rectangle)\n", verticesPerQuad, len(q.QuadVertices), vertexPositionSize, len(q.QuadTexCoords), vertexTexCoordSize, len(q.QuadColors), vertexColorSize, q.QuadCount()*indicesPerQuad, indicesPerQuad)
+}
+
+func (q *ElementQuads) DrawRectangle(w float32, h float32, z float32, clr color.NRGBA) {
+	q.QuadVertices = append(q.QuadVertices, makeQuadVertices(w, h, q.zFor(z))...)
+	q.QuadTexCoords = append(q.QuadTexCoords, makeQuadTextureCoord()...)
+	q.QuadColors = append(q.QuadColors, makeQuadColors(clr)...)
+	q.QuadEffects = append(q.QuadEffects, EffectColor)
+	q.QuadLayers = append(q.QuadLayers, LayerWorld)
+	q.QuadOrders = append(q.QuadOrders, 0)
+	q.addTint()
+	q.addShape()
+}
+
+func (q *ElementQuads) DrawRectangleAt(x float32, y float32, w float32, h float32, z float32, clr color.NRGBA) {
+	q.QuadVertices = append(q.QuadVertices, makeQuadVerticesAt(x, y, w, h, q.zFor(z))...)
+	q.QuadTexCoords = append(q.QuadTexCoords, makeQuadTextureCoord()...)
+	q.QuadColors = append(q.QuadColors, makeQuadColors(clr)...)
+	q.QuadEffects = append(q.QuadEffects, EffectColor)
+	q.QuadLayers = append(q.QuadLayers, LayerWorld)
+	q.QuadOrders = append(q.QuadOrders, 0)
+	q.addTint()
+	q.addShape()
+}
+
+// Reset removes all quads (keeping the storage of their slices) so they can
+// be drawn again from scratch, like once per frame. Gradients are kept.
+// Every per-quad slice is truncated, so they stay in step with the vertices,
+// and the tints and shapes are uploaded in full next time.
+func (q *ElementQuads) Reset() {
+	q.QuadVertices = q.QuadVertices[:0]
+	q.QuadTexCoords = q.QuadTexCoords[:0]
+	q.QuadColors = q.QuadColors[:0]
+	q.QuadEffects = q.QuadEffects[:0]
+	q.QuadLayers = q.QuadLayers[:0]
+	q.QuadOrders = q.QuadOrders[:0]
+	q.lastOrder = 0
+	q.QuadTints = q.QuadTints[:0]
+	q.QuadRandomTints = q.QuadRandomTints[:0]
+	q.tintsFrom, q.tintsTo = 0, 0
+	q.QuadShapes = q.QuadShapes[:0]
+	q.shapesChanged = true
+	if q.autoZ != nil {
+		q.autoZ.next = 0
+	}
+}
+
+// SetLayout computes the layout for the current quads and fills BytesTotal
+// and the Offset* fields of the attributes it has ("position", "texcoord" and "color").
+// Offsets of an interleaved layout are within a vertex, use Upload and UploadColors for those.
+func (q *ElementQuads) SetLayout(layout *Layout) {
+	q.SetLayoutFor(layout, q.vertexCount())
+}
+
+// SetLayoutFor is SetLayout with room for more vertices than the quads have now
+func (q *ElementQuads) SetLayoutFor(layout *Layout, vertices int) {
+	layout.Compute(vertices)
+	q.BytesTotal = layout.BytesTotal
+	if layout.Has("position") {
+		q.OffsetVertices = layout.Offset("position")
+	}
+	if layout.Has("texcoord") {
+		q.OffsetTexCoords = layout.Offset("texcoord")
+	}
+	if layout.Has("color") {
+		q.OffsetColors = layout.Offset("color")
+	}
+}
+
+func (q *ElementQuads) vertexCount() int {
+	return len(q.QuadVertices) / vertexPositionSize
+}
+
+// QuadCount is the number of quads, they are drawn with the shared quad indices (see QuadIndexBuffer)
+func (q *ElementQuads) QuadCount() int {
+	return q.vertexCount() / verticesPerQuad
+}
+
+// attribData is the vertex data by layout attribute name
+func (q *ElementQuads) attribData() map[string][]byte {
+	return map[string][]byte{
+		"position": float32Bytes(q.QuadVertices),
+		"texcoord": q.QuadTexCoords,
+		"color":    q.QuadColors,
+	}
+}
+
+// Upload copies all vertex data into the bound VBO, storage must be allocated (BufferData)
+func (q *ElementQuads) Upload(layout *Layout) {
+	data := layout.Pack(q.vertexCount(), q.attribData())
+	gl.BufferSubData(gl.ARRAY_BUFFER, 0, len(data), gl.Ptr(data))
+}
+
+// UploadColors copies QuadColors into the bound VBO. Planar layouts update
+// just the color block, interleaved layouts have colors spread over every
+// vertex and re-upload the whole buffer.
+func (q *ElementQuads) UploadColors(layout *Layout) {
+	if layout.Interleaved {
+		q.Upload(layout)
+		return
+	}
+	gl.BufferSubData(gl.ARRAY_BUFFER, q.OffsetColors, len(q.QuadColors)*bytesUint8, gl.Ptr(q.QuadColors))
+}
+
+// Bounds returns the bounding box of all vertices (object coordinates)
+func (q *ElementQuads) Bounds() (min, max mgl32.Vec3) {
+	if len(q.QuadVertices) < vertexPositionSize {
+		return min, max
+	}
+	min = mgl32.Vec3{q.QuadVertices[0], q.QuadVertices[1], q.QuadVertices[2]}
+	max = min
+	for i := vertexPositionSize; i+vertexPositionSize <= len(q.QuadVertices); i += vertexPositionSize {
+		for axis := 0; axis < vertexPositionSize; axis++ {
+			v := q.QuadVertices[i+axis]
+			if v < min[axis] {
+				min[axis] = v
+			}
+			if v > max[axis] {
+				max[axis] = v
+			}
+		}
+	}
+	return min, max
+}
+
+func destroy() {
+	ctxText.destroy()
+	ctxGraph.destroy()
+	life.destroy()
+	ssao.destroy()
+	depthView.destroy()
+	overdraw.destroy()
+	dropped.destroy()
+	postProcessing.destroy()
+	stereo.destroy()
+	renderGraph.destroy()
+	ctxParticles.destroy()
+	ctxWater.destroy()
+	ctxTerrain.destroy()
+	ctxFramebufferMultisample.destroy()
+	ctxScreen.destroy()
+	quadIndices.destroy()
+}
+
+func load() {
+	registerPassOutputs()
+	ctxScreen.load()
+	ctxFramebufferMultisample.load()
+	ctxGraph.load()
+	ctxText.load()
+	stereo.load()
+	ssao.load()
+	if *lifeMode {
+		life.load()
+	}
+	postProcessing.add("outline", &fragmentShaderOutline, false)
+	crt := postProcessing.add("crt", &fragmentShaderCRT, false)
+	crt.SetParam("curvature", 0.08)
+	crt.SetParam("scanlines", 0.35)
+	crt.SetParam("aberration", 1.5)
+	depthView.load()
+	overdraw.load()
+	if *terrainMode {
+		ctxTerrain.load()
+	}
+	if *waterMode {
+		ctxWater.load()
+	}
+	if *particleMode {
+		ctxParticles.load()
+	}
+	if *controlPanelOn {
+		controlPanel.load()
+	}
+}
+
+func (ctx *ContextScreen) load() {
+
+	// initalize screen quads
+	ctx.quads = &ElementQuads{
+		QuadVertices:    []float32{},
+		QuadTexCoords:   []uint8{},
+		OffsetVertices:  0,
+		OffsetTexCoords: 0,
+		BytesTotal:      0, // will be calculated to the total bytes needed for VBO buffer (QuadVertices + QuadTexCoords)
+	}
+
+	// TODO: make makeQuadVertices more generalized by introducing x,y,z positions as well as width, height values.
+	// a single quad to cover entire screen in white
+	//ctx.quads.QuadVertices = append(ctx.quads.QuadVertices, makeQuadVertices(1, 1, 0)...) // z-depth does not matter, we disable DEPTH_TEST for "real screen"
+	ctx.quads.QuadVertices = []float32{
+		1, 1, 0, // v0 position = top-right
+		-1, 1, 0, // v1 position = top-left
+		-1, -1, 0, // v2 position = bottom-left
+		1, -1, 0, // v3 position = bottom-right
+	}
+	ctx.quads.QuadTexCoords = append(ctx.quads.QuadTexCoords, makeQuadTextureCoord()...)
+
+}
+
+func (ctx *ContextFramebufferMultisample) load() {
+
+	// initalize framebuffer quads
+	ctx.quads = &ElementQuads{
+		QuadVertices:    []float32{},
+		QuadTexCoords:   []uint8{},
+		OffsetVertices:  0,
+		OffsetTexCoords: 0,
+		BytesTotal:      0, // will be calculated to the total bytes needed for VBO buffer (QuadVertices + QuadTexCoords + QuadColors)
+		QuadColors:      []uint8{},
+		OffsetColors:    0,
+	}
+
+	// draw red rectangle
+	ctx.quads.DrawRectangle(2, 2, -1.2, color.NRGBA{1, 0, 0, 1})
+
+	// draw blue rectangle
+	ctx.quads.DrawRectangle(1, 1, -1.1, color.NRGBA{0, 0, 255, 1})
+
+	// mix effects, blue rectangle uses animated stripes
+	ctx.quads.SetEffect(1, EffectStripes)
+
+	// both get a random color every frame, the quads added below keep theirs
+	ctx.quads.SetRandomTint(0, true)
+	ctx.quads.SetRandomTint(1, true)
+
+	// red rectangle is the backdrop, drawn first whatever its depth
+	ctx.quads.SetLayer(0, LayerBackground)
+
+	// indexed color sprite in the corner, colored by a palette
+	ctx.quads.DrawRectangleAt(0.6, 0.6, 0.5, 0.5, -1.0, color.NRGBA{255, 255, 255, 255})
+	ctx.quads.SetEffect(2, EffectPalette)
+	if *flipSprite {
+		ctx.quads.SetUV(2, QuadUV{FlipX: true})
+	}
+
+	// mirror in the opposite corner, between the backdrop and the blue rectangle
+	if *reflectionMode {
+		ctx.quads.DrawRectangleAt(-0.6, -0.6, 0.5, 0.5, -1.15, color.NRGBA{200, 220, 255, 255})
+		ctx.quads.SetEffect(3, EffectMirror)
+	}
+
+	// debug texture in the upper left corner, white so the texels show unchanged
+	pattern, err := parsePattern(*patternName)
+	if err != nil {
+		panic(err)
+	}
+	ctx.pattern = pattern
+	if pattern != PatternOff {
+		ctx.quads.DrawRectangleAt(-0.6, 0.6, 0.5, 0.5, -1.0, color.NRGBA{255, 255, 255, 255})
+		ctx.quads.SetEffect(ctx.quads.QuadCount()-1, EffectPattern)
+		tiles := uint8(*patternTiles)
+		ctx.quads.SetUV(ctx.quads.QuadCount()-1, QuadUV{RepeatX: tiles, RepeatY: tiles})
+	}
+
+	// live canvas in the lower right corner
+	if *canvasMode {
+		ctx.quads.DrawRectangleAt(0.6, -0.6, 0.5, 0.5, -1.0, color.NRGBA{255, 255, 255, 255})
+		ctx.quads.SetEffect(ctx.quads.QuadCount()-1, EffectCanvas)
+	}
+
+	// video at the top, between the pattern and the sprite
+	if *videoSource != "" {
+		ctx.quads.DrawRectangleAt(0, 0.6, 0.5, 0.5, -1.0, color.NRGBA{255, 255, 255, 255})
+		ctx.quads.SetEffect(ctx.quads.QuadCount()-1, EffectVideo)
+	}
+
+	// the last frame at the bottom, a feedback loop through the "post" pass output
+	if *pipMode {
+		ctx.quads.DrawRectangleAt(0, -0.6, 0.5, 0.5, -1.0, color.NRGBA{255, 255, 255, 255})
+		ctx.quads.SetEffect(ctx.quads.QuadCount()-1, EffectPass)
+	}
+
+	// rounded panels along the bottom edge, on the UI layer
+	if *roundedRects {
+		ctx.quads.DrawRoundedRect(Rect{-0.6, -0.9, 0.5, 0.16}, -1.0, 0.08, color.NRGBA{40, 120, 220, 255}, 0, color.NRGBA{})
+		ctx.quads.DrawRoundedRect(Rect{0, -0.9, 0.5, 0.16}, -1.0, 0.04, color.NRGBA{}, 0.015, color.NRGBA{255, 255, 255, 255})
+		ctx.quads.DrawRoundedRect(Rect{0.6, -0.9, 0.5, 0.16}, -1.0, 0.08, color.NRGBA{30, 30, 30, 200}, 0.02, color.NRGBA{255, 200, 0, 255})
+	}
+
+	// line styles across the top, a zigzag below them
+	if *lineStyles {
+		white := color.NRGBA{255, 255, 255, 255}
+		ctx.quads.DrawLine(-0.9, 0.95, 0.9, 0.95, -1.0, white, LineStyle{Width: 0.01})
+		ctx.quads.DrawLine(-0.9, 0.9, 0.9, 0.9, -1.0, white, LineStyle{Width: 0.02, Cap: LineCapButt, Dash: 0.08, Gap: 0.04})
+		ctx.quads.DrawLine(-0.9, 0.85, 0.9, 0.85, -1.0, white, LineStyle{Width: 0.02, Cap: LineCapRound, Dash: 0.06, Gap: 0.06})
+		ctx.quads.DrawLine(-0.9, 0.8, 0.9, 0.8, -1.0, white, LineStyle{Width: 0.02, Cap: LineCapSquare, Dash: 0.06, Gap: 0.06})
+		zigzag := []mgl32.Vec2{{-0.9, 0.7}, {-0.6, 0.55}, {-0.3, 0.7}, {0, 0.55}, {0.3, 0.7}, {0.6, 0.55}, {0.9, 0.7}}
+		ctx.quads.DrawPolyline(zigzag, -1.0, color.NRGBA{255, 160, 0, 255}, LineStyle{Width: 0.03, RoundJoins: true})
+	}
+
+	// vector drawings in the middle, curves flattened to within a fraction of a pixel
+	if *curvePaths {
+		curve := NewPath(0.002).MoveTo(-0.8, -0.3).CubicTo(-0.5, 0.4, -0.3, -0.7, 0, 0).QuadTo(0.2, 0.3, 0.4, 0)
+		ctx.quads.StrokePath(curve, -1.0, color.NRGBA{120, 220, 255, 255}, LineStyle{Width: 0.02, Cap: LineCapRound})
+		heart := NewPath(0.002).MoveTo(0.65, -0.25).
+			CubicTo(0.45, -0.05, 0.45, 0.15, 0.57, 0.15).QuadTo(0.65, 0.15, 0.65, 0.05).
+			QuadTo(0.65, 0.15, 0.73, 0.15).CubicTo(0.85, 0.15, 0.85, -0.05, 0.65, -0.25).Close()
+		ctx.quads.StrokePath(heart, -1.0, color.NRGBA{255, 80, 120, 255}, LineStyle{Width: 0.015, Dash: 0.03, Gap: 0.015, Cap: LineCapRound})
+		ctx.quads.DrawArrow(-0.2, -0.4, 0.1, -0.2, -1.0, 0.06, color.NRGBA{255, 255, 255, 255}, LineStyle{Width: 0.012, Cap: LineCapRound})
+		ctx.quads.DrawArrow(0.2, -0.4, 0.5, -0.4, -1.0, 0.06, color.NRGBA{255, 255, 255, 255}, LineStyle{Width: 0.012, Cap: LineCapSquare})
+	}
+
+	// SVG drawing in the middle
+	if *svgDrawing != "" {
+		drawing, err := loadSVG(*svgDrawing)
+		if err != nil {
+			panic(err)
+		}
+		ctx.quads.DrawSVG(drawing, Rect{0, 0, 1, 0.6}, -1.0)
+	}
+
+	// gradient fills on the left edge, drawn white so the stops show unchanged
+	if *gradientFills {
+		white := color.NRGBA{255, 255, 255, 255}
+		sunset := []GradientStop{{0, color.NRGBA{40, 20, 120, 255}}, {0.6, color.NRGBA{230, 80, 90, 255}}, {1, color.NRGBA{255, 210, 80, 255}}}
+		panel := Rect{-0.85, 0.3, 0.25, 0.25}
+		ctx.quads.DrawRectangleAt(panel.X, panel.Y, panel.W, panel.H, -1.0, white)
+		ctx.quads.SetGradient(ctx.quads.QuadCount()-1, ctx.quads.QuadCount(), ctx.quads.AddGradient(LinearGradient(panel, 90, sunset...)))
+
+		button := Rect{-0.85, 0, 0.25, 0.25}
+		glow := RadialGradient(mgl32.Vec2{button.X, button.Y}, 0.15, GradientStop{0, white}, GradientStop{1, color.NRGBA{20, 90, 200, 255}})
+		ctx.quads.DrawRoundedRect(button, -1.0, 0.05, white, 0.01, white)
+		ctx.quads.SetGradient(ctx.quads.QuadCount()-1, ctx.quads.QuadCount(), ctx.quads.AddGradient(glow))
+
+		var star []mgl32.Vec2
+		for i := 0; i < 10; i++ {
+			radius := float32(0.13)
+			if i%2 == 1 {
+				radius = 0.055
+			}
+			angle := math.Pi/2 + float64(i)*math.Pi/5
+			star = append(star, mgl32.Vec2{-0.85 + radius*float32(math.Cos(angle)), -0.3 + radius*float32(math.Sin(angle))})
+		}
+		first := ctx.quads.QuadCount()
+		ctx.quads.FillPolygon(star, -1.0, white)
+		ctx.quads.SetGradient(first, ctx.quads.QuadCount(), ctx.quads.AddGradient(LinearGradient(Rect{-0.85, -0.3, 0.26, 0.26}, -45, sunset...)))
+	}
+
+	// independent objects in a grid, each with its own quads (see BufferArena)
+	ctx.objects = nil
+	columns := int(math.Ceil(math.Sqrt(float64(*objectCount))))
+	for i := 0; i < *objectCount; i++ {
+		size := 1.6 / float32(columns)
+		x := -0.8 + size*(float32(i%columns)+0.5)
+		y := -0.8 + size*(float32(i/columns)+0.5)
+		object := &arenaObject{quads: &ElementQuads{}}
+		object.quads.DrawRectangleAt(x, y, size*0.8, size*0.8, -1.15, RandomColorInRGBA())
+		ctx.objects = append(ctx.objects, object)
+	}
+
+	// print debug info for shapes
+	ctx.quads.DebugPrint()
+
+}
+
+func draw() {
+
+	// run the passes of the frame, see setupRenderGraph
+	renderGraph.execute()
+
+	// check for accumulated OpenGL errors
+	//CheckGLError()
+
+}
+
+// setupRenderGraph declares the passes of a frame and the textures between them:
+//
+//	right eye  stereo only, draws and post-processes the right eye into "right"
+//	scene      draws the quads or terrain into the multisample "scene"
+//	post       post-processing effects on the resolved scene (resolved by the graph), into "post"
+//	screen     the rendered image and the overlays onto the real screen
+func setupRenderGraph() {
+
+	renderGraph = NewRenderGraph()
+	renderDesc := TextureDesc{InternalFormat: gl.RGBA, Format: gl.RGBA, Size: SizeRender}
+	screenDesc := TextureDesc{InternalFormat: gl.RGBA, Format: gl.RGBA, Size: SizeScreen}
+	sceneDesc := renderDesc
+	sceneDesc.Samples = msaaSamples
+
+	renderGraph.Import("scene", sceneDesc, func() uint32 { return ctxFramebufferMultisample.fboTexture }, func() uint32 { return ctxFramebufferMultisample.fbo })
+	renderGraph.Import("post", renderDesc, func() uint32 { return postProcessing.output }, nil)
+	renderGraph.Import("screen", screenDesc, func() uint32 { return 0 }, func() uint32 { return 0 })
+
+	// in stereo the right eye is drawn first and kept aside, the rest of the frame is the left eye
+	screenReads := []string{"post"}
+	if stereo.mode != StereoOff {
+		renderGraph.Import("right", renderDesc, func() uint32 { return stereo.right.pass.ColorTexture() }, func() uint32 { return stereo.right.pass.Framebuffer() })
+		renderGraph.AddPass(&RenderPass{Name: "right eye", Writes: []string{"right"}, Run: func(_ *RenderGraph) {
+			stereo.drawRightEye()
+		}})
+		screenReads = append(screenReads, "right")
+	}
+
+	// bind proxy offscreen (framebuffer) and draw elements, reflective quads need their surroundings first (see -reflection)
+	renderGraph.AddPass(&RenderPass{Name: "scene", Writes: []string{"scene"}, Run: func(_ *RenderGraph) {
+		if ctxFramebufferMultisample.reflection != nil && !*terrainMode {
+			// the GPU skips the faces while the mirror was hidden (see -occlusion)
+			query := ctxFramebufferMultisample.mirrorQuery()
+			conditional := query != nil && query.BeginConditional()
+			ctxFramebufferMultisample.reflection.Update(ctxFramebufferMultisample.drawFrom)
+			if conditional {
+				query.EndConditional()
+			}
+		}
+		drawScene()
+	}})
+
+	// run post-processing effects, screen samples their result (or the resolved scene if none is enabled)
+	renderGraph.AddPass(&RenderPass{Name: "post", Reads: []string{"scene"}, Writes: []string{"post"}, Run: func(g *RenderGraph) {
+		postProcessing.apply(g.Texture("scene"))
+	}})
+
+	// bind real screen and draw rasterized texture (output from framebuffer)
+	// in other words, using the proxy screen's rendered image, overlay ontop real screen using a single quad
+	renderGraph.AddPass(&RenderPass{Name: "screen", Reads: screenReads, Writes: []string{"screen"}, Run: func(g *RenderGraph) {
+
+		downsampled, rightEye := g.Texture("post"), g.Texture("post") // right is masked out without stereo, see Stereo.views
+		if stereo.mode != StereoOff {
+			rightEye = g.Texture("right")
+		}
+		ctxScreen.material.SetTexture("downsampledTexture", gl.TEXTURE_2D, downsampled)
+		ctxScreen.material.SetTexture("rightTexture", gl.TEXTURE_2D, rightEye)
+		stereo.endFrame()
+
+		ctxScreen.bind()
+		ctxScreen.draw()
+
+		// overlay frame-time graph ontop real screen
+		ctxGraph.bind()
+		ctxGraph.draw()
+
+		// overlay text queued during the frame, e.g. the stats page and the control panel
+		if stats.page {
+			stats.drawPage()
+		}
+		controlPanel.draw()
+		ctxText.bind()
+		ctxText.draw()
+
+	}})
+
+	if err := renderGraph.compile(); err != nil {
+		panic(err)
+	}
+	if *graphDumpPath != "" {
+		dumpRenderGraph(renderGraph, *graphDumpPath)
+	}
+
+}
+
+// drawScene draws the terrain or the quads into the proxy screen
+func drawScene() {
+	if *terrainMode {
+		ctxTerrain.drawShadows()
+	}
+	if *waterMode {
+		ctxWater.drawReflection()
+	}
+	ctxFramebufferMultisample.bind()
+	if *terrainMode {
+		ctxTerrain.draw()
+		if *waterMode {
+			ctxWater.draw()
+		}
+	} else {
+		ctxFramebufferMultisample.draw()
+	}
+	if *particleMode {
+		ctxParticles.draw()
+	}
+}
+
+// sceneProjection is the projection of the camera the scene is drawn with, see depthCamera
+func sceneProjection() mgl32.Mat4 {
+	switch {
+	case *terrainMode:
+		return ctxTerrain.camera.Projection()
+	case ctxFramebufferMultisample.using2D:
+		return ctxFramebufferMultisample.camera2D.Projection()
+	}
+	return ctxFramebufferMultisample.camera.Projection()
+}
+
+// sceneView is the view matrix of the camera the scene is drawn with
+func sceneView() mgl32.Mat4 {
+	switch {
+	case *terrainMode:
+		return ctxTerrain.camera.View()
+	case ctxFramebufferMultisample.using2D:
+		return ctxFramebufferMultisample.camera2D.View()
+	}
+	return ctxFramebufferMultisample.camera.View()
+}
+
+// use proxy offscreen for rendering using framebuffers
+func (ctx *ContextFramebufferMultisample) bind() {
+
+	// bind proxy framebuffer instead of default framebuffer
+	gl.BindFramebuffer(gl.FRAMEBUFFER, ctx.fbo)
+	gl.BindFramebuffer(gl.READ_FRAMEBUFFER, ctx.fbo)
+	gl.BindFramebuffer(gl.DRAW_FRAMEBUFFER, ctx.fbo)
+
+	// bind Framebuffer program
+	gl.UseProgram(ctx.program)
+
+	// draw into the proxy screen, it may be smaller than the real screen (see renderSize and viewportSize)
+	viewportWidth, viewportHeight := viewportSize()
+	gl.Viewport(0, 0, viewportWidth, viewportHeight)
+
+	// clear proxy screen to gray, or to nothing when the desktop shows through (premultiplied, see WindowOptions)
+	if *transparentMode {
+		gl.ClearColor(0, 0, 0, 0)
+	} else {
+		gl.ClearColor(0.5, 0.5, 0.5, 0) // ALPHA = 0 is a must for anti-aliasing
+	}
+	gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
+
+	// ensure depth test is enabled during proxy screen usage
+	gl.Enable(gl.DEPTH_TEST)
+
+	// enable multisample
+	gl.Enable(gl.MULTISAMPLE)
+
+}
+
+// use default (real) screen for rendering
+func (ctx *ContextScreen) bind() {
+
+	// unbind proxy framebuffer and set back to default framebuffer
+	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+	gl.BindFramebuffer(gl.READ_FRAMEBUFFER, 0)
+	gl.BindFramebuffer(gl.DRAW_FRAMEBUFFER, 0)
+
+	// bind Screen program
+	gl.UseProgram(ctx.program)
+
+	// clear screen to black, the viewport is the whole screen (see RenderGraph.execute)
+	gl.ClearColor(0, 0, 0, 0)     // ALPHA = 0 is a must for anti-aliasing
+	gl.Clear(gl.COLOR_BUFFER_BIT) // no need to clear depth, we will disable depth
+
+	// disable depth test
+	gl.Disable(gl.DEPTH_TEST) // must disable depth-test for anti-aliasing
+
+	// enable multisample
+	gl.Enable(gl.MULTISAMPLE)
+
+}
+
+func (ctx *ContextFramebuffer) bind() {
+
+	gl.BindFramebuffer(gl.READ_FRAMEBUFFER, ctxFramebufferMultisample.fbo)
+	gl.BindFramebuffer(gl.DRAW_FRAMEBUFFER, ctx.pass.Framebuffer())
+
+}
+
+func (ctx *ContextFramebuffer) draw() {
+
+	width, height := viewportSize()
+
+	gl.BlitFramebuffer(0, 0, width, height, 0, 0, width, height, gl.COLOR_BUFFER_BIT, gl.NEAREST)
+
+}
+
+func (ctx *ContextFramebufferMultisample) draw() {
+
+	// upload camera matrices, if the camera moved
+	// once fully orthographic the 2D camera takes over, so the scene can be panned and zoomed
+	// (a probe uploaded its own, see drawFrom)
+	use2D := ctx.camera.Orthographic() && !ctx.camera.Animating()
+	if use2D != ctx.using2D {
+		ctx.camera.Invalidate()
+		ctx.camera2D.Invalidate()
+		ctx.using2D = use2D
+	}
+	switch {
+	case ctx.probing:
+	case use2D:
+		ctx.camera2D.Update()
+	default:
+		ctx.camera.Update()
+	}
+	eye := ctx.camera.Position()
+	gl.Uniform3fv(ctx.uniformEyePosition, 1, &eye[0])
+
+	// upload model matrix, if it was rotated
+	ctx.arcball.Update()
+
+	// quads were added, upload them (see AddQuad)
+	if ctx.quads.vertexCount() != ctx.uploadedVertices {
+		ctx.uploadQuads()
+	}
+
+	// randomize the tint of the rectangles marked by SetRandomTint, only the tint texture is uploaded
+	// (before the material binds its textures, the upload binds the tint texture)
+	nQuads := ctx.quads.QuadCount()
+	if ctx.tints != nil {
+		if !ctx.sameColors {
+			for i := 0; i < nQuads; i++ {
+				if !ctx.quads.QuadRandomTints[i] {
+					continue
+				}
+				tint := RandomColorInRGBA()
+				tint.A = 255 // keep the vertex alpha
+				ctx.quads.SetTint(i, tint)
+			}
+		}
+		ctx.tints.update(ctx.quads)
+	}
+	gl.Uniform1i(ctx.uniformTinted, boolToInt32(ctx.tints != nil))
+	ctx.shapes.update(ctx.quads)
+	ctx.gradients.update(ctx.quads)
+	gl.Uniform1i(ctx.uniformGradients, 1)
+
+	// gl.Begin()
+	gl.BindBuffer(gl.ARRAY_BUFFER, ctx.vbo)         // bind vertex buffer
+	gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, ctx.ibo) // bind indices buffer
+	ctx.material.Bind()                             // bind sprite and palette textures
+
+	// or randomize their color values (see -vertexcolors)
+	if ctx.tints == nil && !ctx.sameColors {
+		for i := 0; i < nQuads; i++ {
+			if ctx.quads.QuadRandomTints[i] {
+				copy(ctx.quads.QuadColors[i*verticesPerQuad*vertexColorSize:], makeQuadColors(RandomColorInRGBA()))
+			}
+		}
+	}
+	if ctx.tints == nil && ctx.colorRing == nil && ctx.colorBuffers == nil {
+		ctx.quads.UploadColors(ctx.layout)
+	}
+	if ctx.quads.texCoordsDirty {
+		ctx.quads.UploadTexCoords(ctx.layout)
+	}
+
+	// configure and enable vertex position, texture coordinate and color
+	ctx.layout.Enable(ctx.attribVertexPosition, ctx.attribVertexTexCoord, ctx.attribVertexColor)
+
+	// write colors into this frame's ring region and read them from there instead of the VBO
+	colorBuffer, colorOffset := uint32(0), 0
+	if ctx.colorRing != nil {
+		region, offset := ctx.colorRing.Next()
+		copy(region, ctx.quads.QuadColors)
+		colorBuffer, colorOffset = ctx.colorRing.Buffer(), offset
+	}
+
+	// or copy them into this frame's color buffer
+	if ctx.colorBuffers != nil {
+		colorBuffer = ctx.colorBuffers.Next()
+		gl.BufferSubData(gl.ARRAY_BUFFER, 0, len(ctx.quads.QuadColors)*bytesUint8, gl.Ptr(ctx.quads.QuadColors))
+		gl.BindBuffer(gl.ARRAY_BUFFER, ctx.vbo)
+	}
+
+	// rebase points the vertex attributes at a vertex, for quads past the reach of 16 bit indices (see QuadIndexBuffer.Draw)
+	rebase := func(vertex int) {
+		ctx.layout.EnableFrom(0, vertex, ctx.attribVertexPosition, ctx.attribVertexTexCoord, ctx.attribVertexColor)
+		gl.Uniform1i(ctx.uniformVertexBase, int32(vertex)) // gl_VertexID starts over, the tints don't
+		if colorBuffer != 0 {
+			gl.BindBuffer(gl.ARRAY_BUFFER, colorBuffer)
+			gl.VertexAttribPointer(ctx.attribVertexColor, vertexColorSize, gl.UNSIGNED_BYTE, true, 0, gl.PtrOffset(colorOffset+vertex*vertexColorSize*bytesUint8))
+			gl.BindBuffer(gl.ARRAY_BUFFER, ctx.vbo)
+		}
+	}
+	if colorBuffer != 0 {
+		rebase(0) // colors are read from colorBuffer instead of the VBO
+	}
+
+	// draw rectangles, batched by layer and effect
+	for i, batch := range ctx.batches {
+		if ctx.probing && batch.effect == EffectMirror {
+			continue // mirrors do not see themselves, their cubemap is being rendered
+		}
+		if i == 0 || batch.layer != ctx.batches[i-1].layer {
+			batch.layer.apply()
+		}
+		gl.Uniform1i(ctx.uniformEffect, int32(batch.effect))
+		gl.Uniform1i(ctx.uniformPalette, ctx.palette.row)
+
+		// only the frame's own view counts, not the probe's faces or the overdraw counter
+		query := ctx.occlusion.Get(i)
+		if query != nil && !ctx.probing && !overdraw.counting {
+			query.Begin()
+			ctx.drawQuads(batch.first, batch.count, rebase)
+			query.End()
+		} else {
+			ctx.drawQuads(batch.first, batch.count, rebase)
+		}
+	}
+
+	// draw independent objects, the arena buffers are bound once for all of them
+	// objects are quads too, they are drawn with the shared quad indices instead of the arena IBO
+	if ctx.arena != nil {
+		ctx.arena.Bind()
+		gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, ctx.ibo)
+		gl.Uniform1i(ctx.uniformEffect, int32(EffectColor))
+		gl.Uniform1i(ctx.uniformTinted, 0) // objects have no tints, their quads start over at vertex 0
+		gl.Uniform1i(ctx.uniformGradients, 0)
+		if ctx.objectsIndirect {
+			// the attributes point at the start of the arena, each object is a base vertex into it
+			ctx.objects[0].layout.EnableAt(0, ctx.attribVertexPosition, ctx.attribVertexTexCoord, ctx.attribVertexColor)
+			for _, object := range ctx.objects {
+				quadIndices.AddCommands(ctx.indirect, 0, object.quads.QuadCount(), object.block.VertexOffset/int(object.layout.Stride))
+			}
+			ctx.indirect.Submit()
+		} else {
+			for _, object := range ctx.objects {
+				object.layout.EnableAt(object.block.VertexOffset, ctx.attribVertexPosition, ctx.attribVertexTexCoord, ctx.attribVertexColor)
+				quadIndices.Draw(0, object.quads.QuadCount(), func(vertex int) {
+					object.layout.EnableFrom(object.block.VertexOffset, vertex, ctx.attribVertexPosition, ctx.attribVertexTexCoord, ctx.attribVertexColor)
+				})
+			}
+		}
+	}
+
+	// the GPU reads this frame's colors until these draws complete
+	if ctx.colorRing != nil {
+		ctx.colorRing.Fence()
+	}
+
+	// restore world state (see bind)
+	LayerWorld.apply()
+
+	// gl.End()
+	gl.BindBuffer(gl.ARRAY_BUFFER, 0)         // unbind vertex buffer
+	gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, 0) // unbind indices buffer
+	ctx.material.Unbind()                     // unbind textures
+
+	// disable vertex attributes
+	ctx.layout.Disable(ctx.attribVertexPosition, ctx.attribVertexTexCoord, ctx.attribVertexColor)
+
+}
+
+// drawFrom draws the quads with the given matrices instead of the cameras'
+// into the bound framebuffer, for the faces of a CubemapProbe. The colors
+// are those of the last frame.
+func (ctx *ContextFramebufferMultisample) drawFrom(view, projection mgl32.Mat4) {
+
+	gl.UseProgram(ctx.program)
+	gl.UniformMatrix4fv(ctx.camera.projectionUniform, 1, false, &projection[0])
+	gl.UniformMatrix4fv(ctx.camera.cameraUniform, 1, false, &view[0])
+
+	sameColors := ctx.sameColors
+	ctx.probing, ctx.sameColors = true, true
+	ctx.draw()
+	ctx.probing, ctx.sameColors = false, sameColors
+
+	// the cameras upload their matrices again
+	ctx.camera.Invalidate()
+	ctx.camera2D.Invalidate()
+
+}
+
+// drawQuads draws quads of the bound VBO, as indirect commands with -indirect
+// (one call however many chunks) or directly (see QuadIndexBuffer.Draw)
+func (ctx *ContextFramebufferMultisample) drawQuads(first, count int, rebase func(vertex int)) {
+	if ctx.indirect == nil {
+		quadIndices.Draw(first, count, rebase)
+		return
+	}
+	quadIndices.AddCommands(ctx.indirect, first, count, 0)
+	ctx.indirect.Submit()
+}
+
+// BatchVisible is whether any sample of batch i (see SortBatches) passed the
+// depth test a frame or more ago, true without -occlusion or before the first
+// result, e.g. to stop animating what can't be seen
+func (ctx *ContextFramebufferMultisample) BatchVisible(i int) bool {
+	return ctx.occlusion.Visible(i)
+}
+
+// mirrorQuery is the occlusion query of the first mirror batch, nil without one
+func (ctx *ContextFramebufferMultisample) mirrorQuery() *OcclusionQuery {
+	for i, batch := range ctx.batches {
+		if batch.effect == EffectMirror {
+			return ctx.occlusion.Get(i)
+		}
+	}
+	return nil
+}
+
+// arenaObject is a set of quads drawn on its own from a block of a BufferArena
+type arenaObject struct {
+	quads  *ElementQuads
+	layout *Layout // offsets within block
+	block  ArenaBlock
+}
+
+// AddQuad adds a rectangle after setup, it is uploaded before the next draw
+func (ctx *ContextFramebufferMultisample) AddQuad(x, y, w, h, z float32, clr color.NRGBA) {
+	ctx.quads.DrawRectangleAt(x, y, w, h, z, clr)
+}
+
+// uploadQuads copies the quads into the VBO, a GrowableBuffer, and the layout
+// is computed for a power of two number of vertices, so adding quads
+// reallocates only once that capacity is exceeded. Quads are drawn with the
+// shared quad indices, there is no IBO to upload.
+func (ctx *ContextFramebufferMultisample) uploadQuads() {
+
+	// group quads by layer and effect (one draw call per group), this moves their vertices
+	batches, unchanged := ctx.quads.SortBatches()
+	ctx.batches = batches
+	ctx.ibo = quadIndices.Buffer()
+
+	// one occlusion query per batch, the old results belong to other batches once their number changed
+	if ctx.occlusion != nil && len(ctx.occlusion.queries) != len(batches) {
+		ctx.occlusion.resize(len(batches))
+	}
+
+	// grow vertex capacity, the layout offsets depend on it (planar blocks are capacity vertices long)
+	vertices := ctx.quads.vertexCount()
+	if vertices > ctx.vertexCapacity {
+		ctx.vertexCapacity = nextPowerOfTwo(vertices)
+	}
+	ctx.quads.SetLayoutFor(ctx.layout, ctx.vertexCapacity)
+
+	// interleaved vertices keep their place when the buffer grows, so they are copied on the GPU
+	// and only new (or moved by sorting) vertices uploaded. Planar blocks move with the capacity,
+	// everything is uploaded.
+	preserve := 0
+	if ctx.layout.Interleaved {
+		preserve = ctx.uploadedVertices
+		if unchanged*verticesPerQuad < preserve {
+			preserve = unchanged * verticesPerQuad
+		}
+		preserve *= int(ctx.layout.Stride)
+	}
+	ctx.vbo = ctx.vertexBuffer.Reserve(ctx.quads.BytesTotal, preserve)
+	gl.BindBuffer(gl.ARRAY_BUFFER, ctx.vbo)
+	if preserve > 0 {
+		data := ctx.layout.Pack(vertices, ctx.quads.attribData())[:vertices*int(ctx.layout.Stride)]
+		gl.BufferSubData(gl.ARRAY_BUFFER, preserve, len(data)-preserve, gl.Ptr(data[preserve:]))
+	} else {
+		ctx.quads.Upload(ctx.layout)
+	}
+	gl.BindBuffer(gl.ARRAY_BUFFER, 0)
+	ctx.uploadedVertices = vertices
+
+	// colors of planar layouts are one block, stream them through a persistently mapped ring,
+	// large enough for the vertex capacity (interleaved colors are spread over the VBO, no ring)
+	colorBytes := ctx.vertexCapacity * vertexColorSize * bytesUint8
+	switch {
+	case ctx.layout.Interleaved || ctx.tints != nil:
+	case usePersistentMapping && (ctx.colorRing == nil || ctx.colorRing.RegionBytes() < colorBytes):
+		if ctx.colorRing != nil {
+			ctx.colorRing.destroy()
+		}
+		ctx.colorRing = NewPersistentRing("multisample color ring", colorBytes)
+	case !usePersistentMapping && (ctx.colorBuffers == nil || ctx.colorBuffers.Bytes() < colorBytes):
+		if ctx.colorBuffers != nil {
+			ctx.colorBuffers.destroy()
+		}
+		ctx.colorBuffers = NewDynamicBuffer("multisample color vbo", colorBytes)
+	}
+
+}
+
+// RandomColorInRGB, from sceneRand so a replay draws the same colors
+func RandomColorInRGBA() color.NRGBA {
+	r := uint8(sceneRand.Intn(0xff))
+	g := uint8(sceneRand.Intn(0xff))
+	b := uint8(sceneRand.Intn(0xff))
+	a := uint8(1)
+	return color.NRGBA{r, g, b, a}
+}
+
+func (ctx *ContextScreen) draw() {
+
+	// gl.Begin()
+	gl.BindBuffer(gl.ARRAY_BUFFER, ctx.vbo)         // bind vertex buffer
+	gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, ctx.ibo) // bind indices buffer
+	ctx.material.Bind()                             // bind to downsampled shared texture
+
+	// configure and enable vertex position and texture coordinate
+	ctx.layout.Enable(ctx.attribVertexPosition, ctx.attribVertexTexCoord)
+
+	// draw the rendered image into its part of the screen (letterboxed in pixel-art mode),
+	// once per view: the channels taken from each eye are all from the left (the only) eye without stereo
+	for _, view := range stereo.views() {
+		gl.Viewport(view.x, view.y, view.width, view.height)
+		gl.Uniform3fv(ctx.uniformLeftMask, 1, &view.left[0])
+		gl.Uniform3fv(ctx.uniformRightMask, 1, &view.right[0])
+		quadIndices.Draw(0, ctx.quads.QuadCount(), nil)
+	}
+
+	// back to the whole screen, for the overlays
+	screenWidth, screenHeight := screenSize()
+	gl.Viewport(0, 0, screenWidth, screenHeight)
+
+	// gl.End()
+	gl.BindBuffer(gl.ARRAY_BUFFER, 0)         // unbind vertex buffer
+	gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, 0) // unbind indices buffer
+	ctx.material.Unbind()                     // unbind texture
+
+	// disable vertex attributes
+	ctx.layout.Disable(ctx.attribVertexPosition, ctx.attribVertexTexCoord)
+
+}
+
+// drawWindow draws the rendered image over the whole default framebuffer of
+// another window sharing the objects (see SharedWindows), whose VAO is bound.
+// Unlike bind and draw it leaves out the stereo views and the letterbox.
+func (ctx *ContextScreen) drawWindow(width, height int32) {
+
+	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+	gl.UseProgram(ctx.program)
+	gl.Viewport(0, 0, width, height)
+	gl.ClearColor(0, 0, 0, 0)
+	gl.Clear(gl.COLOR_BUFFER_BIT)
+	gl.Disable(gl.DEPTH_TEST)
+
+	gl.BindBuffer(gl.ARRAY_BUFFER, ctx.vbo)
+	gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, ctx.ibo)
+	ctx.material.Bind()
+	ctx.layout.Enable(ctx.attribVertexPosition, ctx.attribVertexTexCoord)
+
+	// all channels from the left (the only) eye
+	left, right := [3]float32{1, 1, 1}, [3]float32{0, 0, 0}
+	gl.Uniform3fv(ctx.uniformLeftMask, 1, &left[0])
+	gl.Uniform3fv(ctx.uniformRightMask, 1, &right[0])
+	quadIndices.Draw(0, ctx.quads.QuadCount(), nil)
+
+	gl.BindBuffer(gl.ARRAY_BUFFER, 0)
+	gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, 0)
+	ctx.material.Unbind()
+	ctx.layout.Disable(ctx.attribVertexPosition, ctx.attribVertexTexCoord)
+	gl.UseProgram(0)
+
+}
+
+// release GL objects owned by the real screen
+func (ctx *ContextScreen) destroy() {
+	gpuResources.Release(ResourceVertexArray, ctx.vao)
+	gpuResources.Release(ResourceBuffer, ctx.vbo)
+	gpuResources.Release(ResourceProgram, ctx.program)
+	ctx.vao, ctx.vbo, ctx.ibo, ctx.program = 0, 0, 0, 0
+}
+
+// release GL objects owned by the proxy screen
+func (ctx *ContextFramebufferMultisample) destroy() {
+	gpuResources.Release(ResourceFramebuffer, ctx.fbo)
+	gpuResources.Release(ResourceTexture, ctx.fboTexture)
+	gpuResources.Release(ResourceRenderbuffer, ctx.fboRenderbuffer)
+	gpuResources.Release(ResourceVertexArray, ctx.vao)
+	gpuResources.Release(ResourceBuffer, ctx.vbo)
+	gpuResources.Release(ResourceProgram, ctx.program)
+	gpuResources.Release(ResourceTexture, ctx.spriteTexture)
+	gpuResources.Release(ResourceTexture, ctx.paletteTexture)
+	gpuResources.Release(ResourceTexture, ctx.patternTexture)
+	if ctx.arena != nil {
+		ctx.arena.destroy()
+	}
+	if ctx.colorRing != nil {
+		ctx.colorRing.destroy()
+		ctx.colorRing = nil
+	}
+	if ctx.colorBuffers != nil {
+		ctx.colorBuffers.destroy()
+		ctx.colorBuffers = nil
+	}
+	if ctx.reflection != nil {
+		ctx.reflection.destroy()
+		ctx.reflection = nil
+	}
+	if ctx.occlusion != nil {
+		ctx.occlusion.destroy()
+		ctx.occlusion = nil
+	}
+	if ctx.indirect != nil {
+		ctx.indirect.destroy()
+		ctx.indirect = nil
+	}
+	if ctx.tints != nil {
+		ctx.tints.destroy()
+		ctx.tints = nil
+	}
+	if ctx.shapes != nil {
+		ctx.shapes.destroy()
+		ctx.shapes = nil
+	}
+	if ctx.gradients != nil {
+		ctx.gradients.destroy()
+		ctx.gradients = nil
+	}
+	if ctx.canvas != nil {
+		ctx.canvas.destroy()
+		ctx.canvas = nil
+	}
+	if ctx.video != nil {
+		ctx.video.destroy()
+		ctx.video = nil
+	}
+	ctx.spriteTexture, ctx.paletteTexture, ctx.patternTexture = 0, 0, 0
+	ctx.fbo, ctx.fboTexture, ctx.fboRenderbuffer = 0, 0, 0
+	ctx.vao, ctx.vbo, ctx.ibo, ctx.program = 0, 0, 0, 0
+}
+
+// release GL objects owned by the blitz intermediate
+func (ctx *ContextFramebuffer) destroy() {
+	if ctx.pass != nil {
+		ctx.pass.destroy()
+		ctx.pass = nil
+	}
+}
+
+// resizeAttachments recreates the color texture and depth/stencil renderbuffer at the current renderSize
+func (ctx *ContextFramebufferMultisample) resizeAttachments() {
+
+	gpuResources.Release(ResourceTexture, ctx.fboTexture)
+	gpuResources.Release(ResourceRenderbuffer, ctx.fboRenderbuffer)
+
+	gl.BindFramebuffer(gl.FRAMEBUFFER, ctx.fbo)
+	ctx.attachTextureMultisample()
+	ctx.attachRenderbufferMultisample()
+	CheckGLFramebufferStatus()
+	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+
+}
+
+func (ctx *ContextFramebuffer) setupBuffers() {
+
+	// create FBO with a color texture, alpha is only kept for a transparent window
+	format := int32(gl.RGB)
+	if *transparentMode {
+		format = gl.RGBA
+	}
+	var err error
+	ctx.pass, err = renderer.Pass(ctx.name, PassOptions{Color: format})
+	if err != nil {
+		panic(err)
+	}
+
+}
+
+func (ctx *ContextScreen) setupBuffers() {
+
+	// use SCREEN program
+	gl.UseProgram(ctx.program)
+
+	// unbind FBO
+	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+
+	// to be more efficient, vertices position are in float32 and texture coordinate in uint8
+	ctx.layout = NewLayout().Float32("position", vertexPositionSize).UInt8("texcoord", vertexTexCoordSize, false)
+	ctx.quads.SetLayout(ctx.layout)
+
+	// create and bind VAO
+	ctx.vao = genVertexArray("screen vao")
+	gl.BindVertexArray(ctx.vao)
+
+	// create VBOs
+	ctx.vbo = genBuffer("screen vbo") // buffer for vertex position and texture coordinate
+	ctx.ibo = quadIndices.Buffer()    // shared quad indices
+
+	// copy vertex data to VBO
+	gl.BindBuffer(gl.ARRAY_BUFFER, ctx.vbo)
+	gl.BufferData(gl.ARRAY_BUFFER, ctx.quads.BytesTotal, nil, gl.STATIC_DRAW)                                                              // initalize but do not copy any data
+	gl.BufferSubData(gl.ARRAY_BUFFER, ctx.quads.OffsetVertices, len(ctx.quads.QuadVertices)*bytesFloat32, gl.Ptr(ctx.quads.QuadVertices))  // copy vertices starting from 0 offest
+	gl.BufferSubData(gl.ARRAY_BUFFER, ctx.quads.OffsetTexCoords, len(ctx.quads.QuadTexCoords)*bytesUint8, gl.Ptr(ctx.quads.QuadTexCoords)) // copy textures after vertices
+	gpuResources.SetBytes(ResourceBuffer, ctx.vbo, ctx.quads.BytesTotal)
+	gl.BindBuffer(gl.ARRAY_BUFFER, 0)
+
+	// -------------------------
+	// one-time global settings
+	// -------------------------
+
+	// if multiple shapes have same z-value, take their
+	// draw order in account and show if possible
+	gl.DepthFunc(gl.LEQUAL)
+
+	// unbind SCREEN program
+	gl.UseProgram(0)
+
+}
+
+// https://en.wikipedia.org/wiki/Vertex_buffer_object
+// https://www.songho.ca/opengl/gl_vbo.html#create
+// https://learnopengl.com/Advanced-OpenGL/Framebuffers
+func (ctx *ContextFramebufferMultisample) setupBuffers() {
+
+	// use PROXY program
+	gl.UseProgram(ctx.program)
+
+	// to be more efficient, vertices position are in float32, texture coordinate in uint8, and color is in uint8
+	ctx.layout = NewLayout().Float32("position", vertexPositionSize).UInt8("texcoord", vertexTexCoordSize, false).UInt8("color", vertexColorSize, true)
+	if *interleavedMode {
+		ctx.layout.Interleave()
+	}
+
+	// create FBO and bind to it
+	ctx.fbo = genFramebuffer("multisample fbo") // offscreen rendering use framebuffer extension
+	gl.BindFramebuffer(gl.FRAMEBUFFER, ctx.fbo)
+
+	// attach texture to FBO (color buffer component)
+	ctx.attachTextureMultisample()
+
+	/// attach renderbuffer to FBO (combined depth and stencil buffer component)
+	ctx.attachRenderbufferMultisample()
+
+	// check if FBO is ready and valid
+	CheckGLFramebufferStatus()
+
+	// create and bind VAO
+	ctx.vao = genVertexArray("multisample vao")
+	gl.BindVertexArray(ctx.vao)
+
+	// create VBO, it grows when quads are added later (indices are the shared quad indices)
+	ctx.vertexBuffer = NewGrowableBuffer(gl.ARRAY_BUFFER, gl.STATIC_DRAW, "multisample vbo") // buffer for vertex position, texture coordinate, and color
+	ctx.vertexCapacity, ctx.uploadedVertices = 0, 0
+	ctx.colorRing, ctx.colorBuffers = nil, nil // created by uploadQuads, earlier ones died with their context (see recoverContext)
+
+	// tints instead of rewriting the vertex colors every frame, uploaded before each draw
+	ctx.tints = nil
+	if !*vertexColors {
+		ctx.tints = NewQuadTints("quad tints")
+		ctx.quads.markTints(0, len(ctx.quads.QuadTints))
+	}
+
+	// outlines of the rounded rectangles, uploaded before each draw when changed
+	ctx.shapes = NewShapeTexture("quad shapes")
+	ctx.quads.shapesChanged = true
+	ctx.gradients = NewGradientRamps("gradient ramps")
+	ctx.quads.gradientsChanged = true
+
+	// copy vertex data to VBO, grouped by layer and effect (one draw call per group)
+	ctx.uploadQuads()
+
+	// draw commands for indirect draws, if the GL has them
+	ctx.indirect = nil
+	if *indirectMode && indirectSupported() {
+		ctx.indirect = NewIndirectBuffer("quads indirect")
+	} else if *indirectMode {
+		fmt.Println("INDIRECT -- not supported (needs OpenGL 4.0 or GL_ARB_draw_indirect), drawing directly")
+	}
+
+	// pack the independent objects into one arena, their indices are the shared quad indices
+	// indirect draws need them interleaved, a base vertex can't skip the blocks of a planar layout
+	ctx.arena = nil
+	ctx.objectsIndirect = false
+	if len(ctx.objects) > 0 {
+		vertexBytes := 0
+		for _, object := range ctx.objects {
+			object.layout = NewLayout().Float32("position", vertexPositionSize).UInt8("texcoord", vertexTexCoordSize, false).UInt8("color", vertexColorSize, true)
+			if ctx.indirect != nil {
+				object.layout.Interleave()
+			}
+			object.quads.SetLayout(object.layout)
+			vertexBytes += align(object.quads.BytesTotal, arenaAlignment)
+		}
+		ctx.arena = NewBufferArena("objects arena", vertexBytes, 0)
+		for _, object := range ctx.objects {
+			block, err := ctx.arena.Alloc(object.quads.BytesTotal, 0)
+			if err != nil {
+				panic(err)
+			}
+			object.block = block
+			ctx.arena.Upload(block, object.layout.Pack(object.quads.vertexCount(), object.quads.attribData()), nil)
+		}
+
+		// blocks are aligned to arenaAlignment, a base vertex only reaches those starting at a whole vertex
+		ctx.objectsIndirect = ctx.indirect != nil
+		for _, object := range ctx.objects {
+			if object.block.VertexOffset%int(object.layout.Stride) != 0 {
+				ctx.objectsIndirect = false
+			}
+		}
+	}
+
+	// upload indexed sprite and its palettes
+	spriteIndices, spriteWidth, spriteHeight := makeSpriteIndices()
+	ctx.spriteTexture = newIndexTexture("sprite indices", spriteIndices, spriteWidth, spriteHeight)
+	ctx.paletteTexture = newPaletteTexture("sprite palettes", spritePalettes)
+	ctx.material.SetTexture("spriteTexture", gl.TEXTURE_2D, ctx.spriteTexture)
+	ctx.material.SetTexture("paletteTexture", gl.TEXTURE_2D, ctx.paletteTexture)
+
+	// generate the debug texture, uv holds coordinates rather than colors
+	ctx.patternTexture = 0
+	if ctx.pattern != PatternOff {
+		space := TextureColor
+		if ctx.pattern == PatternUV {
+			space = TextureData
+		}
+		wrap, err := parseWrapMode(*patternWrap)
+		if err != nil {
+			panic(err)
+		}
+		ctx.patternTexture = newImageTexture("pattern", makePatternImage(ctx.pattern, patternSize), space, wrap)
+	}
+	ctx.material.SetTexture("patternTexture", gl.TEXTURE_2D, ctx.patternTexture)
+
+	// painted on the CPU, the sampler needs a texture unit even without one
+	ctx.canvas = nil
+	canvasTexture := uint32(0)
+	if *canvasMode {
+		ctx.canvas = NewCanvas("canvas")
+		canvasTexture = ctx.canvas.Texture()
+	}
+	ctx.material.SetTexture("canvasTexture", gl.TEXTURE_2D, canvasTexture)
+
+	// video frames, uploaded as they change
+	ctx.video = nil
+	videoTexture := uint32(0)
+	if *videoSource != "" {
+		source, err := newFrameSource(*videoSource)
+		if err != nil {
+			panic(err)
+		}
+		ctx.video = NewVideoTexture("video", source)
+		videoTexture = ctx.video.Texture()
+	}
+	ctx.material.SetTexture("videoTexture", gl.TEXTURE_2D, videoTexture)
+
+	// picture in picture, the pass is drawn later in the frame so this is the frame before
+	ctx.material.SetPassTexture("passTexture", "post")
+
+	// dropped image, 0 until a file is dropped (see DroppedImage)
+	ctx.material.SetTexture("droppedTexture", gl.TEXTURE_2D, dropped.texture)
+
+	// render the surroundings of the mirror into a cubemap, the samplerCube needs a texture unit even without one
+	ctx.reflection = nil
+	reflectionMap := uint32(0)
+	if *reflectionMode {
+		ctx.reflection = NewCubemapProbe("reflection", mgl32.Vec3{-0.6, -0.6, -1.15}, reflectionProbeSize, defaultNear, defaultFar)
+		reflectionMap = ctx.reflection.Texture()
+	}
+	ctx.material.SetTexture("reflectionMap", gl.TEXTURE_CUBE_MAP, reflectionMap)
+
+	// tint per quad, the sampler needs a texture unit even without tints
+	tintTexture := uint32(0)
+	if ctx.tints != nil {
+		tintTexture = ctx.tints.Texture()
+	}
+	ctx.material.SetTexture("tintTexture", gl.TEXTURE_2D, tintTexture)
+	ctx.material.SetTexture("shapeTexture", gl.TEXTURE_2D, ctx.shapes.Texture())
+	ctx.material.SetTexture("gradientTexture", gl.TEXTURE_2D, ctx.gradients.Texture())
+
+	// queries are created per batch once the quads are uploaded
+	if *occlusionMode {
+		ctx.occlusion = &OcclusionQueries{name: "batch"}
+	}
+
+	// unbind FBO
+	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+
+	// unbind PROXY program
+	gl.UseProgram(0)
+
+}
+
+// http://www.songho.ca/opengl/gl_fbo.html
+func (ctx *ContextFramebufferMultisample) attachTextureMultisample() {
+
+	// create texture for framebuffer attachment, and bind to it
+	// NOTE: a texture can be attached to multiple FBOs, where its image storage is shared
+	//       this is an important, we use it to render the final drawn texture from Framebuffer-FBO to Screen-FBO.
+	ctx.fboTexture = genTexture("multisample fbo color")
+	gl.BindTexture(gl.TEXTURE_2D_MULTISAMPLE, ctx.fboTexture)
+
+	// initalize texture (memory space and min/mag filters)
+	width, height := renderSize()
+	samples := glInfo.Samples(msaaSamples)
+	gl.TexImage2DMultisample(gl.TEXTURE_2D_MULTISAMPLE, samples, gl.RGBA, width, height, true)
+	gpuResources.SetBytes(ResourceTexture, ctx.fboTexture, int(width)*int(height)*4*int(samples))
+
+	// unbind texture
+	gl.BindTexture(gl.TEXTURE_2D_MULTISAMPLE, 0)
+
+	// attach texture to framebuffer
+	gl.FramebufferTexture2D(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT0, gl.TEXTURE_2D_MULTISAMPLE, ctx.fboTexture, 0)
+
+}
+
+// http://www.songho.ca/opengl/gl_fbo.html
+func (ctx *ContextFramebufferMultisample) attachRenderbufferMultisample() {
+
+	// create renderbuffer for depth and stencil testing. and bind to it
+	ctx.fboRenderbuffer = genRenderbuffer("multisample fbo depth/stencil")
+	gl.BindRenderbuffer(gl.RENDERBUFFER, ctx.fboRenderbuffer)
+
+	// initalize renderbuffer memory space
+	width, height := renderSize()
+	samples := glInfo.Samples(msaaSamples) // same count as the color texture, or the FBO is incomplete
+	gl.RenderbufferStorageMultisample(gl.RENDERBUFFER, samples, gl.DEPTH24_STENCIL8, width, height)
+	gpuResources.SetBytes(ResourceRenderbuffer, ctx.fboRenderbuffer, int(width)*int(height)*4*int(samples)) // 24 bit depth + 8 bit stencil
+
+	// unbind renderbuffer
+	gl.BindRenderbuffer(gl.RENDERBUFFER, 0)
+
+	// attach renderbuffer to framebuffer
+	gl.FramebufferRenderbuffer(gl.FRAMEBUFFER, gl.DEPTH_STENCIL_ATTACHMENT, gl.RENDERBUFFER, ctx.fboRenderbuffer)
+
+}
+
+func (ctx *ContextScreen) setupProgram() {
+
+	var err error
+
+	// configure program, load shaders, and link attributes
+	ctx.program, err = newProgram(vertexShaderScreen, fragmentShaderScreen)
+	if err != nil {
+		panic(err)
+	}
+	gpuResources.SetLabel(ResourceProgram, ctx.program, "screen program")
+	gl.UseProgram(ctx.program)
+
+	// get attribute index for later use
+	ctx.attribVertexPosition = uint32(gl.GetAttribLocation(ctx.program, gl.Str("vertexPosition\x00")))
+	ctx.attribVertexTexCoord = uint32(gl.GetAttribLocation(ctx.program, gl.Str("vertexTexCoord\x00")))
+	ctx.uniformLeftMask = gl.GetUniformLocation(ctx.program, gl.Str("uLeftMask\x00"))
+	ctx.uniformRightMask = gl.GetUniformLocation(ctx.program, gl.Str("uRightMask\x00"))
+
+	// textures are bound through the material, see Material
+	ctx.material = NewMaterial(ctx.program)
+
+	// debug print
+	fmt.Printf("attribVertexPosition: %v attribVertexTexCoord: %v\n", ctx.attribVertexPosition, ctx.attribVertexTexCoord)
+
+	// unbind program
+	gl.UseProgram(0)
+
+}
+
+func (ctx *ContextFramebufferMultisample) setupProgram() {
+
+	var err error
+
+	// configure program, load shaders, and link attributes
+	ctx.program, err = newProgram(vertexShaderFramebuffer, fragmentShaderFramebuffer)
+	if err != nil {
+		panic(err)
+	}
+	gpuResources.SetLabel(ResourceProgram, ctx.program, "multisample program")
+	gl.UseProgram(ctx.program)
+
+	// get attribute index for later use
+	ctx.attribVertexPosition = uint32(gl.GetAttribLocation(ctx.program, gl.Str("vertexPosition\x00")))
+	ctx.attribVertexTexCoord = uint32(gl.GetAttribLocation(ctx.program, gl.Str("vertexTexCoord\x00")))
+	ctx.attribVertexColor = uint32(gl.GetAttribLocation(ctx.program, gl.Str("vertexColor\x00")))
+	ctx.uniformEffect = gl.GetUniformLocation(ctx.program, gl.Str("uEffect\x00"))
+	ctx.uniformOverdraw = gl.GetUniformLocation(ctx.program, gl.Str("uOverdraw\x00"))
+	ctx.uniformTinted = gl.GetUniformLocation(ctx.program, gl.Str("uTinted\x00"))
+	ctx.uniformVertexBase = gl.GetUniformLocation(ctx.program, gl.Str("uVertexBase\x00"))
+	ctx.uniformGradients = gl.GetUniformLocation(ctx.program, gl.Str("uGradients\x00"))
+
+	// textures are bound through the material, see Material
+	ctx.material = NewMaterial(ctx.program)
+	ctx.uniformPalette = gl.GetUniformLocation(ctx.program, gl.Str("uPalette\x00"))
+	ctx.uniformEyePosition = gl.GetUniformLocation(ctx.program, gl.Str("uEyePosition\x00"))
+
+	// a transparent window composites by alpha, the quads must cover the desktop
+	gl.Uniform1i(gl.GetUniformLocation(ctx.program, gl.Str("uOpaqueAlpha\x00")), boolToInt32(*transparentMode))
+
+	// debug print
+	fmt.Printf("attribVertexPosition: %v attribVertexTexCoord: %v attribVertexColor: %v\n", ctx.attribVertexPosition, ctx.attribVertexTexCoord, ctx.attribVertexColor)
+
+	// unbind program
+	gl.UseProgram(0)
+
+}
+
+func newProgram(vertexShaderSource, fragmentShaderSource string) (uint32, error) {
+	return newGeometryProgram(vertexShaderSource, "", fragmentShaderSource)
+}
+
+// newGeometryProgram is newProgram with a geometry shader between the vertex
+// and fragment shaders, it runs once per primitive and may emit other
+// primitives, e.g. a quad for a point (see ContextParticles). An empty
+// geometryShaderSource links without one.
+func newGeometryProgram(vertexShaderSource, geometryShaderSource, fragmentShaderSource string) (uint32, error) {
+	return linkProgram([]shaderStage{
+		{vertexShaderSource, gl.VERTEX_SHADER},
+		{geometryShaderSource, gl.GEOMETRY_SHADER},
+		{fragmentShaderSource, gl.FRAGMENT_SHADER},
+	}, nil)
+}
+
+// newFeedbackProgram creates a vertex shader only program whose outputs
+// named by varyings are captured into a transform feedback buffer, one
+// after the other per vertex (interleaved). Draw with gl.RASTERIZER_DISCARD
+// enabled, there is no fragment shader. Needs OpenGL 3.0 or GLES 3.0.
+func newFeedbackProgram(vertexShaderSource string, varyings []string) (uint32, error) {
+	return linkProgram([]shaderStage{{vertexShaderSource, gl.VERTEX_SHADER}}, varyings)
+}
+
+// shaderStage is the source of one shader of a program, see linkProgram
+type shaderStage struct {
+	source     string // empty to skip the stage
+	shaderType uint32 // e.g. gl.VERTEX_SHADER
+}
+
+// linkProgram compiles the stages and links them into a program, varyings are
+// the outputs captured by transform feedback (see newFeedbackProgram)
+func linkProgram(stages []shaderStage, varyings []string) (uint32, error) {
+
+	var shaders []uint32
+	for _, stage := range stages {
+		if stage.source == "" {
+			continue
+		}
+		shader, err := compileShader(stage.source, stage.shaderType)
+		if err != nil {
+			return 0, err
+		}
+		shaders = append(shaders, shader)
+	}
+
+	program := gl.CreateProgram()
+
+	for _, shader := range shaders {
+		gl.AttachShader(program, shader)
+	}
+
+	// captured outputs are part of the link
+	if len(varyings) > 0 {
+		names := make([]string, len(varyings))
+		for i, varying := range varyings {
+			names[i] = varying + "\x00"
+		}
+		cnames, free := gl.Strs(names...)
+		gl.TransformFeedbackVaryings(program, int32(len(names)), cnames, gl.INTERLEAVED_ATTRIBS)
+		free()
+	}
+
+	gl.LinkProgram(program)
+
+	var status int32
+	gl.GetProgramiv(program, gl.LINK_STATUS, &status)
+	if status == gl.FALSE {
+
+		var logLength int32
+		gl.GetProgramiv(program, gl.INFO_LOG_LENGTH, &logLength)
+
+		log := strings.Repeat("\x00", int(logLength+1))
+		gl.GetProgramInfoLog(program, logLength, nil, gl.Str(log))
+
+		return 0, fmt.Errorf("failed to link program: %v", log)
+
+	}
+
+	for _, shader := range shaders {
+		gl.DeleteShader(shader)
+	}
+
+	// program is owned by the resource registry, callers may rename it with SetLabel
+	gpuResources.add(ResourceProgram, program, "program")
+
+	// active attributes and uniforms, for materials and -validate
+	programInfos.register(program)
+
+	// uTime and uResolution are updated every frame, if the program uses them
+	shaderGlobals.register(program)
+
+	return program, nil
+
+}
+
+func compileShader(source string, shaderType uint32) (uint32, error) {
+
+	shader := gl.CreateShader(shaderType)
+
+	csources, free := gl.Strs(source)
+	gl.ShaderSource(shader, 1, csources, nil)
+	free()
+	gl.CompileShader(shader)
+
+	var status int32
+	gl.GetShaderiv(shader, gl.COMPILE_STATUS, &status)
+	if status == gl.FALSE {
+
+		var logLength int32
+		gl.GetShaderiv(shader, gl.INFO_LOG_LENGTH, &logLength)
+
+		log := strings.Repeat("\x00", int(logLength+1))
+		gl.GetShaderInfoLog(shader, logLength, nil, gl.Str(log))
+
+		return 0, fmt.Errorf("failed to compile %v: %v", source, log)
+
+	}
+
+	return shader, nil
+
+}
+
+var GL_ERROR_LOOKUP = map[uint32]string{
+	0x500: `GL_INVALID_ENUM`,
+	0x501: `GL_INVALID_VALUE`,
+	0x502: `GL_INVALID_OPERATION`,
+	0x503: `GL_STACK_OVERFLOW`,
+	0x504: `GL_STACK_UNDERFLOW`,
+	0x505: `GL_OUT_OF_MEMORY`,
+	0x506: `GL_INVALID_FRAMEBUFFER_OPERATION`,
+	0x507: `GL_CONTEXT_LOST`,
+}
+
+func CheckGLError() {
+	for {
+		glerr := gl.GetError()
+		if glerr == gl.NO_ERROR {
+			break
+		}
+		if glerr == gl.CONTEXT_LOST {
+			// not a bug in our code, the gameloop will rebuild everything on a new context
+			contextRecovery.markLost()
+			break
+		}
+		panic_GL_ERROR(glerr)
+	}
+}
+
+func panic_GL_ERROR(errcode uint32) {
+	if errstr, ok := GL_ERROR_LOOKUP[errcode]; ok {
+		panic(fmt.Sprintf("GL_ERROR: %s\n", errstr))
+	} else {
+		panic(fmt.Sprintf("GL_ERROR UNKNOWN: %v\n", errcode))
+	}
+}
+
+var GL_FRAMEBUFFER_STATUS_LOOKUP = map[uint32]string{
+	0x8CD5: `GL_FRAMEBUFFER_COMPLETE`,
+	0x8CD6: `GL_FRAMEBUFFER_INCOMPLETE_ATTACHMENT`,
+	0x8CD7: `GL_FRAMEBUFFER_INCOMPLETE_MISSING_ATTACHMENT`,
+	0x8CD9: `GL_FRAMEBUFFER_INCOMPLETE_DIMENSIONS`,
+	0x8CDA: `GL_FRAMEBUFFER_INCOMPLETE_FORMATS`,
+	0x8CDB: `GL_FRAMEBUFFER_INCOMPLETE_DRAW_BUFFER`,
+	0x8CDC: `GL_FRAMEBUFFER_INCOMPLETE_READ_BUFFER`,
+	0x8CDD: `GL_FRAMEBUFFER_UNSUPPORTED`,
+	0x8D56: `GL_FRAMEBUFFER_INCOMPLETE_MULTISAMPLE`,
+	0x8219: `GL_FRAMEBUFFER_UNDEFINED`,
+}
+
+func CheckGLFramebufferStatus() {
+	for {
+		glstatus := gl.CheckFramebufferStatus(gl.FRAMEBUFFER)
+		if glstatus == gl.FRAMEBUFFER_COMPLETE {
+			break
+		}
+		panic_GL_Framebuffer_STATUS(glstatus)
+	}
+}
+
+func panic_GL_Framebuffer_STATUS(statuscode uint32) {
+	if statusstr, ok := GL_FRAMEBUFFER_STATUS_LOOKUP[statuscode]; ok {
+		panic(fmt.Sprintf("GL_FRAMEBUFFER_STATUS: %s\n", statusstr))
+	} else {
+		panic(fmt.Sprintf("GL_FRAMEBUFFER_STATUS UNKNOWN: %v\n", statuscode))
+	}
+}
@@ -0,0 +1,133 @@
+package multisample
+
+import (
+	"github.com/go-gl/gl/v3.2-core/gl"
+)
+
+const (
+	maxBatchQuads = 65536 / verticesPerQuad // uint16 indices reach 65536 vertices, the most quads one draw call can use
+)
+
+var (
+	quadIndices = &QuadIndexBuffer{}
+)
+
+// QuadIndexBuffer is one static IBO shared by every set of quads. The
+// indices of a quad only depend on its position, quad n is always the
+// triangles (4n, 4n+1, 4n+2) and (4n, 4n+2, 4n+3), so the buffer is filled
+// once for maxBatchQuads quads and never uploaded again. Quads draw in
+// vertex order, to draw them in another order move their vertices (see
+// SortBatches).
+//
+// More quads than 16 bit indices can reach are drawn in chunks of
+// maxBatchQuads that all use the same indices, see Draw.
+type QuadIndexBuffer struct {
+	ibo        uint32
+	baseVertex bool // glDrawElementsBaseVertex is supported (and not disabled by -nobasevertex)
+}
+
+// setup creates and fills the IBO, requires a current GL context
+func (b *QuadIndexBuffer) setup() {
+
+	// base vertex is core in OpenGL 3.2, GL 2.1 and GLES2 may have the extension
+	b.baseVertex = !*noBaseVertex && (glInfo.AtLeast(3, 2) || glInfo.Has("GL_ARB_draw_elements_base_vertex"))
+
+	indices := make([]uint16, 0, maxBatchQuads*indicesPerQuad)
+	for quad := 0; quad < maxBatchQuads; quad++ {
+		i := uint16(quad * verticesPerQuad)
+		indices = append(indices,
+			i, i+1, i+2, // first triangle
+			i, i+2, i+3, // second triangle
+		)
+	}
+
+	b.ibo = genBuffer("shared quad ibo")
+	gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, b.ibo)
+	gl.BufferData(gl.ELEMENT_ARRAY_BUFFER, len(indices)*bytesUint16, gl.Ptr(indices), gl.STATIC_DRAW)
+	gpuResources.SetBytes(ResourceBuffer, b.ibo, len(indices)*bytesUint16)
+	gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, 0)
+
+}
+
+// Buffer is the IBO, bind it as ELEMENT_ARRAY_BUFFER to draw quads
+func (b *QuadIndexBuffer) Buffer() uint32 {
+	return b.ibo
+}
+
+// Offset is the byte offset of the indices of a quad, for DrawElements
+func (b *QuadIndexBuffer) Offset(quad int) int {
+	return quad * indicesPerQuad * bytesUint16
+}
+
+// Count is the number of indices drawing n quads
+func (b *QuadIndexBuffer) Count(quads int) int32 {
+	return int32(quads * indicesPerQuad)
+}
+
+// Draw draws count quads starting at quad first from the bound VBO, with the
+// IBO bound. Quads of the first chunk are drawn as they are, the indices of
+// later chunks are offset on the GPU with glDrawElementsBaseVertex. Without
+// base vertex support the indices can not be offset, rebase is called instead
+// to point the vertex attributes at the first vertex of the chunk (and at
+// vertex 0 once done). rebase may be nil for quads that fit one chunk.
+func (b *QuadIndexBuffer) Draw(first, count int, rebase func(vertex int)) {
+
+	validateDraw("quads")
+
+	rebased := false
+	for count > 0 {
+
+		// quads left in the chunk holding first
+		chunk := first / maxBatchQuads * maxBatchQuads
+		n := chunk + maxBatchQuads - first
+		if n > count {
+			n = count
+		}
+
+		quadDrawCalls.add(1, 1)
+		switch {
+		case chunk == 0:
+			gl.DrawElements(gl.TRIANGLES, b.Count(n), gl.UNSIGNED_SHORT, gl.PtrOffset(b.Offset(first)))
+		case b.baseVertex:
+			gl.DrawElementsBaseVertex(gl.TRIANGLES, b.Count(n), gl.UNSIGNED_SHORT, gl.PtrOffset(b.Offset(first-chunk)), int32(chunk*verticesPerQuad))
+		default:
+			rebase(chunk * verticesPerQuad)
+			rebased = true
+			gl.DrawElements(gl.TRIANGLES, b.Count(n), gl.UNSIGNED_SHORT, gl.PtrOffset(b.Offset(first-chunk)))
+		}
+
+		first += n
+		count -= n
+	}
+
+	if rebased {
+		rebase(0)
+	}
+
+}
+
+// AddCommands queues the draws of count quads starting at quad first into an
+// indirect buffer, one command per chunk (see Draw). baseVertex is added to
+// every index, e.g. for quads of an object stored after other vertices.
+func (b *QuadIndexBuffer) AddCommands(commands *IndirectBuffer, first, count, baseVertex int) {
+	for count > 0 {
+		chunk := first / maxBatchQuads * maxBatchQuads
+		n := chunk + maxBatchQuads - first
+		if n > count {
+			n = count
+		}
+		commands.Add(DrawElementsIndirectCommand{
+			Count:         uint32(b.Count(n)),
+			InstanceCount: 1,
+			FirstIndex:    uint32((first - chunk) * indicesPerQuad),
+			BaseVertex:    int32(baseVertex + chunk*verticesPerQuad),
+		})
+		first += n
+		count -= n
+	}
+}
+
+func (b *QuadIndexBuffer) destroy() {
+	gpuResources.Release(ResourceBuffer, b.ibo)
+	b.ibo = 0
+}
@@ -0,0 +1,166 @@
+package multisample
+
+import (
+	"strings"
+
+	"github.com/go-gl/gl/v3.2-core/gl"
+)
+
+var (
+	programInfos = &ProgramInfos{programs: map[uint32]*ProgramInfo{}}
+)
+
+// samplerTargets are the sampler uniform types and the texture target each one samples
+var samplerTargets = map[uint32]uint32{
+	gl.SAMPLER_2D:                          gl.TEXTURE_2D,
+	gl.SAMPLER_2D_SHADOW:                   gl.TEXTURE_2D,
+	gl.INT_SAMPLER_2D:                      gl.TEXTURE_2D,
+	gl.UNSIGNED_INT_SAMPLER_2D:             gl.TEXTURE_2D,
+	gl.SAMPLER_3D:                          gl.TEXTURE_3D,
+	gl.SAMPLER_CUBE:                        gl.TEXTURE_CUBE_MAP,
+	gl.SAMPLER_2D_ARRAY:                    gl.TEXTURE_2D_ARRAY,
+	gl.SAMPLER_2D_ARRAY_SHADOW:             gl.TEXTURE_2D_ARRAY,
+	gl.SAMPLER_2D_MULTISAMPLE:              gl.TEXTURE_2D_MULTISAMPLE,
+	gl.INT_SAMPLER_2D_MULTISAMPLE:          gl.TEXTURE_2D_MULTISAMPLE,
+	gl.UNSIGNED_INT_SAMPLER_2D_MULTISAMPLE: gl.TEXTURE_2D_MULTISAMPLE,
+	gl.SAMPLER_BUFFER:                      gl.TEXTURE_BUFFER,
+	gl.SAMPLER_2D_RECT:                     gl.TEXTURE_RECTANGLE,
+}
+
+// ProgramVariable is an active attribute or uniform of a program. Arrays
+// are one variable of Size elements, named without "[0]".
+type ProgramVariable struct {
+	Name     string
+	Location int32  // of the first element, -1 for uniforms in a uniform block
+	Type     uint32 // e.g. gl.FLOAT_VEC3 or gl.SAMPLER_2D
+	Size     int32  // number of array elements, 1 if not an array
+}
+
+// IsSampler reports whether the variable is a sampler uniform
+func (v ProgramVariable) IsSampler() bool {
+	_, ok := samplerTargets[v.Type]
+	return ok
+}
+
+// Target is the texture target a sampler uniform samples, 0 if it is no sampler
+func (v ProgramVariable) Target() uint32 {
+	return samplerTargets[v.Type]
+}
+
+// ProgramInfo is what a linked program reads: its active attributes and
+// uniforms, as reported by glGetActiveAttrib and glGetActiveUniform. The
+// GLSL compiler removes variables that do not affect the output, those are
+// missing, like they have location -1.
+type ProgramInfo struct {
+	Program    uint32
+	Attributes []ProgramVariable // sorted by index, not by location
+	Uniforms   []ProgramVariable
+
+	attributes map[string]int // index into Attributes by name
+	uniforms   map[string]int // index into Uniforms by name
+}
+
+// reflectProgram reads the active variables of a linked program
+func reflectProgram(program uint32) *ProgramInfo {
+
+	info := &ProgramInfo{Program: program, attributes: map[string]int{}, uniforms: map[string]int{}}
+
+	var count, maxLength int32
+	gl.GetProgramiv(program, gl.ACTIVE_ATTRIBUTES, &count)
+	gl.GetProgramiv(program, gl.ACTIVE_ATTRIBUTE_MAX_LENGTH, &maxLength)
+	name := make([]uint8, maxLength+1)
+	for i := int32(0); i < count; i++ {
+		var length, size int32
+		var xtype uint32
+		gl.GetActiveAttrib(program, uint32(i), maxLength+1, &length, &size, &xtype, &name[0])
+		v := ProgramVariable{Name: string(name[:length]), Type: xtype, Size: size}
+		if strings.HasPrefix(v.Name, "gl_") {
+			continue // built-in, e.g. gl_VertexID
+		}
+		v.Location = gl.GetAttribLocation(program, gl.Str(v.Name+"\x00"))
+		info.attributes[v.Name] = len(info.Attributes)
+		info.Attributes = append(info.Attributes, v)
+	}
+
+	gl.GetProgramiv(program, gl.ACTIVE_UNIFORMS, &count)
+	gl.GetProgramiv(program, gl.ACTIVE_UNIFORM_MAX_LENGTH, &maxLength)
+	name = make([]uint8, maxLength+1)
+	for i := int32(0); i < count; i++ {
+		var length, size int32
+		var xtype uint32
+		gl.GetActiveUniform(program, uint32(i), maxLength+1, &length, &size, &xtype, &name[0])
+		v := ProgramVariable{Name: strings.TrimSuffix(string(name[:length]), "[0]"), Type: xtype, Size: size}
+		v.Location = gl.GetUniformLocation(program, gl.Str(v.Name+"\x00"))
+		info.uniforms[v.Name] = len(info.Uniforms)
+		info.Uniforms = append(info.Uniforms, v)
+	}
+
+	return info
+
+}
+
+// Attribute is the active attribute called name
+func (info *ProgramInfo) Attribute(name string) (ProgramVariable, bool) {
+	i, ok := info.attributes[name]
+	if !ok {
+		return ProgramVariable{Location: -1}, false
+	}
+	return info.Attributes[i], true
+}
+
+// Uniform is the active uniform called name, arrays without "[0]"
+func (info *ProgramInfo) Uniform(name string) (ProgramVariable, bool) {
+	i, ok := info.uniforms[name]
+	if !ok {
+		return ProgramVariable{Location: -1}, false
+	}
+	return info.Uniforms[i], true
+}
+
+// UniformLocation is like glGetUniformLocation without a GL call, -1 if the uniform is not active
+func (info *ProgramInfo) UniformLocation(name string) int32 {
+	v, _ := info.Uniform(name)
+	return v.Location
+}
+
+// Samplers are the sampler uniforms, the textures a draw with the program needs
+func (info *ProgramInfo) Samplers() []ProgramVariable {
+	var samplers []ProgramVariable
+	for _, v := range info.Uniforms {
+		if v.IsSampler() {
+			samplers = append(samplers, v)
+		}
+	}
+	return samplers
+}
+
+// ProgramInfos holds the ProgramInfo of every program linked by linkProgram
+type ProgramInfos struct {
+	programs map[uint32]*ProgramInfo
+}
+
+// register reflects a linked program
+func (p *ProgramInfos) register(program uint32) {
+	p.programs[program] = reflectProgram(program)
+}
+
+// Get is the ProgramInfo of program. Programs not linked by linkProgram are
+// reflected on first use, requires a current GL context.
+func (p *ProgramInfos) Get(program uint32) *ProgramInfo {
+	info, ok := p.programs[program]
+	if !ok {
+		info = reflectProgram(program)
+		p.programs[program] = info
+	}
+	return info
+}
+
+// unregister drops a program, called when the program is deleted
+func (p *ProgramInfos) unregister(program uint32) {
+	delete(p.programs, program)
+}
+
+// forget drops every program without touching GL, used when the GL context is lost
+func (p *ProgramInfos) forget() {
+	p.programs = map[uint32]*ProgramInfo{}
+}
@@ -0,0 +1,432 @@
+package multisample
+
+import (
+	"fmt"
+
+	"github.com/go-gl/gl/v3.2-core/gl"
+)
+
+var (
+	renderGraph = NewRenderGraph()
+)
+
+// GraphSize is the size of a render graph texture, it follows the window
+type GraphSize int
+
+const (
+	SizeRender GraphSize = iota // renderSize, the offscreen framebuffers (see -renderscale)
+	SizeScreen                  // screenSize, the default framebuffer
+)
+
+// TextureDesc describes a texture of the render graph
+type TextureDesc struct {
+	InternalFormat int32  // e.g. gl.RGBA or gl.RGBA16F
+	Format         uint32 // pixel format of the internal format, e.g. gl.RGBA
+	Samples        int32  // > 0 for a multisample texture, 0 for a plain one
+	Size           GraphSize
+}
+
+// size is the texture size in pixels, and the part of it frames draw into
+func (d TextureDesc) size() (width, height, usedWidth, usedHeight int32) {
+	if d.Size == SizeScreen {
+		width, height = screenSize()
+		return width, height, width, height
+	}
+	width, height = renderSize()
+	usedWidth, usedHeight = viewportSize() // dynamic resolution draws into a corner
+	return width, height, usedWidth, usedHeight
+}
+
+// RenderPass is a step of the frame. It declares the textures it reads
+// (Reads) and writes (Writes), the graph orders the passes by them and
+// prepares the textures before Run is called. Run finds the textures and
+// framebuffers by name (see RenderGraph.Texture and Framebuffer).
+type RenderPass struct {
+	Name   string
+	Reads  []string
+	Writes []string
+	Run    func(g *RenderGraph)
+}
+
+// graphResource is a named texture of the graph
+type graphResource struct {
+	name     string
+	desc     TextureDesc
+	imported bool
+
+	// imported resources are owned by a context, looked up every frame since
+	// contexts recreate them (e.g. on resize); framebuffer may be nil
+	texture     func() uint32
+	framebuffer func() uint32
+
+	resolve *graphResource // single sample copy read by passes, for multisample resources
+	target  *graphTexture  // texture the graph allocated, for declared resources
+}
+
+// graphTexture is a texture and framebuffer allocated by the graph. Resources
+// alive at different times of the frame share one (aliasing).
+type graphTexture struct {
+	desc      TextureDesc
+	texture   uint32
+	fbo       uint32
+	resources []*graphResource
+	lastStep  int // last step using it, later resources may alias it
+}
+
+// graphStep is a pass or a blit the graph inserted to resolve a multisample resource
+type graphStep struct {
+	pass     *RenderPass
+	from, to *graphResource // blits only
+}
+
+// RenderGraph declares the frame as passes and the textures between them
+// instead of hand-wiring framebuffers:
+//
+//   - passes run in dependency order, a pass reading a texture runs after
+//     the passes writing it. Passes without a dependency between them keep
+//     the order they were added in.
+//   - a multisample texture read by a pass is resolved first, the graph
+//     inserts the blit into a single sample copy (what ctxBlitz used to do).
+//   - declared textures are allocated by the graph, one framebuffer each,
+//     and textures whose lifetimes do not overlap share the same memory.
+//     Imported textures belong to a context (e.g. the multisample
+//     framebuffer, the default framebuffer), the graph only orders their use.
+//
+// The graph is compiled once in setup, after all passes were added.
+type RenderGraph struct {
+	resources map[string]*graphResource
+	names     []string // declaration order
+	passes    []*RenderPass
+	steps     []graphStep
+	textures  []*graphTexture
+}
+
+// NewRenderGraph creates an empty graph
+func NewRenderGraph() *RenderGraph {
+	return &RenderGraph{resources: map[string]*graphResource{}}
+}
+
+// Declare adds a texture the graph allocates, its contents only live from
+// the pass writing it to the last pass reading it
+func (g *RenderGraph) Declare(name string, desc TextureDesc) {
+	g.add(&graphResource{name: name, desc: desc})
+}
+
+// Import adds a texture owned by a context, framebuffer is the one it is
+// attached to (nil if passes do not need it, 0 for the default framebuffer)
+func (g *RenderGraph) Import(name string, desc TextureDesc, texture, framebuffer func() uint32) {
+	g.add(&graphResource{name: name, desc: desc, imported: true, texture: texture, framebuffer: framebuffer})
+}
+
+func (g *RenderGraph) add(r *graphResource) {
+	if _, ok := g.resources[r.name]; ok {
+		panic(fmt.Sprintf("GRAPH: texture %q declared twice", r.name))
+	}
+	g.resources[r.name] = r
+	g.names = append(g.names, r.name)
+}
+
+// AddPass adds a pass, before compile
+func (g *RenderGraph) AddPass(pass *RenderPass) {
+	g.passes = append(g.passes, pass)
+}
+
+// compile orders the passes, inserts the resolve blits and allocates the
+// declared textures. Requires a current GL context.
+func (g *RenderGraph) compile() error {
+
+	// every texture used must be declared, and written before it is read
+	writers := map[*graphResource][]int{}
+	for i, pass := range g.passes {
+		for _, name := range append(append([]string{}, pass.Reads...), pass.Writes...) {
+			if g.resources[name] == nil {
+				return fmt.Errorf("pass %q uses undeclared texture %q", pass.Name, name)
+			}
+		}
+		for _, name := range pass.Writes {
+			writers[g.resources[name]] = append(writers[g.resources[name]], i)
+		}
+	}
+
+	// order by dependencies (Kahn), taking the earliest added pass that is ready
+	dependencies := make([]map[int]bool, len(g.passes))
+	for i, pass := range g.passes {
+		dependencies[i] = map[int]bool{}
+		for _, name := range pass.Reads {
+			r := g.resources[name]
+			if len(writers[r]) == 0 && !r.imported {
+				return fmt.Errorf("pass %q reads %q, no pass writes it", pass.Name, name)
+			}
+			for _, writer := range writers[r] {
+				if writer != i {
+					dependencies[i][writer] = true
+				}
+			}
+		}
+	}
+	done := make([]bool, len(g.passes))
+	order := make([]int, 0, len(g.passes))
+	for len(order) < len(g.passes) {
+		next := -1
+		for i := range g.passes {
+			if done[i] {
+				continue
+			}
+			ready := true
+			for dependency := range dependencies[i] {
+				ready = ready && done[dependency]
+			}
+			if ready {
+				next = i
+				break
+			}
+		}
+		if next == -1 {
+			return fmt.Errorf("passes depend on each other in a cycle")
+		}
+		done[next] = true
+		order = append(order, next)
+	}
+
+	// steps, with a resolve before the first read after each write of a multisample texture
+	g.steps = nil
+	resolved := map[*graphResource]bool{}
+	for _, i := range order {
+		pass := g.passes[i]
+		for _, name := range pass.Reads {
+			r := g.resources[name]
+			if r.desc.Samples == 0 || resolved[r] {
+				continue
+			}
+			if r.resolve == nil {
+				r.resolve = &graphResource{name: r.name + " resolved", desc: resolvedDesc(r.desc)}
+				g.add(r.resolve)
+			}
+			g.steps = append(g.steps, graphStep{from: r, to: r.resolve})
+			resolved[r] = true
+		}
+		for _, name := range pass.Writes {
+			resolved[g.resources[name]] = false
+		}
+		g.steps = append(g.steps, graphStep{pass: pass})
+	}
+
+	g.allocate()
+	return nil
+
+}
+
+// resolvedDesc is the single sample copy of a multisample texture. The alpha
+// of the scene is only kept for a transparent window (see WindowOptions).
+func resolvedDesc(desc TextureDesc) TextureDesc {
+	desc.Samples = 0
+	if desc.InternalFormat == gl.RGBA && !*transparentMode {
+		desc.InternalFormat, desc.Format = gl.RGB, gl.RGB
+	}
+	return desc
+}
+
+// lifetime is the first step writing and the last step reading a resource, -1 if unused
+func (g *RenderGraph) lifetime(r *graphResource) (first, last int) {
+	first, last = -1, -1
+	for s, step := range g.steps {
+		uses := step.to == r || step.from == r
+		if step.pass != nil {
+			for _, name := range append(append([]string{}, step.pass.Reads...), step.pass.Writes...) {
+				uses = uses || g.resources[name] == r || g.resources[name].resolve == r
+			}
+		}
+		if uses {
+			if first == -1 {
+				first = s
+			}
+			last = s
+		}
+	}
+	return first, last
+}
+
+// allocate creates the textures of the declared resources, reusing the
+// texture of a resource that is done by the time the next one is written
+func (g *RenderGraph) allocate() {
+
+	g.destroy()
+	for _, name := range g.names {
+		r := g.resources[name]
+		if r.imported {
+			continue
+		}
+		first, last := g.lifetime(r)
+		if first == -1 {
+			continue // nobody uses it, nothing to allocate
+		}
+
+		var target *graphTexture
+		for _, t := range g.textures {
+			if t.desc == r.desc && t.lastStep < first {
+				target = t
+				break
+			}
+		}
+		if target == nil {
+			target = &graphTexture{desc: r.desc}
+			g.textures = append(g.textures, target)
+		}
+		target.resources = append(target.resources, r)
+		target.lastStep = last
+		r.target = target
+	}
+
+	for _, t := range g.textures {
+		t.create()
+	}
+
+}
+
+// label names the texture after the resources sharing it
+func (t *graphTexture) label() string {
+	label := "graph"
+	for _, r := range t.resources {
+		label += " " + r.name
+	}
+	return label
+}
+
+// create allocates the texture at the current size and attaches it to a framebuffer
+func (t *graphTexture) create() {
+
+	width, height, _, _ := t.desc.size()
+	t.texture = genTexture(t.label())
+	if t.desc.Samples > 0 {
+		samples := glInfo.Samples(t.desc.Samples)
+		gl.BindTexture(gl.TEXTURE_2D_MULTISAMPLE, t.texture)
+		gl.TexImage2DMultisample(gl.TEXTURE_2D_MULTISAMPLE, samples, uint32(t.desc.InternalFormat), width, height, true)
+		gl.BindTexture(gl.TEXTURE_2D_MULTISAMPLE, 0)
+		gpuResources.SetBytes(ResourceTexture, t.texture, int(width)*int(height)*4*int(samples))
+	} else {
+		gl.BindTexture(gl.TEXTURE_2D, t.texture)
+		gl.TexImage2D(gl.TEXTURE_2D, 0, t.desc.InternalFormat, width, height, 0, t.desc.Format, gl.UNSIGNED_BYTE, nil)
+		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, renderFilter())
+		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, renderFilter())
+		gl.BindTexture(gl.TEXTURE_2D, 0)
+		gpuResources.SetBytes(ResourceTexture, t.texture, int(width)*int(height)*4) // RGB is usually padded to 4 bytes per texel
+	}
+
+	t.fbo = genFramebuffer(t.label() + " fbo")
+	gl.BindFramebuffer(gl.FRAMEBUFFER, t.fbo)
+	target := uint32(gl.TEXTURE_2D)
+	if t.desc.Samples > 0 {
+		target = gl.TEXTURE_2D_MULTISAMPLE
+	}
+	gl.FramebufferTexture2D(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT0, target, t.texture, 0)
+	CheckGLFramebufferStatus()
+	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+
+}
+
+func (t *graphTexture) destroy() {
+	gpuResources.Release(ResourceFramebuffer, t.fbo)
+	gpuResources.Release(ResourceTexture, t.texture)
+	t.fbo, t.texture = 0, 0
+}
+
+// execute runs the steps of a frame. Each pass starts with the viewport on
+// the part of the first texture it writes that frames draw into.
+func (g *RenderGraph) execute() {
+	for _, step := range g.steps {
+		if step.pass != nil {
+			if len(step.pass.Writes) > 0 {
+				_, _, width, height := g.resources[step.pass.Writes[0]].desc.size()
+				setViewport(0, 0, width, height)
+			}
+			step.pass.Run(g)
+			continue
+		}
+
+		// resolve the samples of the part drawn into
+		_, _, width, height := step.from.desc.size()
+		gl.BindFramebuffer(gl.READ_FRAMEBUFFER, g.framebufferOf(step.from))
+		gl.BindFramebuffer(gl.DRAW_FRAMEBUFFER, g.framebufferOf(step.to))
+		gl.BlitFramebuffer(0, 0, width, height, 0, 0, width, height, gl.COLOR_BUFFER_BIT, gl.NEAREST)
+		gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+	}
+}
+
+// readable is the resource passes sample for name, its resolved copy if it is multisample
+func (g *RenderGraph) readable(name string) *graphResource {
+	r, ok := g.resources[name]
+	if !ok {
+		panic(fmt.Sprintf("GRAPH: no texture %q", name))
+	}
+	if r.resolve != nil {
+		return r.resolve
+	}
+	return r
+}
+
+// Texture is the texture to sample for name, resolved if it is multisample.
+// 0 before compile.
+func (g *RenderGraph) Texture(name string) uint32 {
+	r := g.readable(name)
+	switch {
+	case r.imported:
+		return r.texture()
+	case r.target != nil:
+		return r.target.texture
+	}
+	return 0
+}
+
+// Framebuffer is the framebuffer holding name as color attachment, resolved
+// if it is multisample, e.g. for glReadPixels
+func (g *RenderGraph) Framebuffer(name string) uint32 {
+	return g.framebufferOf(g.readable(name))
+}
+
+// WriteFramebuffer is the framebuffer a pass draws name into
+func (g *RenderGraph) WriteFramebuffer(name string) uint32 {
+	r, ok := g.resources[name]
+	if !ok {
+		panic(fmt.Sprintf("GRAPH: no texture %q", name))
+	}
+	return g.framebufferOf(r)
+}
+
+func (g *RenderGraph) framebufferOf(r *graphResource) uint32 {
+	switch {
+	case r.imported && r.framebuffer != nil:
+		return r.framebuffer()
+	case r.target != nil:
+		return r.target.fbo
+	}
+	panic(fmt.Sprintf("GRAPH: texture %q has no framebuffer", r.name))
+}
+
+// OutputTexture is Texture as a *Texture, for PassOutputs
+func (g *RenderGraph) OutputTexture(name string) *Texture {
+	if _, ok := g.resources[name]; !ok {
+		return nil
+	}
+	r := g.readable(name)
+	width, height, _, _ := r.desc.size()
+	return &Texture{ID: g.Texture(name), Width: int(width), Height: int(height)}
+}
+
+// resize recreates the allocated textures at the current size
+func (g *RenderGraph) resize() {
+	for _, t := range g.textures {
+		t.destroy()
+		t.create()
+	}
+}
+
+// destroy releases the allocated textures, compile allocates them again
+func (g *RenderGraph) destroy() {
+	for _, t := range g.textures {
+		t.destroy()
+	}
+	g.textures = nil
+	for _, r := range g.resources {
+		r.target = nil
+	}
+}
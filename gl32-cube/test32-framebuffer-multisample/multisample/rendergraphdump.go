@@ -0,0 +1,174 @@
+package multisample
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-gl/gl/v3.2-core/gl"
+)
+
+// graphDump is the compiled render graph as written by writeJSON, the DOT
+// output shows the same: passes in execution order, resolve blits, and the
+// textures with their formats, sizes and the allocations they share
+type graphDump struct {
+	Steps    []graphDumpStep    `json:"steps"`
+	Textures []graphDumpTexture `json:"textures"`
+}
+
+type graphDumpStep struct {
+	Name   string   `json:"name"`
+	Blit   bool     `json:"blit,omitempty"` // inserted by the graph to resolve a multisample texture
+	Reads  []string `json:"reads"`
+	Writes []string `json:"writes"`
+}
+
+type graphDumpTexture struct {
+	Name       string `json:"name"`
+	Format     string `json:"format"`
+	Samples    int32  `json:"samples,omitempty"`
+	Width      int32  `json:"width"`
+	Height     int32  `json:"height"`
+	Imported   bool   `json:"imported,omitempty"` // owned by a context, not allocated by the graph
+	Allocation int    `json:"allocation"`         // index of the allocated texture, shared by aliased textures, -1 if imported or unused
+}
+
+// dump describes the graph at the current render and screen size, after compile
+func (g *RenderGraph) dump() graphDump {
+
+	var d graphDump
+	for _, step := range g.steps {
+		if step.pass == nil {
+			d.Steps = append(d.Steps, graphDumpStep{Name: "resolve " + step.from.name, Blit: true, Reads: []string{step.from.name}, Writes: []string{step.to.name}})
+			continue
+		}
+		d.Steps = append(d.Steps, graphDumpStep{Name: step.pass.Name, Reads: step.pass.Reads, Writes: step.pass.Writes})
+	}
+
+	for _, name := range g.names {
+		r := g.resources[name]
+		width, height, _, _ := r.desc.size()
+		allocation := -1
+		for i, t := range g.textures {
+			if t == r.target {
+				allocation = i
+			}
+		}
+		d.Textures = append(d.Textures, graphDumpTexture{
+			Name:       r.name,
+			Format:     formatName(r.desc.InternalFormat),
+			Samples:    r.desc.Samples,
+			Width:      width,
+			Height:     height,
+			Imported:   r.imported,
+			Allocation: allocation,
+		})
+	}
+
+	return d
+
+}
+
+// writeJSON writes the dump as indented JSON
+func (g *RenderGraph) writeJSON(w io.Writer) error {
+	data, err := json.MarshalIndent(g.dump(), "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// writeDOT writes the dump as a Graphviz digraph, passes are boxes and
+// textures ellipses (dashed if imported), e.g. dot -Tsvg graph.dot > graph.svg
+func (g *RenderGraph) writeDOT(w io.Writer) error {
+
+	d := g.dump()
+	var b strings.Builder
+	b.WriteString("digraph render {\n\trankdir=LR;\n\tnode [fontname=\"monospace\"];\n")
+
+	for _, t := range d.Textures {
+		label := fmt.Sprintf("%v\\n%v %vx%v", t.Name, t.Format, t.Width, t.Height)
+		if t.Samples > 0 {
+			label += fmt.Sprintf(" x%v", t.Samples)
+		}
+		style := "solid"
+		if t.Imported {
+			style = "dashed"
+		} else if t.Allocation >= 0 {
+			label += fmt.Sprintf("\\nallocation %v", t.Allocation)
+		}
+		fmt.Fprintf(&b, "\t%q [shape=ellipse, style=%v, label=\"%v\"];\n", "texture "+t.Name, style, label)
+	}
+
+	for i, step := range d.Steps {
+		shape := "box"
+		if step.Blit {
+			shape = "cds"
+		}
+		node := fmt.Sprintf("pass %v", i)
+		fmt.Fprintf(&b, "\t%q [shape=%v, label=\"%v. %v\"];\n", node, shape, i+1, step.Name)
+		for _, name := range step.Reads {
+			fmt.Fprintf(&b, "\t%q -> %q;\n", "texture "+name, node)
+		}
+		for _, name := range step.Writes {
+			fmt.Fprintf(&b, "\t%q -> %q;\n", node, "texture "+name)
+		}
+	}
+
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+
+}
+
+// dumpRenderGraph writes the graph to path (-graphdump), as JSON if the
+// extension is .json and as Graphviz DOT otherwise
+func dumpRenderGraph(g *RenderGraph, path string) {
+
+	file, err := os.Create(path)
+	if err != nil {
+		log.Println("failed to write render graph:", err)
+		return
+	}
+	defer file.Close()
+
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		err = g.writeJSON(file)
+	} else {
+		err = g.writeDOT(file)
+	}
+	if err != nil {
+		log.Println("failed to write render graph:", err)
+		return
+	}
+	fmt.Printf("GRAPH -- render graph written to %v\n", path)
+
+}
+
+// formatName is the GL name of an internal texture format
+func formatName(format int32) string {
+	switch format {
+	case gl.RGB:
+		return "RGB"
+	case gl.RGBA:
+		return "RGBA"
+	case gl.RGBA8:
+		return "RGBA8"
+	case gl.SRGB8_ALPHA8:
+		return "SRGB8_ALPHA8"
+	case gl.RGBA16F:
+		return "RGBA16F"
+	case gl.RGBA32F:
+		return "RGBA32F"
+	case gl.R8:
+		return "R8"
+	case gl.R32F:
+		return "R32F"
+	}
+	return fmt.Sprintf("0x%x", format)
+}
@@ -0,0 +1,281 @@
+package multisample
+
+import (
+	"fmt"
+
+	"github.com/go-gl/gl/v3.2-core/gl"
+)
+
+var (
+	renderer = &Renderer{}
+)
+
+// PassOptions are the attachments of a Pass and the size they follow
+type PassOptions struct {
+	Size    GraphSize // follow renderSize (the default) or screenSize
+	Divisor int32     // fraction of that size, e.g. 2 for half resolution (rounded up), 0 = 1
+	Width   int32     // fixed size instead, e.g. a shadow map, 0 = follow Size
+	Height  int32
+
+	Color   int32 // internal format of the color texture, e.g. gl.RGBA8, 0 = no color
+	Filter  int32 // filter of the color texture, 0 = renderFilter()
+	Depth   int32 // internal format of the depth attachment, e.g. gl.DEPTH_COMPONENT24, 0 = no depth
+	Samples int32 // > 0 for multisample attachments
+
+	// sample the depth (e.g. shadows, depth of field) instead of only
+	// testing against it, a texture instead of a renderbuffer
+	DepthTexture bool
+}
+
+// Pass is a framebuffer with its attachments, created by Renderer.Pass. It
+// recreates the attachment storage when the size it follows changes (see
+// Renderer.resize), the GL object names stay the same, so materials and
+// framebuffers using them need no update.
+type Pass struct {
+	name  string
+	opts  PassOptions
+	fbo   uint32
+	color uint32 // texture, 0 without color
+	depth uint32 // texture or renderbuffer (see DepthTexture), 0 without depth
+
+	width, height int32
+}
+
+// Renderer owns the passes, so they follow the window and render scale
+type Renderer struct {
+	passes []*Pass
+}
+
+// Pass creates a framebuffer called name with the attachments of opts and
+// checks it is complete, e.g.
+//
+//	reflection, err := renderer.Pass("water reflection", PassOptions{Divisor: 2, Color: gl.RGBA8, Depth: gl.DEPTH_COMPONENT24})
+//
+// Requires a current GL context.
+func (r *Renderer) Pass(name string, opts PassOptions) (*Pass, error) {
+
+	p := &Pass{name: name, opts: opts}
+	p.fbo = genFramebuffer(name + " fbo")
+	if opts.Color != 0 {
+		p.color = genTexture(name + " color")
+	}
+	if opts.Depth != 0 && opts.DepthTexture {
+		p.depth = genTexture(name + " depth")
+	} else if opts.Depth != 0 {
+		p.depth = genRenderbuffer(name + " depth")
+	}
+
+	if err := p.allocate(); err != nil {
+		p.destroy()
+		return nil, err
+	}
+	r.passes = append(r.passes, p)
+	return p, nil
+
+}
+
+// size is the size the pass follows, in pixels
+func (p *Pass) size() (width, height int32) {
+	if p.opts.Width > 0 && p.opts.Height > 0 {
+		return p.opts.Width, p.opts.Height
+	}
+	if p.opts.Size == SizeScreen {
+		width, height = screenSize()
+	} else {
+		width, height = renderSize()
+	}
+	if divisor := p.opts.Divisor; divisor > 1 {
+		width, height = (width+divisor-1)/divisor, (height+divisor-1)/divisor
+	}
+	return width, height
+}
+
+// allocate (re)specifies the attachment storage at the current size and
+// attaches it, the framebuffer must be complete
+func (p *Pass) allocate() error {
+
+	p.width, p.height = p.size()
+	gl.BindFramebuffer(gl.FRAMEBUFFER, p.fbo)
+	defer gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+
+	if p.color != 0 {
+		p.attachTexture(p.color, p.opts.Color, gl.COLOR_ATTACHMENT0)
+	} else {
+		gl.DrawBuffer(gl.NONE)
+		gl.ReadBuffer(gl.NONE)
+	}
+
+	switch {
+	case p.depth != 0 && p.opts.DepthTexture:
+		p.attachTexture(p.depth, p.opts.Depth, gl.DEPTH_ATTACHMENT)
+	case p.depth != 0:
+		samples := int32(1)
+		gl.BindRenderbuffer(gl.RENDERBUFFER, p.depth)
+		if p.opts.Samples > 0 {
+			samples = glInfo.Samples(p.opts.Samples)
+			gl.RenderbufferStorageMultisample(gl.RENDERBUFFER, samples, uint32(p.opts.Depth), p.width, p.height)
+		} else {
+			gl.RenderbufferStorage(gl.RENDERBUFFER, uint32(p.opts.Depth), p.width, p.height)
+		}
+		gl.BindRenderbuffer(gl.RENDERBUFFER, 0)
+		gpuResources.SetBytes(ResourceRenderbuffer, p.depth, int(p.width)*int(p.height)*4*int(samples))
+		gl.FramebufferRenderbuffer(gl.FRAMEBUFFER, gl.DEPTH_ATTACHMENT, gl.RENDERBUFFER, p.depth)
+	}
+
+	if status := gl.CheckFramebufferStatus(gl.FRAMEBUFFER); status != gl.FRAMEBUFFER_COMPLETE {
+		name, ok := GL_FRAMEBUFFER_STATUS_LOOKUP[status]
+		if !ok {
+			name = fmt.Sprintf("0x%x", status)
+		}
+		return fmt.Errorf("pass %q: framebuffer incomplete, %v", p.name, name)
+	}
+	return nil
+
+}
+
+// attachTexture specifies the storage of a color or depth texture and attaches it
+func (p *Pass) attachTexture(texture uint32, internalFormat int32, attachment uint32) {
+
+	format, xtype, bytes := pixelFormatOf(internalFormat)
+	if p.opts.Samples > 0 {
+		samples := glInfo.Samples(p.opts.Samples)
+		gl.BindTexture(gl.TEXTURE_2D_MULTISAMPLE, texture)
+		gl.TexImage2DMultisample(gl.TEXTURE_2D_MULTISAMPLE, samples, uint32(internalFormat), p.width, p.height, true)
+		gl.BindTexture(gl.TEXTURE_2D_MULTISAMPLE, 0)
+		gpuResources.SetBytes(ResourceTexture, texture, int(p.width)*int(p.height)*bytes*int(samples))
+		gl.FramebufferTexture2D(gl.FRAMEBUFFER, attachment, gl.TEXTURE_2D_MULTISAMPLE, texture, 0)
+		return
+	}
+
+	filter := p.opts.Filter
+	switch {
+	case attachment == gl.DEPTH_ATTACHMENT:
+		filter = gl.NEAREST // exact depths
+	case filter == 0:
+		filter = renderFilter()
+	}
+	gl.BindTexture(gl.TEXTURE_2D, texture)
+	gl.TexImage2D(gl.TEXTURE_2D, 0, internalFormat, p.width, p.height, 0, format, xtype, nil)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, filter)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, filter)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
+	gl.BindTexture(gl.TEXTURE_2D, 0)
+	gpuResources.SetBytes(ResourceTexture, texture, int(p.width)*int(p.height)*bytes)
+	gl.FramebufferTexture2D(gl.FRAMEBUFFER, attachment, gl.TEXTURE_2D, texture, 0)
+
+}
+
+// Framebuffer is the framebuffer, to bind for drawing or to blit from
+func (p *Pass) Framebuffer() uint32 {
+	return p.fbo
+}
+
+// ColorTexture is the color attachment, 0 without color
+func (p *Pass) ColorTexture() uint32 {
+	return p.color
+}
+
+// Depth is the depth attachment, a texture with DepthTexture and a renderbuffer otherwise
+func (p *Pass) Depth() uint32 {
+	return p.depth
+}
+
+// Size is the size of the attachments in pixels
+func (p *Pass) Size() (width, height int32) {
+	return p.width, p.height
+}
+
+// Viewport is the part of the attachments a frame draws into: all of it for
+// a fixed size or the screen, the dynamic resolution part of renderSize
+func (p *Pass) Viewport() (width, height int32) {
+	if (p.opts.Width > 0 && p.opts.Height > 0) || p.opts.Size == SizeScreen {
+		return p.width, p.height
+	}
+	width, height = viewportSize()
+	if divisor := p.opts.Divisor; divisor > 1 {
+		width, height = (width+divisor-1)/divisor, (height+divisor-1)/divisor
+	}
+	return width, height
+}
+
+// Bind makes the pass the draw target, with viewport and scissor box on its Viewport
+func (p *Pass) Bind() {
+	width, height := p.Viewport()
+	gl.BindFramebuffer(gl.FRAMEBUFFER, p.fbo)
+	setViewport(0, 0, width, height)
+}
+
+// setViewport sets the viewport and the scissor box, so passes enabling the
+// scissor test are clipped to their viewport rather than a stale rectangle
+func setViewport(x, y, width, height int32) {
+	gl.Viewport(x, y, width, height)
+	gl.Scissor(x, y, width, height)
+}
+
+// Texture is the color attachment as a *Texture, for PassOutputs
+func (p *Pass) Texture() *Texture {
+	return &Texture{ID: p.color, Width: int(p.width), Height: int(p.height)}
+}
+
+// destroy releases the framebuffer and attachments and stops resizing the pass
+func (p *Pass) destroy() {
+
+	gpuResources.Release(ResourceFramebuffer, p.fbo)
+	gpuResources.Release(ResourceTexture, p.color)
+	if p.opts.DepthTexture {
+		gpuResources.Release(ResourceTexture, p.depth)
+	} else {
+		gpuResources.Release(ResourceRenderbuffer, p.depth)
+	}
+	p.fbo, p.color, p.depth = 0, 0, 0
+
+	for i, pass := range renderer.passes {
+		if pass == p {
+			renderer.passes = append(renderer.passes[:i], renderer.passes[i+1:]...)
+			break
+		}
+	}
+
+}
+
+// resize reallocates the passes following the window, after the window size
+// or render scale changed. The filter of the color textures is applied
+// again, renderFilter depends on the render scale.
+func (r *Renderer) resize() {
+	for _, p := range r.passes {
+		if p.opts.Width > 0 && p.opts.Height > 0 {
+			continue // fixed size
+		}
+		if err := p.allocate(); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// forget drops the passes of a lost context (see ContextRecovery), their GL objects died with it
+func (r *Renderer) forget() {
+	r.passes = nil
+}
+
+// pixelFormatOf is the pixel format and type matching an internal format
+// (for glTexImage2D without data), and its size per texel
+func pixelFormatOf(internalFormat int32) (format, xtype uint32, bytes int) {
+	switch internalFormat {
+	case gl.RGBA16F:
+		return gl.RGBA, gl.HALF_FLOAT, 8
+	case gl.RGBA32F:
+		return gl.RGBA, gl.FLOAT, 16
+	case gl.R8:
+		return gl.RED, gl.UNSIGNED_BYTE, 1
+	case gl.R32F:
+		return gl.RED, gl.FLOAT, 4
+	case gl.RGB, gl.RGB8:
+		return gl.RGB, gl.UNSIGNED_BYTE, 4 // RGB is usually padded to 4 bytes per texel
+	case gl.DEPTH_COMPONENT16, gl.DEPTH_COMPONENT24, gl.DEPTH_COMPONENT32F:
+		return gl.DEPTH_COMPONENT, gl.FLOAT, 4
+	case gl.DEPTH24_STENCIL8:
+		return gl.DEPTH_STENCIL, gl.UNSIGNED_INT_24_8, 4
+	}
+	return gl.RGBA, gl.UNSIGNED_BYTE, 4
+}
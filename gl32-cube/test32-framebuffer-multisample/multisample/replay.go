@@ -0,0 +1,281 @@
+package multisample
+
+import (
+	"bufio"
+	"fmt"
+	"math/rand"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/paperboard/glfw/v3.3/glfw"
+)
+
+const (
+	captureSeed = 1 // random numbers seed of the scene for -thumbnail and -screenshot, same colors every run
+)
+
+var (
+	replay = &Replay{}
+
+	// inputRand are the random numbers of input actions (see addRandomQuad),
+	// seeded by the recording so the same quads appear on playback
+	inputRand = rand.New(rand.NewSource(time.Now().UnixNano()))
+
+	// sceneRand are the random numbers of the scene, e.g. the colors of the
+	// quads every frame (see RandomColorInRGBA), seeded like inputRand
+	sceneRand = rand.New(rand.NewSource(time.Now().UnixNano()))
+
+	// replayValues is the number of values of each record
+	replayValues = map[string]int{"seed": 1, "window": 2, "frame": 1, "key": 3, "button": 5, "cursor": 2, "scroll": 4, "gamepad": 3}
+)
+
+// Replay records the input of a session (-record) and plays it back
+// (-replay), so the session happens again exactly: the same keys and mouse
+// moves reach the same frames, with the same update time.
+//
+// A recording is a text file: the seed of the random numbers (see
+// seedRandom), the window size, then per frame the wall time it took and the
+// input that arrived after it was drawn, e.g.
+//
+//	seed 1718040187
+//	window 1280 720
+//	frame 0.016684
+//	key 78 1 0
+//	cursor 412.5 300
+//
+// Playback feeds the recorded times to the update clock and publishes the
+// recorded events to the event bus at the same frames, live input is ignored
+// until the recording ends. Anything following the wall clock rather than
+// the update clock (-dynres, -worker, the frame-time graph) and window
+// resizes after the start are not replayed.
+type Replay struct {
+	file     *os.File
+	out      *bufio.Writer // recording, nil when not recording
+	recorded int           // frames recorded, input before the first is dropped
+
+	frames []replayFrame // playback, nil when not playing
+	frame  int           // next frame to play
+}
+
+// replayFrame is the wall time of a frame and the input after it
+type replayFrame struct {
+	elapsed float64
+	events  []interface{}
+}
+
+// record starts writing the input to path, with a new seed for the random
+// numbers. Start it before the scene loads, its colors are random too.
+func (r *Replay) record(path string, window *glfw.Window) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	r.file, r.out = file, bufio.NewWriter(file)
+
+	seed := time.Now().UnixNano()
+	seedRandom(seed)
+	width, height := window.GetSize()
+	fmt.Fprintf(r.out, "seed %d\nwindow %d %d\n", seed, width, height)
+	fmt.Println("REPLAY -- recording input to", path)
+	return nil
+}
+
+// play loads a recording and starts playing it from the next frame, in a
+// window of the recorded size. Start it before the scene loads, like record.
+func (r *Replay) play(path string, window *glfw.Window) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	r.frames = []replayFrame{}
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		if err := r.parseLine(scanner.Text(), window); err != nil {
+			r.frames = nil
+			return fmt.Errorf("%s: line %d: %v", path, line, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		r.frames = nil
+		return err
+	}
+	r.frame = 0
+	fmt.Printf("REPLAY -- playing %v frames from %v\n", len(r.frames), path)
+	return nil
+}
+
+// playing reports whether a recording is being played, live input is ignored meanwhile
+func (r *Replay) playing() bool {
+	return r.frames != nil
+}
+
+// frameTime is the wall time the clock advances by this frame: elapsed,
+// recorded when recording, or the recorded time when playing
+func (r *Replay) frameTime(elapsed float64) float64 {
+	switch {
+	case r.out != nil:
+		fmt.Fprintf(r.out, "frame %v\n", strconv.FormatFloat(elapsed, 'g', -1, 64))
+		r.recorded++
+	case r.playing() && r.frame < len(r.frames):
+		return r.frames[r.frame].elapsed
+	}
+	return elapsed
+}
+
+// input publishes the input recorded after the current frame, call it after
+// glfw.PollEvents. The last frame ends the playback.
+func (r *Replay) input(window *glfw.Window) {
+	if !r.playing() {
+		return
+	}
+	if r.frame < len(r.frames) {
+		for _, event := range r.frames[r.frame].events {
+			events.publish(withWindow(event, window))
+		}
+		r.frame++
+	}
+	if r.frame >= len(r.frames) {
+		r.frames = nil
+		fmt.Println("REPLAY -- done, live input again")
+	}
+}
+
+// write records an event of the current frame
+func (r *Replay) write(event interface{}) {
+	if r.out == nil || r.recorded == 0 {
+		return
+	}
+	f := func(v float64) string { return strconv.FormatFloat(v, 'g', -1, 64) }
+	switch e := event.(type) {
+	case KeyEvent:
+		fmt.Fprintf(r.out, "key %d %d %d\n", e.Key, e.Action, e.Mods)
+	case MouseButtonEvent:
+		fmt.Fprintf(r.out, "button %d %d %d %v %v\n", e.Button, e.Action, e.Mods, f(e.X), f(e.Y))
+	case CursorEvent:
+		fmt.Fprintf(r.out, "cursor %v %v\n", f(e.X), f(e.Y))
+	case ScrollEvent:
+		fmt.Fprintf(r.out, "scroll %v %v %v %v\n", f(e.X), f(e.Y), f(e.OffsetX), f(e.OffsetY))
+	case GamepadEvent:
+		fmt.Fprintf(r.out, "gamepad %d %d %d\n", e.Joystick, e.Kind, e.Button)
+	case DropEvent:
+		names := make([]string, len(e.Names))
+		for i, name := range e.Names {
+			names[i] = strconv.Quote(name)
+		}
+		fmt.Fprintf(r.out, "drop %v\n", strings.Join(names, " "))
+	}
+}
+
+// close finishes the recording
+func (r *Replay) close() {
+	if r.out == nil {
+		return
+	}
+	if err := r.out.Flush(); err != nil {
+		fmt.Println("REPLAY -- failed to write recording:", err)
+	}
+	r.file.Close()
+	r.file, r.out = nil, nil
+}
+
+// parseLine reads a line of a recording, events belong to the last frame
+func (r *Replay) parseLine(line string, window *glfw.Window) error {
+	command, rest, _ := strings.Cut(strings.TrimSpace(line), " ")
+	if command == "" {
+		return nil
+	}
+	if command == "drop" {
+		var names []string
+		for rest = strings.TrimSpace(rest); rest != ""; rest = strings.TrimSpace(rest) {
+			quoted, err := strconv.QuotedPrefix(rest)
+			if err != nil {
+				return err
+			}
+			name, _ := strconv.Unquote(quoted)
+			names = append(names, name)
+			rest = rest[len(quoted):]
+		}
+		return r.addEvent(DropEvent{Names: names})
+	}
+
+	fields := strings.Fields(rest)
+	v := make([]float64, len(fields))
+	for i, field := range fields {
+		var err error
+		if v[i], err = strconv.ParseFloat(field, 64); err != nil {
+			return err
+		}
+	}
+	if n, ok := replayValues[command]; !ok {
+		return fmt.Errorf("unknown record %q", command)
+	} else if len(v) != n {
+		return fmt.Errorf("%v needs %d values, got %d", command, n, len(v))
+	}
+
+	switch command {
+	case "seed":
+		seed, err := strconv.ParseInt(fields[0], 10, 64)
+		if err != nil {
+			return err
+		}
+		seedRandom(seed)
+	case "window":
+		window.SetSize(int(v[0]), int(v[1]))
+	case "frame":
+		r.frames = append(r.frames, replayFrame{elapsed: v[0]})
+	case "key":
+		return r.addEvent(KeyEvent{Key: glfw.Key(v[0]), Action: glfw.Action(v[1]), Mods: glfw.ModifierKey(v[2])})
+	case "button":
+		return r.addEvent(MouseButtonEvent{Button: glfw.MouseButton(v[0]), Action: glfw.Action(v[1]), Mods: glfw.ModifierKey(v[2]), X: v[3], Y: v[4]})
+	case "cursor":
+		return r.addEvent(CursorEvent{X: v[0], Y: v[1]})
+	case "scroll":
+		return r.addEvent(ScrollEvent{X: v[0], Y: v[1], OffsetX: v[2], OffsetY: v[3]})
+	case "gamepad":
+		return r.addEvent(GamepadEvent{Joystick: glfw.Joystick(v[0]), Kind: GamepadEventKind(v[1]), Button: glfw.GamepadButton(v[2])})
+	}
+	return nil
+}
+
+// addEvent adds a played back event to the last frame
+func (r *Replay) addEvent(event interface{}) error {
+	if len(r.frames) == 0 {
+		return fmt.Errorf("input before the first frame")
+	}
+	frame := &r.frames[len(r.frames)-1]
+	frame.events = append(frame.events, event)
+	return nil
+}
+
+// seedRandom seeds the random numbers of the input and the scene, so a
+// replay sees the same ones as the recording
+func seedRandom(seed int64) {
+	inputRand.Seed(seed)
+	sceneRand.Seed(seed)
+}
+
+// withWindow sets the window of a played back event, the recording has none
+func withWindow(event interface{}, window *glfw.Window) interface{} {
+	switch e := event.(type) {
+	case KeyEvent:
+		e.Window = window
+		return e
+	case MouseButtonEvent:
+		e.Window = window
+		return e
+	case CursorEvent:
+		e.Window = window
+		return e
+	case ScrollEvent:
+		e.Window = window
+		return e
+	case DropEvent:
+		e.Window = window
+		return e
+	}
+	return event
+}
@@ -0,0 +1,131 @@
+package multisample
+
+import (
+	"fmt"
+
+	"github.com/go-gl/gl/v3.2-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+const (
+	pixelArtWidth  = 320 // render width in -pixelart mode
+	pixelArtHeight = 180 // render height in -pixelart mode
+)
+
+const (
+	renderScaleMin  = 0.25 // lowest render scale, a quarter of the screen resolution per axis
+	renderScaleMax  = 2.0  // highest render scale, 2x2 supersampling
+	renderScaleStep = 0.25 // render scale change per key press
+)
+
+var (
+	renderScale float32 = 1 // offscreen framebuffer size relative to the screen, see setRenderScale
+)
+
+// screenSize is the size of the window's default framebuffer in pixels,
+// larger than the window size on high-dpi screens
+func screenSize() (width, height int32) {
+	return framebufferWidth, framebufferHeight
+}
+
+// renderSize is the size of the offscreen framebuffers the scene is drawn
+// into (multisample, blitz and post-processing), in pixels
+func renderSize() (width, height int32) {
+	if *pixelArtMode {
+		return pixelArtWidth, pixelArtHeight
+	}
+	width, height = screenSize()
+	width, height = int32(float32(width)*renderScale+0.5), int32(float32(height)*renderScale+0.5)
+	if width < 1 {
+		width = 1
+	}
+	if height < 1 {
+		height = 1
+	}
+	return width, height
+}
+
+// renderFilter is the filter used when the screen pass reads the offscreen
+// image: linear when its size differs from the screen, so supersampled
+// images are averaged down and undersampled ones smoothly stretched (also
+// into half the screen, for split stereo). Pixel-art stays nearest, its
+// pixels are meant to be blocks.
+func renderFilter() int32 {
+	if *pixelArtMode || (renderScale == 1 && !stereo.split()) {
+		return gl.NEAREST
+	}
+	return gl.LINEAR
+}
+
+// setRenderScale changes the offscreen framebuffer size relative to the
+// screen, >1 supersamples and <1 undersamples. The attachments of every
+// offscreen framebuffer are recreated at the new size.
+func setRenderScale(scale float32) {
+
+	scale = mgl32.Clamp(scale, renderScaleMin, renderScaleMax)
+	if scale == renderScale {
+		return
+	}
+	renderScale = scale
+
+	// GL objects do not exist yet, setup will use the new size
+	if ctxFramebufferMultisample.fbo == 0 {
+		return
+	}
+	resizeOffscreen()
+
+	width, height := renderSize()
+	fmt.Printf("render scale %v%% (%vx%v)\n", renderScale*100, width, height)
+
+}
+
+// resizeOffscreen recreates the attachments of every offscreen framebuffer
+// at the current renderSize, after the render scale or the screen changed
+func resizeOffscreen() {
+
+	ctxFramebufferMultisample.resizeAttachments()
+	renderGraph.resize()
+	renderer.resize()
+	postProcessing.resize()
+	ssao.resize()
+	depthView.resize()
+
+	// pixel snapping of the 2D camera depends on the render height
+	ctxFramebufferMultisample.camera2D.Invalidate()
+
+}
+
+// viewportSize is the part of the offscreen framebuffers the scene is drawn
+// into, smaller than renderSize while dynamic resolution scales down
+func viewportSize() (width, height int32) {
+	width, height = renderSize()
+	scale := dynamicResolution.scale
+	return int32(float32(width) * scale), int32(float32(height) * scale)
+}
+
+// renderAspect is the aspect ratio (width / height) of the offscreen framebuffers
+func renderAspect() float32 {
+	width, height := renderSize()
+	return float32(width) / float32(height)
+}
+
+// presentViewport is the part of the screen showing the rendered image.
+// In pixel-art mode the image is scaled by the largest whole factor that
+// fits, so every rendered pixel becomes an equal square block, and is
+// centered with black bars (letterboxing) around it.
+func presentViewport() (x, y, width, height int32) {
+	screenWidth, screenHeight := screenSize()
+	if !*pixelArtMode {
+		return 0, 0, screenWidth, screenHeight
+	}
+	renderWidth, renderHeight := renderSize()
+	scale := screenWidth / renderWidth
+	if s := screenHeight / renderHeight; s < scale {
+		scale = s
+	}
+	if scale < 1 {
+		scale = 1 // window smaller than the render size, crop instead
+	}
+	width, height = renderWidth*scale, renderHeight*scale
+	return (screenWidth - width) / 2, (screenHeight - height) / 2, width, height
+}
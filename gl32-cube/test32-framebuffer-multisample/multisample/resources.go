@@ -0,0 +1,320 @@
+package multisample
+
+import (
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/go-gl/gl/v3.2-core/gl"
+)
+
+const (
+	resourceStackDepth = 8 // callers recorded per GL object, for the leak report
+)
+
+// ResourceKind is the type of a GL object tracked by the resource registry
+type ResourceKind int
+
+const (
+	ResourceBuffer       ResourceKind = iota // VBO / IBO
+	ResourceTexture                          // texture (incl. multisample textures)
+	ResourceFramebuffer                      // FBO, holds no memory itself
+	ResourceRenderbuffer                     // FBO attachment (e.g. depth & stencil)
+	ResourceVertexArray                      // VAO, holds no memory itself
+	ResourceProgram                          // linked shader program
+	ResourceQuery                            // query object (e.g. GPU timer), holds no memory itself
+)
+
+// order in which Close deletes objects, containers (FBO, VAO) before what they reference
+var resourceCloseOrder = []ResourceKind{
+	ResourceFramebuffer,
+	ResourceVertexArray,
+	ResourceRenderbuffer,
+	ResourceTexture,
+	ResourceBuffer,
+	ResourceProgram,
+	ResourceQuery,
+}
+
+func (k ResourceKind) String() string {
+	switch k {
+	case ResourceBuffer:
+		return "buffer"
+	case ResourceTexture:
+		return "texture"
+	case ResourceFramebuffer:
+		return "framebuffer"
+	case ResourceRenderbuffer:
+		return "renderbuffer"
+	case ResourceVertexArray:
+		return "vertex array"
+	case ResourceProgram:
+		return "program"
+	case ResourceQuery:
+		return "query"
+	}
+	return fmt.Sprintf("ResourceKind(%d)", int(k))
+}
+
+// Resource is a single GL object known to the registry
+type Resource struct {
+	Kind  ResourceKind
+	ID    uint32
+	Label string // human readable name, e.g. "screen vbo"
+	Bytes int    // estimated GPU memory, 0 until storage is allocated
+	Refs  int    // number of owners, the GL object is deleted when it drops to 0
+
+	stack []uintptr // callers of the gen* helper that created it, see CreationStack
+}
+
+// CreationStack is where the object was created, one "function (file:line)" per caller
+func (res *Resource) CreationStack() []string {
+	var lines []string
+	frames := runtime.CallersFrames(res.stack)
+	for {
+		frame, more := frames.Next()
+		if frame.Function != "" && !strings.HasPrefix(frame.Function, "runtime.") {
+			lines = append(lines, fmt.Sprintf("%v (%v:%v)", frame.Function, filepath.Base(frame.File), frame.Line))
+		}
+		if !more {
+			return lines
+		}
+	}
+}
+
+type resourceKey struct {
+	kind ResourceKind
+	id   uint32
+}
+
+// ResourceRegistry records every GL object created through the gen* helpers
+// below, so we can estimate GPU memory use and find objects never deleted.
+//
+// It also owns these objects: each gen* call hands out one reference, owners
+// that share an object (e.g. a texture attached to two FBOs) call Retain, and
+// every owner calls Release when done. The last Release hands the GL object to
+// the deletion queue, which deletes it once the GPU no longer uses it.
+// Close deletes whatever is left, so cleanup at shutdown is deterministic.
+//
+// Creations and deletions are counted per kind, and every object remembers
+// where it was created, so the leak report at shutdown (see ReportLeaks)
+// points at the code that forgot to release it.
+type ResourceRegistry struct {
+	resources map[resourceKey]*Resource
+	created   map[ResourceKind]int
+	deleted   map[ResourceKind]int // including objects lost with a context
+}
+
+var (
+	gpuResources = &ResourceRegistry{resources: map[resourceKey]*Resource{}, created: map[ResourceKind]int{}, deleted: map[ResourceKind]int{}}
+)
+
+func (r *ResourceRegistry) add(kind ResourceKind, id uint32, label string) {
+	res := &Resource{Kind: kind, ID: id, Label: label, Refs: 1, stack: make([]uintptr, resourceStackDepth)}
+	res.stack = res.stack[:runtime.Callers(3, res.stack)] // skip Callers, add and the gen* helper
+	r.resources[resourceKey{kind, id}] = res
+	r.created[kind]++
+}
+
+func (r *ResourceRegistry) remove(kind ResourceKind, id uint32) {
+	if _, ok := r.resources[resourceKey{kind, id}]; ok {
+		r.deleted[kind]++
+	}
+	delete(r.resources, resourceKey{kind, id})
+}
+
+// forget drops every entry without deleting, used when the GL context (and its objects) is lost
+func (r *ResourceRegistry) forget() {
+	for _, res := range r.resources {
+		r.deleted[res.Kind]++
+	}
+	r.resources = map[resourceKey]*Resource{}
+}
+
+// SetLabel renames a GL object, e.g. programs that are created by newProgram
+func (r *ResourceRegistry) SetLabel(kind ResourceKind, id uint32, label string) {
+	if res, ok := r.resources[resourceKey{kind, id}]; ok {
+		res.Label = label
+	}
+}
+
+// Retain adds an owner to a GL object
+func (r *ResourceRegistry) Retain(kind ResourceKind, id uint32) {
+	res, ok := r.resources[resourceKey{kind, id}]
+	if !ok {
+		panic(fmt.Sprintf("GPU_RESOURCES: retain of unknown %v %v", kind, id))
+	}
+	res.Refs++
+}
+
+// Release removes an owner from a GL object, and queues it for deletion when no owners are left
+// releasing id 0 is a no-op, so never-created objects can be released safely
+func (r *ResourceRegistry) Release(kind ResourceKind, id uint32) {
+	if id == 0 {
+		return
+	}
+	res, ok := r.resources[resourceKey{kind, id}]
+	if !ok {
+		panic(fmt.Sprintf("GPU_RESOURCES: release of unknown %v %v", kind, id))
+	}
+	if res.Refs <= 0 {
+		panic(fmt.Sprintf("GPU_RESOURCES: release of already released %v %v (%q)", kind, id, res.Label))
+	}
+	res.Refs--
+	if res.Refs > 0 {
+		return
+	}
+	deletionQueue.enqueue(kind, id)
+}
+
+// Close deletes every GL object still alive regardless of its owners,
+// leaks are reported before deleting so they are not silently hidden
+func (r *ResourceRegistry) Close() {
+	deletionQueue.flush()
+	r.ReportLeaks()
+	for _, kind := range resourceCloseOrder {
+		for _, res := range r.Live() {
+			if res.Kind == kind {
+				deleteResource(res.Kind, res.ID)
+				r.remove(res.Kind, res.ID)
+			}
+		}
+	}
+}
+
+// SetBytes records the (estimated) memory held by a GL object, call it after glBufferData, glTexImage2D, etc.
+func (r *ResourceRegistry) SetBytes(kind ResourceKind, id uint32, bytes int) {
+	if res, ok := r.resources[resourceKey{kind, id}]; ok {
+		res.Bytes = bytes
+	}
+}
+
+// TotalBytes is the estimated memory of all live objects of a kind
+func (r *ResourceRegistry) TotalBytes(kind ResourceKind) int {
+	total := 0
+	for _, res := range r.resources {
+		if res.Kind == kind {
+			total += res.Bytes
+		}
+	}
+	return total
+}
+
+// Live returns all objects not yet deleted, ordered by kind and id
+func (r *ResourceRegistry) Live() []*Resource {
+	live := make([]*Resource, 0, len(r.resources))
+	for _, res := range r.resources {
+		live = append(live, res)
+	}
+	sort.Slice(live, func(i, j int) bool {
+		if live[i].Kind != live[j].Kind {
+			return live[i].Kind < live[j].Kind
+		}
+		return live[i].ID < live[j].ID
+	})
+	return live
+}
+
+// ReportLeaks prints how many objects of each kind were created and deleted,
+// and every object still alive with the stack that created it. Call it at
+// shutdown after all owners released their objects.
+func (r *ResourceRegistry) ReportLeaks() {
+
+	fmt.Println("GPU_RESOURCES -- created / deleted:")
+	for _, kind := range resourceCloseOrder {
+		if r.created[kind] > 0 {
+			fmt.Printf("  %-12v %5v / %v\n", kind, r.created[kind], r.deleted[kind])
+		}
+	}
+
+	live := r.Live()
+	if len(live) == 0 {
+		fmt.Println("GPU_RESOURCES -- no leaks")
+		return
+	}
+	fmt.Printf("GPU_RESOURCES -- %v objects were never deleted:\n", len(live))
+	for _, res := range live {
+		fmt.Printf("  %-12v %4v  %-32q %v (%v refs)\n", res.Kind, res.ID, res.Label, formatBytes(res.Bytes), res.Refs)
+		for _, line := range res.CreationStack() {
+			fmt.Printf("        created by %v\n", line)
+		}
+	}
+
+}
+
+func genBuffer(label string) uint32 {
+	var id uint32
+	gl.GenBuffers(1, &id)
+	gpuResources.add(ResourceBuffer, id, label)
+	return id
+}
+
+func genTexture(label string) uint32 {
+	var id uint32
+	gl.GenTextures(1, &id)
+	gpuResources.add(ResourceTexture, id, label)
+	return id
+}
+
+func genFramebuffer(label string) uint32 {
+	var id uint32
+	gl.GenFramebuffers(1, &id)
+	gpuResources.add(ResourceFramebuffer, id, label)
+	return id
+}
+
+func genRenderbuffer(label string) uint32 {
+	var id uint32
+	gl.GenRenderbuffers(1, &id)
+	gpuResources.add(ResourceRenderbuffer, id, label)
+	return id
+}
+
+func genVertexArray(label string) uint32 {
+	var id uint32
+	gl.GenVertexArrays(1, &id)
+	gpuResources.add(ResourceVertexArray, id, label)
+	return id
+}
+
+func genQuery(label string) uint32 {
+	var id uint32
+	gl.GenQueries(1, &id)
+	gpuResources.add(ResourceQuery, id, label)
+	return id
+}
+
+// deleteResource frees the GL object itself, use Release instead
+func deleteResource(kind ResourceKind, id uint32) {
+	switch kind {
+	case ResourceBuffer:
+		gl.DeleteBuffers(1, &id)
+	case ResourceTexture:
+		gl.DeleteTextures(1, &id)
+	case ResourceFramebuffer:
+		gl.DeleteFramebuffers(1, &id)
+	case ResourceRenderbuffer:
+		gl.DeleteRenderbuffers(1, &id)
+	case ResourceVertexArray:
+		gl.DeleteVertexArrays(1, &id)
+	case ResourceProgram:
+		gl.DeleteProgram(id)
+		shaderGlobals.unregister(id)
+		programInfos.unregister(id)
+	case ResourceQuery:
+		gl.DeleteQueries(1, &id)
+	}
+}
+
+// formatBytes prints a byte count in a human readable unit
+func formatBytes(bytes int) string {
+	switch {
+	case bytes >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(bytes)/(1<<20))
+	case bytes >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(bytes)/(1<<10))
+	}
+	return fmt.Sprintf("%v B", bytes)
+}
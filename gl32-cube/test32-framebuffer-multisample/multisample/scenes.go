@@ -0,0 +1,158 @@
+package multisample
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/paperboard/glfw/v3.3/glfw"
+)
+
+var (
+	msaaScene = &MSAAScene{}
+	launcher  = &SceneLauncher{scenes: []Scene{msaaScene, &TriangleScene{}, &QuadScene{}, &FramebufferScene{}}}
+)
+
+// Scene is a demo the launcher runs in the window, see SceneLauncher. The
+// methods are called on the main thread with the GL context current.
+type Scene interface {
+	Name() string // for -scene and the window title, e.g. "msaa"
+
+	Load()                  // prepares CPU-side data, once, before the first Setup
+	Setup()                 // creates the GL objects, again after Unload or a lost context
+	Update(now, dt float64) // advances the scene by dt seconds of the update clock (see Clock)
+	Draw()                  // draws a frame into the default framebuffer
+	Unload()                // releases the GL objects, before another scene is set up
+}
+
+// SceneLauncher hosts the demos as scenes sharing one window and context:
+// F1 to F4 switch between them at runtime. Only the current scene has GL
+// objects, the others keep their CPU-side data (see Scene.Load) so switching
+// back is quick.
+type SceneLauncher struct {
+	scenes  []Scene
+	current int
+	loaded  map[Scene]bool
+}
+
+// Scenes are the scenes Run and Bench take by name, in the order F1, F2 and
+// so on switch to them
+func Scenes() []Scene {
+	return launcher.scenes
+}
+
+// find is the scene called name, -1 if there is none
+func (l *SceneLauncher) find(name string) int {
+	for i, scene := range l.scenes {
+		if scene.Name() == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// names lists the scenes, for error messages
+func (l *SceneLauncher) names() string {
+	var names []string
+	for _, scene := range l.scenes {
+		names = append(names, scene.Name())
+	}
+	return strings.Join(names, ", ")
+}
+
+// Current is the scene being shown
+func (l *SceneLauncher) Current() Scene {
+	return l.scenes[l.current]
+}
+
+// start loads and sets up the scene called name (-scene) as the first scene
+func (l *SceneLauncher) start(window *glfw.Window, name string) error {
+	i := l.find(name)
+	if i < 0 {
+		return fmt.Errorf("unknown scene %q, use %v", name, l.names())
+	}
+	l.current = i
+	l.setup(window)
+	return nil
+}
+
+// setup loads the current scene if it never was and creates its GL objects
+func (l *SceneLauncher) setup(window *glfw.Window) {
+	scene := l.Current()
+	if l.loaded == nil {
+		l.loaded = map[Scene]bool{}
+	}
+	if !l.loaded[scene] {
+		scene.Load()
+		l.loaded[scene] = true
+	}
+	scene.Setup()
+	window.SetTitle(fmt.Sprintf("%v - %v", windowTitle, scene.Name()))
+}
+
+// switchTo unloads the current scene and sets up scene i, if there is one
+func (l *SceneLauncher) switchTo(window *glfw.Window, i int) {
+	if i < 0 || i >= len(l.scenes) || i == l.current {
+		return
+	}
+	from := l.Current()
+	from.Unload()
+	l.current = i
+	l.setup(window)
+	fmt.Printf("SCENE -- %v\n", l.Current().Name())
+	events.publish(SceneChangeEvent{From: from, To: l.Current()})
+}
+
+// MSAAScene is the multisample quad demo this program grew from, with every
+// feature behind its flags: the proxy screen, post effects, terrain, text
+// and graph overlays. Most keys and the mouse only act on it.
+type MSAAScene struct {
+	fog FogMode // parsed from -fog with the other flags, see run
+}
+
+func (s *MSAAScene) Name() string {
+	return "msaa"
+}
+
+func (s *MSAAScene) Load() {
+	load()
+}
+
+func (s *MSAAScene) Setup() {
+	setup(s.fog)
+}
+
+func (s *MSAAScene) Update(now, dt float64) {
+
+	// animate perspective/orthographic switch (see handleKey)
+	ctxFramebufferMultisample.camera.Animate(dt)
+
+	// move the sparks of the fountain
+	if *particleMode {
+		ctxParticles.update(dt)
+	}
+
+	// paint the canvas and upload what changed
+	if ctxFramebufferMultisample.canvas != nil {
+		ctxFramebufferMultisample.canvas.update(dt)
+	}
+
+	// play the video, uploading new frames
+	if ctxFramebufferMultisample.video != nil {
+		ctxFramebufferMultisample.video.update(dt)
+	}
+
+	// run the commands the worker recorded since the last frame
+	commandQueue.execute()
+
+	// pick sprite palette, e.g. during a damage flash
+	ctxFramebufferMultisample.palette.update(now)
+
+}
+
+func (s *MSAAScene) Draw() {
+	draw()
+}
+
+func (s *MSAAScene) Unload() {
+	destroy()
+}
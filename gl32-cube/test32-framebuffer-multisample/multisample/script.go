@@ -0,0 +1,217 @@
+package multisample
+
+import (
+	"fmt"
+	"image/color"
+
+	"github.com/go-gl/example/internal/capture"
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/paperboard/glfw/v3.3/glfw"
+)
+
+const (
+	scriptSeed = 1 // random numbers seed of the scene when a script starts, same quads and colors every run
+)
+
+var (
+	scripts = &ScriptRunner{}
+)
+
+// Script is a demo sequence written as straight-line code, e.g.
+//
+//	scripts.Start("tour", func(s *Script) {
+//		s.MoveCamera(mgl32.Vec3{0, 0.5, 1}, mgl32.Vec3{0, 0, -1}, 3)
+//		s.SetEffect("crt", true)
+//		s.SpawnQuads(100)
+//		s.Wait(1)
+//		s.Screenshot("tour.png")
+//		s.Quit()
+//	})
+//
+// It runs as a coroutine: a goroutine taking turns with the main thread, so
+// only one of them runs at a time and the script sees a consistent frame.
+// Frame, Wait and the animations yield until the next frame, everything
+// touching the scene or GL runs on the main thread through Do. Time is the
+// update clock (see UpdateClock), a paused clock holds the script too.
+// Starting a script seeds the random numbers of the scene (see seedRandom),
+// start it before the scene loads for screenshots that compare between runs.
+type Script struct {
+	name   string
+	resume chan float64     // main thread to script: the update time, run until the next yield
+	yield  chan scriptYield // script to main thread: what to do before resuming it, or that the frame is over
+	now    float64          // update time of the frame the script runs in
+	window *glfw.Window     // of the frame the script runs in
+}
+
+// scriptYield is a message of the script to the main thread
+type scriptYield struct {
+	run  func() // runs on the main thread, then the script continues in the same frame
+	done bool   // the script returned
+}
+
+// ScriptRunner resumes the running scripts once per frame, after the frame is drawn
+type ScriptRunner struct {
+	scripts []*Script
+}
+
+// Start runs fn as a script from the next frame on
+func (r *ScriptRunner) Start(name string, fn func(s *Script)) *Script {
+	s := &Script{name: name, resume: make(chan float64), yield: make(chan scriptYield)}
+	seedRandom(scriptSeed)
+	go func() {
+		s.now = <-s.resume
+		fn(s)
+		s.yield <- scriptYield{done: true}
+	}()
+	r.scripts = append(r.scripts, s)
+	fmt.Printf("SCRIPT -- %v started\n", name)
+	return s
+}
+
+// step runs every script up to its next yield, on the main thread: after
+// drawing, so a screenshot shows the frame and changes show in the next one
+func (r *ScriptRunner) step(window *glfw.Window, now float64) {
+
+	// scripts started meanwhile run from the next frame on
+	current := r.scripts
+	r.scripts = nil
+	var running []*Script
+	for _, s := range current {
+		s.window = window
+		s.resume <- now
+		msg := <-s.yield
+		for msg.run != nil {
+			msg.run()
+			s.resume <- now
+			msg = <-s.yield
+		}
+		if msg.done {
+			fmt.Printf("SCRIPT -- %v done\n", s.name)
+			continue
+		}
+		running = append(running, s)
+	}
+	r.scripts = append(running, r.scripts...)
+
+}
+
+// Now is the update time of the current frame
+func (s *Script) Now() float64 {
+	return s.now
+}
+
+// Frame waits for the next frame
+func (s *Script) Frame() {
+	s.yield <- scriptYield{}
+	s.now = <-s.resume
+}
+
+// Frames waits n frames
+func (s *Script) Frames(n int) {
+	for i := 0; i < n; i++ {
+		s.Frame()
+	}
+}
+
+// Wait waits until seconds of update time passed
+func (s *Script) Wait(seconds float64) {
+	end := s.now + seconds
+	for s.now < end {
+		s.Frame()
+	}
+}
+
+// Do runs fn on the main thread with the GL context current, the script
+// continues in the same frame when it returns
+func (s *Script) Do(fn func()) {
+	s.yield <- scriptYield{run: fn}
+	<-s.resume
+}
+
+// Tween calls fn on the main thread once per frame for seconds, t running
+// from 0 to 1 eased in and out, the last call has t = 1
+func (s *Script) Tween(seconds float64, fn func(t float32)) {
+	start := s.now
+	for {
+		t := float32(1)
+		if seconds > 0 && s.now-start < seconds {
+			t = float32((s.now - start) / seconds)
+		}
+		eased := t * t * (3 - 2*t)
+		s.Do(func() { fn(eased) })
+		if t >= 1 {
+			return
+		}
+		s.Frame()
+	}
+}
+
+// MoveCamera moves the 3D camera from where it is to position, looking at
+// target, over seconds (0 = at once)
+func (s *Script) MoveCamera(position, target mgl32.Vec3, seconds float64) {
+	var fromPosition, fromTarget mgl32.Vec3
+	s.Do(func() {
+		camera := ctxFramebufferMultisample.camera
+		fromPosition, fromTarget = camera.Position(), camera.Target()
+	})
+	s.Tween(seconds, func(t float32) {
+		camera := ctxFramebufferMultisample.camera
+		camera.SetPosition(fromPosition.Add(position.Sub(fromPosition).Mul(t)))
+		camera.LookAt(fromTarget.Add(target.Sub(fromTarget).Mul(t)))
+	})
+}
+
+// SetEffect enables or disables a post effect by name, see PostProcessing
+func (s *Script) SetEffect(name string, enabled bool) error {
+	found := false
+	s.Do(func() {
+		for _, effect := range postProcessing.effects {
+			if effect.name == name {
+				effect.enabled = enabled
+				found = true
+			}
+		}
+	})
+	if !found {
+		return fmt.Errorf("unknown effect %q", name)
+	}
+	return nil
+}
+
+// SpawnQuads adds n small quads at random positions and colors, the same
+// ones every run of the script
+func (s *Script) SpawnQuads(n int) {
+	s.Do(func() {
+		for i := 0; i < n; i++ {
+			x, y := sceneRand.Float32()*2-1, sceneRand.Float32()*2-1
+			clr := color.NRGBA{uint8(sceneRand.Intn(256)), uint8(sceneRand.Intn(256)), uint8(sceneRand.Intn(256)), 255}
+			ctxFramebufferMultisample.AddQuad(x, y, 0.1, 0.1, -1.05, clr)
+		}
+	})
+}
+
+// Press presses and releases a key, as if typed (see handleKey)
+func (s *Script) Press(key glfw.Key, mods glfw.ModifierKey) {
+	s.Do(func() {
+		events.publish(KeyEvent{Window: s.window, Key: key, Action: glfw.Press, Mods: mods})
+		events.publish(KeyEvent{Window: s.window, Key: key, Action: glfw.Release, Mods: mods})
+	})
+}
+
+// Screenshot writes the frame drawn last to a png file, like -screenshot
+func (s *Script) Screenshot(path string) error {
+	var err error
+	s.Do(func() {
+		if err = capture.SavePNG(path, renderToImage()); err == nil {
+			fmt.Println("screenshot written to", path)
+		}
+	})
+	return err
+}
+
+// Quit closes the window, the program ends after this frame
+func (s *Script) Quit() {
+	s.Do(func() {
+		s.window.SetShouldClose(true)
+	})
+}
@@ -0,0 +1,233 @@
+package multisample
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/paperboard/glfw/v3.3/glfw"
+)
+
+// scriptKeys are the key names of the key command besides letters, digits and f1-f12
+var scriptKeys = map[string]glfw.Key{
+	"space": glfw.KeySpace,
+	"-":     glfw.KeyMinus,
+	"=":     glfw.KeyEqual,
+	".":     glfw.KeyPeriod,
+	"[":     glfw.KeyLeftBracket,
+	"]":     glfw.KeyRightBracket,
+}
+
+// loadScript reads a demo sequence (-script), one command per line, run
+// one after the other by a Script:
+//
+//	# comment
+//	wait 1.5                     seconds of update time
+//	frames 10                    frames
+//	camera 0 0.5 1  0 0 -1  3    move the camera to x y z looking at x y z, over 3 seconds (optional)
+//	effect crt on                enable or disable a post effect (on, off), one of the scene's
+//	quads 100                    add quads at random positions, the same every run
+//	key ctrl+v                   press a key: a-z, 0-9, f1-f12, space, - = . [ ] after ctrl+ shift+ alt+
+//	screenshot tour.png          write the frame to a png file
+//	quit                         close the window
+func loadScript(path string) (*ScriptFile, error) {
+	data, err := assets.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	file, err := parseScript(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	file.path = path
+	return file, nil
+}
+
+// ScriptFile is a demo sequence read by loadScript
+type ScriptFile struct {
+	path    string
+	steps   []func(s *Script)
+	effects []scriptEffect // checked once the scene added its post effects, see check
+}
+
+// scriptEffect is the post effect an effect command names
+type scriptEffect struct {
+	line int
+	name string
+}
+
+// run runs the steps one after the other, as the function of a Script
+func (f *ScriptFile) run(s *Script) {
+	for _, step := range f.steps {
+		step(s)
+	}
+}
+
+// check reports effect commands naming a post effect the loaded scene does not have
+func (f *ScriptFile) check() error {
+	for _, effect := range f.effects {
+		if !postProcessing.has(effect.name) {
+			return fmt.Errorf("%s: line %d: unknown effect %q, use %v", f.path, effect.line, effect.name, postProcessing.names())
+		}
+	}
+	return nil
+}
+
+// parseScript turns the commands into steps, checking all of them before the first runs
+func parseScript(text string) (*ScriptFile, error) {
+	file := &ScriptFile{}
+	for i, line := range strings.Split(text, "\n") {
+		if comment := strings.IndexByte(line, '#'); comment >= 0 {
+			line = line[:comment]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		step, err := parseScriptCommand(fields[0], fields[1:])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", i+1, err)
+		}
+		if fields[0] == "effect" {
+			file.effects = append(file.effects, scriptEffect{line: i + 1, name: fields[1]})
+		}
+		file.steps = append(file.steps, step)
+	}
+	return file, nil
+}
+
+func parseScriptCommand(command string, args []string) (func(s *Script), error) {
+	switch command {
+
+	case "wait":
+		seconds, err := scriptFloats(args, 1, 1)
+		if err != nil {
+			return nil, err
+		}
+		return func(s *Script) { s.Wait(float64(seconds[0])) }, nil
+
+	case "frames":
+		if len(args) != 1 {
+			return nil, fmt.Errorf("frames needs a count")
+		}
+		n, err := strconv.Atoi(args[0])
+		if err != nil {
+			return nil, err
+		}
+		return func(s *Script) { s.Frames(n) }, nil
+
+	case "camera":
+		v, err := scriptFloats(args, 6, 7)
+		if err != nil {
+			return nil, err
+		}
+		seconds := float64(0)
+		if len(v) == 7 {
+			seconds = float64(v[6])
+		}
+		position, target := mgl32.Vec3{v[0], v[1], v[2]}, mgl32.Vec3{v[3], v[4], v[5]}
+		return func(s *Script) { s.MoveCamera(position, target, seconds) }, nil
+
+	case "effect":
+		if len(args) != 2 || (args[1] != "on" && args[1] != "off") {
+			return nil, fmt.Errorf("effect needs a name and on or off")
+		}
+		name, enabled := args[0], args[1] == "on"
+		return func(s *Script) {
+			if err := s.SetEffect(name, enabled); err != nil {
+				log.Fatalln("script:", err)
+			}
+		}, nil
+
+	case "quads":
+		if len(args) != 1 {
+			return nil, fmt.Errorf("quads needs a count")
+		}
+		n, err := strconv.Atoi(args[0])
+		if err != nil {
+			return nil, err
+		}
+		return func(s *Script) { s.SpawnQuads(n) }, nil
+
+	case "key":
+		if len(args) != 1 {
+			return nil, fmt.Errorf("key needs a key name")
+		}
+		key, mods, err := parseScriptKey(args[0])
+		if err != nil {
+			return nil, err
+		}
+		return func(s *Script) { s.Press(key, mods) }, nil
+
+	case "screenshot":
+		if len(args) != 1 {
+			return nil, fmt.Errorf("screenshot needs a path")
+		}
+		path := args[0]
+		return func(s *Script) {
+			if err := s.Screenshot(path); err != nil {
+				log.Fatalln("failed to write screenshot:", err)
+			}
+		}, nil
+
+	case "quit":
+		return func(s *Script) { s.Quit() }, nil
+
+	}
+	return nil, fmt.Errorf("unknown command %q", command)
+}
+
+// scriptFloats parses min to max numbers
+func scriptFloats(args []string, min, max int) ([]float32, error) {
+	if len(args) < min || len(args) > max {
+		return nil, fmt.Errorf("expected %d to %d numbers, got %d", min, max, len(args))
+	}
+	values := make([]float32, len(args))
+	for i, arg := range args {
+		v, err := strconv.ParseFloat(arg, 32)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = float32(v)
+	}
+	return values, nil
+}
+
+// parseScriptKey parses a key name with modifiers, e.g. "shift+h"
+func parseScriptKey(name string) (glfw.Key, glfw.ModifierKey, error) {
+	var mods glfw.ModifierKey
+	name = strings.ToLower(name)
+	for {
+		prefix, rest, found := strings.Cut(name, "+")
+		if !found || rest == "" {
+			break
+		}
+		switch prefix {
+		case "ctrl":
+			mods |= glfw.ModControl
+		case "shift":
+			mods |= glfw.ModShift
+		case "alt":
+			mods |= glfw.ModAlt
+		default:
+			return 0, 0, fmt.Errorf("unknown modifier %q", prefix)
+		}
+		name = rest
+	}
+
+	if key, ok := scriptKeys[name]; ok {
+		return key, mods, nil
+	}
+	if len(name) == 1 && name[0] >= 'a' && name[0] <= 'z' {
+		return glfw.KeyA + glfw.Key(name[0]-'a'), mods, nil
+	}
+	if len(name) == 1 && name[0] >= '0' && name[0] <= '9' {
+		return glfw.Key0 + glfw.Key(name[0]-'0'), mods, nil
+	}
+	if n, err := strconv.Atoi(strings.TrimPrefix(name, "f")); err == nil && strings.HasPrefix(name, "f") && n >= 1 && n <= 12 {
+		return glfw.KeyF1 + glfw.Key(n-1), mods, nil
+	}
+	return 0, 0, fmt.Errorf("unknown key %q", name)
+}
@@ -0,0 +1,193 @@
+package multisample
+
+import (
+	"fmt"
+	"path"
+
+	"github.com/go-gl/gl/v3.2-core/gl"
+)
+
+// The GLSL sources of every program, read from assets/shaders by
+// loadShaders. They are embedded into the binary (see Assets), with
+// -assets the files in that directory are used instead and reloaded when
+// they change, so shaders can be edited while the demo runs.
+var (
+	// fragmentShaderLife computes the next generation of one cell (red channel,
+	// 1 = alive) from its eight neighbours
+	fragmentShaderLife string
+
+	// fragmentShaderLifeOverlay tints the image where cells are alive
+	fragmentShaderLifeOverlay string
+
+	vertexShaderGraph   string
+	fragmentShaderGraph string
+
+	// fragmentShaderNoise is Noise.FBM on the GPU, with a hash for the gradients
+	// instead of the permutation table, so the values differ from the CPU noise
+	fragmentShaderNoise string
+
+	vertexShaderParticle string
+
+	// vertexShaderParticleUpdate moves one particle per vertex, the outputs are
+	// captured into the other state VBO. Dead particles respawn with the cone
+	// of ContextParticles.spawn, randomized by a hash instead of math/rand.
+	vertexShaderParticleUpdate string
+
+	// geometryShaderParticle turns each point into a quad, built in eye
+	// coordinates so it always faces the camera (a billboard)
+	geometryShaderParticle string
+
+	fragmentShaderParticle string
+
+	// vertexShaderParticlePoint projects each particle to a point sprite,
+	// gl_PointSize shrinks with the distance like the quads do
+	vertexShaderParticlePoint   string
+	fragmentShaderParticlePoint string
+
+	// fragmentShaderOutline darkens edges found by a Sobel filter on luminance,
+	// the proxy screen has no depth/normal texture to sample, so edges are color changes
+	fragmentShaderOutline string
+
+	// fragmentShaderCRT imitates an old CRT monitor: curved glass (barrel
+	// distortion), dark scanlines and color fringes (chromatic aberration)
+	fragmentShaderCRT string
+
+	vertexShaderFramebuffer   string
+	fragmentShaderFramebuffer string
+	vertexShaderScreen        string
+	fragmentShaderScreen      string
+	vertexShaderShadow        string
+	fragmentShaderShadow      string
+
+	// fragmentShaderOcclusion computes the occlusion from the depth buffer,
+	// 1 = open, 0 = fully occluded
+	fragmentShaderOcclusion string
+
+	// fragmentShaderOcclusionBlur averages 4x4 pixels, the size of the noise
+	// tile, so every pixel sees all rotations
+	fragmentShaderOcclusionBlur string
+
+	// fragmentShaderSSAO darkens the image by the blurred occlusion
+	fragmentShaderSSAO string
+
+	// fragmentShaderDepthView shows linearized depth as grayscale, near black
+	fragmentShaderDepthView string
+
+	// fragmentShaderOverdraw maps fragments per pixel to a heat palette
+	fragmentShaderOverdraw string
+
+	vertexShaderTerrain   string
+	fragmentShaderTerrain string
+	vertexShaderText      string
+	fragmentShaderText    string
+	vertexShaderWater     string
+	fragmentShaderWater   string
+
+	// the basic scenes (see TriangleScene) draw colored or textured 2D meshes
+	vertexShaderBasic          string
+	fragmentShaderBasic        string
+	fragmentShaderBasicTexture string
+)
+
+// shaderFiles are the shader variables and the asset each is read from
+var shaderFiles = map[*string]string{
+	&fragmentShaderLife:          "shaders/life.frag",
+	&fragmentShaderLifeOverlay:   "shaders/lifeoverlay.frag",
+	&vertexShaderGraph:           "shaders/graph.vert",
+	&fragmentShaderGraph:         "shaders/graph.frag",
+	&fragmentShaderNoise:         "shaders/noise.frag",
+	&vertexShaderParticle:        "shaders/particle.vert",
+	&vertexShaderParticleUpdate:  "shaders/particleupdate.vert",
+	&geometryShaderParticle:      "shaders/particle.geom",
+	&fragmentShaderParticle:      "shaders/particle.frag",
+	&vertexShaderParticlePoint:   "shaders/particlepoint.vert",
+	&fragmentShaderParticlePoint: "shaders/particlepoint.frag",
+	&fragmentShaderOutline:       "shaders/outline.frag",
+	&fragmentShaderCRT:           "shaders/crt.frag",
+	&vertexShaderFramebuffer:     "shaders/framebuffer.vert",
+	&fragmentShaderFramebuffer:   "shaders/framebuffer.frag",
+	&vertexShaderScreen:          "shaders/screen.vert",
+	&fragmentShaderScreen:        "shaders/screen.frag",
+	&vertexShaderShadow:          "shaders/shadow.vert",
+	&fragmentShaderShadow:        "shaders/shadow.frag",
+	&fragmentShaderOcclusion:     "shaders/occlusion.frag",
+	&fragmentShaderOcclusionBlur: "shaders/occlusionblur.frag",
+	&fragmentShaderSSAO:          "shaders/ssao.frag",
+	&fragmentShaderDepthView:     "shaders/depthview.frag",
+	&fragmentShaderOverdraw:      "shaders/overdraw.frag",
+	&vertexShaderTerrain:         "shaders/terrain.vert",
+	&fragmentShaderTerrain:       "shaders/terrain.frag",
+	&vertexShaderText:            "shaders/text.vert",
+	&fragmentShaderText:          "shaders/text.frag",
+	&vertexShaderWater:           "shaders/water.vert",
+	&fragmentShaderWater:         "shaders/water.frag",
+	&vertexShaderBasic:           "shaders/basic.vert",
+	&fragmentShaderBasic:         "shaders/basic.frag",
+	&fragmentShaderBasicTexture:  "shaders/basictexture.frag",
+}
+
+// loadShaders reads every shader variable from its asset
+func loadShaders() error {
+	for source, name := range shaderFiles {
+		data, err := assets.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		*source = string(data) + "\x00"
+	}
+	return nil
+}
+
+// shaderStageOf is the shader type of an asset by its extension
+func shaderStageOf(name string) uint32 {
+	switch path.Ext(name) {
+	case ".vert":
+		return gl.VERTEX_SHADER
+	case ".geom":
+		return gl.GEOMETRY_SHADER
+	}
+	return gl.FRAGMENT_SHADER
+}
+
+// reloadAssets reads the shaders again and rebuilds every GL object with
+// them, after files in the -assets directory changed. Setup is the recipe
+// for all GL objects of a scene (see ContextRecovery), so this is Unload
+// and Setup of the current scene.
+// A shader that does not compile is reported and the previous sources are
+// kept, a typo while editing does not end the demo.
+func reloadAssets() {
+
+	previous := map[*string]string{}
+	for source := range shaderFiles {
+		previous[source] = *source
+	}
+	keep := func() {
+		for source, text := range previous {
+			*source = text
+		}
+	}
+
+	if err := loadShaders(); err != nil {
+		fmt.Println("ASSETS -- failed to reload shaders:", err)
+		keep()
+		return
+	}
+	for source, name := range shaderFiles {
+		if *source == previous[source] {
+			continue
+		}
+		shader, err := compileShader(*source, shaderStageOf(name))
+		if err != nil {
+			fmt.Printf("ASSETS -- %v: %v\n", name, err)
+			keep()
+			return
+		}
+		gl.DeleteShader(shader)
+	}
+
+	fmt.Println("ASSETS -- shaders changed, rebuilding GL objects")
+	scene := launcher.Current()
+	scene.Unload()
+	scene.Setup()
+
+}
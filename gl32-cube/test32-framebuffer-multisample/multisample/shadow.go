@@ -0,0 +1,208 @@
+package multisample
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/go-gl/gl/v3.2-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+const (
+	shadowCascadesMax = 4    // cascades the receiving shaders have room for
+	shadowMapSize     = 1024 // width and height of each cascade in texels
+	shadowSplitLambda = 0.75 // cascade split blend, 0 = even slices, 1 = logarithmic (detail close to the camera)
+)
+
+// https://learnopengl.com/Guest-Articles/2021/CSM
+// https://developer.nvidia.com/gpugems/gpugems3/part-ii-light-and-shadows/chapter-10-parallel-split-shadow-maps-programmable-gpus
+//
+// ShadowCascades renders the depth of the scene as seen from the sun into a
+// few shadow maps (cascades). Each covers a slice of the camera frustum, the
+// near slices are small so close shadows get most of the texels, the far
+// ones large. The receiving fragment shader picks the cascade by view depth
+// and compares its depth with the map (see fragmentShaderTerrain).
+type ShadowCascades struct {
+	count   int
+	sun     mgl32.Vec3 // direction towards the sun, normalized
+	fbo     uint32
+	texture uint32 // TEXTURE_2D_ARRAY of depth, one layer per cascade, compared by a sampler2DArrayShadow
+	debug   bool   // tint the receiver by cascade, to see the boundaries
+
+	// depth only program drawing the casters
+	program              uint32
+	attribVertexPosition uint32
+	uniformLightMatrix   int32
+
+	// the last fit, uploaded to the receiver
+	splits   [shadowCascadesMax]float32    // view depth where each cascade ends
+	matrices [shadowCascadesMax]mgl32.Mat4 // world to shadow map coordinates (0..1), per cascade
+
+	// uniforms of the receiving program
+	uniformMatrices int32
+	uniformSplits   int32
+	uniformCount    int32
+	uniformDebug    int32
+}
+
+// newShadowCascades creates the shadow maps and the caster program,
+// receiver is the program sampling them. Requires a current GL context.
+func newShadowCascades(count int, sun mgl32.Vec3, receiver uint32) *ShadowCascades {
+
+	if count < 1 || count > shadowCascadesMax {
+		panic(fmt.Errorf("%v shadow cascades, use 1 to %v", count, shadowCascadesMax))
+	}
+	s := &ShadowCascades{count: count, sun: sun.Normalize()}
+
+	// depth layers, compared in the sampler: linear filtering averages 4 comparisons
+	s.texture = genTexture("shadow cascades")
+	gl.BindTexture(gl.TEXTURE_2D_ARRAY, s.texture)
+	gl.TexImage3D(gl.TEXTURE_2D_ARRAY, 0, gl.DEPTH_COMPONENT24, shadowMapSize, shadowMapSize, int32(count), 0, gl.DEPTH_COMPONENT, gl.UNSIGNED_INT, nil)
+	gpuResources.SetBytes(ResourceTexture, s.texture, count*shadowMapSize*shadowMapSize*4)
+	gl.TexParameteri(gl.TEXTURE_2D_ARRAY, gl.TEXTURE_MIN_FILTER, gl.LINEAR)
+	gl.TexParameteri(gl.TEXTURE_2D_ARRAY, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
+	gl.TexParameteri(gl.TEXTURE_2D_ARRAY, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
+	gl.TexParameteri(gl.TEXTURE_2D_ARRAY, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
+	gl.TexParameteri(gl.TEXTURE_2D_ARRAY, gl.TEXTURE_COMPARE_MODE, gl.COMPARE_REF_TO_TEXTURE)
+	gl.TexParameteri(gl.TEXTURE_2D_ARRAY, gl.TEXTURE_COMPARE_FUNC, gl.LEQUAL)
+	gl.BindTexture(gl.TEXTURE_2D_ARRAY, 0)
+
+	// framebuffer without color, the depth attachment is switched to each layer while rendering
+	s.fbo = genFramebuffer("shadow fbo")
+	gl.BindFramebuffer(gl.FRAMEBUFFER, s.fbo)
+	gl.FramebufferTextureLayer(gl.FRAMEBUFFER, gl.DEPTH_ATTACHMENT, s.texture, 0, 0)
+	gl.DrawBuffer(gl.NONE)
+	gl.ReadBuffer(gl.NONE)
+	CheckGLFramebufferStatus()
+	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+
+	var err error
+	s.program, err = newProgram(vertexShaderShadow, fragmentShaderShadow)
+	if err != nil {
+		panic(err)
+	}
+	gpuResources.SetLabel(ResourceProgram, s.program, "shadow program")
+	s.attribVertexPosition = uint32(gl.GetAttribLocation(s.program, gl.Str("vertexPosition\x00")))
+	s.uniformLightMatrix = gl.GetUniformLocation(s.program, gl.Str("lightMatrix\x00"))
+
+	s.uniformMatrices = gl.GetUniformLocation(receiver, gl.Str("uShadowMatrices\x00"))
+	s.uniformSplits = gl.GetUniformLocation(receiver, gl.Str("uCascadeSplits\x00"))
+	s.uniformCount = gl.GetUniformLocation(receiver, gl.Str("uCascades\x00"))
+	s.uniformDebug = gl.GetUniformLocation(receiver, gl.Str("uCascadeDebug\x00"))
+
+	return s
+
+}
+
+// Texture is the depth array, for a sampler2DArrayShadow
+func (s *ShadowCascades) Texture() uint32 {
+	return s.texture
+}
+
+// ToggleDebug tints the receiver by cascade (red, green, blue, yellow from near to far)
+func (s *ShadowCascades) ToggleDebug() {
+	s.debug = !s.debug
+}
+
+// fit splits the camera frustum and fits a cascade around each slice,
+// casters between the sun and a slice are included up to the scene bounds
+func (s *ShadowCascades) fit(camera *Camera, sceneMin, sceneMax mgl32.Vec3) {
+
+	near, far := camera.ClipPlanes()
+	sceneRadius := sceneMax.Sub(sceneMin).Len() / 2
+	eyeToWorld := camera.View().Inv()
+	tanY := float32(math.Tan(float64(mgl32.DegToRad(camera.FOV())) / 2))
+	tanX := tanY * camera.aspect
+
+	// texture coordinates and depth from clip coordinates
+	bias := mgl32.Translate3D(0.5, 0.5, 0.5).Mul4(mgl32.Scale3D(0.5, 0.5, 0.5))
+
+	sliceNear := near
+	for i := 0; i < s.count; i++ {
+
+		// split between even and logarithmic, the log split keeps the texel to pixel ratio constant
+		t := float32(i+1) / float32(s.count)
+		even := near + (far-near)*t
+		log := near * float32(math.Pow(float64(far/near), float64(t)))
+		sliceFar := lerp(even, log, shadowSplitLambda)
+		s.splits[i] = sliceFar
+
+		// bounding sphere of the slice corners, a sphere keeps the map size
+		// constant while the camera turns, so shadow edges do not swim
+		var corners [8]mgl32.Vec3
+		center := mgl32.Vec3{}
+		for c := range corners {
+			depth := sliceNear
+			if c >= 4 {
+				depth = sliceFar
+			}
+			x, y := float32(c&1*2-1), float32(c>>1&1*2-1)
+			corners[c] = eyeToWorld.Mul4x1(mgl32.Vec4{x * depth * tanX, y * depth * tanY, -depth, 1}).Vec3()
+			center = center.Add(corners[c])
+		}
+		center = center.Mul(1.0 / 8)
+		radius := float32(0)
+		for _, corner := range corners {
+			radius = float32(math.Max(float64(radius), float64(corner.Sub(center).Len())))
+		}
+		radius = float32(math.Ceil(float64(radius)*16)) / 16
+
+		// look at the slice from the sun, far enough back for every caster of the scene
+		distance := radius + sceneRadius
+		view := mgl32.LookAtV(center.Add(s.sun.Mul(distance)), center, mgl32.Vec3{0, 1, 0})
+		projection := mgl32.Ortho(-radius, radius, -radius, radius, 0, distance+radius)
+
+		// snap the origin to whole texels, or shadow edges shimmer while the camera moves
+		origin := projection.Mul4(view).Mul4x1(mgl32.Vec4{0, 0, 0, 1}).Mul(shadowMapSize / 2)
+		projection[12] += (float32(math.Round(float64(origin.X()))) - origin.X()) * 2 / shadowMapSize
+		projection[13] += (float32(math.Round(float64(origin.Y()))) - origin.Y()) * 2 / shadowMapSize
+
+		s.matrices[i] = bias.Mul4(projection).Mul4(view)
+		sliceNear = sliceFar
+	}
+
+}
+
+// render draws the casters into every cascade, draw issues the draw calls
+// with the vertex positions bound to position. The framebuffer and viewport
+// are left to the caller to restore.
+func (s *ShadowCascades) render(draw func(position uint32)) {
+
+	gl.UseProgram(s.program)
+	gl.BindFramebuffer(gl.FRAMEBUFFER, s.fbo)
+	gl.Viewport(0, 0, shadowMapSize, shadowMapSize)
+	gl.Enable(gl.DEPTH_TEST)
+
+	// push the caster depth back against self-shadowing (shadow acne)
+	gl.Enable(gl.POLYGON_OFFSET_FILL)
+	gl.PolygonOffset(2, 4)
+
+	// the cascade matrices map to 0..1, the shadow pass renders in clip coordinates
+	unbias := mgl32.Scale3D(2, 2, 2).Mul4(mgl32.Translate3D(-0.5, -0.5, -0.5))
+	for i := 0; i < s.count; i++ {
+		gl.FramebufferTextureLayer(gl.FRAMEBUFFER, gl.DEPTH_ATTACHMENT, s.texture, 0, int32(i))
+		gl.Clear(gl.DEPTH_BUFFER_BIT)
+		lightMatrix := unbias.Mul4(s.matrices[i])
+		gl.UniformMatrix4fv(s.uniformLightMatrix, 1, false, &lightMatrix[0])
+		draw(s.attribVertexPosition)
+	}
+
+	gl.Disable(gl.POLYGON_OFFSET_FILL)
+	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+
+}
+
+// upload sets the cascade uniforms of the receiving program, which must be in use
+func (s *ShadowCascades) upload() {
+	gl.UniformMatrix4fv(s.uniformMatrices, int32(s.count), false, &s.matrices[0][0])
+	gl.Uniform1fv(s.uniformSplits, int32(s.count), &s.splits[0])
+	gl.Uniform1i(s.uniformCount, int32(s.count))
+	gl.Uniform1i(s.uniformDebug, boolToInt32(s.debug))
+}
+
+func (s *ShadowCascades) destroy() {
+	gpuResources.Release(ResourceFramebuffer, s.fbo)
+	gpuResources.Release(ResourceTexture, s.texture)
+	gpuResources.Release(ResourceProgram, s.program)
+	s.fbo, s.texture, s.program = 0, 0, 0
+}
@@ -0,0 +1,141 @@
+package multisample
+
+import (
+	"image/color"
+
+	"github.com/go-gl/gl/v3.2-core/gl"
+)
+
+const (
+	shapeTextureWidth = 256 // quads per row of the shape texture, must match the fragment shader
+	shapeTexels       = 5   // texels per quad: size and radius, stroke color, dashes, gradient geometry and row
+)
+
+// Rect is a rectangle in the units of the quad vertices, X, Y is its center
+// like for DrawRectangleAt
+type Rect struct {
+	X, Y, W, H float32
+}
+
+// QuadShape is the outline of a quad drawn with EffectRoundedRect or
+// EffectLine, in the units of its vertices. Quads of other effects keep the
+// zero value.
+type QuadShape struct {
+	Width, Height float32
+	Radius        float32 // corner radius, clamped to half the shorter side
+	StrokeWidth   float32 // 0 = no outline, only filled
+	StrokeColor   color.NRGBA
+
+	// dashes of a line along its width, see LineStyle
+	Dash, Gap float32
+	Phase     float32 // distance into the dash pattern at the start of the line
+	Cap       LineCap // of the line and each dash
+
+	// Gradient fills the quad instead of its vertex colors, 0 = none, else
+	// the index of ElementQuads.Gradients + 1, see SetGradient
+	Gradient int
+}
+
+// DrawRoundedRect adds a rectangle with rounded corners centered at rect's
+// x, y, filled with fill and outlined strokeWidth wide inside its edge. It is
+// a single quad, the fragment shader cuts the corners from a signed distance
+// field and anti-aliases the edges by their screen size, so they stay crisp
+// at any scale. A fill with alpha 0 draws just the outline.
+//
+// The quad goes on LayerUI, blended over the world (the corners are
+// transparent), change it with SetLayer. Its color can be tinted like any
+// other quad, the stroke is not.
+func (q *ElementQuads) DrawRoundedRect(rect Rect, z, radius float32, fill color.NRGBA, strokeWidth float32, strokeColor color.NRGBA) {
+	q.DrawRectangleAt(rect.X, rect.Y, rect.W, rect.H, z, fill)
+	quad := q.QuadCount() - 1
+	q.SetEffect(quad, EffectRoundedRect)
+	q.SetLayer(quad, LayerUI)
+	q.SetShape(quad, QuadShape{Width: rect.W, Height: rect.H, Radius: radius, StrokeWidth: strokeWidth, StrokeColor: strokeColor})
+}
+
+// SetShape changes the outline of a quad (by draw order), see QuadShape
+func (q *ElementQuads) SetShape(quad int, shape QuadShape) {
+	q.QuadShapes[quad] = shape
+	q.shapesChanged = true
+}
+
+// addShape adds the (empty) shape of a new quad
+func (q *ElementQuads) addShape() {
+	q.QuadShapes = append(q.QuadShapes, QuadShape{})
+}
+
+// ShapeTexture holds the QuadShape of every quad, shapeTextureWidth quads per
+// row and five float texels each: width, height, radius and stroke width,
+// then the stroke color, then dash, gap, phase and cap, then the geometry of
+// the gradient (from and to, or center and radius) and its row in
+// GradientRamps and kind (row -1 without a gradient). The fragment shader fetches those of its quad, like the
+// vertex shader fetches tints (see QuadTints). Shapes rarely change, the
+// whole texture is uploaded when one did.
+type ShapeTexture struct {
+	texture uint32
+	rows    int       // allocated rows
+	pix     []float32 // RGBA per texel, a whole number of rows
+}
+
+// NewShapeTexture creates the texture, storage is allocated by the first update
+func NewShapeTexture(label string) *ShapeTexture {
+	t := &ShapeTexture{texture: genTexture(label)}
+	gl.BindTexture(gl.TEXTURE_2D, t.texture)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.NEAREST)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.NEAREST)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
+	gl.BindTexture(gl.TEXTURE_2D, 0)
+	return t
+}
+
+// Texture is the texture, the sampler of the fragment shader reads it with texelFetch
+func (t *ShapeTexture) Texture() uint32 {
+	return t.texture
+}
+
+// update uploads the shapes if any changed since the last update, call it
+// before binding the material (it binds the texture to the active unit)
+func (t *ShapeTexture) update(quads *ElementQuads) {
+	if !quads.shapesChanged {
+		return
+	}
+	quads.shapesChanged = false
+
+	rows := (len(quads.QuadShapes) + shapeTextureWidth - 1) / shapeTextureWidth
+	if rows == 0 {
+		return
+	}
+	gl.BindTexture(gl.TEXTURE_2D, t.texture)
+	if rows > t.rows {
+		t.rows = nextPowerOfTwo(rows)
+		t.pix = make([]float32, t.rows*shapeTextureWidth*shapeTexels*4)
+		gl.TexImage2D(gl.TEXTURE_2D, 0, gl.RGBA32F, shapeTextureWidth*shapeTexels, int32(t.rows), 0, gl.RGBA, gl.FLOAT, nil)
+		gpuResources.SetBytes(ResourceTexture, t.texture, len(t.pix)*bytesFloat32)
+	}
+	for quad, shape := range quads.QuadShapes {
+		texel := t.pix[quad*shapeTexels*4 : (quad+1)*shapeTexels*4]
+		texel[0], texel[1], texel[2], texel[3] = shape.Width, shape.Height, shape.Radius, shape.StrokeWidth
+		stroke := shape.StrokeColor
+		texel[4], texel[5], texel[6], texel[7] = float32(stroke.R)/255, float32(stroke.G)/255, float32(stroke.B)/255, float32(stroke.A)/255
+		texel[8], texel[9], texel[10], texel[11] = shape.Dash, shape.Gap, shape.Phase, float32(shape.Cap)
+		texel[12], texel[13], texel[14], texel[15] = 0, 0, 0, 0
+		texel[16], texel[17], texel[18], texel[19] = -1, 0, 0, 0
+		if shape.Gradient > 0 {
+			gradient := quads.Gradients[shape.Gradient-1]
+			if gradient.Kind == GradientRadial {
+				texel[12], texel[13], texel[14] = gradient.Center.X(), gradient.Center.Y(), gradient.Radius
+			} else {
+				texel[12], texel[13], texel[14], texel[15] = gradient.From.X(), gradient.From.Y(), gradient.To.X(), gradient.To.Y()
+			}
+			texel[16], texel[17] = float32(shape.Gradient-1), float32(gradient.Kind)
+		}
+	}
+	gl.TexSubImage2D(gl.TEXTURE_2D, 0, 0, 0, shapeTextureWidth*shapeTexels, int32(rows), gl.RGBA, gl.FLOAT, gl.Ptr(t.pix))
+	gl.BindTexture(gl.TEXTURE_2D, 0)
+}
+
+func (t *ShapeTexture) destroy() {
+	gpuResources.Release(ResourceTexture, t.texture)
+	t.texture, t.rows, t.pix = 0, 0, nil
+}
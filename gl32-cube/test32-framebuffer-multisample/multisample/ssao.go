@@ -0,0 +1,196 @@
+package multisample
+
+import (
+	"math/rand"
+
+	"github.com/go-gl/gl/v3.2-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+const (
+	ssaoKernelSize = 16  // hemisphere samples per pixel, must match fragmentShaderOcclusion
+	ssaoNoiseSize  = 4   // the random rotations repeat every 4x4 pixels, the blur averages them away
+	ssaoSeed       = 7   // kernel and noise seed, same pattern every run
+	ssaoRadius     = 0.1 // hemisphere radius in world units
+	ssaoStrength   = 0.8 // 0 = no darkening, 1 = full occlusion
+)
+
+var (
+	ssao = &SSAO{}
+)
+
+// https://learnopengl.com/Advanced-Lighting/SSAO
+// https://john-chapman-graphics.blogspot.com/2013/01/ssao-tutorial.html
+//
+// SSAO (screen-space ambient occlusion) darkens creases, corners and
+// contact areas, where less of the sky's ambient light gets in. It needs
+// nothing but the depth buffer: view positions are reconstructed from depth
+// with the inverse projection and normals from the change of the position
+// between neighbouring pixels. Points of a hemisphere around the normal are
+// tested against the depth buffer, the share hidden behind the surface is
+// the occlusion. The samples are rotated per pixel by a small tiling noise
+// texture and blurred, so few samples give a smooth result.
+//
+// The occlusion is composited by a post effect ("ssao", the first in the
+// chain), darkening the lit image. Scenes have no separate ambient term to
+// darken instead.
+type SSAO struct {
+	composite *PostEffect // post-processing pass multiplying the image by the occlusion
+	occlusion *PostEffect // depth to raw occlusion, not in the chain
+	blur      *PostEffect // raw to smooth occlusion, not in the chain
+
+	depthFBO     uint32
+	depthTexture uint32    // multisample depth resolved for sampling
+	fbos         [2]uint32 // raw and blurred occlusion
+	textures     [2]uint32
+	noise        uint32    // random rotation vectors, repeated across the screen
+	kernel       []float32 // hemisphere sample offsets (x, y, z), z along the normal
+}
+
+// load registers the composite effect, before postProcessing.setupProgram
+func (s *SSAO) load() {
+
+	s.composite = postProcessing.add("ssao", &fragmentShaderSSAO, *ssaoMode)
+	s.composite.SetParam("strength", ssaoStrength)
+	s.composite.prepare = s.render
+	s.occlusion = &PostEffect{name: "ssao occlusion", fragmentShader: &fragmentShaderOcclusion}
+	s.occlusion.SetParam("radius", ssaoRadius)
+	s.blur = &PostEffect{name: "ssao blur", fragmentShader: &fragmentShaderOcclusionBlur}
+
+	// samples inside the unit hemisphere, denser close to the center where occluders matter most
+	rng := rand.New(rand.NewSource(ssaoSeed))
+	s.kernel = nil
+	for i := 0; i < ssaoKernelSize; i++ {
+		sample := mgl32.Vec3{rng.Float32()*2 - 1, rng.Float32()*2 - 1, rng.Float32()}.Normalize()
+		t := float32(i) / ssaoKernelSize
+		sample = sample.Mul(rng.Float32() * lerp(0.1, 1, t*t))
+		s.kernel = append(s.kernel, sample[:]...)
+	}
+
+}
+
+func (s *SSAO) setupProgram() {
+	s.occlusion.setupProgram()
+	s.blur.setupProgram()
+	gl.UseProgram(s.occlusion.program)
+	gl.Uniform3fv(gl.GetUniformLocation(s.occlusion.program, gl.Str("kernel\x00")), ssaoKernelSize, &s.kernel[0])
+	gl.UseProgram(0)
+}
+
+// setupBuffers creates the depth and occlusion textures at the current renderSize, after postProcessing.setupProgram
+func (s *SSAO) setupBuffers() {
+
+	width, height := renderSize()
+
+	// depth, the format must match the multisample depth for the resolving blit
+	s.depthFBO = genFramebuffer("ssao depth fbo")
+	gl.BindFramebuffer(gl.FRAMEBUFFER, s.depthFBO)
+	s.depthTexture = genTexture("ssao depth")
+	gl.BindTexture(gl.TEXTURE_2D, s.depthTexture)
+	gl.TexImage2D(gl.TEXTURE_2D, 0, gl.DEPTH24_STENCIL8, width, height, 0, gl.DEPTH_STENCIL, gl.UNSIGNED_INT_24_8, nil)
+	gpuResources.SetBytes(ResourceTexture, s.depthTexture, int(width)*int(height)*4)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.NEAREST)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.NEAREST)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
+	gl.BindTexture(gl.TEXTURE_2D, 0)
+	gl.FramebufferTexture2D(gl.FRAMEBUFFER, gl.DEPTH_STENCIL_ATTACHMENT, gl.TEXTURE_2D, s.depthTexture, 0)
+	gl.DrawBuffer(gl.NONE)
+	gl.ReadBuffer(gl.NONE)
+	CheckGLFramebufferStatus()
+
+	// occlusion, one channel
+	for i := range s.fbos {
+		s.fbos[i] = genFramebuffer("ssao fbo")
+		gl.BindFramebuffer(gl.FRAMEBUFFER, s.fbos[i])
+		s.textures[i] = genTexture("ssao occlusion")
+		gl.BindTexture(gl.TEXTURE_2D, s.textures[i])
+		gl.TexImage2D(gl.TEXTURE_2D, 0, gl.R8, width, height, 0, gl.RED, gl.UNSIGNED_BYTE, nil)
+		gpuResources.SetBytes(ResourceTexture, s.textures[i], int(width)*int(height))
+		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.NEAREST)
+		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.NEAREST)
+		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
+		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
+		gl.BindTexture(gl.TEXTURE_2D, 0)
+		gl.FramebufferTexture2D(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT0, gl.TEXTURE_2D, s.textures[i], 0)
+		CheckGLFramebufferStatus()
+	}
+	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+
+	// random rotations around the normal (x, y in 0..1), repeated
+	rng := rand.New(rand.NewSource(ssaoSeed))
+	noise := make([]uint8, ssaoNoiseSize*ssaoNoiseSize*2)
+	for i := range noise {
+		noise[i] = uint8(rng.Intn(256))
+	}
+	s.noise = genTexture("ssao noise")
+	gl.BindTexture(gl.TEXTURE_2D, s.noise)
+	gl.PixelStorei(gl.UNPACK_ALIGNMENT, 1)
+	gl.TexImage2D(gl.TEXTURE_2D, 0, gl.RG8, ssaoNoiseSize, ssaoNoiseSize, 0, gl.RG, gl.UNSIGNED_BYTE, gl.Ptr(noise))
+	gl.PixelStorei(gl.UNPACK_ALIGNMENT, 4)
+	gpuResources.SetBytes(ResourceTexture, s.noise, len(noise))
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.NEAREST)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.NEAREST)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.REPEAT)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.REPEAT)
+	gl.BindTexture(gl.TEXTURE_2D, 0)
+
+	s.occlusion.material.SetTexture("depthTexture", gl.TEXTURE_2D, s.depthTexture)
+	s.occlusion.material.SetTexture("noiseTexture", gl.TEXTURE_2D, s.noise)
+	s.blur.material.SetTexture("inputTexture", gl.TEXTURE_2D, s.textures[0])
+	s.composite.material.SetTexture("occlusionTexture", gl.TEXTURE_2D, s.textures[1])
+
+}
+
+// render resolves the depth of the scene just drawn and computes the
+// blurred occlusion, called by PostProcessing.apply before the composite
+// pass (with the screen quad's buffers bound)
+func (s *SSAO) render() {
+
+	width, height := viewportSize()
+
+	// resolve depth, depth blits must use NEAREST
+	gl.BindFramebuffer(gl.READ_FRAMEBUFFER, ctxFramebufferMultisample.fbo)
+	gl.BindFramebuffer(gl.DRAW_FRAMEBUFFER, s.depthFBO)
+	gl.BlitFramebuffer(0, 0, width, height, 0, 0, width, height, gl.DEPTH_BUFFER_BIT, gl.NEAREST)
+
+	// positions are reconstructed with the projection of the frame
+	projection := sceneProjection()
+	inverse := projection.Inv()
+	gl.UseProgram(s.occlusion.program)
+	gl.UniformMatrix4fv(gl.GetUniformLocation(s.occlusion.program, gl.Str("projection\x00")), 1, false, &projection[0])
+	gl.UniformMatrix4fv(gl.GetUniformLocation(s.occlusion.program, gl.Str("inverseProjection\x00")), 1, false, &inverse[0])
+
+	gl.BindFramebuffer(gl.FRAMEBUFFER, s.fbos[0])
+	s.occlusion.draw()
+	gl.BindFramebuffer(gl.FRAMEBUFFER, s.fbos[1])
+	s.blur.draw()
+
+}
+
+// resize recreates the depth and occlusion textures at the current renderSize
+func (s *SSAO) resize() {
+	s.releaseBuffers()
+	s.setupBuffers()
+}
+
+func (s *SSAO) releaseBuffers() {
+	gpuResources.Release(ResourceFramebuffer, s.depthFBO)
+	gpuResources.Release(ResourceTexture, s.depthTexture)
+	gpuResources.Release(ResourceTexture, s.noise)
+	for i := range s.fbos {
+		gpuResources.Release(ResourceFramebuffer, s.fbos[i])
+		gpuResources.Release(ResourceTexture, s.textures[i])
+		s.fbos[i], s.textures[i] = 0, 0
+	}
+	s.depthFBO, s.depthTexture, s.noise = 0, 0, 0
+}
+
+// destroy releases the buffers and the occlusion programs, the composite program belongs to postProcessing
+func (s *SSAO) destroy() {
+	s.releaseBuffers()
+	for _, effect := range []*PostEffect{s.occlusion, s.blur} {
+		gpuResources.Release(ResourceProgram, effect.program)
+		effect.program = 0
+	}
+}
@@ -0,0 +1,110 @@
+package multisample
+
+import (
+	"fmt"
+	"image/color"
+	"strings"
+
+	"github.com/paperboard/glfw/v3.3/glfw"
+)
+
+const (
+	statsInterval = 1.0 // seconds between stats refresh
+)
+
+var (
+	stats = &FrameStats{}
+)
+
+// FrameStats is shown in the window title, next to the frame-time graph.
+// The stats page (see drawPage) shows more of them as text ontop the screen.
+type FrameStats struct {
+	frames int     // frames drawn since last refresh
+	since  float64 // time of last refresh
+	fps    float64
+	page   bool // draw the stats page, see handleKey
+}
+
+// update counts a frame and refreshes the window title once per statsInterval
+func (s *FrameStats) update(window *glfw.Window, now float64) {
+
+	s.frames++
+	if now-s.since < statsInterval {
+		return
+	}
+	s.fps = float64(s.frames) / (now - s.since)
+	s.frames = 0
+	s.since = now
+
+	window.SetTitle(s.String())
+
+}
+
+func (s *FrameStats) String() string {
+	frameTime := "-"
+	if s.fps > 0 {
+		frameTime = fmt.Sprintf("%.1f", 1000/s.fps)
+	}
+	target := "unlimited"
+	if frameLimiter.Target() > 0 {
+		target = fmt.Sprintf("%.1f ms", frameLimiter.Target()*1000)
+	}
+	return fmt.Sprintf("%v | %.1f fps | %v / %v | VBO %v | TEX %v | RBO %v",
+		windowTitle,
+		s.fps,
+		frameTime,
+		target,
+		formatBytes(gpuResources.TotalBytes(ResourceBuffer)),
+		formatBytes(gpuResources.TotalBytes(ResourceTexture)),
+		formatBytes(gpuResources.TotalBytes(ResourceRenderbuffer)),
+	)
+}
+
+// drawPage queues the stats page text, one line per stat
+func (s *FrameStats) drawPage() {
+
+	gpuTime := "-"
+	if seconds, ok := gpuTimer.result(); ok {
+		gpuTime = fmt.Sprintf("%.2f ms", seconds*1000)
+	}
+	renderWidth, renderHeight := renderSize()
+	glyphs := "batched quads"
+	if ctxText.instanced {
+		glyphs = "instances"
+	}
+	cursor := "outside"
+	if x, y, inside := CursorToFramebuffer(mouse.x, mouse.y); inside {
+		cursor = fmt.Sprintf("%v, %v (framebuffer pixel)", int(x), int(y))
+	}
+
+	lines := []string{
+		fmt.Sprintf("fps         %.1f", s.fps),
+		fmt.Sprintf("gpu time    %v", gpuTime),
+		fmt.Sprintf("resolution  %vx%v (scale %v)", renderWidth, renderHeight, renderScale),
+		fmt.Sprintf("quads       %v", ctxFramebufferMultisample.quads.QuadCount()),
+		fmt.Sprintf("buffers     %v", formatBytes(gpuResources.TotalBytes(ResourceBuffer))),
+		fmt.Sprintf("textures    %v", formatBytes(gpuResources.TotalBytes(ResourceTexture))),
+		fmt.Sprintf("renderbuffer %v", formatBytes(gpuResources.TotalBytes(ResourceRenderbuffer))),
+		fmt.Sprintf("glyphs      %v", glyphs),
+		fmt.Sprintf("cursor      %v", cursor),
+		fmt.Sprintf("window      %v", windowAttributes),
+	}
+	if ctxFramebufferMultisample.video != nil {
+		lines = append(lines, fmt.Sprintf("video       %v", ctxFramebufferMultisample.video))
+	}
+	if indirect := ctxFramebufferMultisample.indirect; indirect != nil {
+		lines = append(lines, fmt.Sprintf("draw calls  %v (%v)", quadDrawCalls, indirect))
+	} else {
+		lines = append(lines, fmt.Sprintf("draw calls  %v (direct)", quadDrawCalls))
+	}
+	if ctxFramebufferMultisample.occlusion != nil {
+		lines = append(lines, fmt.Sprintf("occlusion   %v", ctxFramebufferMultisample.occlusion))
+	}
+
+	bounds := ctxText.DrawTextLayout(16, 16, strings.Join(lines, "\n"), TextLayout{LineSpacing: 1.2}, color.NRGBA{255, 255, 255, 230})
+
+	// key help below, wrapped to the width of the stats
+	help := "{yellow}T{/} hides this page, {yellow}Space{/} pauses, {yellow}D{/} dumps the next frame, {yellow}B{/} prints the buffers, {yellow}N{/} adds a quad"
+	ctxText.DrawTextLayout(bounds.X, bounds.Y+bounds.Height+24, help, TextLayout{Width: bounds.Width}, color.NRGBA{180, 180, 180, 230})
+
+}
@@ -0,0 +1,180 @@
+package multisample
+
+import (
+	"fmt"
+
+	"github.com/go-gl/gl/v3.2-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// StereoMode is how the two eye images are shown, see -stereo
+type StereoMode int
+
+const (
+	StereoOff        StereoMode = iota
+	StereoAnaglyph              // red/cyan glasses: red from the left eye, green and blue from the right
+	StereoSideBySide            // left eye in the left half of the screen, right eye in the right half
+	StereoTopBottom             // left eye in the top half, right eye in the bottom half
+)
+
+var (
+	stereo = &Stereo{right: &ContextFramebuffer{name: "stereo right eye"}}
+)
+
+// Stereo draws the scene twice, with the 3D camera moved half the eye
+// separation to the right and then to the left (see Camera.SetEye). The
+// right eye is drawn first, resolved and post-processed into its own FBO,
+// then the left eye takes the usual way through the render graph. The screen pass
+// draws one or more views (see views), each combining both images with
+// channel masks (uLeftMask, uRightMask): anaglyph draws one view mixing the
+// eyes, side-by-side and top-bottom draw one view per eye.
+//
+// The eye separation (-eyesep) sets the depth effect, the convergence
+// distance (-convergence) the depth that appears at the screen. Points
+// closer than it float in front of the screen.
+type Stereo struct {
+	mode  StereoMode
+	right *ContextFramebuffer // right eye image, the left eye uses the resolved "scene" of the render graph
+}
+
+// parseStereoMode reads the -stereo flag
+func parseStereoMode(name string) (StereoMode, error) {
+	switch name {
+	case "", "off":
+		return StereoOff, nil
+	case "anaglyph":
+		return StereoAnaglyph, nil
+	case "sbs":
+		return StereoSideBySide, nil
+	case "tb":
+		return StereoTopBottom, nil
+	}
+	return StereoOff, fmt.Errorf("unknown stereo mode %q, use off, anaglyph, sbs or tb", name)
+}
+
+// load reads the -stereo flag, before setup since it changes the render filter
+func (s *Stereo) load() {
+	mode, err := parseStereoMode(*stereoMode)
+	if err != nil {
+		panic(err)
+	}
+	s.mode = mode
+}
+
+// setup creates the right eye FBO if a stereo mode is chosen
+func (s *Stereo) setup() {
+	if s.mode != StereoOff {
+		s.right.setupBuffers()
+	}
+}
+
+func (s *Stereo) destroy() {
+	s.right.destroy()
+}
+
+// camera is the 3D camera of the scene drawn
+func (s *Stereo) camera() *Camera {
+	if *terrainMode {
+		return ctxTerrain.camera
+	}
+	return ctxFramebufferMultisample.camera
+}
+
+// split is true for modes giving each eye its own part of the screen
+func (s *Stereo) split() bool {
+	return s.mode == StereoSideBySide || s.mode == StereoTopBottom
+}
+
+// setEye moves the camera to an eye, -1 = left, 1 = right, 0 = center.
+// Without -convergence the eyes converge at the camera target.
+func (s *Stereo) setEye(eye float32) {
+	camera := s.camera()
+	convergence := float32(*eyeConvergence)
+	if convergence <= 0 {
+		convergence = camera.Target().Sub(camera.Position()).Len()
+	}
+	camera.SetEye(eye*float32(*eyeSeparation)/2, convergence)
+
+	// an eye's half of the screen is narrower (or flatter), keep the image undistorted
+	switch s.mode {
+	case StereoSideBySide:
+		camera.SetAspect(renderAspect() / 2)
+	case StereoTopBottom:
+		camera.SetAspect(renderAspect() * 2)
+	}
+}
+
+// drawRightEye draws, resolves and post-processes the right eye image and
+// moves the camera to the left eye for the rest of the frame. It returns
+// the right eye texture, post effects included.
+func (s *Stereo) drawRightEye() uint32 {
+
+	s.setEye(1)
+	drawScene()
+	s.right.bind()
+	s.right.draw()
+
+	// the post-processing textures are reused by the left eye, keep the result
+	right := s.right.pass
+	result := postProcessing.apply(right.ColorTexture())
+	if result != right.ColorTexture() {
+		width, height := viewportSize()
+		gl.BindFramebuffer(gl.READ_FRAMEBUFFER, postProcessing.framebufferOf(result))
+		gl.BindFramebuffer(gl.DRAW_FRAMEBUFFER, right.Framebuffer())
+		gl.BlitFramebuffer(0, 0, width, height, 0, 0, width, height, gl.COLOR_BUFFER_BIT, gl.NEAREST)
+		gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+	}
+
+	// the left eye draws the same colors
+	s.setEye(-1)
+	ctxFramebufferMultisample.sameColors = true
+
+	return right.ColorTexture()
+
+}
+
+// endFrame moves the camera back to the center, e.g. for picking and labels
+func (s *Stereo) endFrame() {
+	if s.mode != StereoOff {
+		s.setEye(0)
+		ctxFramebufferMultisample.sameColors = false
+	}
+}
+
+// stereoView is a part of the screen the screen pass draws into, and the
+// color channels it takes from each eye
+type stereoView struct {
+	x, y, width, height int32 // viewport, origin bottom-left
+	left, right         mgl32.Vec3
+}
+
+// views are the viewports of the screen pass, one without stereo (all from
+// the left eye, the only one) and for anaglyph, one per eye for split modes
+func (s *Stereo) views() []stereoView {
+	x, y, width, height := presentViewport()
+	all, none := mgl32.Vec3{1, 1, 1}, mgl32.Vec3{0, 0, 0}
+	switch s.mode {
+	case StereoAnaglyph:
+		return []stereoView{{x, y, width, height, mgl32.Vec3{1, 0, 0}, mgl32.Vec3{0, 1, 1}}}
+	case StereoSideBySide:
+		half := width / 2
+		return []stereoView{{x, y, half, height, all, none}, {x + half, y, width - half, height, none, all}}
+	case StereoTopBottom:
+		half := height / 2
+		return []stereoView{{x, y + height - half, width, half, all, none}, {x, y, width, height - half, none, all}}
+	}
+	return []stereoView{{x, y, width, height, all, none}}
+}
+
+// viewAt finds the view under screen pixels x, y (origin top-left, see WindowToScreen)
+func (s *Stereo) viewAt(x, y float32) (stereoView, bool) {
+	_, screenHeight := screenSize()
+	views := s.views()
+	for _, view := range views {
+		bottom := float32(screenHeight) - y // views have origin bottom-left
+		if x >= float32(view.x) && x < float32(view.x+view.width) && bottom > float32(view.y) && bottom <= float32(view.y+view.height) {
+			return view, true
+		}
+	}
+	return views[0], false
+}
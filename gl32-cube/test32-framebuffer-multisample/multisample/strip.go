@@ -0,0 +1,62 @@
+package multisample
+
+import (
+	"math"
+)
+
+const (
+	primitiveRestartIndex = math.MaxUint16 // ends a triangle strip in Mesh.Indices, the next index starts a new one
+)
+
+var (
+	usePrimitiveRestart = false // primitive restart is supported, see setupPrimitiveRestart
+)
+
+// setupPrimitiveRestart checks for primitive restart (OpenGL 3.1, GL 2.1 has
+// NV_primitive_restart with other entry points and GLES2 has none)
+func setupPrimitiveRestart() {
+	usePrimitiveRestart = glInfo.AtLeast(3, 1)
+}
+
+// gridStripIndices connects a size x size grid of vertices (row-major) with
+// one triangle strip per row of cells, separated by primitiveRestartIndex.
+// A strip of n cells takes 2n+2 indices instead of 6n for TRIANGLES.
+// Triangles are counter-clockwise seen from above, like makeHeightmapMesh.
+func gridStripIndices(size int) []uint16 {
+	var indices []uint16
+	for z := 0; z < size-1; z++ {
+		if z > 0 {
+			indices = append(indices, primitiveRestartIndex)
+		}
+		for x := 0; x < size; x++ {
+			i := uint16(z*size + x)
+			indices = append(indices, i, i+uint16(size)) // vertex on this row, vertex below
+		}
+	}
+	return indices
+}
+
+// stitchStrips joins the strips separated by primitiveRestartIndex into a
+// single strip, for drivers without primitive restart. Strips are connected
+// by degenerate (zero area) triangles that repeat the last index of a strip
+// and the first of the next, every strip keeps starting at an even position
+// so its triangles keep their winding.
+func stitchStrips(indices []uint16) []uint16 {
+	stitched := make([]uint16, 0, len(indices))
+	restart := false
+	for _, i := range indices {
+		if i == primitiveRestartIndex {
+			restart = true
+			continue
+		}
+		if restart && len(stitched) > 0 {
+			stitched = append(stitched, stitched[len(stitched)-1], i)
+			if len(stitched)%2 == 1 {
+				stitched = append(stitched, i)
+			}
+		}
+		restart = false
+		stitched = append(stitched, i)
+	}
+	return stitched
+}
@@ -0,0 +1,552 @@
+package multisample
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"image/color"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+const (
+	svgTolerance = 0.001 // curves are flattened to within this part of the drawing's height
+)
+
+// SVGDrawing is a drawing loaded from a subset of SVG: path, rect, circle,
+// line, polyline and polygon elements in nested groups, their fill, stroke,
+// stroke-width, stroke-linecap, stroke-dasharray and opacities, as
+// attributes or in a style attribute. Transforms, arcs, gradients and text
+// are not supported. Draw it with DrawSVG.
+type SVGDrawing struct {
+	ViewBox [4]float32 // min x, min y, width, height in SVG units
+	shapes  []svgShape
+}
+
+// svgShape is an element of the drawing, in SVG units
+type svgShape struct {
+	kind     string       // "path", "rect" or "circle"
+	x, y     float32      // rect top-left, circle center
+	w, h     float32      // rect size
+	r        float32      // rect corner, circle radius
+	segments []svgSegment // path outline
+	style    svgStyle
+}
+
+// svgSegment is a path command made absolute: 'M', 'L', 'Q', 'C' or 'Z' and
+// its points, the end point last
+type svgSegment struct {
+	command byte
+	points  []mgl32.Vec2
+}
+
+// svgStyle is the paint of a shape, inherited from the enclosing groups
+type svgStyle struct {
+	fill, stroke  color.NRGBA // alpha 0 = none
+	strokeWidth   float32
+	cap           LineCap
+	dash, gap     float32
+	opacity       float32
+	fillOpacity   float32
+	strokeOpacity float32
+}
+
+// loadSVG reads a drawing from an asset or file, see Assets.Open
+func loadSVG(path string) (*SVGDrawing, error) {
+	data, err := assets.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	drawing, err := parseSVG(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return drawing, nil
+}
+
+// parseSVG reads the elements of the subset, others are skipped
+func parseSVG(data []byte) (*SVGDrawing, error) {
+
+	drawing := &SVGDrawing{}
+	styles := []svgStyle{{fill: color.NRGBA{0, 0, 0, 255}, strokeWidth: 1, opacity: 1, fillOpacity: 1, strokeOpacity: 1}} // SVG defaults
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch element := token.(type) {
+		case xml.StartElement:
+			attrs := map[string]string{}
+			for _, attr := range element.Attr {
+				attrs[attr.Name.Local] = attr.Value
+			}
+			style, err := parseSVGStyle(styles[len(styles)-1], attrs)
+			if err != nil {
+				return nil, fmt.Errorf("<%s>: %v", element.Name.Local, err)
+			}
+			styles = append(styles, style)
+			shape, err := parseSVGElement(drawing, element.Name.Local, attrs)
+			if err != nil {
+				return nil, fmt.Errorf("<%s>: %v", element.Name.Local, err)
+			}
+			if shape != nil {
+				shape.style = style
+				drawing.shapes = append(drawing.shapes, *shape)
+			}
+		case xml.EndElement:
+			styles = styles[:len(styles)-1]
+		}
+	}
+	if drawing.ViewBox[2] <= 0 || drawing.ViewBox[3] <= 0 {
+		return nil, fmt.Errorf("no viewBox or size")
+	}
+	return drawing, nil
+}
+
+// parseSVGElement reads the geometry of an element, nil for those that have none (groups)
+func parseSVGElement(drawing *SVGDrawing, name string, attrs map[string]string) (*svgShape, error) {
+	number := func(key string) float32 {
+		return parseSVGNumber(attrs[key])
+	}
+	switch name {
+	case "svg":
+		if viewBox := parseSVGNumbers(attrs["viewBox"]); len(viewBox) == 4 {
+			copy(drawing.ViewBox[:], viewBox)
+		} else {
+			drawing.ViewBox = [4]float32{0, 0, number("width"), number("height")}
+		}
+	case "rect":
+		r := number("rx")
+		if r == 0 {
+			r = number("ry")
+		}
+		return &svgShape{kind: "rect", x: number("x"), y: number("y"), w: number("width"), h: number("height"), r: r}, nil
+	case "circle":
+		return &svgShape{kind: "circle", x: number("cx"), y: number("cy"), r: number("r")}, nil
+	case "line":
+		return &svgShape{kind: "path", segments: []svgSegment{
+			{'M', []mgl32.Vec2{{number("x1"), number("y1")}}},
+			{'L', []mgl32.Vec2{{number("x2"), number("y2")}}},
+		}}, nil
+	case "polyline", "polygon":
+		numbers := parseSVGNumbers(attrs["points"])
+		shape := &svgShape{kind: "path"}
+		for i := 0; i+1 < len(numbers); i += 2 {
+			command := byte('L')
+			if i == 0 {
+				command = 'M'
+			}
+			shape.segments = append(shape.segments, svgSegment{command, []mgl32.Vec2{{numbers[i], numbers[i+1]}}})
+		}
+		if name == "polygon" {
+			shape.segments = append(shape.segments, svgSegment{command: 'Z'})
+		}
+		return shape, nil
+	case "path":
+		segments, err := parseSVGPath(attrs["d"])
+		if err != nil {
+			return nil, err
+		}
+		return &svgShape{kind: "path", segments: segments}, nil
+	}
+	return nil, nil
+}
+
+// parseSVGStyle applies the paint attributes of an element, and those in its
+// style attribute (which win), to the style it inherits
+func parseSVGStyle(style svgStyle, attrs map[string]string) (svgStyle, error) {
+	properties := map[string]string{}
+	for key, value := range attrs {
+		properties[key] = value
+	}
+	for _, declaration := range strings.Split(attrs["style"], ";") {
+		if key, value, ok := strings.Cut(declaration, ":"); ok {
+			properties[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		}
+	}
+
+	var err error
+	for key, value := range properties {
+		switch key {
+		case "fill":
+			style.fill, err = parseSVGColor(value)
+		case "stroke":
+			style.stroke, err = parseSVGColor(value)
+		case "stroke-width":
+			style.strokeWidth = parseSVGNumber(value)
+		case "stroke-linecap":
+			switch value {
+			case "round":
+				style.cap = LineCapRound
+			case "square":
+				style.cap = LineCapSquare
+			default:
+				style.cap = LineCapButt
+			}
+		case "stroke-dasharray":
+			style.dash, style.gap = 0, 0
+			if dashes := parseSVGNumbers(value); len(dashes) > 0 {
+				style.dash, style.gap = dashes[0], dashes[0]
+				if len(dashes) > 1 {
+					style.gap = dashes[1]
+				}
+			}
+		case "opacity":
+			style.opacity *= parseSVGNumber(value) // of the group times the element
+		case "fill-opacity":
+			style.fillOpacity = parseSVGNumber(value)
+		case "stroke-opacity":
+			style.strokeOpacity = parseSVGNumber(value)
+		}
+		if err != nil {
+			return style, err
+		}
+	}
+	return style, nil
+}
+
+// svgNamedColors are the color keywords understood besides #rgb, #rrggbb and rgb()
+var svgNamedColors = map[string]color.NRGBA{
+	"black":  {0, 0, 0, 255},
+	"white":  {255, 255, 255, 255},
+	"red":    {255, 0, 0, 255},
+	"green":  {0, 128, 0, 255},
+	"blue":   {0, 0, 255, 255},
+	"yellow": {255, 255, 0, 255},
+	"orange": {255, 165, 0, 255},
+	"gray":   {128, 128, 128, 255},
+	"grey":   {128, 128, 128, 255},
+}
+
+// parseSVGColor reads a paint, none (and transparent) are alpha 0
+func parseSVGColor(value string) (color.NRGBA, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if value == "none" || value == "transparent" {
+		return color.NRGBA{}, nil
+	}
+	if clr, ok := svgNamedColors[value]; ok {
+		return clr, nil
+	}
+	if strings.HasPrefix(value, "#") {
+		hex := value[1:]
+		if len(hex) == 3 {
+			hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+		}
+		if v, err := strconv.ParseUint(hex, 16, 32); err == nil && len(hex) == 6 {
+			return color.NRGBA{uint8(v >> 16), uint8(v >> 8), uint8(v), 255}, nil
+		}
+	}
+	if strings.HasPrefix(value, "rgb(") && strings.HasSuffix(value, ")") {
+		separator := func(r rune) bool { return r == ',' || unicode.IsSpace(r) }
+		if rgb := strings.FieldsFunc(value[4:len(value)-1], separator); len(rgb) == 3 {
+			return color.NRGBA{parseSVGChannel(rgb[0]), parseSVGChannel(rgb[1]), parseSVGChannel(rgb[2]), 255}, nil
+		}
+	}
+	return color.NRGBA{}, fmt.Errorf("unsupported color %q", value)
+}
+
+// parseSVGChannel reads a channel of rgb(), 0 to 255 or a percentage, out of range values are clamped
+func parseSVGChannel(value string) uint8 {
+	v := float64(parseSVGNumber(value))
+	if strings.HasSuffix(value, "%") {
+		v = v * 255 / 100
+	}
+	return uint8(math.Max(0, math.Min(255, math.Round(v))))
+}
+
+// parseSVGNumber reads a length or opacity, units (px) are ignored
+func parseSVGNumber(value string) float32 {
+	numbers := parseSVGNumbers(value)
+	if len(numbers) == 0 {
+		return 0
+	}
+	return numbers[0]
+}
+
+// parseSVGNumbers reads the numbers of a list separated by whitespace and/or
+// commas, like SVG allows: "1,2 3-4" and "0.5.5" are 4 and 2 numbers
+func parseSVGNumbers(value string) []float32 {
+	scanner := svgScanner{s: value}
+	var numbers []float32
+	for {
+		v, ok := scanner.number()
+		if !ok {
+			return numbers
+		}
+		numbers = append(numbers, v)
+	}
+}
+
+// parseSVGPath reads path data, making every command absolute and turning
+// H and V into L, S into C and T into Q (with the reflected control point)
+func parseSVGPath(d string) ([]svgSegment, error) {
+
+	scanner := svgScanner{s: d}
+	var segments []svgSegment
+	var current, start, control mgl32.Vec2
+	command, previous := byte(0), byte(0) // command 0: the next must be given, at the start and after Z
+	for !scanner.done() {
+		if c, ok := scanner.command(); ok {
+			command = c
+		} else if command == 0 {
+			return nil, fmt.Errorf("path numbers without a command")
+		}
+
+		relative := command >= 'a'
+		upper := command &^ 0x20
+		point := func() (mgl32.Vec2, bool) {
+			x, ok1 := scanner.number()
+			y, ok2 := scanner.number()
+			p := mgl32.Vec2{x, y}
+			if relative {
+				p = p.Add(current)
+			}
+			return p, ok1 && ok2
+		}
+
+		var segment svgSegment
+		ok := true
+		switch upper {
+		case 'M', 'L':
+			var p mgl32.Vec2
+			p, ok = point()
+			segment = svgSegment{upper, []mgl32.Vec2{p}}
+			if upper == 'M' {
+				start = p
+				command = 'L' | (command & 0x20) // further pairs are lines
+			}
+		case 'H', 'V':
+			var v float32
+			v, ok = scanner.number()
+			p := current
+			if upper == 'H' {
+				p[0] = v
+				if relative {
+					p[0] += current.X()
+				}
+			} else {
+				p[1] = v
+				if relative {
+					p[1] += current.Y()
+				}
+			}
+			segment = svgSegment{'L', []mgl32.Vec2{p}}
+		case 'Q', 'T':
+			c := current
+			if upper == 'T' && (previous == 'Q' || previous == 'T') {
+				c = current.Mul(2).Sub(control)
+			}
+			if upper == 'Q' {
+				var ok1 bool
+				c, ok1 = point()
+				ok = ok1
+			}
+			p, ok2 := point()
+			ok = ok && ok2
+			control = c
+			segment = svgSegment{'Q', []mgl32.Vec2{c, p}}
+		case 'C', 'S':
+			c1 := current
+			if upper == 'S' && (previous == 'C' || previous == 'S') {
+				c1 = current.Mul(2).Sub(control)
+			}
+			if upper == 'C' {
+				var ok1 bool
+				c1, ok1 = point()
+				ok = ok1
+			}
+			c2, ok2 := point()
+			p, ok3 := point()
+			ok = ok && ok2 && ok3
+			control = c2
+			segment = svgSegment{'C', []mgl32.Vec2{c1, c2, p}}
+		case 'Z':
+			segments = append(segments, svgSegment{command: 'Z'})
+			current, previous, command = start, 'Z', 0
+			continue
+		default:
+			return nil, fmt.Errorf("unsupported path command %q", command)
+		}
+		if !ok {
+			return nil, fmt.Errorf("path command %q is missing numbers", command)
+		}
+		segments = append(segments, segment)
+		current = segment.points[len(segment.points)-1]
+		previous = upper
+	}
+	return segments, nil
+}
+
+// svgScanner splits SVG number lists and path data
+type svgScanner struct {
+	s string
+	i int
+}
+
+// skip steps over whitespace and commas
+func (s *svgScanner) skip() {
+	for s.i < len(s.s) && strings.IndexByte(" \t\r\n,", s.s[s.i]) >= 0 {
+		s.i++
+	}
+}
+
+// done reports whether only separators are left
+func (s *svgScanner) done() bool {
+	s.skip()
+	return s.i >= len(s.s)
+}
+
+// command reads a path command letter, if one is next
+func (s *svgScanner) command() (byte, bool) {
+	s.skip()
+	if s.i < len(s.s) {
+		c := s.s[s.i]
+		if (c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z') && c != 'e' && c != 'E' {
+			s.i++
+			return c, true
+		}
+	}
+	return 0, false
+}
+
+// number reads a number, if one is next
+func (s *svgScanner) number() (float32, bool) {
+	s.skip()
+	begin := s.i
+	if s.i < len(s.s) && (s.s[s.i] == '+' || s.s[s.i] == '-') {
+		s.i++
+	}
+	digits, dot := 0, false
+	for s.i < len(s.s) {
+		c := s.s[s.i]
+		if c >= '0' && c <= '9' {
+			digits++
+		} else if c == '.' && !dot {
+			dot = true
+		} else {
+			break
+		}
+		s.i++
+	}
+	if digits > 0 && s.i < len(s.s) && (s.s[s.i] == 'e' || s.s[s.i] == 'E') {
+		exponent := s.i
+		s.i++
+		if s.i < len(s.s) && (s.s[s.i] == '+' || s.s[s.i] == '-') {
+			s.i++
+		}
+		if s.i < len(s.s) && s.s[s.i] >= '0' && s.s[s.i] <= '9' {
+			for s.i < len(s.s) && s.s[s.i] >= '0' && s.s[s.i] <= '9' {
+				s.i++
+			}
+		} else {
+			s.i = exponent // an e not followed by digits is no exponent
+		}
+	}
+	if digits == 0 {
+		s.i = begin
+		return 0, false
+	}
+	v, err := strconv.ParseFloat(s.s[begin:s.i], 32)
+	if err != nil {
+		s.i = begin
+		return 0, false
+	}
+	// skip a unit
+	for s.i < len(s.s) && (s.s[s.i] == 'p' || s.s[s.i] == 'x' || s.s[s.i] == '%') {
+		s.i++
+	}
+	return float32(v), true
+}
+
+// DrawSVG draws a drawing fitted into rect (keeping its aspect ratio,
+// centered), shapes in the order of the file. Fills of paths are polygons
+// (see FillPath), strokes are lines (see StrokePath), rects and circles are
+// rounded rectangles (see DrawRoundedRect).
+//
+// All quads go on LayerUI, each shape with its own order (see SetOrder), so
+// shapes stack like in the file, painted one over the other, and above the
+// quads drawn before.
+func (q *ElementQuads) DrawSVG(drawing *SVGDrawing, rect Rect, z float32) {
+
+	// SVG y points down
+	viewBox := drawing.ViewBox
+	scale := float32(math.Min(float64(rect.W/viewBox[2]), float64(rect.H/viewBox[3])))
+	left := rect.X - viewBox[2]*scale*0.5
+	top := rect.Y + viewBox[3]*scale*0.5
+	transform := func(p mgl32.Vec2) mgl32.Vec2 {
+		return mgl32.Vec2{left + (p.X()-viewBox[0])*scale, top - (p.Y()-viewBox[1])*scale}
+	}
+
+	for _, shape := range drawing.shapes {
+		first, order := q.QuadCount(), q.NextOrder()
+		style := shape.style
+		fill := svgPaint(style.fill, style.opacity*style.fillOpacity)
+		stroke := svgPaint(style.stroke, style.opacity*style.strokeOpacity)
+		strokeWidth := style.strokeWidth * scale
+		if stroke.A == 0 {
+			strokeWidth = 0
+		}
+
+		switch shape.kind {
+		case "rect", "circle":
+			// SVG strokes are centered on the outline, those of DrawRoundedRect are inside it
+			center := mgl32.Vec2{shape.x + shape.w*0.5, shape.y + shape.h*0.5}
+			w, h, r := shape.w*scale+strokeWidth, shape.h*scale+strokeWidth, shape.r*scale+strokeWidth*0.5
+			if shape.kind == "circle" {
+				center = mgl32.Vec2{shape.x, shape.y}
+				w, h = shape.r*2*scale+strokeWidth, shape.r*2*scale+strokeWidth
+			}
+			if fill.A == 0 && strokeWidth == 0 {
+				continue
+			}
+			center = transform(center)
+			q.DrawRoundedRect(Rect{center.X(), center.Y(), w, h}, z, r, fill, strokeWidth, stroke)
+		case "path":
+			path := NewPath(viewBox[3] * scale * svgTolerance)
+			for _, segment := range shape.segments {
+				points := make([]mgl32.Vec2, len(segment.points))
+				for i, p := range segment.points {
+					points[i] = transform(p)
+				}
+				switch segment.command {
+				case 'M':
+					path.MoveTo(points[0].X(), points[0].Y())
+				case 'L':
+					path.LineTo(points[0].X(), points[0].Y())
+				case 'Q':
+					path.QuadTo(points[0].X(), points[0].Y(), points[1].X(), points[1].Y())
+				case 'C':
+					path.CubicTo(points[0].X(), points[0].Y(), points[1].X(), points[1].Y(), points[2].X(), points[2].Y())
+				case 'Z':
+					path.Close()
+				}
+			}
+			if fill.A > 0 {
+				q.FillPath(path, z, fill)
+			}
+			if strokeWidth > 0 {
+				q.StrokePath(path, z, stroke, LineStyle{Width: strokeWidth, Cap: style.cap, Dash: style.dash * scale, Gap: style.gap * scale, RoundJoins: true})
+			}
+		}
+
+		// the fill and stroke of a shape still batch by effect, the fill first
+		for quad := first; quad < q.QuadCount(); quad++ {
+			q.SetOrder(quad, order)
+		}
+	}
+}
+
+// svgPaint applies an opacity to a color
+func svgPaint(clr color.NRGBA, opacity float32) color.NRGBA {
+	clr.A = uint8(float32(clr.A)*opacity + 0.5)
+	return clr
+}
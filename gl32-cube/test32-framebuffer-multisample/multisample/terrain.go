@@ -0,0 +1,406 @@
+package multisample
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"math/rand"
+
+	"github.com/go-gl/gl/v3.2-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+const (
+	terrainSamples     = 65  // heightmap samples per side (64 x 64 cells)
+	terrainWidth       = 2.0 // terrain size on the xz-plane in world units
+	terrainHeight      = 0.5 // world height of the highest point
+	terrainTiling      = 8.0 // how often the layer textures repeat across the terrain
+	terrainTextureSize = 64  // size of the generated layer textures in texels
+	terrainSeed        = 3   // heightmap and texture noise seed, same terrain every run
+	terrainLODLevels   = 3   // generated levels of detail, each with a quarter of the triangles
+	terrainLODDistance = 3.0 // camera distance of the first level of detail, doubled for each next level
+)
+
+var (
+	ctxTerrain = &ContextTerrain{}
+	terrainSun = mgl32.Vec3{0.5, 1, 0.3} // direction towards the sun, lights and shadows the relief
+)
+
+// terrainLayer is one ground texture blended by the splat map
+type terrainLayer struct {
+	sampler string      // sampler uniform name in fragmentShaderTerrain
+	base    color.NRGBA // average color, texels vary around it
+}
+
+// layers in splat map channel order (r, g, b, a)
+var terrainLayers = []terrainLayer{
+	{"layerSand", color.NRGBA{194, 178, 128, 255}},
+	{"layerGrass", color.NRGBA{86, 125, 70, 255}},
+	{"layerRock", color.NRGBA{110, 104, 98, 255}},
+	{"layerSnow", color.NRGBA{235, 240, 245, 255}},
+}
+
+// ContextTerrain draws a heightmap terrain into the proxy screen, ground
+// textures are blended per texel by the weights stored in a splat map.
+// It is drawn instead of the quads when the -terrain flag is set.
+type ContextTerrain struct {
+	mesh                 *Mesh
+	heights              []float32      // heightmap 0..1, terrainSamples x terrainSamples
+	splat                *image.NRGBA   // layer weights, one channel per layer
+	layers               []*image.NRGBA // tiled ground textures, see terrainLayers
+	program              uint32         // connects vertex and fragment shaders (Terrain shaders)
+	vbo                  uint32         // stores vertex position, normal and texture coordinate array data
+	ibo                  uint32         // stores sets of indicies to draw that make up elements (e.g. triangles)
+	vao                  uint32         // only need to initalize it, we never use it
+	textures             []uint32       // splat map followed by the layer textures
+	attribVertexPosition uint32         // reference to position input for shader variable (Terrain shaders)
+	attribVertexNormal   uint32         // reference to normal input for shader variable (Terrain shaders)
+	attribVertexTexCoord uint32         // reference to texture coordinate input for shader variable (Terrain shaders)
+	material             *Material      // splat map and layer textures
+	camera               *Camera
+	shadows              *ShadowCascades // sun shadows, nil without -cascades
+	uniformClipPlane     int32           // reference to uClipPlane uniform, see drawFrom
+
+	// levels of detail in the IBO, full mesh first (see Mesh.SelectLOD)
+	levels []terrainLevel
+	center mgl32.Vec3 // distances are measured to this point
+	level  int        // level drawn last frame
+}
+
+// terrainLevel is the index range of a level of detail
+type terrainLevel struct {
+	mode   uint32 // gl.TRIANGLES or gl.TRIANGLE_STRIP
+	offset int    // byte offset in the IBO
+	count  int32  // strips are stitched without primitive restart, their count differs from Mesh.Indices
+}
+
+// load generates the heightmap, mesh, splat map and layer textures (CPU only)
+func (ctx *ContextTerrain) load() {
+
+	rng := rand.New(rand.NewSource(terrainSeed))
+
+	ctx.heights = makeHeightmap(rng, terrainSamples)
+	ctx.mesh = makeHeightmapMesh(ctx.heights, terrainSamples, terrainWidth, terrainHeight, *terrainStrips)
+	ctx.splat = makeSplatMap(ctx.heights, ctx.mesh.Normals, terrainSamples)
+
+	ctx.layers = nil
+	for i, layer := range terrainLayers {
+		noise := NewNoise(terrainSeed + int64(i))
+		ctx.layers = append(ctx.layers, makeNoiseTexture(noise, rng, terrainTextureSize, layer.base))
+	}
+
+	cells := (terrainSamples - 1) * (terrainSamples - 1)
+	fmt.Printf("TERRAIN -- %v vertices, %v triangles, %v indices\n", len(ctx.mesh.Positions)/vertexPositionSize, cells*2, len(ctx.mesh.Indices))
+
+	// coarser levels for a distant camera, by decimation
+	ctx.mesh.GenerateLODs(terrainLODLevels, (terrainSamples-1)/2, terrainLODDistance)
+	for i, lod := range ctx.mesh.LODs {
+		fmt.Printf("TERRAIN -- LOD %v from distance %v: %v triangles\n", i+1, lod.Distance, len(lod.Indices)/3)
+	}
+	min, max := ctx.mesh.Bounds()
+	ctx.center = min.Add(max).Mul(0.5)
+
+}
+
+func (ctx *ContextTerrain) setupProgram() {
+
+	var err error
+
+	// configure program, load shaders, and link attributes
+	ctx.program, err = newProgram(vertexShaderTerrain, fragmentShaderTerrain)
+	if err != nil {
+		panic(err)
+	}
+	gpuResources.SetLabel(ResourceProgram, ctx.program, "terrain program")
+	gl.UseProgram(ctx.program)
+
+	// get attribute index for later use
+	ctx.attribVertexPosition = uint32(gl.GetAttribLocation(ctx.program, gl.Str("vertexPosition\x00")))
+	ctx.attribVertexNormal = uint32(gl.GetAttribLocation(ctx.program, gl.Str("vertexNormal\x00")))
+	ctx.attribVertexTexCoord = uint32(gl.GetAttribLocation(ctx.program, gl.Str("vertexTexCoord\x00")))
+
+	// layer textures repeat across the terrain
+	gl.Uniform1f(gl.GetUniformLocation(ctx.program, gl.Str("tiling\x00")), terrainTiling)
+
+	// decode color textures in the shader, if the driver can not (see TextureColor)
+	gl.Uniform1i(gl.GetUniformLocation(ctx.program, gl.Str("decodeSRGB\x00")), boolToInt32(!srgbTextures))
+
+	ctx.uniformClipPlane = gl.GetUniformLocation(ctx.program, gl.Str("uClipPlane\x00"))
+
+	sun := terrainSun.Normalize()
+	gl.Uniform3fv(gl.GetUniformLocation(ctx.program, gl.Str("sunDirection\x00")), 1, &sun[0])
+
+	// terrain does not move, model matrix stays identity
+	model := mgl32.Ident4()
+	gl.UniformMatrix4fv(gl.GetUniformLocation(ctx.program, gl.Str("model\x00")), 1, false, &model[0])
+
+	// look at the terrain from above, at an angle
+	ctx.camera = NewCamera(ctx.program, 60, mgl32.Vec3{0, 1.2, 1.6}, mgl32.Vec3{0, 0, 0})
+	if err := ctx.camera.FitClipPlanes(ctx.mesh.Bounds()); err != nil {
+		panic(err)
+	}
+
+	// unbind program
+	gl.UseProgram(0)
+
+}
+
+func (ctx *ContextTerrain) setupBuffers() {
+
+	// create and bind VAO
+	ctx.vao = genVertexArray("terrain vao")
+	gl.BindVertexArray(ctx.vao)
+
+	// create VBOs
+	ctx.vbo = genBuffer("terrain vbo") // buffer for vertex position, normal, and texture coordinate
+	ctx.ibo = genBuffer("terrain ibo") // buffer for vertex indices
+
+	// copy vertex data to VBO
+	m := ctx.mesh
+	gl.BindBuffer(gl.ARRAY_BUFFER, ctx.vbo)
+	gl.BufferData(gl.ARRAY_BUFFER, m.BytesTotal, nil, gl.STATIC_DRAW)                                        // initalize but do not copy any data
+	gl.BufferSubData(gl.ARRAY_BUFFER, m.OffsetPositions, len(m.Positions)*bytesFloat32, gl.Ptr(m.Positions)) // copy positions starting from 0 offest
+	gl.BufferSubData(gl.ARRAY_BUFFER, m.OffsetNormals, len(m.Normals)*bytesFloat32, gl.Ptr(m.Normals))       // copy normals after positions
+	gl.BufferSubData(gl.ARRAY_BUFFER, m.OffsetTexCoords, len(m.TexCoords)*bytesFloat32, gl.Ptr(m.TexCoords)) // copy textures after normals
+	gpuResources.SetBytes(ResourceBuffer, ctx.vbo, m.BytesTotal)
+	gl.BindBuffer(gl.ARRAY_BUFFER, 0)
+
+	// copy index data of all levels of detail to VBO, one after the other
+	// strips are joined by degenerate triangles if the driver can not restart them
+	var indices []uint16
+	ctx.levels = nil
+	for level := 0; level <= len(m.LODs); level++ {
+		levelIndices, mode := m.LODIndices(level)
+		if mode == gl.TRIANGLE_STRIP && !usePrimitiveRestart {
+			levelIndices = stitchStrips(levelIndices)
+		}
+		ctx.levels = append(ctx.levels, terrainLevel{mode: mode, offset: len(indices) * bytesUint16, count: int32(len(levelIndices))})
+		indices = append(indices, levelIndices...)
+	}
+	gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, ctx.ibo)
+	gl.BufferData(gl.ELEMENT_ARRAY_BUFFER, len(indices)*bytesUint16, gl.Ptr(indices), gl.STATIC_DRAW)
+	gpuResources.SetBytes(ResourceBuffer, ctx.ibo, len(indices)*bytesUint16)
+	gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, 0)
+
+	// upload splat map (stretched over the whole terrain) and layer textures (repeated)
+	ctx.material = NewMaterial(ctx.program)
+	ctx.material.SetCulling(CullBack, gl.CCW) // terrain is only seen from above
+	splat := newImageTexture("terrain splat map", ctx.splat, TextureData, gl.CLAMP_TO_EDGE)
+	ctx.material.SetTexture("splatMap", gl.TEXTURE_2D, splat)
+	ctx.textures = []uint32{splat}
+	for i, layer := range terrainLayers {
+		texture := newImageTexture("terrain "+layer.sampler, ctx.layers[i], TextureColor, gl.REPEAT)
+		ctx.material.SetTexture(layer.sampler, gl.TEXTURE_2D, texture)
+		ctx.textures = append(ctx.textures, texture)
+	}
+
+	// sun shadows, the sampler2DArrayShadow needs a texture unit even without one
+	ctx.shadows = nil
+	shadowMap := uint32(0)
+	if *shadowCascades > 0 {
+		ctx.shadows = newShadowCascades(*shadowCascades, terrainSun, ctx.program)
+		shadowMap = ctx.shadows.Texture()
+	}
+	ctx.material.SetTexture("shadowMap", gl.TEXTURE_2D_ARRAY, shadowMap)
+
+}
+
+func (ctx *ContextTerrain) draw() {
+
+	// terrain has its own program, the proxy screen's program is bound by ContextFramebufferMultisample.bind
+	gl.UseProgram(ctx.program)
+
+	// upload camera matrices, if the camera moved
+	ctx.camera.Update()
+	if ctx.shadows != nil {
+		ctx.shadows.upload()
+	}
+	ctx.drawMesh()
+
+}
+
+// drawFrom draws the terrain with the given matrices instead of the
+// camera's into the bound framebuffer, e.g. the mirrored view of
+// ContextWater. clipPlane (a, b, c, d in world coordinates) clips where
+// ax + by + cz + d < 0, while gl.CLIP_DISTANCE0 is enabled. A mirrored
+// view flips the winding of the triangles, so the back faces are culled.
+func (ctx *ContextTerrain) drawFrom(view, projection mgl32.Mat4, clipPlane mgl32.Vec4, mirrored bool) {
+
+	gl.UseProgram(ctx.program)
+	gl.UniformMatrix4fv(ctx.camera.projectionUniform, 1, false, &projection[0])
+	gl.UniformMatrix4fv(ctx.camera.cameraUniform, 1, false, &view[0])
+	gl.Uniform4fv(ctx.uniformClipPlane, 1, &clipPlane[0])
+	if mirrored {
+		ctx.material.SetCulling(CullBack, gl.CW)
+	}
+
+	ctx.drawMesh()
+
+	ctx.material.SetCulling(CullBack, gl.CCW)
+	ctx.camera.Invalidate() // the camera uploads its matrices again
+
+}
+
+// drawMesh draws the level of detail for the camera distance, the program must be in use
+func (ctx *ContextTerrain) drawMesh() {
+
+	// gl.Begin()
+	gl.BindBuffer(gl.ARRAY_BUFFER, ctx.vbo)         // bind vertex buffer
+	gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, ctx.ibo) // bind indices buffer
+	ctx.material.Bind()                             // bind splat map and layer textures
+
+	// configure and enable vertex attributes
+	ctx.mesh.Layout.Enable(ctx.attribVertexPosition, ctx.attribVertexNormal, ctx.attribVertexTexCoord)
+
+	// pick the level of detail for the camera distance
+	level := ctx.mesh.SelectLOD(lodDistance(ctx.camera.Position(), ctx.center))
+	if level != ctx.level {
+		fmt.Printf("TERRAIN -- LOD %v\n", level)
+		ctx.level = level
+	}
+	ctx.drawLevel(level)
+
+	// gl.End()
+	gl.BindBuffer(gl.ARRAY_BUFFER, 0)                                                                   // unbind vertex buffer
+	gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, 0)                                                           // unbind indices buffer
+	ctx.material.Unbind()                                                                               // unbind textures
+	ctx.mesh.Layout.Disable(ctx.attribVertexPosition, ctx.attribVertexNormal, ctx.attribVertexTexCoord) // disable vertex attributes
+
+}
+
+// drawShadows renders the sun's shadow cascades for the camera, before the
+// proxy screen is bound since the shadow pass has its own framebuffer
+func (ctx *ContextTerrain) drawShadows() {
+
+	if ctx.shadows == nil {
+		return
+	}
+	min, max := ctx.mesh.Bounds()
+	ctx.shadows.fit(ctx.camera, min, max)
+
+	// casters are the level of detail drawn, so they match the receiving surface
+	gl.BindBuffer(gl.ARRAY_BUFFER, ctx.vbo)
+	gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, ctx.ibo)
+	ctx.shadows.render(func(position uint32) {
+		ctx.mesh.Layout.EnableAttrib("position", position)
+		ctx.drawLevel(ctx.level)
+		gl.DisableVertexAttribArray(position)
+	})
+	gl.BindBuffer(gl.ARRAY_BUFFER, 0)
+	gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, 0)
+
+}
+
+// drawLevel draws a level of detail from the bound IBO, primitiveRestartIndex starts a new strip
+func (ctx *ContextTerrain) drawLevel(level int) {
+	draw := ctx.levels[level]
+	restart := draw.mode == gl.TRIANGLE_STRIP && usePrimitiveRestart
+	if restart {
+		gl.Enable(gl.PRIMITIVE_RESTART)
+		gl.PrimitiveRestartIndex(primitiveRestartIndex)
+	}
+	validateDraw("terrain")
+	gl.DrawElements(draw.mode, draw.count, gl.UNSIGNED_SHORT, gl.PtrOffset(draw.offset))
+	if restart {
+		gl.Disable(gl.PRIMITIVE_RESTART)
+	}
+}
+
+func (ctx *ContextTerrain) destroy() {
+	if ctx.shadows != nil {
+		ctx.shadows.destroy()
+		ctx.shadows = nil
+	}
+	for _, texture := range ctx.textures {
+		gpuResources.Release(ResourceTexture, texture)
+	}
+	gpuResources.Release(ResourceVertexArray, ctx.vao)
+	gpuResources.Release(ResourceBuffer, ctx.vbo)
+	gpuResources.Release(ResourceBuffer, ctx.ibo)
+	gpuResources.Release(ResourceProgram, ctx.program)
+	ctx.textures = nil
+	ctx.vao, ctx.vbo, ctx.ibo, ctx.program = 0, 0, 0, 0
+}
+
+// makeHeightmap generates size x size heights between 0 and 1 from a few
+// octaves of value noise (smoothly interpolated random lattice values)
+func makeHeightmap(rng *rand.Rand, size int) []float32 {
+
+	const octaves = 4
+
+	heights := make([]float32, size*size)
+	amplitude, total := float32(1), float32(0)
+	for octave := 0; octave < octaves; octave++ {
+		cells := 2 << uint(octave) // lattice cells per side, doubles every octave
+		lattice := make([]float32, (cells+1)*(cells+1))
+		for i := range lattice {
+			lattice[i] = rng.Float32()
+		}
+		for z := 0; z < size; z++ {
+			for x := 0; x < size; x++ {
+				fx := float32(x) / float32(size-1) * float32(cells)
+				fz := float32(z) / float32(size-1) * float32(cells)
+				x0, z0 := clampInt(int(fx), 0, cells-1), clampInt(int(fz), 0, cells-1)
+				tx, tz := smoothstep(fx-float32(x0)), smoothstep(fz-float32(z0))
+				top := lerp(lattice[z0*(cells+1)+x0], lattice[z0*(cells+1)+x0+1], tx)
+				bottom := lerp(lattice[(z0+1)*(cells+1)+x0], lattice[(z0+1)*(cells+1)+x0+1], tx)
+				heights[z*size+x] += lerp(top, bottom, tz) * amplitude
+			}
+		}
+		total += amplitude
+		amplitude /= 2
+	}
+
+	// normalize to 0..1
+	for i := range heights {
+		heights[i] /= total
+	}
+	return heights
+
+}
+
+// makeSplatMap decides the layer weights per heightmap sample:
+// sand in low areas, grass above, rock on steep slopes and snow on peaks
+func makeSplatMap(heights []float32, normals []float32, size int) *image.NRGBA {
+
+	img := image.NewNRGBA(image.Rect(0, 0, size, size))
+	for i, h := range heights {
+		slope := 1 - normals[i*vertexNormalSize+1] // 0 = flat, 1 = vertical
+
+		sand := 1 - smoothstep(mgl32.Clamp((h-0.3)/0.1, 0, 1))
+		snow := smoothstep(mgl32.Clamp((h-0.65)/0.1, 0, 1))
+		rock := smoothstep(mgl32.Clamp((slope-0.15)/0.2, 0, 1))
+		grass := float32(math.Max(0, float64(1-sand-snow-rock)))
+
+		sum := sand + grass + rock + snow
+		img.Pix[i*4+0] = uint8(255 * sand / sum)
+		img.Pix[i*4+1] = uint8(255 * grass / sum)
+		img.Pix[i*4+2] = uint8(255 * rock / sum)
+		img.Pix[i*4+3] = uint8(255 * snow / sum)
+	}
+	return img
+
+}
+
+// makeNoiseTexture fills a texture with variations of a base color: patches
+// of tileable Perlin noise and a per texel grain, so the texture tiles without seams
+func makeNoiseTexture(noise *Noise, rng *rand.Rand, size int, base color.NRGBA) *image.NRGBA {
+	img := makeNoiseImage(noise, size, 4, 3)
+	for i := 0; i < len(img.Pix); i += 4 {
+		shade := 0.75 + 0.3*float32(img.Pix[i])/255 + 0.2*rng.Float32()
+		img.Pix[i+0] = uint8(mgl32.Clamp(float32(base.R)*shade, 0, 255))
+		img.Pix[i+1] = uint8(mgl32.Clamp(float32(base.G)*shade, 0, 255))
+		img.Pix[i+2] = uint8(mgl32.Clamp(float32(base.B)*shade, 0, 255))
+		img.Pix[i+3] = 255
+	}
+	return img
+}
+
+func lerp(a, b, t float32) float32 {
+	return a + (b-a)*t
+}
+
+func smoothstep(t float32) float32 {
+	return t * t * (3 - 2*t)
+}
@@ -0,0 +1,476 @@
+package multisample
+
+import (
+	"fmt"
+	"image/color"
+	"log"
+	"strings"
+
+	"github.com/go-gl/gl/v3.2-core/gl"
+	gl33 "github.com/go-gl/gl/v3.3-core/gl" // glVertexAttribDivisor only
+)
+
+const (
+	textScale = 2 // font texels are drawn as textScale x textScale pixels
+)
+
+var (
+	ctxText = &ContextText{}
+)
+
+// textCorners is the unit quad every glyph instance is made of, as triangle strip
+var textCorners = []float32{
+	0, 0, // top-left
+	1, 0, // top-right
+	0, 1, // bottom-left
+	1, 1, // bottom-right
+}
+
+// textQuadCorners are the corners of a batched glyph quad, in the vertex order of the shared quad indices
+var textQuadCorners = []float32{
+	1, 0, // v0 = top-right
+	0, 0, // v1 = top-left
+	0, 1, // v2 = bottom-left
+	1, 1, // v3 = bottom-right
+}
+
+// ContextText is an overlay drawing the text queued by DrawText during a
+// frame, ontop the real screen. Every glyph is a screen rectangle, an atlas
+// rectangle and a color (36 bytes). With instancing (OpenGL 3.3 or
+// ARB_instanced_arrays) that is the per instance data of one unit quad, so
+// a full screen of text costs one small upload and one draw call. Without it
+// every glyph is a batched quad whose 4 vertices repeat the glyph data,
+// drawn with the shared quad indices. Both use the same shaders.
+//
+// Runes missing from the built-in font come from the fallback fonts (-fonts),
+// all fonts share one atlas so text stays a single draw call.
+type ContextText struct {
+	fonts             []*Font // built-in font first, then the fallbacks in the order tried
+	program           uint32  // connects vertex and fragment shaders (Text shaders)
+	vbo               uint32  // stores glyph data, the current one of glyphBuffers
+	cornerVBO         uint32  // stores the unit quad (instancing only)
+	vao               uint32  // only need to initalize it, we never use it
+	texture           uint32  // font atlas
+	attribCorner      uint32  // reference to corner input for shader variable (Text shaders)
+	attribRect        uint32  // reference to rectangle input for shader variable (Text shaders)
+	attribUV          uint32  // reference to atlas rectangle input for shader variable (Text shaders)
+	attribColor       uint32  // reference to color input for shader variable (Text shaders)
+	uniformScreenSize int32   // reference to uScreenSize uniform
+	material          *Material
+
+	// the fonts' atlases stacked vertically
+	atlas       []uint8
+	atlasWidth  int
+	atlasHeight int
+	fontY       []int // atlas row of each font
+	fontScales  []int // pixels per texel of each font, fallbacks get about the line height of the built-in font
+	spriteY     int   // atlas row of the UI sprites below the fonts, see DrawBox
+
+	clips []TextRect // glyphs are cut to the last one, see PushClip
+
+	instanced    bool                        // glyphs are instances, see setupBuffers
+	divisor      func(index, divisor uint32) // glVertexAttribDivisor, core or ARB (instancing only)
+	layout       *Layout                     // glyph data, per instance or (batched) per vertex
+	cornerLayout *Layout                     // unit quad (instancing only)
+	glyphBuffers *DynamicBuffer              // glyph data is rewritten every frame
+
+	// glyphs queued for this frame
+	rects  []float32 // x, y, width, height in pixels, top-left origin
+	uvs    []float32 // u, v, width, height in the atlas (0..1)
+	colors []uint8   // r, g, b, a
+}
+
+// DrawText queues a string at x, y (top-left of the first line, in pixels from the
+// top-left of the window), '\n' starts a new line. It is drawn at the end of the frame.
+// Markup changes color and style, e.g. "{red}warning{/}" (see textStyle).
+// See DrawTextLayout for wrapping and alignment.
+func (ctx *ContextText) DrawText(x, y float32, text string, clr color.NRGBA) {
+	ctx.DrawTextLayout(x, y, text, TextLayout{}, clr)
+}
+
+// textGlyph is a glyph of one of the fonts, placed in pixels
+type textGlyph struct {
+	fontGlyph                        // x, y are in the combined atlas
+	left, top, width, height float32 // rectangle relative to the pen at the top of the line
+	advance                  float32
+}
+
+// lookup finds the glyph of a rune in the first font having it. Runes no font
+// has are drawn as a box (two cells wide for wide runes), but combining marks
+// are dropped (ok is false).
+func (ctx *ContextText) lookup(r rune) (glyph textGlyph, ok bool) {
+	for i, f := range ctx.fonts {
+		if glyph, ok := f.Glyph(r); ok {
+			return ctx.place(i, glyph, r), true
+		}
+	}
+	if isCombiningMark(r) {
+		return textGlyph{}, false
+	}
+	if isWideRune(r) {
+		return ctx.place(0, builtinFont.wideReplacement, r), true
+	}
+	return ctx.place(0, builtinFont.replacement, r), true
+}
+
+// place scales a glyph of a font to pixels, fonts share the baseline of the built-in font
+func (ctx *ContextText) place(font int, glyph fontGlyph, r rune) textGlyph {
+	f, scale := ctx.fonts[font], float32(ctx.fontScales[font])
+	baseline := float32(ctx.fonts[0].ascent*textScale) - float32(f.ascent)*scale
+	placed := textGlyph{
+		left:    float32(glyph.offsetX) * scale,
+		top:     baseline + float32(glyph.offsetY)*scale,
+		width:   float32(glyph.width) * scale,
+		height:  float32(glyph.height) * scale,
+		advance: float32(glyph.advance) * scale,
+	}
+	glyph.y += ctx.fontY[font]
+	placed.fontGlyph = glyph
+
+	// wide runes in fonts made for narrow ones must not overlap the next glyph
+	if isWideRune(r) && placed.advance < placed.left+placed.width+scale {
+		placed.advance = placed.left + placed.width + scale
+	}
+	return placed
+}
+
+// eachGlyph calls fn with the glyphs of a single line and their pen position
+// relative to the line start, styles is changed by its tags before fn is
+// called. Combining marks do not advance the pen: marks with an advance are
+// centered over the previous glyph, marks without one are placed by the font.
+func (ctx *ContextText) eachGlyph(text string, styles *textStyleStack, fn func(penX float32, glyph textGlyph)) {
+	penX := float32(0)
+	var base textGlyph
+	baseX, hasBase := float32(0), false
+	for _, token := range parseMarkup(text) {
+		if token.isTag {
+			styles.handle(token.tag)
+			continue
+		}
+		glyph, ok := ctx.lookup(token.r)
+		if !ok {
+			continue
+		}
+		if isCombiningMark(token.r) && hasBase {
+			x := penX
+			if glyph.advance > 0 {
+				x = baseX + (base.advance-glyph.advance)/2
+			}
+			glyph.advance = 0
+			fn(x, glyph)
+			continue
+		}
+		fn(penX, glyph)
+		base, baseX, hasBase = glyph, penX, true
+		penX += glyph.advance
+	}
+}
+
+// drawLine queues the glyphs of a single line, styles is changed by its tags
+func (ctx *ContextText) drawLine(x, y float32, text string, styles *textStyleStack) {
+	line, _ := ctx.lookup('_')
+	ctx.eachGlyph(text, styles, func(penX float32, glyph textGlyph) {
+		style := styles.top()
+		ctx.addGlyph(x+penX+glyph.left, y+glyph.top, glyph.width, glyph.height, glyph.x, glyph.y, glyph.fontGlyph.width, glyph.fontGlyph.height, style.color)
+
+		// bold draws the glyph again, one pixel to the right
+		if style.bold {
+			ctx.addGlyph(x+penX+glyph.left+1, y+glyph.top, glyph.width, glyph.height, glyph.x, glyph.y, glyph.fontGlyph.width, glyph.fontGlyph.height, style.color)
+		}
+
+		// underline is the bottom row of '_' stretched over the advance, below the baseline
+		if style.underline && glyph.advance > 0 {
+			ctx.addGlyph(x+penX, y+float32((ctx.fonts[0].ascent+1)*textScale), glyph.advance, textScale, line.x, line.y+line.fontGlyph.height-1, 1, 1, style.color)
+		}
+	})
+}
+
+// addGlyph queues a rectangle (pixels) showing an atlas rectangle (texels),
+// cut to the clip rectangle if there is one
+func (ctx *ContextText) addGlyph(x, y, width, height float32, atlasX, atlasY, atlasWidth, atlasHeight int, clr color.NRGBA) {
+	u, v, uWidth, vHeight := float32(atlasX), float32(atlasY), float32(atlasWidth), float32(atlasHeight)
+
+	// the atlas rectangle shrinks in proportion, exact for the stretched 1 texel pieces of boxes
+	if n := len(ctx.clips); n > 0 && width > 0 && height > 0 {
+		clip := ctx.clips[n-1]
+		left, top := maxFloat32(x, clip.X), maxFloat32(y, clip.Y)
+		right, bottom := minFloat32(x+width, clip.X+clip.Width), minFloat32(y+height, clip.Y+clip.Height)
+		if right <= left || bottom <= top {
+			return
+		}
+		u, v = u+(left-x)/width*uWidth, v+(top-y)/height*vHeight
+		uWidth, vHeight = uWidth*(right-left)/width, vHeight*(bottom-top)/height
+		x, y, width, height = left, top, right-left, bottom-top
+	}
+
+	ctx.rects = append(ctx.rects, x, y, width, height)
+	ctx.uvs = append(ctx.uvs,
+		u/float32(ctx.atlasWidth), v/float32(ctx.atlasHeight),
+		uWidth/float32(ctx.atlasWidth), vHeight/float32(ctx.atlasHeight),
+	)
+	ctx.colors = append(ctx.colors, clr.R, clr.G, clr.B, clr.A)
+}
+
+func (ctx *ContextText) load() {
+
+	// built-in font, then the fallbacks given by -fonts
+	ctx.fonts = []*Font{builtinFont}
+	if *fallbackFonts != "" {
+		for _, path := range strings.Split(*fallbackFonts, ",") {
+			f, err := loadBDFFont(strings.TrimSpace(path))
+			if err != nil {
+				log.Println("failed to load font:", err)
+				continue
+			}
+			ctx.fonts = append(ctx.fonts, f)
+		}
+	}
+
+	// fallbacks are scaled by whole factors to about the line height of the built-in font
+	lineHeight := builtinFont.lineHeight * textScale
+	ctx.fontScales = []int{textScale}
+	for _, f := range ctx.fonts[1:] {
+		scale := (lineHeight + f.lineHeight/2) / f.lineHeight
+		if scale < 1 {
+			scale = 1
+		}
+		ctx.fontScales = append(ctx.fontScales, scale)
+	}
+
+	// stack the atlases
+	ctx.atlasWidth, ctx.atlasHeight, ctx.fontY = 0, 0, nil
+	for _, f := range ctx.fonts {
+		if f.atlasWidth > ctx.atlasWidth {
+			ctx.atlasWidth = f.atlasWidth
+		}
+		ctx.fontY = append(ctx.fontY, ctx.atlasHeight)
+		ctx.atlasHeight += f.atlasHeight
+	}
+	if ctx.atlasWidth < boxSprites*boxSpriteSize {
+		ctx.atlasWidth = boxSprites * boxSpriteSize
+	}
+	ctx.spriteY = ctx.atlasHeight
+	ctx.atlasHeight += boxSpriteSize
+	ctx.atlas = make([]uint8, ctx.atlasWidth*ctx.atlasHeight)
+	for i, f := range ctx.fonts {
+		for y := 0; y < f.atlasHeight; y++ {
+			copy(ctx.atlas[(ctx.fontY[i]+y)*ctx.atlasWidth:], f.atlas[y*f.atlasWidth:(y+1)*f.atlasWidth])
+		}
+		fmt.Printf("TEXT -- font %q: %d glyphs, scale %d\n", f.name, len(f.glyphs), ctx.fontScales[i])
+	}
+	ctx.drawBoxSprites()
+
+}
+
+func (ctx *ContextText) setupProgram() {
+
+	var err error
+
+	// configure program, load shaders, and link attributes
+	ctx.program, err = newProgram(vertexShaderText, fragmentShaderText)
+	if err != nil {
+		panic(err)
+	}
+	gpuResources.SetLabel(ResourceProgram, ctx.program, "text program")
+	gl.UseProgram(ctx.program)
+
+	// get attribute index for later use
+	ctx.attribCorner = uint32(gl.GetAttribLocation(ctx.program, gl.Str("corner\x00")))
+	ctx.attribRect = uint32(gl.GetAttribLocation(ctx.program, gl.Str("glyphRect\x00")))
+	ctx.attribUV = uint32(gl.GetAttribLocation(ctx.program, gl.Str("glyphUV\x00")))
+	ctx.attribColor = uint32(gl.GetAttribLocation(ctx.program, gl.Str("glyphColor\x00")))
+	ctx.uniformScreenSize = gl.GetUniformLocation(ctx.program, gl.Str("uScreenSize\x00"))
+
+	// unbind program
+	gl.UseProgram(0)
+
+}
+
+func (ctx *ContextText) setupBuffers() {
+
+	// instance attributes need glVertexAttribDivisor, core in OpenGL 3.3 (the 3.2
+	// binding doesn't load it) or glVertexAttribDivisorARB of GL_ARB_instanced_arrays
+	ctx.divisor = nil
+	switch {
+	case *noInstancing:
+	case glInfo.AtLeast(3, 3):
+		if err := gl33.Init(); err != nil {
+			log.Println("TEXT -- failed to load OpenGL 3.3 functions:", err)
+			break
+		}
+		ctx.divisor = gl33.VertexAttribDivisor
+	case glInfo.Has("GL_ARB_instanced_arrays"):
+		ctx.divisor = gl.VertexAttribDivisorARB
+	}
+	ctx.instanced = ctx.divisor != nil
+	fmt.Printf("TEXT -- instanced glyphs: %v\n", ctx.instanced)
+
+	// glyph rectangles and atlas rectangles are in float32, color in uint8
+	ctx.layout = NewLayout().Float32("rect", 4).Float32("uv", 4).UInt8("color", 4, true).Interleave()
+	if !ctx.instanced {
+		ctx.layout = NewLayout().Float32("corner", 2).Float32("rect", 4).Float32("uv", 4).UInt8("color", 4, true).Interleave()
+	}
+
+	// create and bind VAO
+	ctx.vao = genVertexArray("text vao")
+	gl.BindVertexArray(ctx.vao)
+
+	// unit quad shared by all instances
+	ctx.cornerVBO = 0
+	if ctx.instanced {
+		ctx.cornerLayout = NewLayout().Float32("corner", 2).Compute(len(textCorners) / 2)
+		ctx.cornerVBO = genBuffer("text corners")
+		gl.BindBuffer(gl.ARRAY_BUFFER, ctx.cornerVBO)
+		gl.BufferData(gl.ARRAY_BUFFER, len(textCorners)*bytesFloat32, gl.Ptr(textCorners), gl.STATIC_DRAW)
+		gpuResources.SetBytes(ResourceBuffer, ctx.cornerVBO, len(textCorners)*bytesFloat32)
+		gl.BindBuffer(gl.ARRAY_BUFFER, 0)
+	}
+
+	// glyph data buffers are created by the first draw, sized to the text
+	ctx.glyphBuffers, ctx.vbo = nil, 0
+
+	// upload font atlas, single channel and nearest sampled like palette indices, so texels stay sharp when scaled
+	ctx.texture = newIndexTexture("font atlas", ctx.atlas, ctx.atlasWidth, ctx.atlasHeight)
+	ctx.material = NewMaterial(ctx.program)
+	ctx.material.SetTexture("fontAtlas", gl.TEXTURE_2D, ctx.texture)
+
+}
+
+// draw the text ontop of whatever is on the real screen
+func (ctx *ContextText) bind() {
+
+	// bind Text program
+	gl.UseProgram(ctx.program)
+
+	// text covers the whole window (the viewport of the screen pass), positions are in window pixels
+	screenWidth, screenHeight := screenSize()
+	gl.Uniform2f(ctx.uniformScreenSize, float32(screenWidth), float32(screenHeight))
+
+	// text is an overlay, ignore depth and blend with the screen underneath,
+	// alpha adds up as coverage so a transparent window gets premultiplied alpha
+	gl.Disable(gl.DEPTH_TEST)
+	gl.Enable(gl.BLEND)
+	gl.BlendFuncSeparate(gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA, gl.ONE, gl.ONE_MINUS_SRC_ALPHA)
+
+}
+
+func (ctx *ContextText) draw() {
+
+	glyphs := len(ctx.colors) / 4
+	if glyphs == 0 {
+		gl.Disable(gl.BLEND)
+		return
+	}
+
+	// gl.Begin()
+	ctx.material.Bind() // bind font atlas
+
+	if ctx.instanced {
+
+		// one unit quad per glyph, glyph data advances once per instance
+		ctx.upload(ctx.layout.Compute(glyphs).Pack(glyphs, map[string][]byte{
+			"rect":  float32Bytes(ctx.rects),
+			"uv":    float32Bytes(ctx.uvs),
+			"color": ctx.colors,
+		}))
+		gl.BindBuffer(gl.ARRAY_BUFFER, ctx.cornerVBO)
+		ctx.cornerLayout.Enable(ctx.attribCorner)
+		gl.BindBuffer(gl.ARRAY_BUFFER, ctx.vbo)
+		ctx.layout.Enable(ctx.attribRect, ctx.attribUV, ctx.attribColor)
+		for _, attrib := range []uint32{ctx.attribRect, ctx.attribUV, ctx.attribColor} {
+			ctx.divisor(attrib, 1)
+		}
+
+		// draw glyphs
+		gl.DrawArraysInstanced(gl.TRIANGLE_STRIP, 0, int32(len(textCorners)/2), int32(glyphs))
+
+		// disable vertex attributes, divisors are VAO state and the default is per vertex
+		for _, attrib := range []uint32{ctx.attribRect, ctx.attribUV, ctx.attribColor} {
+			ctx.divisor(attrib, 0)
+		}
+		ctx.layout.Disable(ctx.attribRect, ctx.attribUV, ctx.attribColor)
+		ctx.cornerLayout.Disable(ctx.attribCorner)
+
+	} else {
+
+		// one quad of 4 vertices per glyph, each vertex repeats the glyph data
+		vertices := glyphs * verticesPerQuad
+		ctx.upload(ctx.layout.Compute(vertices).Pack(vertices, map[string][]byte{
+			"corner": float32Bytes(repeatFloats(textQuadCorners, glyphs, len(textQuadCorners))),
+			"rect":   float32Bytes(repeatFloats(ctx.rects, verticesPerQuad, 4)),
+			"uv":     float32Bytes(repeatFloats(ctx.uvs, verticesPerQuad, 4)),
+			"color":  repeatBytes(ctx.colors, verticesPerQuad, 4),
+		}))
+		gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, quadIndices.Buffer())
+		ctx.layout.Enable(ctx.attribCorner, ctx.attribRect, ctx.attribUV, ctx.attribColor)
+
+		// draw glyphs
+		quadIndices.Draw(0, glyphs, func(vertex int) {
+			ctx.layout.EnableFrom(0, vertex, ctx.attribCorner, ctx.attribRect, ctx.attribUV, ctx.attribColor)
+		})
+
+		// disable vertex attributes
+		ctx.layout.Disable(ctx.attribCorner, ctx.attribRect, ctx.attribUV, ctx.attribColor)
+
+	}
+
+	// gl.End()
+	gl.BindBuffer(gl.ARRAY_BUFFER, 0)         // unbind vertex buffer
+	gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, 0) // unbind indices buffer
+	ctx.material.Unbind()                     // unbind texture
+
+	// restore blending state
+	gl.Disable(gl.BLEND)
+
+	// text is queued again next frame
+	ctx.rects, ctx.uvs, ctx.colors = ctx.rects[:0], ctx.uvs[:0], ctx.colors[:0]
+
+}
+
+// upload copies this frame's glyph data into the next glyph buffer and binds it,
+// the buffers are replaced by larger ones when the text outgrows them
+func (ctx *ContextText) upload(data []byte) {
+	if ctx.glyphBuffers == nil || ctx.glyphBuffers.Bytes() < len(data) {
+		if ctx.glyphBuffers != nil {
+			ctx.glyphBuffers.destroy()
+		}
+		ctx.glyphBuffers = NewDynamicBuffer("text glyphs", nextPowerOfTwo(len(data)))
+	}
+	ctx.vbo = ctx.glyphBuffers.Next()
+	gl.BufferSubData(gl.ARRAY_BUFFER, 0, len(data), gl.Ptr(data))
+}
+
+// release GL objects owned by the text overlay
+func (ctx *ContextText) destroy() {
+	gpuResources.Release(ResourceVertexArray, ctx.vao)
+	gpuResources.Release(ResourceBuffer, ctx.cornerVBO)
+	gpuResources.Release(ResourceTexture, ctx.texture)
+	gpuResources.Release(ResourceProgram, ctx.program)
+	if ctx.glyphBuffers != nil {
+		ctx.glyphBuffers.destroy()
+		ctx.glyphBuffers = nil
+	}
+	ctx.vao, ctx.vbo, ctx.cornerVBO, ctx.texture, ctx.program = 0, 0, 0, 0, 0
+}
+
+// repeatFloats repeats every group of size values n times
+func repeatFloats(values []float32, n int, size int) []float32 {
+	repeated := make([]float32, 0, len(values)*n)
+	for i := 0; i+size <= len(values); i += size {
+		for j := 0; j < n; j++ {
+			repeated = append(repeated, values[i:i+size]...)
+		}
+	}
+	return repeated
+}
+
+// repeatBytes repeats every group of size values n times
+func repeatBytes(values []uint8, n int, size int) []uint8 {
+	repeated := make([]uint8, 0, len(values)*n)
+	for i := 0; i+size <= len(values); i += size {
+		for j := 0; j < n; j++ {
+			repeated = append(repeated, values[i:i+size]...)
+		}
+	}
+	return repeated
+}
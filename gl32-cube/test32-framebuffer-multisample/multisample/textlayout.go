@@ -0,0 +1,160 @@
+package multisample
+
+import (
+	"image/color"
+	"strings"
+)
+
+// TextAlign is the horizontal alignment of the lines of a text
+type TextAlign int
+
+const (
+	AlignLeft TextAlign = iota
+	AlignCenter
+	AlignRight
+)
+
+// TextLayout tells DrawTextLayout how to break and place lines
+type TextLayout struct {
+	Width       float32   // wrap lines at this width in pixels, 0 = break only at '\n'
+	Align       TextAlign // within Width, or within the widest line if Width is 0
+	LineSpacing float32   // distance of lines as multiple of the font line height, 0 = 1
+}
+
+// TextRect is the area covered by laid out text, in pixels from the top-left of the window
+type TextRect struct {
+	X, Y          float32
+	Width, Height float32
+}
+
+// textLine is one line of laid out text
+type textLine struct {
+	text  string
+	width float32 // in pixels
+}
+
+// MeasureText returns the bounds a text would cover if drawn at 0, 0
+func (ctx *ContextText) MeasureText(text string, layout TextLayout) TextRect {
+	_, bounds := ctx.layoutLines(0, 0, text, layout)
+	return bounds
+}
+
+// DrawTextLayout queues a text broken into lines and aligned as the layout
+// says, x, y is the top-left of the layout box. The bounds returned are the
+// area the glyphs cover, e.g. to place a background or the next element.
+func (ctx *ContextText) DrawTextLayout(x, y float32, text string, layout TextLayout, clr color.NRGBA) TextRect {
+	lines, bounds := ctx.layoutLines(x, y, text, layout)
+	styles := textStyleStack{{color: clr}} // tags may span lines
+	for _, line := range lines {
+		ctx.drawLine(line.x, line.y, line.text, &styles)
+	}
+	return bounds
+}
+
+// placedLine is a line with its position
+type placedLine struct {
+	textLine
+	x, y float32
+}
+
+// layoutLines breaks the text into lines and places them
+func (ctx *ContextText) layoutLines(x, y float32, text string, layout TextLayout) ([]placedLine, TextRect) {
+
+	lines := ctx.wrap(text, layout.Width)
+
+	// align within the layout width, or the widest line
+	box := layout.Width
+	if box == 0 {
+		for _, line := range lines {
+			if line.width > box {
+				box = line.width
+			}
+		}
+	}
+
+	spacing := layout.LineSpacing
+	if spacing == 0 {
+		spacing = 1
+	}
+	lineHeight := float32(ctx.fonts[0].lineHeight*textScale) * spacing
+
+	placed := make([]placedLine, 0, len(lines))
+	bounds := TextRect{X: x, Y: y}
+	right := x
+	for i, line := range lines {
+		lineX := x
+		switch layout.Align {
+		case AlignCenter:
+			lineX += (box - line.width) / 2
+		case AlignRight:
+			lineX += box - line.width
+		}
+		placed = append(placed, placedLine{textLine: line, x: lineX, y: y + float32(i)*lineHeight})
+		if i == 0 || lineX < bounds.X {
+			bounds.X = lineX
+		}
+		if lineX+line.width > right {
+			right = lineX + line.width
+		}
+	}
+	bounds.Width = right - bounds.X
+	if len(lines) > 0 {
+		bounds.Height = float32(len(lines)-1)*lineHeight + fontGlyphHeight*textScale
+	}
+	return placed, bounds
+
+}
+
+// wrap breaks the text at '\n' and, for width > 0, greedily between words so
+// lines fit the width. Words wider than the width are broken between glyphs.
+// Markup stays in the lines, it has no width.
+func (ctx *ContextText) wrap(text string, width float32) []textLine {
+
+	var lines []textLine
+	for _, paragraph := range strings.Split(text, "\n") {
+		if width <= 0 {
+			lines = append(lines, textLine{paragraph, ctx.lineWidth(paragraph)})
+			continue
+		}
+
+		line := ""
+		for _, word := range strings.Fields(paragraph) {
+			candidate := word
+			if line != "" {
+				candidate = line + " " + word
+			}
+			if ctx.lineWidth(candidate) <= width {
+				line = candidate
+				continue
+			}
+			if line != "" {
+				lines = append(lines, textLine{line, ctx.lineWidth(line)})
+			}
+
+			// word alone is too wide, break it where it overflows
+			line = ""
+			for _, token := range parseMarkup(word) {
+				if !token.isTag && line != "" && ctx.lineWidth(line+token.raw) > width {
+					lines = append(lines, textLine{line, ctx.lineWidth(line)})
+					line = ""
+				}
+				line += token.raw
+			}
+		}
+		lines = append(lines, textLine{line, ctx.lineWidth(line)})
+	}
+	return lines
+
+}
+
+// lineWidth is the width of a single line in pixels, up to the right edge of its last glyph
+func (ctx *ContextText) lineWidth(text string) float32 {
+	width := float32(0)
+	styles := textStyleStack{{}}
+	ctx.eachGlyph(text, &styles, func(penX float32, glyph textGlyph) {
+		if right := penX + glyph.left + glyph.width; right > width {
+			width = right
+		}
+	})
+	return width
+}
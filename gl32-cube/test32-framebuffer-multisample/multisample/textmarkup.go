@@ -0,0 +1,129 @@
+package multisample
+
+import (
+	"image/color"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// textColors are the color names known to markup, e.g. "{red}warning{/}"
+var textColors = map[string]color.NRGBA{
+	"white":   {255, 255, 255, 255},
+	"gray":    {160, 160, 160, 255},
+	"black":   {0, 0, 0, 255},
+	"red":     {255, 80, 80, 255},
+	"green":   {80, 220, 80, 255},
+	"blue":    {90, 140, 255, 255},
+	"yellow":  {255, 230, 80, 255},
+	"orange":  {255, 160, 40, 255},
+	"cyan":    {80, 230, 230, 255},
+	"magenta": {230, 90, 230, 255},
+}
+
+// textStyle is how glyphs look, markup tags change it:
+//
+//	{red}     color by name, see textColors
+//	{#ff8800} color as hex rgb
+//	{b}       bold
+//	{u}       underline
+//	{/}       back to the style before the last tag
+//	{{        a literal '{'
+//
+// Tags nest, "{red}a {b}bold{/} word{/}". Anything else in braces is drawn as is.
+type textStyle struct {
+	color     color.NRGBA
+	bold      bool
+	underline bool
+}
+
+// markupToken is a rune or a tag of text with markup
+type markupToken struct {
+	raw   string // as written, e.g. "{red}", "{{" or "a"
+	r     rune   // the rune to draw, for anything but tags
+	isTag bool
+	tag   string // tag name without braces
+}
+
+// parseMarkup splits text into runes and tags
+func parseMarkup(text string) []markupToken {
+	var tokens []markupToken
+	for i := 0; i < len(text); {
+		if strings.HasPrefix(text[i:], "{{") {
+			tokens = append(tokens, markupToken{raw: "{{", r: '{'})
+			i += 2
+			continue
+		}
+		if text[i] == '{' {
+			if end := strings.IndexByte(text[i:], '}'); end > 0 && validTag(text[i+1:i+end]) {
+				tokens = append(tokens, markupToken{raw: text[i : i+end+1], isTag: true, tag: text[i+1 : i+end]})
+				i += end + 1
+				continue
+			}
+		}
+		r, size := utf8.DecodeRuneInString(text[i:])
+		tokens = append(tokens, markupToken{raw: text[i : i+size], r: r})
+		i += size
+	}
+	return tokens
+}
+
+// validTag is true for the tags textStyle knows
+func validTag(tag string) bool {
+	switch tag {
+	case "/", "b", "u":
+		return true
+	}
+	if _, ok := textColors[tag]; ok {
+		return true
+	}
+	_, ok := parseHexColor(tag)
+	return ok
+}
+
+// apply returns the style after a tag (but "{/}", that pops the style stack)
+func (s textStyle) apply(tag string) textStyle {
+	switch tag {
+	case "b":
+		s.bold = true
+	case "u":
+		s.underline = true
+	default:
+		named, ok := textColors[tag]
+		if !ok {
+			named, _ = parseHexColor(tag)
+		}
+		s.color = color.NRGBA{named.R, named.G, named.B, s.color.A} // opacity stays that of the text
+	}
+	return s
+}
+
+// parseHexColor parses "#rrggbb"
+func parseHexColor(tag string) (color.NRGBA, bool) {
+	if len(tag) != 7 || tag[0] != '#' {
+		return color.NRGBA{}, false
+	}
+	rgb, err := strconv.ParseUint(tag[1:], 16, 32)
+	if err != nil {
+		return color.NRGBA{}, false
+	}
+	return color.NRGBA{uint8(rgb >> 16), uint8(rgb >> 8), uint8(rgb), 255}, true
+}
+
+// textStyleStack holds the styles opened by tags, the bottom one is the text's own
+type textStyleStack []textStyle
+
+func (s *textStyleStack) top() textStyle {
+	return (*s)[len(*s)-1]
+}
+
+// handle pushes the style of a tag, or pops one for "{/}"
+func (s *textStyleStack) handle(tag string) {
+	if tag != "/" {
+		*s = append(*s, s.top().apply(tag))
+		return
+	}
+	if len(*s) > 1 {
+		*s = (*s)[:len(*s)-1]
+	}
+}
@@ -0,0 +1,139 @@
+package multisample
+
+import (
+	"image"
+	imagedraw "image/draw" // draw is the frame function of quad.go
+
+	"github.com/go-gl/gl/v3.2-core/gl"
+)
+
+// TextureSpace tells how texel values are to be interpreted
+type TextureSpace int
+
+const (
+	// TextureColor is an image meant to be looked at (albedo, sprites, UI),
+	// stored sRGB encoded like every png. It is uploaded as SRGB8_ALPHA8 so
+	// sampling returns linear values and filtering/blending is correct. Without
+	// sRGB texture support it is uploaded as RGBA and shaders decode it.
+	TextureColor TextureSpace = iota
+
+	// TextureData holds numbers (splat weights, normals, heights, masks),
+	// uploaded and sampled as is
+	TextureData
+)
+
+var (
+	srgbTextures    bool // driver decodes sRGB textures when sampling, see setupTextureFormats
+	unpackRowLength bool // uploads can skip the rest of each image row, see Texture.Update
+)
+
+// setupTextureFormats checks for sRGB texture support (core since OpenGL 2.1,
+// OpenGL ES 3.0) and GL_UNPACK_ROW_LENGTH (always on desktop, OpenGL ES 3.0),
+// requires a current GL context
+func setupTextureFormats() {
+	srgbTextures = glInfo.AtLeast(2, 1) || glInfo.Has("GL_EXT_texture_sRGB")
+	unpackRowLength = !glInfo.ES || glInfo.AtLeast(3, 0) || glInfo.Has("GL_EXT_unpack_subimage")
+}
+
+// internalFormat returns the texture format for an RGBA image in this space
+func (s TextureSpace) internalFormat() int32 {
+	if s == TextureColor && srgbTextures {
+		return gl.SRGB8_ALPHA8
+	}
+	return gl.RGBA8
+}
+
+// newImageTexture uploads an image as mipmapped RGBA texture
+func newImageTexture(label string, img *image.NRGBA, space TextureSpace, wrap int32) uint32 {
+	texture := genTexture(label)
+	gl.BindTexture(gl.TEXTURE_2D, texture)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR_MIPMAP_LINEAR)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, wrap)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, wrap)
+	gl.PixelStorei(gl.UNPACK_ALIGNMENT, 1) // rows are tightly packed in img.Pix
+	gl.TexImage2D(gl.TEXTURE_2D, 0, space.internalFormat(), int32(img.Rect.Dx()), int32(img.Rect.Dy()), 0, gl.RGBA, gl.UNSIGNED_BYTE, gl.Ptr(img.Pix))
+	gl.GenerateMipmap(gl.TEXTURE_2D)
+	gpuResources.SetBytes(ResourceTexture, texture, len(img.Pix)*4/3) // mipmaps add a third
+	gl.BindTexture(gl.TEXTURE_2D, 0)
+	return texture
+}
+
+// Texture is an RGBA texture whose texels change after creation, e.g. a
+// canvas drawn on the CPU with image/draw and shown on a quad (see Canvas)
+type Texture struct {
+	ID      uint32
+	Width   int
+	Height  int
+	scratch []uint8 // tightly packed copy of the updated rectangle, see Update
+}
+
+// NewTexture allocates a width x height texture, transparent black. It has
+// no mipmaps, they would have to be regenerated after every update.
+func NewTexture(label string, width, height int, space TextureSpace) *Texture {
+	t := &Texture{ID: genTexture(label), Width: width, Height: height}
+	gl.BindTexture(gl.TEXTURE_2D, t.ID)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
+	gl.TexImage2D(gl.TEXTURE_2D, 0, space.internalFormat(), int32(width), int32(height), 0, gl.RGBA, gl.UNSIGNED_BYTE, nil)
+	gpuResources.SetBytes(ResourceTexture, t.ID, width*height*4)
+	gl.BindTexture(gl.TEXTURE_2D, 0)
+	return t
+}
+
+// Update uploads the rect part of img (in image coordinates) with
+// glTexSubImage2D, the top-left of img's bounds is texel 0, 0 like in
+// newImageTexture. Parts outside the image or the texture are skipped.
+//
+// An *image.NRGBA is uploaded straight from its pixels, GL_UNPACK_ROW_LENGTH
+// steps over the texels left and right of rect in each row. Other images, or
+// drivers without GL_UNPACK_ROW_LENGTH, go through a tightly packed copy.
+func (t *Texture) Update(img image.Image, rect image.Rectangle) {
+
+	bounds := img.Bounds()
+	rect = rect.Intersect(bounds).Intersect(image.Rect(0, 0, t.Width, t.Height).Add(bounds.Min))
+	if rect.Empty() {
+		return
+	}
+
+	pixels, rowLength := t.pixels(img, rect)
+
+	gl.BindTexture(gl.TEXTURE_2D, t.ID)
+	gl.PixelStorei(gl.UNPACK_ALIGNMENT, 1)
+	if rowLength != rect.Dx() {
+		gl.PixelStorei(gl.UNPACK_ROW_LENGTH, int32(rowLength))
+	}
+	gl.TexSubImage2D(gl.TEXTURE_2D, 0, int32(rect.Min.X-bounds.Min.X), int32(rect.Min.Y-bounds.Min.Y), int32(rect.Dx()), int32(rect.Dy()), gl.RGBA, gl.UNSIGNED_BYTE, gl.Ptr(pixels))
+	if rowLength != rect.Dx() {
+		gl.PixelStorei(gl.UNPACK_ROW_LENGTH, 0)
+	}
+	gl.PixelStorei(gl.UNPACK_ALIGNMENT, 4)
+	gl.BindTexture(gl.TEXTURE_2D, 0)
+
+}
+
+// pixels returns the non-premultiplied RGBA texels of rect, starting at its
+// top-left, and the texels per row in memory
+func (t *Texture) pixels(img image.Image, rect image.Rectangle) ([]uint8, int) {
+
+	if nrgba, ok := img.(*image.NRGBA); ok && unpackRowLength {
+		return nrgba.Pix[nrgba.PixOffset(rect.Min.X, rect.Min.Y):], nrgba.Stride / 4
+	}
+
+	// image/draw converts any image, e.g. premultiplied *image.RGBA or paletted GIF frames
+	size := rect.Dx() * rect.Dy() * 4
+	if cap(t.scratch) < size {
+		t.scratch = make([]uint8, size)
+	}
+	packed := &image.NRGBA{Pix: t.scratch[:size], Stride: rect.Dx() * 4, Rect: rect}
+	imagedraw.Draw(packed, rect, img, rect.Min, imagedraw.Src)
+	return packed.Pix, rect.Dx()
+
+}
+
+func (t *Texture) destroy() {
+	gpuResources.Release(ResourceTexture, t.ID)
+	t.ID, t.scratch = 0, nil
+}
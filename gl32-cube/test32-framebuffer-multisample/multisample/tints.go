@@ -0,0 +1,119 @@
+package multisample
+
+import (
+	"image/color"
+
+	"github.com/go-gl/gl/v3.2-core/gl"
+)
+
+const (
+	tintTextureWidth = 256 // quads per row of the tint texture, must match the vertex shader
+)
+
+// SetTint changes the tint of a quad (by draw order), multiplied with its
+// vertex colors. Unlike the vertex colors it is uploaded on its own, 4 bytes
+// per quad, see QuadTints.
+func (q *ElementQuads) SetTint(quad int, clr color.NRGBA) {
+	q.QuadTints[quad] = clr
+	q.markTints(quad, quad+1)
+}
+
+// Tint is the tint of a quad (by draw order)
+func (q *ElementQuads) Tint(quad int) color.NRGBA {
+	return q.QuadTints[quad]
+}
+
+// SetRandomTint makes the demo randomize the tint of a quad (by draw order)
+// every frame, with -vertexcolors its vertex colors instead. Other quads keep
+// their tint, white unless SetTint changes it.
+func (q *ElementQuads) SetRandomTint(quad int, random bool) {
+	q.QuadRandomTints[quad] = random
+}
+
+// addTint adds the tint of a new quad, white (untinted) and not randomized
+func (q *ElementQuads) addTint() {
+	q.QuadTints = append(q.QuadTints, color.NRGBA{255, 255, 255, 255})
+	q.QuadRandomTints = append(q.QuadRandomTints, false)
+	q.markTints(len(q.QuadTints)-1, len(q.QuadTints))
+}
+
+// markTints extends the range of quads whose tints changed since the last upload
+func (q *ElementQuads) markTints(from, to int) {
+	if q.tintsFrom >= q.tintsTo {
+		q.tintsFrom, q.tintsTo = from, to
+		return
+	}
+	if from < q.tintsFrom {
+		q.tintsFrom = from
+	}
+	if to > q.tintsTo {
+		q.tintsTo = to
+	}
+}
+
+// QuadTints is a texture holding one RGBA tint per quad, tintTextureWidth
+// quads per row. The vertex shader fetches the tint of its quad by
+// gl_VertexID / 4 (quads are 4 consecutive vertices, see QuadIndexBuffer),
+// so changing a quad's color uploads 4 bytes instead of rewriting the 16
+// bytes of its vertex colors, and the VBO is never touched. Only the rows
+// holding changed quads are uploaded.
+//
+// The texture grows in powers of two rows, its name stays the same, so the
+// material keeps it bound.
+type QuadTints struct {
+	texture uint32
+	rows    int     // allocated rows
+	pix     []uint8 // RGBA per quad, a whole number of rows
+}
+
+// NewQuadTints creates the texture, storage is allocated by the first update
+func NewQuadTints(label string) *QuadTints {
+	t := &QuadTints{texture: genTexture(label)}
+	gl.BindTexture(gl.TEXTURE_2D, t.texture)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.NEAREST)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.NEAREST)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
+	gl.BindTexture(gl.TEXTURE_2D, 0)
+	return t
+}
+
+// Texture is the texture, the sampler of the vertex shader reads it with texelFetch
+func (t *QuadTints) Texture() uint32 {
+	return t.texture
+}
+
+// update uploads the tints changed since the last update, call it before
+// binding the material (it binds the texture to the active unit)
+func (t *QuadTints) update(quads *ElementQuads) {
+
+	from, to := quads.tintsFrom, quads.tintsTo
+	quads.tintsFrom, quads.tintsTo = 0, 0
+
+	// grow, everything is uploaded into the new storage
+	rows := (len(quads.QuadTints) + tintTextureWidth - 1) / tintTextureWidth
+	gl.BindTexture(gl.TEXTURE_2D, t.texture)
+	if rows > t.rows {
+		t.rows = nextPowerOfTwo(rows)
+		t.pix = make([]uint8, t.rows*tintTextureWidth*4)
+		gl.TexImage2D(gl.TEXTURE_2D, 0, gl.RGBA8, tintTextureWidth, int32(t.rows), 0, gl.RGBA, gl.UNSIGNED_BYTE, nil)
+		gpuResources.SetBytes(ResourceTexture, t.texture, len(t.pix))
+		from, to = 0, len(quads.QuadTints)
+	}
+
+	if from < to {
+		for quad := from; quad < to; quad++ {
+			clr := quads.QuadTints[quad]
+			t.pix[quad*4+0], t.pix[quad*4+1], t.pix[quad*4+2], t.pix[quad*4+3] = clr.R, clr.G, clr.B, clr.A
+		}
+		first, last := from/tintTextureWidth, (to-1)/tintTextureWidth
+		gl.TexSubImage2D(gl.TEXTURE_2D, 0, 0, int32(first), tintTextureWidth, int32(last-first+1), gl.RGBA, gl.UNSIGNED_BYTE, gl.Ptr(t.pix[first*tintTextureWidth*4:]))
+	}
+	gl.BindTexture(gl.TEXTURE_2D, 0)
+
+}
+
+func (t *QuadTints) destroy() {
+	gpuResources.Release(ResourceTexture, t.texture)
+	t.texture, t.rows, t.pix = 0, 0, nil
+}
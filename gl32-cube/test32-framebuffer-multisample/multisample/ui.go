@@ -0,0 +1,505 @@
+package multisample
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+)
+
+const (
+	uiPadding    = 8  // pixels between the edge of a panel or button and its content
+	uiSpacing    = 6  // pixels between the widgets of a row or column
+	uiControl    = 16 // pixels per side of checkboxes and slider knobs
+	uiScrollStep = 24 // pixels a scroll view moves per scroll wheel step
+	uiScrollBar  = 6  // pixels wide scroll bar of a scroll view
+)
+
+// uiColors are the colors of every widget
+var uiColors = struct {
+	panel, frame, text    color.NRGBA
+	control, hover, press color.NRGBA
+	accent                color.NRGBA
+}{
+	panel:   color.NRGBA{20, 24, 32, 220},
+	frame:   color.NRGBA{90, 110, 140, 255},
+	text:    color.NRGBA{230, 230, 230, 255},
+	control: color.NRGBA{50, 60, 80, 255},
+	hover:   color.NRGBA{70, 85, 115, 255},
+	press:   color.NRGBA{35, 42, 56, 255},
+	accent:  color.NRGBA{90, 170, 255, 255},
+}
+
+// UIEventKind is what happened to the mouse
+type UIEventKind int
+
+const (
+	UIPress   UIEventKind = iota // left button pressed
+	UIRelease                    // left button released, sent to the pressed widget
+	UIMove                       // cursor moved, sent to the pressed widget
+	UIScroll                     // scroll wheel turned
+)
+
+// UIEvent is mouse input, in screen pixels from the top-left (see WindowToScreen)
+type UIEvent struct {
+	Kind   UIEventKind
+	X, Y   float32
+	Scroll float32 // wheel steps, positive up (UIScroll only)
+}
+
+// Widget is an element of a retained UI: it lives from frame to frame and
+// keeps its state (a slider its value, a scroll view its offset), the UI
+// lays it out and queues its boxes and text every frame.
+type Widget interface {
+	Measure(ui *UI) (width, height float32) // smallest size showing everything, in pixels
+	Layout(ui *UI, bounds TextRect)         // place the widget, and its children in it
+	Bounds() TextRect                       // where the last Layout placed it
+	Draw(ui *UI)                            // queue boxes and text, see ContextText
+	Handle(ui *UI, event UIEvent) bool      // react to input, true when used
+}
+
+// UI is a tree of widgets drawn ontop the screen with the text overlay: a
+// single draw call with the boxes 9-sliced from the font atlas (see DrawBox).
+// Feed it the mouse with MouseButton, MouseMove and Scroll, see ControlPanel.
+type UI struct {
+	Root Widget
+	X, Y float32 // top-left of the root in pixels, it gets the size it measures
+
+	text           *ContextText
+	active         Widget // pressed widget, it gets the moves and the release (see Capture)
+	mouseX, mouseY float32
+}
+
+// NewUI creates a UI showing root at x, y
+func NewUI(root Widget, x, y float32) *UI {
+	return &UI{Root: root, X: x, Y: y, text: ctxText}
+}
+
+// Draw lays out the widgets and queues them for this frame
+func (ui *UI) Draw() {
+	width, height := ui.Root.Measure(ui)
+	ui.Root.Layout(ui, TextRect{X: ui.X, Y: ui.Y, Width: width, Height: height})
+	ui.Root.Draw(ui)
+}
+
+// Capture sends the moves and the release of the current press to w, e.g.
+// so a slider follows the cursor outside of it
+func (ui *UI) Capture(w Widget) {
+	ui.active = w
+}
+
+// Hovered reports whether the cursor is over w, and no other widget is pressed
+func (ui *UI) Hovered(w Widget) bool {
+	return (ui.active == nil || ui.active == w) && w.Bounds().Contains(ui.mouseX, ui.mouseY)
+}
+
+// MouseButton handles the left button at x, y (screen pixels), false when
+// the press was outside the UI and belongs to the scene
+func (ui *UI) MouseButton(press bool, x, y float32) bool {
+	ui.mouseX, ui.mouseY = x, y
+	if !press {
+		active := ui.active
+		ui.active = nil
+		if active == nil {
+			return false
+		}
+		active.Handle(ui, UIEvent{Kind: UIRelease, X: x, Y: y})
+		return true
+	}
+	if !ui.Root.Bounds().Contains(x, y) {
+		return false
+	}
+	ui.Root.Handle(ui, UIEvent{Kind: UIPress, X: x, Y: y})
+	return true
+}
+
+// MouseMove handles the cursor moving to x, y, false unless a widget is pressed
+func (ui *UI) MouseMove(x, y float32) bool {
+	ui.mouseX, ui.mouseY = x, y
+	if ui.active == nil {
+		return false
+	}
+	ui.active.Handle(ui, UIEvent{Kind: UIMove, X: x, Y: y})
+	return true
+}
+
+// Scroll handles the scroll wheel with the cursor at x, y, false outside the UI
+func (ui *UI) Scroll(x, y, steps float32) bool {
+	if !ui.Root.Bounds().Contains(x, y) {
+		return false
+	}
+	ui.Root.Handle(ui, UIEvent{Kind: UIScroll, X: x, Y: y, Scroll: steps})
+	return true
+}
+
+// widgetBounds is the layout part of a widget without children
+type widgetBounds struct {
+	bounds TextRect
+}
+
+func (w *widgetBounds) Layout(_ *UI, bounds TextRect) {
+	w.bounds = bounds
+}
+
+func (w *widgetBounds) Bounds() TextRect {
+	return w.bounds
+}
+
+// handleChildren passes an event to the child under it, the last one drawn first
+func handleChildren(ui *UI, children []Widget, event UIEvent) bool {
+	for i := len(children) - 1; i >= 0; i-- {
+		if children[i].Bounds().Contains(event.X, event.Y) {
+			return children[i].Handle(ui, event)
+		}
+	}
+	return false
+}
+
+// Label is a line of text, markup works (see DrawText)
+type Label struct {
+	widgetBounds
+	Text string
+}
+
+func (l *Label) Measure(ui *UI) (float32, float32) {
+	size := ui.text.MeasureText(l.Text, TextLayout{})
+	return size.Width, size.Height
+}
+
+func (l *Label) Draw(ui *UI) {
+	ui.text.DrawText(l.bounds.X, l.bounds.Y, l.Text, uiColors.text)
+}
+
+func (l *Label) Handle(*UI, UIEvent) bool {
+	return false
+}
+
+// Button calls OnClick when it is released over it
+type Button struct {
+	widgetBounds
+	Text    string
+	OnClick func()
+
+	pressed bool
+}
+
+func (b *Button) Measure(ui *UI) (float32, float32) {
+	size := ui.text.MeasureText(b.Text, TextLayout{})
+	return size.Width + 2*uiPadding, size.Height + 2*uiPadding
+}
+
+func (b *Button) Draw(ui *UI) {
+	clr := uiColors.control
+	switch hovered := ui.Hovered(b); {
+	case b.pressed && hovered:
+		clr = uiColors.press
+	case hovered:
+		clr = uiColors.hover
+	}
+	ui.text.DrawBox(b.bounds, clr)
+	ui.text.DrawTextLayout(b.bounds.X, b.bounds.Y+uiPadding, b.Text, TextLayout{Width: b.bounds.Width, Align: AlignCenter}, uiColors.text)
+}
+
+func (b *Button) Handle(ui *UI, event UIEvent) bool {
+	switch event.Kind {
+	case UIPress:
+		b.pressed = true
+		ui.Capture(b)
+	case UIRelease:
+		b.pressed = false
+		if b.bounds.Contains(event.X, event.Y) && b.OnClick != nil {
+			b.OnClick()
+		}
+	case UIScroll:
+		return false
+	}
+	return true
+}
+
+// Checkbox switches Checked when clicked and calls OnChange with the new state
+type Checkbox struct {
+	widgetBounds
+	Text     string
+	Checked  bool
+	OnChange func(checked bool)
+
+	pressed bool
+}
+
+func (c *Checkbox) Measure(ui *UI) (float32, float32) {
+	size := ui.text.MeasureText(c.Text, TextLayout{})
+	return uiControl + uiSpacing + size.Width, maxFloat32(uiControl, size.Height)
+}
+
+func (c *Checkbox) Draw(ui *UI) {
+	box := TextRect{X: c.bounds.X, Y: c.bounds.Y + (c.bounds.Height-uiControl)/2, Width: uiControl, Height: uiControl}
+	clr := uiColors.control
+	if ui.Hovered(c) {
+		clr = uiColors.hover
+	}
+	ui.text.DrawBox(box, clr)
+	ui.text.DrawFrame(box, uiColors.frame)
+	if c.Checked {
+		ui.text.DrawBox(TextRect{X: box.X + 4, Y: box.Y + 4, Width: box.Width - 8, Height: box.Height - 8}, uiColors.accent)
+	}
+	size := ui.text.MeasureText(c.Text, TextLayout{})
+	ui.text.DrawText(box.X+uiControl+uiSpacing, c.bounds.Y+(c.bounds.Height-size.Height)/2, c.Text, uiColors.text)
+}
+
+func (c *Checkbox) Handle(ui *UI, event UIEvent) bool {
+	switch event.Kind {
+	case UIPress:
+		c.pressed = true
+		ui.Capture(c)
+	case UIRelease:
+		if c.pressed && c.bounds.Contains(event.X, event.Y) {
+			c.Checked = !c.Checked
+			if c.OnChange != nil {
+				c.OnChange(c.Checked)
+			}
+		}
+		c.pressed = false
+	case UIScroll:
+		return false
+	}
+	return true
+}
+
+// Slider picks a Value from Min to Max by dragging its knob, in multiples of
+// Step above Min (0 = any), and calls OnChange when the value changes. The
+// text above the track shows the value with Format (default "%.2f").
+type Slider struct {
+	widgetBounds
+	Text           string
+	Min, Max, Step float32
+	Value          float32
+	Width          float32 // of the track in pixels, 0 = 160
+	Format         string
+	OnChange       func(value float32)
+}
+
+func (s *Slider) Measure(ui *UI) (float32, float32) {
+	size := ui.text.MeasureText(s.label(), TextLayout{})
+	width := s.Width
+	if width == 0 {
+		width = 160
+	}
+	return maxFloat32(width, size.Width), size.Height + uiSpacing + uiControl
+}
+
+// label is the text with the value
+func (s *Slider) label() string {
+	format := s.Format
+	if format == "" {
+		format = "%.2f"
+	}
+	return s.Text + " " + fmt.Sprintf(format, s.Value)
+}
+
+// track is the area the knob moves in
+func (s *Slider) track() TextRect {
+	return TextRect{X: s.bounds.X, Y: s.bounds.Y + s.bounds.Height - uiControl, Width: s.bounds.Width, Height: uiControl}
+}
+
+func (s *Slider) Draw(ui *UI) {
+	ui.text.DrawText(s.bounds.X, s.bounds.Y, s.label(), uiColors.text)
+
+	track := s.track()
+	ui.text.DrawBox(TextRect{X: track.X, Y: track.Y + uiControl/2 - 2, Width: track.Width, Height: 4}, uiColors.control)
+	t := float32(0)
+	if s.Max > s.Min {
+		t = (s.Value - s.Min) / (s.Max - s.Min)
+	}
+	knobX := track.X + t*(track.Width-uiControl)
+	ui.text.FillRect(TextRect{X: track.X, Y: track.Y + uiControl/2 - 1, Width: knobX - track.X, Height: 2}, uiColors.accent)
+	clr := uiColors.accent
+	if ui.Hovered(s) {
+		clr = uiColors.text
+	}
+	ui.text.DrawBox(TextRect{X: knobX, Y: track.Y, Width: uiControl, Height: uiControl}, clr)
+}
+
+func (s *Slider) Handle(ui *UI, event UIEvent) bool {
+	switch event.Kind {
+	case UIPress:
+		ui.Capture(s)
+		s.drag(event.X)
+	case UIMove:
+		s.drag(event.X)
+	case UIScroll:
+		return false
+	}
+	return true
+}
+
+// drag moves the knob center to x
+func (s *Slider) drag(x float32) {
+	track := s.track()
+	t := float32(0)
+	if track.Width > uiControl {
+		t = (x - track.X - uiControl/2) / (track.Width - uiControl)
+	}
+	value := s.Min + t*(s.Max-s.Min)
+	if s.Step > 0 {
+		value = s.Min + float32(math.Round(float64((value-s.Min)/s.Step)))*s.Step
+	}
+	value = minFloat32(maxFloat32(value, s.Min), s.Max)
+	if value != s.Value {
+		s.Value = value
+		if s.OnChange != nil {
+			s.OnChange(value)
+		}
+	}
+}
+
+// Panel is a rounded box with a frame around a child
+type Panel struct {
+	widgetBounds
+	Child Widget
+}
+
+func (p *Panel) Measure(ui *UI) (float32, float32) {
+	width, height := p.Child.Measure(ui)
+	return width + 2*uiPadding, height + 2*uiPadding
+}
+
+func (p *Panel) Layout(ui *UI, bounds TextRect) {
+	p.bounds = bounds
+	p.Child.Layout(ui, TextRect{X: bounds.X + uiPadding, Y: bounds.Y + uiPadding, Width: bounds.Width - 2*uiPadding, Height: bounds.Height - 2*uiPadding})
+}
+
+func (p *Panel) Draw(ui *UI) {
+	ui.text.DrawBox(p.bounds, uiColors.panel)
+	ui.text.DrawFrame(p.bounds, uiColors.frame)
+	p.Child.Draw(ui)
+}
+
+func (p *Panel) Handle(ui *UI, event UIEvent) bool {
+	return handleChildren(ui, []Widget{p.Child}, event)
+}
+
+// Column stacks its children top to bottom, each as wide as the column
+type Column struct {
+	widgetBounds
+	Children []Widget
+}
+
+func (c *Column) Measure(ui *UI) (float32, float32) {
+	width, height := float32(0), float32(0)
+	for i, child := range c.Children {
+		w, h := child.Measure(ui)
+		width = maxFloat32(width, w)
+		height += h
+		if i > 0 {
+			height += uiSpacing
+		}
+	}
+	return width, height
+}
+
+func (c *Column) Layout(ui *UI, bounds TextRect) {
+	c.bounds = bounds
+	y := bounds.Y
+	for _, child := range c.Children {
+		_, h := child.Measure(ui)
+		child.Layout(ui, TextRect{X: bounds.X, Y: y, Width: bounds.Width, Height: h})
+		y += h + uiSpacing
+	}
+}
+
+func (c *Column) Draw(ui *UI) {
+	for _, child := range c.Children {
+		child.Draw(ui)
+	}
+}
+
+func (c *Column) Handle(ui *UI, event UIEvent) bool {
+	return handleChildren(ui, c.Children, event)
+}
+
+// Row puts its children left to right, each as tall as the row
+type Row struct {
+	widgetBounds
+	Children []Widget
+}
+
+func (r *Row) Measure(ui *UI) (float32, float32) {
+	width, height := float32(0), float32(0)
+	for i, child := range r.Children {
+		w, h := child.Measure(ui)
+		width += w
+		height = maxFloat32(height, h)
+		if i > 0 {
+			width += uiSpacing
+		}
+	}
+	return width, height
+}
+
+func (r *Row) Layout(ui *UI, bounds TextRect) {
+	r.bounds = bounds
+	x := bounds.X
+	for _, child := range r.Children {
+		w, _ := child.Measure(ui)
+		child.Layout(ui, TextRect{X: x, Y: bounds.Y, Width: w, Height: bounds.Height})
+		x += w + uiSpacing
+	}
+}
+
+func (r *Row) Draw(ui *UI) {
+	for _, child := range r.Children {
+		child.Draw(ui)
+	}
+}
+
+func (r *Row) Handle(ui *UI, event UIEvent) bool {
+	return handleChildren(ui, r.Children, event)
+}
+
+// ScrollView shows Height pixels of a taller child, the scroll wheel moves
+// it. The child is clipped to the view (see PushClip), a bar on the right
+// shows which part is visible.
+type ScrollView struct {
+	widgetBounds
+	Child  Widget
+	Height float32
+
+	offset float32 // pixels of the child above the view
+}
+
+func (s *ScrollView) Measure(ui *UI) (float32, float32) {
+	width, _ := s.Child.Measure(ui)
+	return width + uiSpacing + uiScrollBar, s.Height
+}
+
+func (s *ScrollView) Layout(ui *UI, bounds TextRect) {
+	s.bounds = bounds
+	_, height := s.Child.Measure(ui)
+	s.offset = minFloat32(maxFloat32(s.offset, 0), maxFloat32(height-bounds.Height, 0))
+	s.Child.Layout(ui, TextRect{X: bounds.X, Y: bounds.Y - s.offset, Width: bounds.Width - uiSpacing - uiScrollBar, Height: height})
+}
+
+func (s *ScrollView) Draw(ui *UI) {
+	ui.text.PushClip(s.bounds)
+	s.Child.Draw(ui)
+	ui.text.PopClip()
+
+	// the bar is as much of the track as the view is of the child
+	height := s.Child.Bounds().Height
+	if height <= s.bounds.Height {
+		return
+	}
+	track := TextRect{X: s.bounds.X + s.bounds.Width - uiScrollBar, Y: s.bounds.Y, Width: uiScrollBar, Height: s.bounds.Height}
+	ui.text.DrawBox(track, uiColors.control)
+	ui.text.DrawBox(TextRect{X: track.X, Y: track.Y + track.Height*s.offset/height, Width: track.Width, Height: track.Height * s.bounds.Height / height}, uiColors.frame)
+}
+
+func (s *ScrollView) Handle(ui *UI, event UIEvent) bool {
+	if event.Kind == UIScroll {
+		if handleChildren(ui, []Widget{s.Child}, event) {
+			return true
+		}
+		s.offset -= event.Scroll * uiScrollStep // clamped by the next Layout
+		return true
+	}
+	return handleChildren(ui, []Widget{s.Child}, event)
+}
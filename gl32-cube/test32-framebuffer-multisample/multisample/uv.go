@@ -0,0 +1,75 @@
+package multisample
+
+import (
+	"fmt"
+
+	"github.com/go-gl/gl/v3.2-core/gl"
+)
+
+// QuadUV adjusts the texture coordinates of a quad, see SetUV. The zero
+// value is the texture once, the way DrawRectangle emits it.
+type QuadUV struct {
+	FlipX, FlipY     bool  // mirror the texture left-right, top-bottom (sprites facing the other way)
+	RepeatX, RepeatY uint8 // times the texture is repeated along u, v (tiled backgrounds), 0 is once
+}
+
+// texCoords emits the texture coordinates of the 4 vertices in the order of
+// makeQuadTextureCoord. They are whole numbers (non-normalized uint8), a
+// repeated texture reaches past 1 and is continued by the wrap mode of its
+// sampler, e.g. gl.REPEAT (see parseWrapMode).
+func (uv QuadUV) texCoords() []uint8 {
+	right, top := uv.RepeatX, uv.RepeatY
+	if right == 0 {
+		right = 1
+	}
+	if top == 0 {
+		top = 1
+	}
+	left, bottom := uint8(0), uint8(0)
+	if uv.FlipX {
+		left, right = right, left
+	}
+	if uv.FlipY {
+		bottom, top = top, bottom
+	}
+	return []uint8{
+		right, top, // v0 = top-right
+		left, top, // v1 = top-left
+		left, bottom, // v2 = bottom-left
+		right, bottom, // v3 = bottom-right
+	}
+}
+
+// SetUV rewrites the texture coordinates of a quad (by draw order), they are
+// uploaded before the next draw (see UploadTexCoords)
+func (q *ElementQuads) SetUV(quad int, uv QuadUV) {
+	size := verticesPerQuad * vertexTexCoordSize
+	copy(q.QuadTexCoords[quad*size:(quad+1)*size], uv.texCoords())
+	q.texCoordsDirty = true
+}
+
+// UploadTexCoords copies QuadTexCoords into the bound VBO, like UploadColors
+// just the texcoord block of planar layouts, everything when interleaved
+func (q *ElementQuads) UploadTexCoords(layout *Layout) {
+	q.texCoordsDirty = false
+	if layout.Interleaved {
+		q.Upload(layout)
+		return
+	}
+	gl.BufferSubData(gl.ARRAY_BUFFER, q.OffsetTexCoords, len(q.QuadTexCoords)*bytesUint8, gl.Ptr(q.QuadTexCoords))
+}
+
+// parseWrapMode reads the -wrap flag, how a sampler continues a texture
+// outside 0..1: repeat tiles it, mirror tiles it flipping every other copy
+// (no seams on textures that don't tile), clamp stretches the border texels
+func parseWrapMode(name string) (int32, error) {
+	switch name {
+	case "", "repeat":
+		return gl.REPEAT, nil
+	case "mirror":
+		return gl.MIRRORED_REPEAT, nil
+	case "clamp":
+		return gl.CLAMP_TO_EDGE, nil
+	}
+	return 0, fmt.Errorf("unknown wrap mode %q, use repeat, mirror or clamp", name)
+}
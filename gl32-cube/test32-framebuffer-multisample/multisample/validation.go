@@ -0,0 +1,149 @@
+package multisample
+
+import (
+	"fmt"
+
+	"github.com/go-gl/gl/v3.2-core/gl"
+)
+
+// validationBindings are the texture targets samplers read and the query of the texture bound to each
+var validationBindings = map[uint32]uint32{
+	gl.TEXTURE_2D:             gl.TEXTURE_BINDING_2D,
+	gl.TEXTURE_3D:             gl.TEXTURE_BINDING_3D,
+	gl.TEXTURE_CUBE_MAP:       gl.TEXTURE_BINDING_CUBE_MAP,
+	gl.TEXTURE_2D_ARRAY:       gl.TEXTURE_BINDING_2D_ARRAY,
+	gl.TEXTURE_2D_MULTISAMPLE: gl.TEXTURE_BINDING_2D_MULTISAMPLE,
+	gl.TEXTURE_BUFFER:         gl.TEXTURE_BINDING_BUFFER,
+	gl.TEXTURE_RECTANGLE:      gl.TEXTURE_BINDING_RECTANGLE,
+}
+
+var (
+	validationReported = map[string]bool{} // problems already printed, each is printed once
+)
+
+// validateDraw checks the state a glDrawElements is about to use (-validate),
+// what names the draw in the report. GL draws with whatever is bound, wrong
+// state renders black or nothing rather than failing:
+//
+//   - an active attribute of the program without an enabled vertex array
+//     reads a constant, usually (0,0,0,1)
+//   - an enabled vertex array without a buffer, or no element buffer
+//   - a sampler uniform pointing at a unit without a texture of its type
+//     samples black, two sampler types on one unit fail the draw
+//
+// Each problem is printed once. Reading state stalls the pipeline, so it
+// does nothing unless -validate is given.
+func validateDraw(what string) {
+
+	if !*validateMode {
+		return
+	}
+
+	var program int32
+	gl.GetIntegerv(gl.CURRENT_PROGRAM, &program)
+	if program == 0 {
+		validationReport(what, "no program is bound")
+		return
+	}
+
+	var elementBuffer int32
+	gl.GetIntegerv(gl.ELEMENT_ARRAY_BUFFER_BINDING, &elementBuffer)
+	if elementBuffer == 0 {
+		validationReport(what, "no element array buffer is bound to the vertex array")
+	}
+
+	validateAttributes(what, uint32(program))
+	validateSamplers(what, uint32(program))
+
+}
+
+// validateAttributes checks every active attribute of program reads an enabled vertex array with a buffer
+func validateAttributes(what string, program uint32) {
+	for _, attrib := range programInfos.Get(program).Attributes {
+
+		if attrib.Location < 0 {
+			continue
+		}
+
+		var enabled, buffer int32
+		gl.GetVertexAttribiv(uint32(attrib.Location), gl.VERTEX_ATTRIB_ARRAY_ENABLED, &enabled)
+		gl.GetVertexAttribiv(uint32(attrib.Location), gl.VERTEX_ATTRIB_ARRAY_BUFFER_BINDING, &buffer)
+		switch {
+		case enabled == 0:
+			validationReport(what, fmt.Sprintf("attribute %q (location %v) has no enabled vertex array, it reads a constant", attrib.Name, attrib.Location))
+		case buffer == 0:
+			validationReport(what, fmt.Sprintf("attribute %q (location %v) is enabled without a buffer", attrib.Name, attrib.Location))
+		}
+
+	}
+}
+
+// validateSamplers checks every sampler uniform of program points at a unit
+// with a texture bound to its target, and no unit is used by two types
+func validateSamplers(what string, program uint32) {
+
+	var activeTexture int32
+	gl.GetIntegerv(gl.ACTIVE_TEXTURE, &activeTexture)
+	defer gl.ActiveTexture(uint32(activeTexture))
+
+	unitTypes := map[int32]uint32{}
+	unitNames := map[int32]string{}
+	for _, sampler := range programInfos.Get(program).Samplers() {
+		for element := int32(0); element < sampler.Size; element++ {
+
+			// array elements have a location each
+			uniform := sampler.Name
+			if sampler.Size > 1 {
+				uniform = fmt.Sprintf("%v[%v]", sampler.Name, element)
+			}
+			location := gl.GetUniformLocation(program, gl.Str(uniform+"\x00"))
+			if location < 0 {
+				continue // e.g. in a uniform block
+			}
+
+			var unit, texture int32
+			gl.GetUniformiv(program, location, &unit)
+			gl.ActiveTexture(gl.TEXTURE0 + uint32(unit))
+			gl.GetIntegerv(validationBindings[sampler.Target()], &texture)
+			if texture == 0 {
+				validationReport(what, fmt.Sprintf("sampler %q reads texture unit %v, which has no %v bound", uniform, unit, validationTarget(sampler.Target())))
+			}
+
+			if other, ok := unitTypes[unit]; ok && other != sampler.Type {
+				validationReport(what, fmt.Sprintf("samplers %q and %q of different types both read texture unit %v", unitNames[unit], uniform, unit))
+			}
+			unitTypes[unit], unitNames[unit] = sampler.Type, uniform
+
+		}
+	}
+
+}
+
+// validationTarget names a texture target, for the report
+func validationTarget(target uint32) string {
+	switch target {
+	case gl.TEXTURE_3D:
+		return "3D texture"
+	case gl.TEXTURE_CUBE_MAP:
+		return "cubemap"
+	case gl.TEXTURE_2D_ARRAY:
+		return "2D array texture"
+	case gl.TEXTURE_2D_MULTISAMPLE:
+		return "multisample texture"
+	case gl.TEXTURE_BUFFER:
+		return "buffer texture"
+	case gl.TEXTURE_RECTANGLE:
+		return "rectangle texture"
+	}
+	return "2D texture"
+}
+
+// validationReport prints a problem of a draw, the first time it occurs
+func validationReport(what, problem string) {
+	key := what + ": " + problem
+	if validationReported[key] {
+		return
+	}
+	validationReported[key] = true
+	fmt.Println("VALIDATION --", key)
+}
@@ -0,0 +1,214 @@
+package multisample
+
+import (
+	"fmt"
+	"image"
+	imagedraw "image/draw" // draw is the frame function of quad.go
+	"image/gif"
+	"math"
+)
+
+const (
+	plasmaSize = 256 // width and height of the synthetic video, see PlasmaSource
+	plasmaFPS  = 30  // frames per second of the synthetic video
+)
+
+// FrameSource produces the frames of a video texture, e.g. a video decoder
+// or an animated GIF. Frames are expected to keep the size of Size.
+type FrameSource interface {
+	Size() (width, height int)
+
+	// Frame returns the frame to show t seconds after the start, and whether
+	// it changed since the last call (unchanged frames are not uploaded)
+	Frame(t float64) (image.Image, bool)
+}
+
+// newFrameSource reads the -video flag: plasma for the synthetic video,
+// otherwise the path of an animated GIF
+func newFrameSource(name string) (FrameSource, error) {
+	if name == "plasma" {
+		return NewPlasmaSource(plasmaSize, plasmaSize, plasmaFPS), nil
+	}
+	return NewGIFSource(name)
+}
+
+// VideoTexture uploads the frames of a FrameSource into a texture as the
+// update clock advances (-video), whole frames every time, so it measures
+// how many texels per second TexSubImage2D sustains (see FrameStats.drawPage).
+type VideoTexture struct {
+	source  FrameSource
+	texture *Texture
+	time    float64 // seconds played
+
+	// uploads during the current statsInterval, and the rates of the last one
+	frames, bytes   int
+	elapsed         float64
+	frameRate, rate float64 // frames and bytes per second
+}
+
+// NewVideoTexture creates a texture of the source's size, requires a current GL context
+func NewVideoTexture(label string, source FrameSource) *VideoTexture {
+	width, height := source.Size()
+	return &VideoTexture{source: source, texture: NewTexture(label, width, height, TextureColor)}
+}
+
+// Texture is the latest frame, for a sampler2D
+func (v *VideoTexture) Texture() uint32 {
+	return v.texture.ID
+}
+
+// update advances the video by dt seconds and uploads the frame if it changed
+func (v *VideoTexture) update(dt float64) {
+
+	v.time += dt
+	if frame, changed := v.source.Frame(v.time); changed {
+		v.texture.Update(frame, frame.Bounds())
+		v.frames++
+		v.bytes += frame.Bounds().Dx() * frame.Bounds().Dy() * 4
+	}
+
+	v.elapsed += dt
+	if v.elapsed >= statsInterval {
+		v.frameRate, v.rate = float64(v.frames)/v.elapsed, float64(v.bytes)/v.elapsed
+		v.frames, v.bytes, v.elapsed = 0, 0, 0
+	}
+
+}
+
+func (v *VideoTexture) String() string {
+	return fmt.Sprintf("%.1f frames/s, %v/s", v.frameRate, formatBytes(int(v.rate)))
+}
+
+func (v *VideoTexture) destroy() {
+	v.texture.destroy()
+}
+
+// GIFSource plays an animated GIF in a loop. The frames are composited at
+// load (GIF frames may only cover the part that changed), so playing is a
+// lookup by time.
+type GIFSource struct {
+	frames []*image.NRGBA
+	ends   []float64 // time each frame ends, from the start of the loop
+	index  int       // frame returned last, -1 before the first
+}
+
+// NewGIFSource decodes the animated GIF at path
+func NewGIFSource(path string) (*GIFSource, error) {
+
+	file, err := assets.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	animation, err := gif.DecodeAll(file)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %v", path, err)
+	}
+
+	s := &GIFSource{index: -1}
+	canvas := image.NewNRGBA(image.Rect(0, 0, animation.Config.Width, animation.Config.Height))
+	end := 0.0
+	for i, frame := range animation.Image {
+
+		var previous *image.NRGBA
+		disposal := byte(0)
+		if i < len(animation.Disposal) {
+			disposal = animation.Disposal[i]
+		}
+		if disposal == gif.DisposalPrevious {
+			previous = cloneNRGBA(canvas)
+		}
+
+		imagedraw.Draw(canvas, frame.Bounds(), frame, frame.Bounds().Min, imagedraw.Over)
+		s.frames = append(s.frames, cloneNRGBA(canvas))
+
+		// delays are in 100ths of a second, browsers show 0 as 0.1
+		delay := float64(animation.Delay[i]) / 100
+		if delay <= 0 {
+			delay = 0.1
+		}
+		end += delay
+		s.ends = append(s.ends, end)
+
+		switch disposal {
+		case gif.DisposalBackground:
+			imagedraw.Draw(canvas, frame.Bounds(), image.Transparent, image.Point{}, imagedraw.Src)
+		case gif.DisposalPrevious:
+			canvas = previous
+		}
+
+	}
+	if len(s.frames) == 0 {
+		return nil, fmt.Errorf("%v: no frames", path)
+	}
+
+	return s, nil
+
+}
+
+func (s *GIFSource) Size() (int, int) {
+	bounds := s.frames[0].Bounds()
+	return bounds.Dx(), bounds.Dy()
+}
+
+func (s *GIFSource) Frame(t float64) (image.Image, bool) {
+	t = math.Mod(t, s.ends[len(s.ends)-1])
+	index := 0
+	for index < len(s.ends)-1 && s.ends[index] <= t {
+		index++
+	}
+	changed := index != s.index
+	s.index = index
+	return s.frames[index], changed
+}
+
+// PlasmaSource stands in for a video decoder: it renders a new frame of an
+// animated plasma on the CPU at a fixed frame rate, every texel changes
+type PlasmaSource struct {
+	frame *image.NRGBA
+	fps   float64
+	index int // frame rendered last, -1 before the first
+}
+
+// NewPlasmaSource creates a width x height plasma changing fps times per second
+func NewPlasmaSource(width, height int, fps float64) *PlasmaSource {
+	return &PlasmaSource{frame: image.NewNRGBA(image.Rect(0, 0, width, height)), fps: fps, index: -1}
+}
+
+func (s *PlasmaSource) Size() (int, int) {
+	return s.frame.Rect.Dx(), s.frame.Rect.Dy()
+}
+
+func (s *PlasmaSource) Frame(t float64) (image.Image, bool) {
+
+	index := int(t * s.fps)
+	if index == s.index {
+		return s.frame, false
+	}
+	s.index = index
+
+	// sum of sines over position and time, mapped to a color cycle
+	time := float64(index) / s.fps
+	width, height := s.Size()
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			u, v := float64(x)/float64(width)*8, float64(y)/float64(height)*8
+			value := math.Sin(u+time) + math.Sin((v+time)/2) + math.Sin((u+v+time)/2) + math.Sin(math.Hypot(u-4, v-4)-time*2)
+			i := s.frame.PixOffset(x, y)
+			s.frame.Pix[i+0] = uint8(128 + 127*math.Sin(value*math.Pi/2))
+			s.frame.Pix[i+1] = uint8(128 + 127*math.Sin(value*math.Pi/2+2.1))
+			s.frame.Pix[i+2] = uint8(128 + 127*math.Sin(value*math.Pi/2+4.2))
+			s.frame.Pix[i+3] = 255
+		}
+	}
+
+	return s.frame, true
+
+}
+
+// cloneNRGBA copies an image, e.g. to keep a frame while drawing the next
+func cloneNRGBA(img *image.NRGBA) *image.NRGBA {
+	clone := *img
+	clone.Pix = append([]uint8(nil), img.Pix...)
+	return &clone
+}
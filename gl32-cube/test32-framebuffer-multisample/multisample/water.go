@@ -0,0 +1,191 @@
+package multisample
+
+import (
+	"github.com/go-gl/gl/v3.2-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+const (
+	waterLevel      = 0.15 // world height of the water plane, about where the sand of the terrain ends
+	waterSamples    = 65   // grid vertices per side, displaced by the waves
+	waterDownsample = 2    // the reflection is rendered at half the resolution per axis, ripples blur it anyway
+	waterRippleSize = 256  // size of the generated ripple noise texture in texels
+	waterRippleSeed = 9    // ripple noise seed, same ripples every run
+)
+
+var (
+	ctxWater = &ContextWater{}
+)
+
+// https://www.khronos.org/opengl/wiki/Vertex_Post-Processing#User-defined_clipping
+// https://developer.nvidia.com/gpugems/gpugems/part-i-natural-effects/chapter-1-effective-water-simulation-physical-models
+//
+// ContextWater draws a lake into the valleys of the terrain (-water). The
+// grid is flat on the CPU, the vertex shader displaces it by a sum of sine
+// waves over time and derives the normals from their slopes. Ripples too
+// small for the grid come from a noise texture generated at setup, two
+// layers of it drift across the water and tilt the normals per fragment.
+//
+// The reflection is planar: before the scene, the terrain is drawn a second
+// time into an auxiliary framebuffer, with the camera mirrored at the water
+// plane. A user clip plane (gl_ClipDistance) drops everything below the
+// water, which would otherwise show through the mirror. The water samples
+// the reflection at its own screen position, offset by the wave normals,
+// and blends it over a deep water color by the Fresnel term.
+type ContextWater struct {
+	mesh                 *Mesh
+	program              uint32 // connects vertex and fragment shaders (Water shaders)
+	vbo                  uint32 // stores vertex positions, y is replaced by the waves
+	ibo                  uint32 // stores the triangles of the grid
+	vao                  uint32 // only need to initalize it, we never use it
+	attribVertexPosition uint32 // reference to position input for shader variable (Water shaders)
+	uniformProjection    int32
+	uniformCamera        int32
+	uniformEyePosition   int32
+	material             *Material // reflection and ripple textures
+	ripples              uint32    // tileable noise, x and y tilt in red and green (see newShaderNoiseTexture)
+
+	// auxiliary framebuffer of the reflection, waterDownsample times smaller than renderSize
+	reflection *Pass
+}
+
+// load generates the flat grid of the water plane (CPU only)
+func (ctx *ContextWater) load() {
+	ctx.mesh = makeHeightmapMesh(make([]float32, waterSamples*waterSamples), waterSamples, terrainWidth, 0, false)
+}
+
+func (ctx *ContextWater) setupProgram() {
+
+	var err error
+
+	// configure program, load shaders, and link attributes
+	ctx.program, err = newProgram(vertexShaderWater, fragmentShaderWater)
+	if err != nil {
+		panic(err)
+	}
+	gpuResources.SetLabel(ResourceProgram, ctx.program, "water program")
+	gl.UseProgram(ctx.program)
+
+	// get attribute and uniform index for later use
+	ctx.attribVertexPosition = uint32(gl.GetAttribLocation(ctx.program, gl.Str("vertexPosition\x00")))
+	ctx.uniformProjection = gl.GetUniformLocation(ctx.program, gl.Str("projection\x00"))
+	ctx.uniformCamera = gl.GetUniformLocation(ctx.program, gl.Str("camera\x00"))
+	ctx.uniformEyePosition = gl.GetUniformLocation(ctx.program, gl.Str("uEyePosition\x00"))
+
+	// the same sun as the terrain, for the glint
+	sun := terrainSun.Normalize()
+	gl.Uniform3fv(gl.GetUniformLocation(ctx.program, gl.Str("sunDirection\x00")), 1, &sun[0])
+	gl.Uniform1f(gl.GetUniformLocation(ctx.program, gl.Str("uWaterLevel\x00")), waterLevel)
+
+	// unbind program
+	gl.UseProgram(0)
+
+}
+
+func (ctx *ContextWater) setupBuffers() {
+
+	// create and bind VAO
+	ctx.vao = genVertexArray("water vao")
+	gl.BindVertexArray(ctx.vao)
+
+	// copy vertex data to VBO, the waves only need positions but the mesh layout keeps all
+	m := ctx.mesh
+	ctx.vbo = genBuffer("water vbo")
+	gl.BindBuffer(gl.ARRAY_BUFFER, ctx.vbo)
+	gl.BufferData(gl.ARRAY_BUFFER, m.BytesTotal, nil, gl.STATIC_DRAW)
+	gl.BufferSubData(gl.ARRAY_BUFFER, m.OffsetPositions, len(m.Positions)*bytesFloat32, gl.Ptr(m.Positions))
+	gpuResources.SetBytes(ResourceBuffer, ctx.vbo, m.BytesTotal)
+	gl.BindBuffer(gl.ARRAY_BUFFER, 0)
+
+	// copy index data to IBO
+	ctx.ibo = genBuffer("water ibo")
+	gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, ctx.ibo)
+	gl.BufferData(gl.ELEMENT_ARRAY_BUFFER, len(m.Indices)*bytesUint16, gl.Ptr(m.Indices), gl.STATIC_DRAW)
+	gpuResources.SetBytes(ResourceBuffer, ctx.ibo, len(m.Indices)*bytesUint16)
+	gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, 0)
+
+	// reflection framebuffer, follows renderSize
+	var err error
+	ctx.reflection, err = renderer.Pass("water reflection", PassOptions{Divisor: waterDownsample, Color: gl.RGBA8, Filter: gl.LINEAR, Depth: gl.DEPTH_COMPONENT24})
+	if err != nil {
+		panic(err)
+	}
+
+	// ripples, generated on the GPU
+	ctx.ripples = newShaderNoiseTexture("water ripples", waterRippleSize, waterRippleSeed, 8, 3)
+
+	ctx.material = NewMaterial(ctx.program)
+	ctx.material.SetTexture("reflectionTexture", gl.TEXTURE_2D, ctx.reflection.ColorTexture())
+	ctx.material.SetTexture("rippleTexture", gl.TEXTURE_2D, ctx.ripples)
+
+}
+
+// drawReflection draws the terrain above the water as seen from below it,
+// before the proxy screen is bound since the reflection has its own framebuffer
+func (ctx *ContextWater) drawReflection() {
+
+	// mirror the world at the water plane, then look at it with the camera
+	camera := ctxTerrain.camera
+	mirror := mgl32.Translate3D(0, waterLevel, 0).Mul4(mgl32.Scale3D(1, -1, 1)).Mul4(mgl32.Translate3D(0, -waterLevel, 0))
+	view := camera.View().Mul4(mirror)
+
+	ctx.reflection.Bind()
+	gl.ClearColor(0.5, 0.5, 0.5, 1) // same gray as the proxy screen
+	gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
+	gl.Enable(gl.DEPTH_TEST)
+
+	// only the terrain above the water is reflected, y - waterLevel >= 0
+	gl.Enable(gl.CLIP_DISTANCE0)
+	ctxTerrain.drawFrom(view, camera.Projection(), mgl32.Vec4{0, 1, 0, -waterLevel}, true)
+	gl.Disable(gl.CLIP_DISTANCE0)
+
+	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+
+}
+
+// draw blends the water over the terrain in the proxy screen, after the terrain
+func (ctx *ContextWater) draw() {
+
+	gl.UseProgram(ctx.program)
+
+	// the terrain's camera, every frame since the waves move anyway
+	camera := ctxTerrain.camera
+	projection, view, eye := camera.Projection(), camera.View(), camera.Position()
+	gl.UniformMatrix4fv(ctx.uniformProjection, 1, false, &projection[0])
+	gl.UniformMatrix4fv(ctx.uniformCamera, 1, false, &view[0])
+	gl.Uniform3fv(ctx.uniformEyePosition, 1, &eye[0])
+
+	// shallow water is see-through, more so when looking straight down
+	gl.Enable(gl.BLEND)
+	gl.BlendFunc(gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA)
+
+	// gl.Begin()
+	gl.BindBuffer(gl.ARRAY_BUFFER, ctx.vbo)
+	gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, ctx.ibo)
+	ctx.material.Bind()
+	ctx.mesh.Layout.EnableAttrib("position", ctx.attribVertexPosition)
+	validateDraw("water")
+	gl.DrawElements(gl.TRIANGLES, int32(len(ctx.mesh.Indices)), gl.UNSIGNED_SHORT, nil)
+
+	// gl.End()
+	gl.DisableVertexAttribArray(ctx.attribVertexPosition)
+	ctx.material.Unbind()
+	gl.BindBuffer(gl.ARRAY_BUFFER, 0)
+	gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, 0)
+	gl.Disable(gl.BLEND)
+
+}
+
+func (ctx *ContextWater) destroy() {
+	if ctx.reflection != nil {
+		ctx.reflection.destroy()
+		ctx.reflection = nil
+	}
+	gpuResources.Release(ResourceTexture, ctx.ripples)
+	gpuResources.Release(ResourceVertexArray, ctx.vao)
+	gpuResources.Release(ResourceBuffer, ctx.vbo)
+	gpuResources.Release(ResourceBuffer, ctx.ibo)
+	gpuResources.Release(ResourceProgram, ctx.program)
+	ctx.ripples = 0
+	ctx.vao, ctx.vbo, ctx.ibo, ctx.program = 0, 0, 0, 0
+}
@@ -0,0 +1,126 @@
+package multisample
+
+import (
+	"fmt"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/paperboard/glfw/v3.3/glfw"
+)
+
+// WindowOptions are the properties of the default framebuffer (the real
+// screen) asked for by createWindow. They are hints, the driver may give
+// other bit depths or no sRGB support, GLInfo has what we got.
+//
+// The scene is drawn into offscreen framebuffers, the default framebuffer is
+// only the target of the screen pass and the text overlay. Its alpha matters
+// though: the screen is cleared with ALPHA = 0, which is invisible in an
+// opaque window but shows the desktop through a transparent one.
+//
+// Compositors expect premultiplied alpha (color already scaled by alpha).
+// With -transparent the proxy screen is cleared to transparent black instead
+// of gray, the quads write alpha 1 (uOpaqueAlpha) and the resolved image
+// keeps its alpha (RGBA instead of RGB), so resolving the MSAA samples gives
+// premultiplied edges. The overlays add their alpha as coverage.
+type WindowOptions struct {
+	SRGB        bool // sRGB-capable, writes are encoded while GL_FRAMEBUFFER_SRGB is enabled
+	Transparent bool // composite the window with the desktop by alpha, needs alpha bits
+
+	RedBits     int
+	GreenBits   int
+	BlueBits    int
+	AlphaBits   int // 0 = no alpha channel
+	DepthBits   int
+	StencilBits int
+}
+
+// newWindowOptions are the glfw defaults, changed by the -srgb, -transparent and -alphabits flags
+func newWindowOptions() WindowOptions {
+	return WindowOptions{
+		SRGB:        *srgbScreen,
+		Transparent: *transparentMode,
+		RedBits:     8,
+		GreenBits:   8,
+		BlueBits:    8,
+		AlphaBits:   *screenAlphaBits,
+		DepthBits:   24,
+		StencilBits: 8,
+	}
+}
+
+// hint passes the options to glfw, for the next glfw.CreateWindow
+func (o WindowOptions) hint() {
+	if o.Transparent && o.AlphaBits == 0 {
+		o.AlphaBits = 8 // without alpha there is nothing to composite by
+	}
+	glfw.WindowHint(glfw.SRGBCapable, boolHint(o.SRGB))
+	glfw.WindowHint(glfw.TransparentFramebuffer, boolHint(o.Transparent))
+	glfw.WindowHint(glfw.RedBits, o.RedBits)
+	glfw.WindowHint(glfw.GreenBits, o.GreenBits)
+	glfw.WindowHint(glfw.BlueBits, o.BlueBits)
+	glfw.WindowHint(glfw.AlphaBits, o.AlphaBits)
+	glfw.WindowHint(glfw.DepthBits, o.DepthBits)
+	glfw.WindowHint(glfw.StencilBits, o.StencilBits)
+}
+
+// boolHint converts to glfw.True or glfw.False
+func boolHint(b bool) int {
+	if b {
+		return glfw.True
+	}
+	return glfw.False
+}
+
+const (
+	opacityStep = 0.1 // opacity change per [ or ] key press
+	minOpacity  = 0.2 // lowest opacity the keys go to, so the window stays findable
+)
+
+// WindowAttributes are the properties of the window that can change while it
+// is open, unlike WindowOptions: opacity, floating (always on top) and
+// decorated (title bar and border). Tools laying the renderer over other
+// windows change them at runtime, createWindow applies the current ones to a
+// new window (also after context recovery). Window managers may ignore them,
+// e.g. Wayland has no window opacity.
+type WindowAttributes struct {
+	Opacity   float32 // 1 = opaque, the whole window including decorations
+	Floating  bool    // stay above other windows
+	Decorated bool    // title bar and border
+}
+
+// newWindowAttributes reads the -opacity, -ontop and -undecorated flags
+func newWindowAttributes() *WindowAttributes {
+	return &WindowAttributes{
+		Opacity:   mgl32.Clamp(float32(*windowOpacity), minOpacity, 1),
+		Floating:  *alwaysOnTop,
+		Decorated: !*undecorated,
+	}
+}
+
+// apply sets all attributes on the window
+func (a *WindowAttributes) apply(window *glfw.Window) {
+	window.SetOpacity(a.Opacity)
+	window.SetAttrib(glfw.Floating, boolHint(a.Floating))
+	window.SetAttrib(glfw.Decorated, boolHint(a.Decorated))
+}
+
+// SetOpacity changes the opacity of the window, minOpacity..1
+func (a *WindowAttributes) SetOpacity(window *glfw.Window, opacity float32) {
+	a.Opacity = mgl32.Clamp(opacity, minOpacity, 1)
+	window.SetOpacity(a.Opacity)
+}
+
+// SetFloating keeps the window above other windows, or not
+func (a *WindowAttributes) SetFloating(window *glfw.Window, floating bool) {
+	a.Floating = floating
+	window.SetAttrib(glfw.Floating, boolHint(floating))
+}
+
+// SetDecorated shows or hides the title bar and border
+func (a *WindowAttributes) SetDecorated(window *glfw.Window, decorated bool) {
+	a.Decorated = decorated
+	window.SetAttrib(glfw.Decorated, boolHint(decorated))
+}
+
+func (a *WindowAttributes) String() string {
+	return fmt.Sprintf("opacity %.1f, floating %v, decorated %v", a.Opacity, a.Floating, a.Decorated)
+}
@@ -0,0 +1,125 @@
+package multisample
+
+import (
+	"fmt"
+
+	"github.com/go-gl/gl/v3.2-core/gl"
+	"github.com/paperboard/glfw/v3.3/glfw"
+)
+
+var (
+	sharedWindows = &SharedWindows{}
+)
+
+// SharedWindow is an extra window (-windows) whose context shares the GL
+// objects of the main window: buffers, textures, shaders and programs are
+// built once and used by both. Container objects (VAOs, framebuffers) and
+// all state (bindings, viewport) are not shared, the window has its own VAO
+// and draws into its own default framebuffer.
+type SharedWindow struct {
+	window *glfw.Window
+	vao    uint32 // belongs to the window's context, not tracked by gpuResources
+}
+
+// SharedWindows are the extra windows. Each frame they show the image the
+// screen pass of the main window draws (see ContextScreen.drawWindow), the
+// scene and post effects render once for all windows. Only the msaa scene
+// has a screen pass, the windows keep their last image during others. The overlays (text,
+// graph) are drawn straight onto the main window and only show there.
+type SharedWindows struct {
+	windows []*SharedWindow
+}
+
+// open creates count windows sharing the objects of main's context, main's
+// context is current again when it returns
+func (s *SharedWindows) open(main *glfw.Window, count int) {
+
+	for i := 0; i < count; i++ {
+
+		// same context version and framebuffer as the main window, see createWindow
+		glfw.WindowHint(glfw.ContextVersionMajor, 3)
+		glfw.WindowHint(glfw.ContextVersionMinor, 2)
+		glfw.WindowHint(glfw.OpenGLProfile, glfw.OpenGLCoreProfile)
+		glfw.WindowHint(glfw.ContextRobustness, glfw.LoseContextOnReset)
+		newWindowOptions().hint()
+
+		window, err := glfw.CreateWindow(windowWidth, windowHeight, fmt.Sprintf("%v (%v)", windowTitle, i+2), nil, main)
+		if err != nil {
+			fmt.Println("WINDOWS -- failed to open a shared window:", err)
+			break
+		}
+
+		// the main window holds the frame rate (see frameLimiter), extra windows do not wait for vsync
+		window.MakeContextCurrent()
+		glfw.SwapInterval(0)
+		windowAttributes.apply(window)
+		window.SetKeyCallback(events.keyCallback)
+
+		w := &SharedWindow{window: window}
+		gl.GenVertexArrays(1, &w.vao)
+		s.windows = append(s.windows, w)
+
+	}
+
+	main.MakeContextCurrent()
+
+}
+
+// draw shows the screen image in every extra window and closes the ones the
+// user closed, main's context is current again when it returns
+func (s *SharedWindows) draw(main *glfw.Window) {
+
+	if len(s.windows) == 0 || launcher.Current() != msaaScene {
+		return
+	}
+
+	// the main context must finish the frame before other contexts sample it
+	gl.Flush()
+
+	open := s.windows[:0]
+	for _, w := range s.windows {
+
+		w.window.MakeContextCurrent()
+		if w.window.ShouldClose() {
+			w.close()
+			continue
+		}
+
+		gl.BindVertexArray(w.vao)
+		width, height := w.window.GetFramebufferSize()
+		ctxScreen.drawWindow(int32(width), int32(height))
+		w.window.SwapBuffers()
+		open = append(open, w)
+
+	}
+	s.windows = open
+
+	main.MakeContextCurrent()
+
+}
+
+// destroy closes every extra window, before the main window, main's context
+// is current again when it returns
+func (s *SharedWindows) destroy(main *glfw.Window) {
+	for _, w := range s.windows {
+		w.window.MakeContextCurrent()
+		w.close()
+	}
+	s.windows = nil
+	main.MakeContextCurrent()
+}
+
+// forget drops the windows of a lost context (see ContextRecovery), without
+// calling glDelete*, the VAOs died with the context
+func (s *SharedWindows) forget() {
+	for _, w := range s.windows {
+		w.window.Destroy()
+	}
+	s.windows = nil
+}
+
+// close deletes the window's VAO and the window, its context must be current
+func (w *SharedWindow) close() {
+	gl.DeleteVertexArrays(1, &w.vao)
+	w.window.Destroy()
+}
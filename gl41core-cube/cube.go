@@ -6,6 +6,7 @@
 package main // import "github.com/go-gl/example/gl41core-cube"
 
 import (
+	"flag"
 	"fmt"
 	"image"
 	"image/draw"
@@ -15,6 +16,7 @@ import (
 	"runtime"
 	"strings"
 
+	"github.com/go-gl/example/internal/capture"
 	"github.com/go-gl/gl/v4.1-core/gl"
 	"github.com/go-gl/glfw/v3.3/glfw"
 	"github.com/go-gl/mathgl/mgl32"
@@ -29,6 +31,8 @@ func init() {
 }
 
 func main() {
+	flag.Parse()
+
 	if err := glfw.Init(); err != nil {
 		log.Fatalln("failed to initialize glfw:", err)
 	}
@@ -39,6 +43,9 @@ func main() {
 	glfw.WindowHint(glfw.ContextVersionMinor, 1)
 	glfw.WindowHint(glfw.OpenGLProfile, glfw.OpenGLCoreProfile)
 	glfw.WindowHint(glfw.OpenGLForwardCompatible, glfw.True)
+	if capture.Enabled() {
+		glfw.WindowHint(glfw.Visible, glfw.False) // thumbnail and screenshot mode render offscreen
+	}
 	window, err := glfw.CreateWindow(windowWidth, windowHeight, "Cube", nil, nil)
 	if err != nil {
 		panic(err)
@@ -132,6 +139,13 @@ func main() {
 
 		gl.DrawArrays(gl.TRIANGLES, 0, 6*2*3)
 
+		// Thumbnail and screenshot mode, save the first frame and quit
+		if capture.Enabled() {
+			width, height := window.GetFramebufferSize()
+			capture.Write(width, height, gl.ReadPixels)
+			break
+		}
+
 		// Maintenance
 		window.SwapBuffers()
 		glfw.PollEvents()
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"image/color"
 	"log"
@@ -9,6 +10,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/go-gl/example/internal/capture"
 	gl "github.com/go-gl/gl/v3.1/gles2"
 	"github.com/go-gl/glfw/v3.3/glfw"
 	"github.com/go-gl/mathgl/mgl32"
@@ -29,11 +31,13 @@ const (
 	verticesPerQuad    = 4   // a rectangle has 4 vertices
 	indicesPerQuad     = 6   // a rectangle has 6 indices
 	msaaSamples        = 8   // use 8 subsamples per pixel, for multi-sample anti-aliasing (MSAA), to smooth edges
+	captureSeed        = 1   // random colors seed for -thumbnail and -screenshot, same colors every run
 )
 
 var (
-	dpiScaleX float32 // to adjust width for high dpi/resolution monitors
-	dpiScaleY float32 // to adjust height for high dpi/resolution monitors
+	dpiScaleX float32    // to adjust width for high dpi/resolution monitors
+	dpiScaleY float32    // to adjust height for high dpi/resolution monitors
+	colorRand *rand.Rand // random colors of the rectangles, see RandomColorInRGBA
 )
 
 var (
@@ -102,6 +106,16 @@ func init() {
 
 func main() {
 
+	// parse command line flags, see -thumbnail and -screenshot
+	flag.Parse()
+
+	// random colors, the same every run in thumbnail and screenshot mode (see tools/imagediff)
+	seed := time.Now().UnixNano()
+	if capture.Enabled() {
+		seed = captureSeed
+	}
+	colorRand = rand.New(rand.NewSource(seed))
+
 	// initalize glfw
 	err := glfw.Init()
 	if err != nil {
@@ -128,6 +142,11 @@ func main() {
 	// suggest glfw to disable window resizing
 	glfw.WindowHint(glfw.Resizable, glfw.False)
 
+	// thumbnail and screenshot mode render offscreen, no need to show the window
+	if capture.Enabled() {
+		glfw.WindowHint(glfw.Visible, glfw.False)
+	}
+
 	// create window handle
 	window, err := glfw.CreateWindow(windowWidth, windowHeight, "Quad 3D Multisample", nil, nil)
 	if err != nil {
@@ -163,6 +182,13 @@ func main() {
 		// draw into buffer
 		draw()
 
+		// thumbnail and screenshot mode, save the first frame and quit (see tools/thumbnails and tools/imagediff)
+		if capture.Enabled() {
+			width, height := window.GetFramebufferSize()
+			capture.Write(width, height, gl.ReadPixels)
+			break
+		}
+
 		// quick hack to slow down rendering
 		time.Sleep(time.Second)
 
@@ -456,10 +482,9 @@ func (ctx *ContextFramebufferMultisample) draw() {
 
 // RandomColorInRGB
 func RandomColorInRGBA() color.NRGBA {
-	rand.Seed(time.Now().UnixNano())
-	r := uint8(rand.Intn(0xff))
-	g := uint8(rand.Intn(0xff))
-	b := uint8(rand.Intn(0xff))
+	r := uint8(colorRand.Intn(0xff))
+	g := uint8(colorRand.Intn(0xff))
+	b := uint8(colorRand.Intn(0xff))
 	a := uint8(1)
 	return color.NRGBA{r, g, b, a}
 }
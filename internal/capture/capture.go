@@ -0,0 +1,163 @@
+// Package capture saves the first frame of an example as a png thumbnail
+// (-thumbnail, used by tools/thumbnails) or screenshot (-screenshot, used by
+// tools/imagediff) and lets it exit instead of running.
+//
+// It works with any OpenGL binding, the examples pass the ReadPixels
+// function of theirs:
+//
+//	if capture.Enabled() {
+//		width, height := window.GetFramebufferSize()
+//		capture.Write(width, height, gl.ReadPixels)
+//	}
+package capture
+
+import (
+	"flag"
+	"fmt"
+	"image"
+	"image/png"
+	"log"
+	"os"
+	"unsafe"
+)
+
+const (
+	thumbnailWidth  = 300 // max thumbnail width, see -thumbnail flag
+	thumbnailHeight = 200 // max thumbnail height, see -thumbnail flag
+
+	// enums of glReadPixels, the same in every binding (GL 2.1 up, GLES 2)
+	glRGBA         = 0x1908
+	glUnsignedByte = 0x1401
+)
+
+var (
+	thumbnailPath  = flag.String("thumbnail", "", "render a single frame in a hidden window, save it as png thumbnail to this path and exit (see tools/thumbnails)")
+	screenshotPath = flag.String("screenshot", "", "same as -thumbnail, but save the frame at full resolution (see tools/imagediff)")
+)
+
+// ReadPixelsFunc is glReadPixels of an OpenGL binding, e.g. gl.ReadPixels
+// of github.com/go-gl/gl/v2.1/gl or gles2.ReadPixels
+type ReadPixelsFunc func(x, y, width, height int32, format, xtype uint32, pixels unsafe.Pointer)
+
+// Enabled is whether to save the first frame and exit instead of running
+func Enabled() bool {
+	return *thumbnailPath != "" || *screenshotPath != ""
+}
+
+// Write reads the frame just drawn into the bound read framebuffer (the
+// back buffer by default) and saves it, see Save. Call it before
+// window.SwapBuffers.
+func Write(width, height int, readPixels ReadPixelsFunc) {
+	img := ReadImage(0, 0, width, height, readPixels)
+
+	// the window may be cleared with ALPHA = 0, make the image opaque
+	for i := 3; i < len(img.Pix); i += 4 {
+		img.Pix[i] = 0xff
+	}
+
+	Save(img)
+}
+
+// ReadImage reads a rectangle of the bound read framebuffer into an image.
+// OpenGL's origin is bottom-left while image's is top-left, so rows are flipped.
+// RGBA rows are a whole number of 4 bytes, they are tightly packed at the
+// default GL_PACK_ALIGNMENT.
+func ReadImage(x, y, width, height int, readPixels ReadPixelsFunc) *image.NRGBA {
+
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	if width == 0 || height == 0 {
+		return img
+	}
+	readPixels(int32(x), int32(y), int32(width), int32(height), glRGBA, glUnsignedByte, unsafe.Pointer(&img.Pix[0]))
+
+	// flip rows
+	stride := img.Stride
+	row := make([]uint8, stride)
+	for top, bottom := 0, height-1; top < bottom; top, bottom = top+1, bottom-1 {
+		copy(row, img.Pix[top*stride:(top+1)*stride])
+		copy(img.Pix[top*stride:(top+1)*stride], img.Pix[bottom*stride:(bottom+1)*stride])
+		copy(img.Pix[bottom*stride:(bottom+1)*stride], row)
+	}
+
+	return img
+
+}
+
+// Save saves a frame as a small png thumbnail (-thumbnail) and/or as a full
+// resolution png screenshot (-screenshot), empty paths are skipped
+func Save(img *image.NRGBA) {
+
+	if *thumbnailPath != "" {
+		if err := SavePNG(*thumbnailPath, Downscale(img, thumbnailWidth, thumbnailHeight)); err != nil {
+			log.Fatalln("failed to write thumbnail:", err)
+		}
+		fmt.Println("thumbnail written to", *thumbnailPath)
+	}
+
+	if *screenshotPath != "" {
+		if err := SavePNG(*screenshotPath, img); err != nil {
+			log.Fatalln("failed to write screenshot:", err)
+		}
+		fmt.Println("screenshot written to", *screenshotPath)
+	}
+
+}
+
+// Downscale shrinks an image to fit into width x height (keeping aspect ratio) using a box filter
+func Downscale(src *image.NRGBA, width, height int) *image.NRGBA {
+
+	sw, sh := src.Bounds().Dx(), src.Bounds().Dy()
+	scale := float64(sw) / float64(width)
+	if s := float64(sh) / float64(height); s > scale {
+		scale = s
+	}
+	if scale <= 1 {
+		return src
+	}
+	dw, dh := int(float64(sw)/scale), int(float64(sh)/scale)
+
+	dst := image.NewNRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		y0, y1 := int(float64(y)*scale), int(float64(y+1)*scale)
+		for x := 0; x < dw; x++ {
+			x0, x1 := int(float64(x)*scale), int(float64(x+1)*scale)
+
+			// average every source pixel covered by the destination pixel
+			var r, g, b, a, n int
+			for sy := y0; sy < y1 && sy < sh; sy++ {
+				for sx := x0; sx < x1 && sx < sw; sx++ {
+					i := src.PixOffset(sx, sy)
+					r += int(src.Pix[i+0])
+					g += int(src.Pix[i+1])
+					b += int(src.Pix[i+2])
+					a += int(src.Pix[i+3])
+					n++
+				}
+			}
+			if n == 0 {
+				continue
+			}
+			i := dst.PixOffset(x, y)
+			dst.Pix[i+0] = uint8(r / n)
+			dst.Pix[i+1] = uint8(g / n)
+			dst.Pix[i+2] = uint8(b / n)
+			dst.Pix[i+3] = uint8(a / n)
+		}
+	}
+
+	return dst
+
+}
+
+// SavePNG writes an image to a png file
+func SavePNG(path string, img image.Image) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(f, img); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
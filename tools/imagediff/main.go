@@ -0,0 +1,279 @@
+// Compares two renderings of the same scene, e.g. an example against a
+// golden image saved before a change.
+//
+// Each argument is either a png file or an example directory. Example
+// directories are built and run with the -screenshot flag (see
+// internal/capture), which renders a single frame in a hidden window and
+// saves it at full resolution. cmd/demos and the multisample example draw
+// that frame with fixed random colors at update time 0, and the gles20
+// framebuffer example with fixed random colors, so two runs compare; other
+// examples with random colors or wall clock animations do not.
+//
+// The framebuffer examples of gl21-cube and gles20-cube draw the same two
+// rectangles through the same camera with OpenGL 2.1 and OpenGL ES 2.0, so
+// comparing them compares the backends. Their colors and anti-aliasing
+// differ (gles20 picks random colors and renders with MSAA), expect an SSIM
+// below 1 and lower -min-ssim. The OpenGL 3.2 multisample example draws the
+// same rectangles under more quads and overlays, it compares only roughly.
+//
+//	go run ./tools/imagediff -diff diff.png gl21-cube/test21-framebuffer gles20-cube/test20-framebuffer-multisample
+//
+// Reports per-pixel differences and the structural similarity index (SSIM)
+// of the luminance, and exits with status 1 when SSIM is below -min-ssim.
+//
+//	go run ./tools/imagediff golden.png cmd/demos
+//	go run ./tools/imagediff -diff diff.png a.png b.png
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io/ioutil"
+	"log"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+)
+
+const (
+	ssimWindow = 8 // SSIM is computed over ssimWindow x ssimWindow blocks
+	ssimStep   = 4 // blocks overlap by half
+)
+
+var (
+	minSSIM   = flag.Float64("min-ssim", 0.98, "exit with status 1 when SSIM is lower than this")
+	threshold = flag.Int("threshold", 8, "per channel difference (0-255) above which a pixel counts as different")
+	diffPath  = flag.String("diff", "", "write a png highlighting differing pixels to this path")
+	timeout   = flag.Duration("timeout", 30*time.Second, "max time an example may take to render its screenshot")
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: imagediff [flags] <png or example dir> <png or example dir>")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 2 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	a, err := loadOrRender(flag.Arg(0))
+	if err != nil {
+		log.Fatalln(err)
+	}
+	b, err := loadOrRender(flag.Arg(1))
+	if err != nil {
+		log.Fatalln(err)
+	}
+	if a.Bounds().Size() != b.Bounds().Size() {
+		log.Fatalf("image sizes differ: %v vs %v\n", a.Bounds().Size(), b.Bounds().Size())
+	}
+
+	d := comparePixels(a, b, uint8(*threshold))
+	ssim := meanSSIM(luminance(a), luminance(b), a.Bounds().Dx(), a.Bounds().Dy())
+
+	fmt.Printf("size          %v\n", a.Bounds().Size())
+	fmt.Printf("pixels diff   %v of %v (%.3f%%, threshold %v)\n", d.count, d.total, 100*float64(d.count)/float64(d.total), *threshold)
+	fmt.Printf("max diff      %v\n", d.max)
+	fmt.Printf("mean abs diff %.3f\n", d.meanAbs)
+	fmt.Printf("SSIM          %.5f\n", ssim)
+
+	if *diffPath != "" {
+		if err := savePNG(*diffPath, d.image); err != nil {
+			log.Fatalln("failed to write diff image:", err)
+		}
+	}
+
+	if ssim < *minSSIM {
+		fmt.Printf("FAIL: SSIM %.5f < %.5f\n", ssim, *minSSIM)
+		os.Exit(1)
+	}
+}
+
+// loadOrRender loads a png, or renders a screenshot if path is an example directory
+func loadOrRender(path string) (*image.NRGBA, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return loadPNG(path)
+	}
+
+	tmp, err := ioutil.TempDir("", "imagediff")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+
+	// build first, so the timeout only applies to rendering (cgo builds are slow)
+	bin := filepath.Join(tmp, "example")
+	build := exec.Command("go", "build", "-o", bin, ".")
+	build.Dir = path
+	if out, err := build.CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("build %v: %v\n%s", path, err, out)
+	}
+
+	screenshot := filepath.Join(tmp, "screenshot.png")
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+	run := exec.CommandContext(ctx, bin, "-screenshot", screenshot)
+	run.Dir = path // examples load assets relative to their directory
+	if out, err := run.CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("render %v (does it support -screenshot?): %v\n%s", path, err, out)
+	}
+
+	return loadPNG(screenshot)
+}
+
+func loadPNG(path string) (*image.NRGBA, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	src, err := png.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %v", path, err)
+	}
+
+	// normalize to NRGBA with origin at 0,0
+	b := src.Bounds()
+	img := image.NewNRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	for y := 0; y < b.Dy(); y++ {
+		for x := 0; x < b.Dx(); x++ {
+			img.Set(x, y, src.At(b.Min.X+x, b.Min.Y+y))
+		}
+	}
+	return img, nil
+}
+
+func savePNG(path string, img image.Image) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(f, img); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// pixelDiff summarizes per-pixel differences
+type pixelDiff struct {
+	count   int         // pixels where any channel differs more than threshold
+	total   int         // pixels compared
+	max     uint8       // largest channel difference
+	meanAbs float64     // mean absolute channel difference
+	image   *image.Gray // white where pixels differ, scaled by difference
+}
+
+func comparePixels(a, b *image.NRGBA, threshold uint8) pixelDiff {
+	w, h := a.Bounds().Dx(), a.Bounds().Dy()
+	d := pixelDiff{total: w * h, image: image.NewGray(image.Rect(0, 0, w, h))}
+	sum := 0
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			i, j := a.PixOffset(x, y), b.PixOffset(x, y)
+			var pixelMax uint8
+			for c := 0; c < 4; c++ {
+				diff := absDiff(a.Pix[i+c], b.Pix[j+c])
+				sum += int(diff)
+				if diff > pixelMax {
+					pixelMax = diff
+				}
+			}
+			if pixelMax > d.max {
+				d.max = pixelMax
+			}
+			if pixelMax > threshold {
+				d.count++
+				d.image.SetGray(x, y, color.Gray{Y: 0x80 + pixelMax/2})
+			}
+		}
+	}
+	d.meanAbs = float64(sum) / float64(d.total*4)
+	return d
+}
+
+func absDiff(a, b uint8) uint8 {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
+
+// luminance converts an image to Rec. 601 luma, premultiplied by alpha
+func luminance(img *image.NRGBA) []float64 {
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	lum := make([]float64, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			i := img.PixOffset(x, y)
+			r, g, b, a := float64(img.Pix[i]), float64(img.Pix[i+1]), float64(img.Pix[i+2]), float64(img.Pix[i+3])
+			lum[y*w+x] = (0.299*r + 0.587*g + 0.114*b) * a / 255
+		}
+	}
+	return lum
+}
+
+// meanSSIM is the mean structural similarity over overlapping windows
+// https://en.wikipedia.org/wiki/Structural_similarity
+func meanSSIM(a, b []float64, w, h int) float64 {
+	const (
+		c1 = (0.01 * 255) * (0.01 * 255)
+		c2 = (0.03 * 255) * (0.03 * 255)
+	)
+	if w < ssimWindow || h < ssimWindow {
+		return ssimBlock(a, b, w, 0, 0, w, h, c1, c2)
+	}
+	sum, n := 0.0, 0
+	for y := 0; y+ssimWindow <= h; y += ssimStep {
+		for x := 0; x+ssimWindow <= w; x += ssimStep {
+			sum += ssimBlock(a, b, w, x, y, ssimWindow, ssimWindow, c1, c2)
+			n++
+		}
+	}
+	return sum / float64(n)
+}
+
+func ssimBlock(a, b []float64, stride, x0, y0, bw, bh int, c1, c2 float64) float64 {
+	n := float64(bw * bh)
+	if n == 0 {
+		return 1
+	}
+	if n < 2 {
+		return 1 - math.Abs(a[y0*stride+x0]-b[y0*stride+x0])/255
+	}
+	var meanA, meanB float64
+	for y := y0; y < y0+bh; y++ {
+		for x := x0; x < x0+bw; x++ {
+			meanA += a[y*stride+x]
+			meanB += b[y*stride+x]
+		}
+	}
+	meanA /= n
+	meanB /= n
+	var varA, varB, cov float64
+	for y := y0; y < y0+bh; y++ {
+		for x := x0; x < x0+bw; x++ {
+			da, db := a[y*stride+x]-meanA, b[y*stride+x]-meanB
+			varA += da * da
+			varB += db * db
+			cov += da * db
+		}
+	}
+	varA /= n - 1
+	varB /= n - 1
+	cov /= n - 1
+	ssim := ((2*meanA*meanB + c1) * (2*cov + c2)) / ((meanA*meanA + meanB*meanB + c1) * (varA + varB + c2))
+	return math.Max(-1, math.Min(1, ssim))
+}
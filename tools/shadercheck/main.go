@@ -0,0 +1,256 @@
+// Validates the GLSL shaders embedded in every example with glslangValidator.
+//
+// Shaders are the string variables and constants named vertexShader*,
+// fragmentShader* and geometryShader*, and the files with the extensions
+// .vert, .frag and .geom (e.g. embedded with go:embed). Each is checked for
+// the dialect its #version line declares (100 for GLES2, 120 for GL 2.1, 150
+// and 330 for core profiles), so a syntax error in the GLES2 shaders shows
+// up without running that backend. Errors in Go strings are reported at the
+// Go source line, e.g.
+//
+//	gl32-cube/test32-framebuffer-multisample/multisample/water.go:251: fragmentShaderWater: 'foo' : undeclared identifier
+//
+// The exit status is 1 if any shader fails, so it can run from go:generate:
+//
+//	go run ./tools/shadercheck
+//
+// glslangValidator is part of the Khronos reference compiler, packaged as
+// glslang-tools or glslang on most systems.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	root      = flag.String("root", ".", "directory to search for Go files holding shaders")
+	validator = flag.String("glslang", "glslangValidator", "glslangValidator binary")
+	verbose   = flag.Bool("v", false, "also print the shaders that pass")
+)
+
+// stages are the shader name prefixes and the file extension glslangValidator takes the stage from
+var stages = []struct{ prefix, ext string }{
+	{"vertexShader", "vert"},
+	{"fragmentShader", "frag"},
+	{"geometryShader", "geom"},
+}
+
+var (
+	versionPattern = regexp.MustCompile(`(?m)^\s*#version\s+(\d+)`)
+	messagePattern = regexp.MustCompile(`^(ERROR|WARNING): [^:]*:(\d+): (.*)$`)
+)
+
+// shader is a GLSL source found in a Go file
+type shader struct {
+	name   string // Go identifier, e.g. fragmentShaderWater, or file name
+	file   string
+	line   int    // line of the first source line in file
+	ext    string // stage, see stages
+	source string
+}
+
+func main() {
+	flag.Parse()
+
+	if _, err := exec.LookPath(*validator); err != nil {
+		log.Fatalln("failed to find glslangValidator, install glslang or pass -glslang:", err)
+	}
+
+	shaders, err := findShaders(*root)
+	if err != nil {
+		log.Fatalln("failed to find shaders:", err)
+	}
+
+	tmp, err := ioutil.TempDir("", "shadercheck")
+	if err != nil {
+		log.Fatalln("failed to create temp dir:", err)
+	}
+	defer os.RemoveAll(tmp)
+
+	failed := 0
+	for _, s := range shaders {
+		messages, ok, err := validate(tmp, s)
+		if err != nil {
+			log.Fatalln("failed to run glslangValidator:", err)
+		}
+		for _, message := range messages {
+			fmt.Println(message)
+		}
+		if !ok {
+			failed++
+		} else if *verbose {
+			fmt.Printf("OK   %v:%v: %v\n", s.file, s.line, s.name)
+		}
+	}
+
+	fmt.Printf("%v shaders, %v failed\n", len(shaders), failed)
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// findShaders parses every Go file below root and returns its shader variables
+func findShaders(root string) ([]shader, error) {
+	var shaders []shader
+	fset := token.NewFileSet()
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() && (info.Name() == ".git" || info.Name() == "tools") {
+			return filepath.SkipDir
+		}
+		if ext := strings.TrimPrefix(filepath.Ext(path), "."); !info.IsDir() && isStage(ext) {
+			source, err := ioutil.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			shaders = append(shaders, shader{name: filepath.Base(path), file: path, line: 1, ext: ext, source: string(source)})
+			return nil
+		}
+		if info.IsDir() || !strings.HasSuffix(path, ".go") || strings.HasSuffix(path, "_test.go") {
+			return nil
+		}
+		f, err := parser.ParseFile(fset, path, nil, 0)
+		if err != nil {
+			return err
+		}
+		for _, decl := range f.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || (gen.Tok != token.VAR && gen.Tok != token.CONST) {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				shaders = append(shaders, specShaders(fset, path, spec.(*ast.ValueSpec))...)
+			}
+		}
+		return nil
+	})
+	sort.Slice(shaders, func(i, j int) bool {
+		if shaders[i].file != shaders[j].file {
+			return shaders[i].file < shaders[j].file
+		}
+		return shaders[i].line < shaders[j].line
+	})
+	return shaders, err
+}
+
+// specShaders returns the shaders declared by a var or const spec
+func specShaders(fset *token.FileSet, path string, spec *ast.ValueSpec) []shader {
+	var shaders []shader
+	for i, name := range spec.Names {
+		if i >= len(spec.Values) {
+			break
+		}
+		ext := stageOf(name.Name)
+		if ext == "" {
+			continue
+		}
+		lit, source, ok := stringValue(spec.Values[i])
+		if !ok {
+			fmt.Printf("SKIP %v: %v is not a string literal\n", fset.Position(name.Pos()), name.Name)
+			continue
+		}
+		shaders = append(shaders, shader{
+			name:   name.Name,
+			file:   path,
+			line:   fset.Position(lit.Pos()).Line,
+			ext:    ext,
+			source: strings.TrimRight(source, "\x00"),
+		})
+	}
+	return shaders
+}
+
+// stageOf is the stage extension of a shader name, "" for other names
+func stageOf(name string) string {
+	for _, stage := range stages {
+		if strings.HasPrefix(name, stage.prefix) {
+			return stage.ext
+		}
+	}
+	return ""
+}
+
+// isStage reports whether ext is the extension of a shader file
+func isStage(ext string) bool {
+	for _, stage := range stages {
+		if ext == stage.ext {
+			return true
+		}
+	}
+	return false
+}
+
+// stringValue evaluates a string literal, or literals joined by +, e.g.
+// `...` + "\x00". lit is the first literal, where the source starts.
+func stringValue(expr ast.Expr) (lit *ast.BasicLit, value string, ok bool) {
+	switch e := expr.(type) {
+	case *ast.BasicLit:
+		if e.Kind != token.STRING {
+			return nil, "", false
+		}
+		value, err := strconv.Unquote(e.Value)
+		return e, value, err == nil
+	case *ast.BinaryExpr:
+		if e.Op != token.ADD {
+			return nil, "", false
+		}
+		lit, x, ok := stringValue(e.X)
+		if !ok {
+			return nil, "", false
+		}
+		_, y, ok := stringValue(e.Y)
+		return lit, x + y, ok
+	case *ast.ParenExpr:
+		return stringValue(e.X)
+	}
+	return nil, "", false
+}
+
+// validate runs glslangValidator on a shader and translates its messages to
+// Go source lines, ok is false if the shader has errors
+func validate(tmp string, s shader) (messages []string, ok bool, err error) {
+
+	if !versionPattern.MatchString(s.source) {
+		return []string{fmt.Sprintf("%v:%v: %v: no #version line", s.file, s.line, s.name)}, false, nil
+	}
+
+	path := filepath.Join(tmp, s.name+"."+s.ext)
+	if err := ioutil.WriteFile(path, []byte(s.source), 0644); err != nil {
+		return nil, false, err
+	}
+
+	out, err := exec.Command(*validator, path).CombinedOutput()
+	if _, exited := err.(*exec.ExitError); err != nil && !exited {
+		return nil, false, err
+	}
+
+	for _, line := range bytes.Split(out, []byte("\n")) {
+		m := messagePattern.FindStringSubmatch(string(bytes.TrimSpace(line)))
+		if m == nil {
+			continue
+		}
+		// a raw string starts on the line of its backquote, source line 1
+		sourceLine, _ := strconv.Atoi(m[2])
+		messages = append(messages, fmt.Sprintf("%v:%v: %v: %v", s.file, s.line+sourceLine-1, s.name, m[3]))
+	}
+
+	// glslangValidator exits with a non-zero status on errors
+	return messages, err == nil, nil
+
+}
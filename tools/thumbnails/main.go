@@ -0,0 +1,184 @@
+// Renders a thumbnail of every example and writes a markdown index of them.
+//
+// Each example directory is built and run with the -thumbnail flag, which
+// renders a single frame in a hidden window and saves it as thumbnail.png
+// next to the example source (see internal/capture). Examples without the
+// flag, e.g. new ones, are skipped and listed at the end.
+//
+//	go run ./tools/thumbnails
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	thumbnailFile  = "thumbnail.png"
+	repoImportPath = "github.com/go-gl/example" // import path of the root, for the packages examples import
+)
+
+var (
+	root    = flag.String("root", ".", "repository root to search for examples")
+	index   = flag.String("index", "THUMBNAILS.md", "markdown index to write (relative to root), empty to skip")
+	timeout = flag.Duration("timeout", 30*time.Second, "max time an example may take to render its thumbnail")
+)
+
+func main() {
+	flag.Parse()
+
+	examples, err := findExamples(*root)
+	if err != nil {
+		log.Fatalln("failed to find examples:", err)
+	}
+
+	var rendered, skipped []string
+	for _, dir := range examples {
+		if !supportsThumbnail(dir) {
+			fmt.Printf("SKIP %v (no -thumbnail flag)\n", dir)
+			skipped = append(skipped, dir)
+			continue
+		}
+		if err := renderThumbnail(dir); err != nil {
+			fmt.Printf("FAIL %v: %v\n", dir, err)
+			continue
+		}
+		fmt.Printf("OK   %v\n", dir)
+		rendered = append(rendered, dir)
+	}
+
+	if *index != "" {
+		if err := writeIndex(filepath.Join(*root, *index), rendered); err != nil {
+			log.Fatalln("failed to write index:", err)
+		}
+	}
+	if len(skipped) > 0 {
+		fmt.Printf("%v examples without thumbnail, add the -thumbnail flag to: %v\n", len(skipped), strings.Join(skipped, ", "))
+	}
+}
+
+// findExamples returns every directory below root holding a main package, except tools
+func findExamples(root string) ([]string, error) {
+	dirs := map[string]bool{}
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() && (info.Name() == ".git" || info.Name() == "tools") {
+			return filepath.SkipDir
+		}
+		if info.IsDir() || !strings.HasSuffix(path, ".go") || strings.HasSuffix(path, "_test.go") {
+			return nil
+		}
+		src, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if bytes.HasPrefix(src, []byte("package main")) || bytes.Contains(src, []byte("\npackage main")) {
+			dirs[filepath.Dir(path)] = true
+		}
+		return nil
+	})
+	var list []string
+	for dir := range dirs {
+		list = append(list, dir)
+	}
+	sort.Strings(list)
+	return list, err
+}
+
+// supportsThumbnail checks the example source for the -thumbnail flag, and
+// the packages of this repository it imports (e.g. cmd/demos, see
+// repoImportPath), running an example without it would open a window until
+// the timeout
+func supportsThumbnail(dir string) bool {
+	files, _ := filepath.Glob(filepath.Join(dir, "*.go"))
+	for _, file := range files {
+		src, err := ioutil.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		if bytes.Contains(src, []byte(`flag.String("thumbnail"`)) {
+			return true
+		}
+		imports, err := parser.ParseFile(token.NewFileSet(), file, src, parser.ImportsOnly)
+		if err != nil {
+			continue
+		}
+		for _, spec := range imports.Imports {
+			path, _ := strconv.Unquote(spec.Path.Value)
+			if strings.HasPrefix(path, repoImportPath+"/") && supportsThumbnail(filepath.Join(*root, strings.TrimPrefix(path, repoImportPath+"/"))) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// renderThumbnail builds the example into a temporary binary and runs it in thumbnail mode
+func renderThumbnail(dir string) error {
+
+	tmp, err := ioutil.TempDir("", "thumbnails")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+
+	// build first, so the timeout only applies to rendering (cgo builds are slow)
+	bin := filepath.Join(tmp, "example")
+	build := exec.Command("go", "build", "-o", bin, ".")
+	build.Dir = dir
+	if out, err := build.CombinedOutput(); err != nil {
+		return fmt.Errorf("build: %v\n%s", err, out)
+	}
+
+	output, err := filepath.Abs(filepath.Join(dir, thumbnailFile))
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+	run := exec.CommandContext(ctx, bin, "-thumbnail", output)
+	run.Dir = dir // examples load assets relative to their directory
+	if out, err := run.CombinedOutput(); err != nil {
+		return fmt.Errorf("run: %v\n%s", err, out)
+	}
+
+	return nil
+
+}
+
+// writeIndex writes a markdown page showing every thumbnail
+func writeIndex(path string, dirs []string) error {
+	var buf bytes.Buffer
+	fmt.Fprintln(&buf, "Thumbnails")
+	fmt.Fprintln(&buf, "==========")
+	fmt.Fprintln(&buf)
+	fmt.Fprintln(&buf, "Generated by `go run ./tools/thumbnails`, one frame of each example.")
+	for _, dir := range dirs {
+		rel, err := filepath.Rel(filepath.Dir(path), dir)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		fmt.Fprintln(&buf)
+		fmt.Fprintf(&buf, "[`%v`](%v)\n", rel, rel)
+		fmt.Fprintln(&buf)
+		fmt.Fprintf(&buf, "![%v](%v/%v)\n", rel, rel, thumbnailFile)
+	}
+	return ioutil.WriteFile(path, buf.Bytes(), 0644)
+}